@@ -19,10 +19,12 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/nebuly-ai/nos/internal/controllers/gpuagent"
+	"github.com/nebuly-ai/nos/internal/controllers/usagehistory"
 	configv1alpha1 "github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/config/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/constant"
@@ -30,11 +32,13 @@ import (
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/resource"
 	"github.com/nebuly-ai/nos/pkg/util"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"os"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"time"
@@ -84,6 +88,10 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	if err = agentConfig.Validate(); err != nil {
+		setupLog.Error(err, "config is invalid")
+		os.Exit(1)
+	}
 	reportingSeconds := agentConfig.ReportConfigIntervalSeconds * time.Second
 	setupLog.Info("loaded config", "reportingInterval", reportingSeconds)
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
@@ -92,6 +100,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup indexer
+	err = mgr.GetFieldIndexer().IndexField(ctx, &v1.Pod{}, constant.PodNodeNameKey, func(rawObj client.Object) []string {
+		p := rawObj.(*v1.Pod)
+		return []string{p.Spec.NodeName}
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to configure indexer")
+		os.Exit(1)
+	}
+
 	// Init MIG client
 	lister, err := resource.NewPodResourcesListerClient(
 		constant.DefaultPodResourcesTimeout,
@@ -103,7 +121,7 @@ func main() {
 	gpuClient := slicing.NewClient(resourceClient, nvmlClient)
 
 	// Check if any of the GPUs of the node has MIG mode enabled
-	anyMigEnabledGpu, err := AnyMigEnabledGpu(nvmlClient)
+	anyMigEnabledGpu, err := AnyMigEnabledGpu(ctx, nvmlClient)
 	if err != nil {
 		setupLog.Error(err, "unable to fetch GPUs")
 		os.Exit(1)
@@ -117,13 +135,29 @@ func main() {
 	reporter := gpuagent.NewReporter(
 		mgr.GetClient(),
 		gpuClient,
+		nvmlClient,
 		reportingSeconds,
+		agentConfig.GetTimeSlicingReplicas,
+		agentConfig.GpuMemoryDerating,
 	)
 	if err = reporter.SetupWithManager(mgr, "reporter", nodeName); err != nil {
 		setupLog.Error(err, "unable to create Reporter")
 		os.Exit(1)
 	}
 
+	// Setup GPU usage history recorder
+	if agentConfig.UsageHistorySize > 0 {
+		recorder := usagehistory.NewRecorder(
+			mgr.GetClient(),
+			agentConfig.UsageHistoryIntervalSeconds*time.Second,
+			agentConfig.UsageHistorySize,
+		)
+		if err = recorder.SetupWithManager(mgr, "usage-history-recorder", nodeName); err != nil {
+			setupLog.Error(err, "unable to create GPU usage history recorder")
+			os.Exit(1)
+		}
+	}
+
 	// Add health check endpoints to manager
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -133,6 +167,10 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err = mgr.AddReadyzCheck("nvml", nvml.ReadyzCheck(nvmlClient)); err != nil {
+		setupLog.Error(err, "unable to set up NVML ready check")
+		os.Exit(1)
+	}
 
 	// Start manager
 	setupLog.Info("starting manager")
@@ -143,8 +181,8 @@ func main() {
 }
 
 // AnyMigEnabledGpu returns true if any of the GPUs of the node has MIG mode enabled
-func AnyMigEnabledGpu(client nvml.Client) (bool, error) {
-	migGpus, err := client.GetMigEnabledGPUs()
+func AnyMigEnabledGpu(ctx context.Context, client nvml.Client) (bool, error) {
+	migGpus, err := client.GetMigEnabledGPUs(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -30,6 +30,7 @@ import (
 	schedulerv1beta3 "github.com/nebuly-ai/nos/pkg/api/scheduler/v1beta3"
 	"github.com/nebuly-ai/nos/pkg/constant"
 	gpumig "github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/scheduler/plugins/capacityscheduling"
 	testutil "github.com/nebuly-ai/nos/pkg/test/util"
 	"github.com/nebuly-ai/nos/pkg/util"
@@ -52,6 +53,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"time"
 	// Ensure scheduler package is initialized.
 	_ "github.com/nebuly-ai/nos/pkg/api/scheduler"
@@ -120,6 +122,22 @@ func main() {
 		)
 	}
 
+	// Setup MIG resource name prefix
+	if config.MigResourceNamePrefix != "" {
+		if err = gpumig.SetResourceNamePrefix(config.MigResourceNamePrefix); err != nil {
+			setupLog.Error(err, "unable to set MIG resource name prefix")
+			os.Exit(1)
+		}
+		setupLog.Info("using custom MIG resource name prefix", "prefix", config.MigResourceNamePrefix)
+	}
+
+	// Setup GPU slices allocation order
+	if err = slicing.SetAllocationOrder(slicing.AllocationOrder(config.SliceAllocationOrder)); err != nil {
+		setupLog.Error(err, "unable to set GPU slices allocation order")
+		os.Exit(1)
+	}
+	setupLog.Info("using GPU slices allocation order", "order", slicing.GetAllocationOrder())
+
 	// Setup known MIG geometries
 	if config.KnownMigGeometriesFile != "" {
 		knownGeometries, err := loadKnownMigGeometriesFromFile(config.KnownMigGeometriesFile)
@@ -151,6 +169,9 @@ func main() {
 	// Init state
 	clusterState := state.NewEmptyClusterState()
 
+	// Expose the GPU slices of the cluster state as metrics
+	metrics.Registry.MustRegister(state.NewGPUMetricsCollector(clusterState))
+
 	// Setup state controllers
 	nodeController := gpupartitioner.NewNodeController(
 		mgr.GetClient(),
@@ -248,6 +269,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup MPS standby slices controller
+	if len(config.MpsStandbySlices) > 0 {
+		standbySlices := make(map[slicing.ProfileName]int, len(config.MpsStandbySlices))
+		for p, q := range config.MpsStandbySlices {
+			profile := slicing.ProfileName(p)
+			if err = profile.Validate(); err != nil {
+				setupLog.Error(err, "invalid MPS standby slices")
+				os.Exit(1)
+			}
+			standbySlices[profile] = q
+		}
+		setupLog.Info("keeping MPS standby slices", "slices", standbySlices)
+		standbyController := mps.NewStandbyController(
+			mgr.GetClient(),
+			clusterState,
+			&mpsSlicingController,
+			standbySlices,
+			config.MpsStandbyIntervalSeconds*time.Second,
+			devicePluginCM,
+			config.DevicePluginDelaySeconds*time.Second,
+		)
+		if err = standbyController.SetupWithManager(mgr, constant.MpsStandbyControllerName); err != nil {
+			setupLog.Error(
+				err,
+				"unable to create controller",
+				"controller",
+				constant.MpsStandbyControllerName,
+			)
+			os.Exit(1)
+		}
+	}
+
 	// Setup health checks
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
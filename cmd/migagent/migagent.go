@@ -23,9 +23,12 @@ import (
 	"flag"
 	"fmt"
 	"github.com/nebuly-ai/nos/internal/controllers/migagent"
+	"github.com/nebuly-ai/nos/internal/controllers/migagent/plan"
+	"github.com/nebuly-ai/nos/internal/controllers/usagehistory"
 	configv1alpha1 "github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/config/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
 	"github.com/nebuly-ai/nos/pkg/resource"
@@ -33,6 +36,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"os"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -86,6 +90,10 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	if err = migAgentConfig.Validate(); err != nil {
+		setupLog.Error(err, "config is invalid")
+		os.Exit(1)
+	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -120,30 +128,106 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Reconcile MIG status annotations with the MIG devices of the GPUs before serving,
+	// using a client that does not rely on the cache since the manager is not started yet
+	directClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+	statusResync := migagent.NewStatusResync(directClient, migClient, nodeName)
+	if err = statusResync.Resync(ctx); err != nil {
+		setupLog.Error(err, "unable to reconcile MIG status annotations")
+		os.Exit(1)
+	}
+
 	// Setup MIG Reporter
 	migReporter := migagent.NewReporter(
 		mgr.GetClient(),
 		migClient,
+		nvmlClient,
 		sharedState,
 		migAgentConfig.ReportConfigIntervalSeconds*time.Second,
+		migAgentConfig.StatusUpdateBatchWindowSeconds*time.Second,
 	)
 	if err = migReporter.SetupWithManager(mgr, "reporter", nodeName); err != nil {
 		setupLog.Error(err, "unable to create MIG Reporter")
 		os.Exit(1)
 	}
 
+	// Setup MIG resource name prefix
+	if migAgentConfig.MigResourceNamePrefix != "" {
+		if err = mig.SetResourceNamePrefix(migAgentConfig.MigResourceNamePrefix); err != nil {
+			setupLog.Error(err, "unable to set MIG resource name prefix")
+			os.Exit(1)
+		}
+		setupLog.Info("using custom MIG resource name prefix", "prefix", migAgentConfig.MigResourceNamePrefix)
+	}
+
+	// Load named MIG geometries
+	namedGeometries := make(mig.NamedGeometries)
+	if migAgentConfig.NamedMigGeometriesFile != "" {
+		namedGeometries, err = loadNamedMigGeometriesFromFile(migAgentConfig.NamedMigGeometriesFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load named MIG geometries")
+			os.Exit(1)
+		}
+		setupLog.Info("using named MIG geometries loaded from file", "geometries", namedGeometries)
+	}
+
+	// Validate delete policy
+	deletePolicy := plan.DeletePolicy(migAgentConfig.DeletePolicy)
+	if err = deletePolicy.Validate(); err != nil {
+		setupLog.Error(err, "invalid mig-agent config")
+		os.Exit(1)
+	}
+
+	// Validate device plugin restart strategy
+	restartStrategy := gpu.DevicePluginRestartStrategy(migAgentConfig.DevicePluginRestartStrategy)
+	if err = restartStrategy.Validate(); err != nil {
+		setupLog.Error(err, "invalid mig-agent config")
+		os.Exit(1)
+	}
+
 	// Setup MIG Actuator
 	migActuator := migagent.NewActuator(
 		mgr.GetClient(),
 		migClient,
 		sharedState,
 		nodeName,
+		migagent.ActuatorOptions{
+			MaxOperationsPerReconcile:      migAgentConfig.MaxOperationsPerReconcile,
+			DeletePolicy:                   deletePolicy,
+			NamedGeometries:                namedGeometries,
+			DevicePluginRestartGracePeriod: migAgentConfig.DevicePluginRestartGracePeriodSeconds * time.Second,
+			DevicePluginRestartStrategy:    restartStrategy,
+		},
 	)
 	if err = migActuator.SetupWithManager(mgr, "actuator"); err != nil {
 		setupLog.Error(err, "unable to create MIG Actuator")
 		os.Exit(1)
 	}
 
+	// Setup GPU inventory publisher
+	inventoryPublisher := migagent.NewInventoryPublisher(mgr.GetClient(), nvmlClient, nodeName)
+	if err = inventoryPublisher.SetupWithManager(mgr, "inventory-publisher"); err != nil {
+		setupLog.Error(err, "unable to create GPU inventory publisher")
+		os.Exit(1)
+	}
+
+	// Setup GPU usage history recorder
+	if migAgentConfig.UsageHistorySize > 0 {
+		recorder := usagehistory.NewRecorder(
+			mgr.GetClient(),
+			migAgentConfig.UsageHistoryIntervalSeconds*time.Second,
+			migAgentConfig.UsageHistorySize,
+		)
+		if err = recorder.SetupWithManager(mgr, "usage-history-recorder", nodeName); err != nil {
+			setupLog.Error(err, "unable to create GPU usage history recorder")
+			os.Exit(1)
+		}
+	}
+
 	// Add health check endpoints to manager
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -153,6 +237,10 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err = mgr.AddReadyzCheck("nvml", nvml.ReadyzCheck(nvmlClient)); err != nil {
+		setupLog.Error(err, "unable to set up NVML ready check")
+		os.Exit(1)
+	}
 
 	// Start manager
 	setupLog.Info("starting manager")
@@ -164,7 +252,7 @@ func main() {
 
 func initAgent(ctx context.Context, nvmlClient nvml.Client, migClient mig.Client) error {
 	setupLog.Info("Checking MIG-enabled GPUs")
-	if err := checkAtLeastOneMigGpu(nvmlClient); err != nil {
+	if err := checkAtLeastOneMigGpu(ctx, nvmlClient); err != nil {
 		return err
 	}
 
@@ -176,8 +264,8 @@ func initAgent(ctx context.Context, nvmlClient nvml.Client, migClient mig.Client
 	return nil
 }
 
-func checkAtLeastOneMigGpu(nvmlClient nvml.Client) error {
-	migGpus, err := nvmlClient.GetMigEnabledGPUs()
+func checkAtLeastOneMigGpu(ctx context.Context, nvmlClient nvml.Client) error {
+	migGpus, err := nvmlClient.GetMigEnabledGPUs(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to get MIG enabled GPUs: %s", err)
 	}
@@ -197,3 +285,15 @@ func cleanupUnusedMigResources(ctx context.Context, migClient mig.Client) error
 	usedResources := resources.GetUsed()
 	return migClient.DeleteAllExcept(ctx, usedResources)
 }
+
+func loadNamedMigGeometriesFromFile(file string) (mig.NamedGeometries, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var namedGeometries = make(mig.NamedGeometries)
+	if err = yaml.Unmarshal(data, &namedGeometries); err != nil {
+		return nil, err
+	}
+	return namedGeometries, nil
+}
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/nebuly-ai/nos/internal/controllers/elasticquota"
+	"github.com/nebuly-ai/nos/internal/webhooks/gpuslicing"
 	configv1alpha1 "github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/config/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/constant"
@@ -109,6 +110,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup GPU slices normalization
+	(&gpuslicing.ProfileNormalizer{}).SetupWebhookWithManager(mgr)
+
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
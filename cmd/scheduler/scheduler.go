@@ -20,6 +20,8 @@ import (
 	"github.com/nebuly-ai/nos/pkg/api/scheduler"
 	"github.com/nebuly-ai/nos/pkg/api/scheduler/v1beta3"
 	"github.com/nebuly-ai/nos/pkg/scheduler/plugins/capacityscheduling"
+	"github.com/nebuly-ai/nos/pkg/scheduler/plugins/gpumodelscoring"
+	"github.com/nebuly-ai/nos/pkg/scheduler/plugins/gpupartitioningfilter"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"math/rand"
 	"os"
@@ -48,6 +50,8 @@ func main() {
 
 	command := app.NewSchedulerCommand(
 		app.WithPlugin(capacityscheduling.Name, capacityscheduling.New),
+		app.WithPlugin(gpumodelscoring.Name, gpumodelscoring.New),
+		app.WithPlugin(gpupartitioningfilter.Name, gpupartitioningfilter.New),
 	)
 
 	logs.InitLogs()
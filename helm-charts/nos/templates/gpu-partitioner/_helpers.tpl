@@ -163,6 +163,20 @@ Create the name of the MIG Agent config ConfigMap
 {{- include "migAgent.fullname" . }}-config
 {{- end }}
 
+{{/*
+Create the name of the named MIG geometries ConfigMap
+*/}}
+{{- define "migAgent.namedMigGeometriesConfigMapName" -}}
+{{- include "migAgent.fullname" . }}-named-mig-geometries
+{{- end }}
+
+{{/*
+Create the name of the file storing the named MIG geometries that can be applied to the nodes
+*/}}
+{{- define "migAgent.namedMigGeometriesFileName" -}}
+named_mig_geometries.yaml
+{{- end }}
+
 {{/*
 *********************************************************************
 * GPU Agent
@@ -19,9 +19,12 @@ package gpuagent
 import (
 	"context"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/util/predicate"
+	"golang.org/x/exp/maps"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -34,18 +37,27 @@ import (
 type Reporter struct {
 	client.Client
 	gpuClient       gpu.Client
+	nvmlClient      nvml.Client
 	refreshInterval time.Duration
+	// timeSlicingReplicas returns the number of time-slicing replicas advertised for each slice of a GPU of the node
+	timeSlicingReplicas func(gpuIndex int) int
+	// memoryDerating is the fraction of the memory of the GPUs of the node that is not usable for creating slices
+	memoryDerating float64
 }
 
-func NewReporter(k8sClient client.Client, gpuClient gpu.Client, refreshInterval time.Duration) Reporter {
+func NewReporter(k8sClient client.Client, gpuClient gpu.Client, nvmlClient nvml.Client, refreshInterval time.Duration, timeSlicingReplicas func(gpuIndex int) int, memoryDerating float64) Reporter {
 	return Reporter{
-		Client:          k8sClient,
-		gpuClient:       gpuClient,
-		refreshInterval: refreshInterval,
+		Client:              k8sClient,
+		gpuClient:           gpuClient,
+		nvmlClient:          nvmlClient,
+		refreshInterval:     refreshInterval,
+		timeSlicingReplicas: timeSlicingReplicas,
+		memoryDerating:      memoryDerating,
 	}
 }
 
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 
 func (r *Reporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := klog.FromContext(ctx)
@@ -64,10 +76,30 @@ func (r *Reporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result
 		return ctrl.Result{}, err
 	}
 
-	// Check if status changed
+	// Correct the status against the slices requested by the pods running on the node,
+	// which might not be reflected yet by the devices
+	var podList v1.PodList
+	if err := r.Client.List(ctx, &podList, client.MatchingFields{constant.PodNodeNameKey: instance.Name}); err != nil {
+		logger.Error(err, "unable to list node pods")
+		return ctrl.Result{}, err
+	}
 	currentStatusAnnotations := devices.AsStatusAnnotation(slicing.ExtractProfileNameStr)
+	currentStatusAnnotations = slicing.ReconcileStatusAnnotations(currentStatusAnnotations, podList.Items)
+
+	// Compute the annotations exposing health, topology, time-slicing replicas and memory derating of the GPUs.
+	// If they cannot be computed, the last reported ones are kept.
+	lastGpuAnnotations := getGpuAnnotations(instance)
+	currentGpuAnnotations := lastGpuAnnotations
+	gpuInfo, err := r.nvmlClient.GetGpuInfo(ctx)
+	if err != nil {
+		logger.Error(err, "unable to fetch GPU health and topology, keeping last reported values")
+	} else {
+		currentGpuAnnotations = slicing.GetGpuAnnotations(gpuInfo, r.timeSlicingReplicas, r.memoryDerating)
+	}
+
+	// Check if status changed
 	logger.Info("computed annotations", "current", currentStatusAnnotations, "last", lastStatusAnnotations, "devices", devices)
-	if currentStatusAnnotations.Equal(lastStatusAnnotations) {
+	if currentStatusAnnotations.Equal(lastStatusAnnotations) && maps.Equal(currentGpuAnnotations, lastGpuAnnotations) {
 		logger.Info("current status is equal to last reported status, nothing to do")
 		return ctrl.Result{RequeueAfter: r.refreshInterval}, nil
 	}
@@ -79,13 +111,16 @@ func (r *Reporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result
 		updated.Annotations = make(map[string]string)
 	}
 	for k := range updated.Annotations {
-		if strings.HasPrefix(k, v1alpha1.AnnotationGpuStatusPrefix) {
+		if strings.HasPrefix(k, v1alpha1.AnnotationGpuStatusPrefix) || isGpuAnnotation(k) {
 			delete(updated.Annotations, k)
 		}
 	}
 	for _, a := range currentStatusAnnotations {
 		updated.Annotations[a.String()] = a.GetValue()
 	}
+	for k, v := range currentGpuAnnotations {
+		updated.Annotations[k] = v
+	}
 	if err := r.Client.Patch(ctx, updated, client.MergeFrom(&instance)); err != nil {
 		logger.Error(err, "unable to update node status annotations", "annotations", updated.Annotations)
 		return ctrl.Result{}, err
@@ -95,6 +130,27 @@ func (r *Reporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result
 	return ctrl.Result{RequeueAfter: r.refreshInterval}, nil
 }
 
+// getGpuAnnotations returns the annotations of the node exposing health, topology, time-slicing replicas
+// and memory derating of its GPUs
+func getGpuAnnotations(node v1.Node) map[string]string {
+	res := make(map[string]string)
+	for k, v := range node.Annotations {
+		if isGpuAnnotation(k) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func isGpuAnnotation(key string) bool {
+	for _, prefix := range slicing.GpuAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Reporter) SetupWithManager(mgr ctrl.Manager, controllerName string, nodeName string) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(
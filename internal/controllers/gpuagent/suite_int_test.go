@@ -24,8 +24,10 @@ import (
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	gpumock "github.com/nebuly-ai/nos/pkg/test/mocks/gpu"
+	nvmlmock "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"path/filepath"
@@ -42,9 +44,10 @@ var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
 var (
-	ctx       context.Context
-	cancel    context.CancelFunc
-	gpuClient *gpumock.Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	gpuClient  *gpumock.Client
+	nvmlClient *nvmlmock.Client
 )
 
 var _ gpu.Client = gpuClient
@@ -57,6 +60,8 @@ const (
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 	gpuClient = gpumock.NewClient(t)
+	nvmlClient = nvmlmock.NewClient(t)
+	nvmlClient.On("GetGpuInfo", mock.Anything).Return([]gpu.Info{}, nil).Maybe()
 	RunSpecs(t, "Controllers Suite")
 }
 
@@ -91,7 +96,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	// Setup Reporter
-	reporter := gpuagent.NewReporter(k8sClient, gpuClient, reporterRefreshInterval)
+	reporter := gpuagent.NewReporter(k8sClient, gpuClient, nvmlClient, reporterRefreshInterval, nil, 0)
 	Expect(reporter.SetupWithManager(k8sManager, "Reporter", nodeName)).To(Succeed())
 
 	go func() {
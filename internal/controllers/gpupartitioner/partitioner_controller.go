@@ -31,6 +31,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sync"
 	"time"
 )
 
@@ -44,6 +45,7 @@ type Controller struct {
 	actuator      core.Actuator
 	snapshotTaker core.SnapshotTaker
 	kind          gpu.PartitioningKind
+	planMtx       *sync.Mutex
 }
 
 func NewController(
@@ -65,9 +67,16 @@ func NewController(
 		actuator:      actuator,
 		snapshotTaker: snapshotTaker,
 		kind:          kind,
+		planMtx:       &sync.Mutex{},
 	}
 }
 
+// PlanLocker returns the lock held by the controller while computing and applying a partitioning plan.
+// Other controllers applying plans to the nodes of the same partitioning kind must hold it as well.
+func (c *Controller) PlanLocker() sync.Locker {
+	return c.planMtx
+}
+
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;patch;create
 //+kubebuilder:rbac:groups=core,resources=persistentvolumes;persistentvolumeclaims;namespaces;services;replicationcontrollers,verbs=get;list;watch
@@ -133,6 +142,14 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	case <-c.podBatcher.Ready():
 		logger.V(1).Info("batch ready")
 		c.currentBatch = make(map[string]v1.Pod)
+		c.planMtx.Lock()
+		defer c.planMtx.Unlock()
+		// A plan may have been applied by another controller while the batch was being filled
+		if waiting := c.waitingAnyNodeToReportPlan(); waiting {
+			logger.V(1).Info("last partitioning plan has not been reported by all nodes yet, skipping batch")
+			c.podBatcher.Reset()
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
 		err := c.processPendingPods(ctx)
 		return ctrl.Result{}, err
 	default:
@@ -212,14 +229,15 @@ func (c *Controller) fetchPendingPods(ctx context.Context) ([]v1.Pod, error) {
 func (c *Controller) waitingAnyNodeToReportPlan() bool {
 	nodes := c.clusterState.GetNodes()
 	for _, n := range nodes {
-		if c.waitingToReportPlan(*n.Node()) {
+		if waitingToReportPlan(*n.Node()) {
 			return true
 		}
 	}
 	return false
 }
 
-func (c *Controller) waitingToReportPlan(n v1.Node) bool {
+// waitingToReportPlan returns true if the node has not reported yet the last partitioning plan applied to it
+func waitingToReportPlan(n v1.Node) bool {
 	plan, ok := n.Annotations[v1alpha1.AnnotationPartitioningPlan]
 	if !ok || plan == "" {
 		return false
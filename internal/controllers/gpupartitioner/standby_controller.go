@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpupartitioner
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/internal/partitioning/core"
+	"github.com/nebuly-ai/nos/internal/partitioning/state"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sync"
+	"time"
+)
+
+// StandbyController keeps a warm standby of free GPU slices on the nodes with slicing partitioning:
+// periodically, it re-partitions the GPUs of each node so that the node provides at least the
+// target quantity of free slices of each profile, as long as the GPUs have enough capacity.
+//
+// The plans are applied while holding the plan lock shared with the partitioner Controller of the
+// same partitioning kind (see Controller.PlanLocker), so that the two controllers never overwrite
+// each other's plans.
+type StandbyController struct {
+	clusterState        *state.ClusterState
+	snapshotTaker       core.SnapshotTaker
+	partitionCalculator core.PartitionCalculator
+	actuator            core.Actuator
+	planLocker          sync.Locker
+	target              map[slicing.ProfileName]int
+	interval            time.Duration
+	name                string
+}
+
+func NewStandbyController(
+	clusterState *state.ClusterState,
+	snapshotTaker core.SnapshotTaker,
+	partitionCalculator core.PartitionCalculator,
+	actuator core.Actuator,
+	planLocker sync.Locker,
+	target map[slicing.ProfileName]int,
+	interval time.Duration,
+) StandbyController {
+	return StandbyController{
+		clusterState:        clusterState,
+		snapshotTaker:       snapshotTaker,
+		partitionCalculator: partitionCalculator,
+		actuator:            actuator,
+		planLocker:          planLocker,
+		target:              target,
+		interval:            interval,
+	}
+}
+
+// Start replenishes the standby slices of the nodes every interval, until the context is cancelled.
+// Failures are only logged, since the slices are replenished again at the next interval.
+func (c *StandbyController) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName(c.name)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.replenish(log.IntoContext(ctx, logger)); err != nil {
+				logger.Error(err, "unable to replenish standby GPU slices")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the plans are applied
+// only by the leader, like the ones of the partitioner Controller.
+func (c *StandbyController) NeedLeaderElection() bool {
+	return true
+}
+
+// replenish applies, in a single plan, the partitioning providing the target free slices to all
+// the nodes with MPS partitioning that have reported the last plan applied to them
+func (c *StandbyController) replenish(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	c.planLocker.Lock()
+	defer c.planLocker.Unlock()
+
+	if !c.clusterState.IsPartitioningEnabled(gpu.PartitioningKindMps) {
+		return nil
+	}
+	snapshot, err := c.snapshotTaker.TakeSnapshot(c.clusterState)
+	if err != nil {
+		return err
+	}
+
+	desired := state.PartitioningState{}
+	for name, nodeInfo := range c.clusterState.GetNodes() {
+		if nodeInfo.Node() == nil || !gpu.IsMpsPartitioningEnabled(*nodeInfo.Node()) {
+			continue
+		}
+		if waitingToReportPlan(*nodeInfo.Node()) {
+			logger.V(1).Info("last partitioning plan has not been reported by the node yet, skipping it", "node", name)
+			continue
+		}
+		n, ok := snapshot.GetNode(name)
+		if !ok {
+			continue
+		}
+		node, ok := n.Clone().(*slicing.Node)
+		if !ok {
+			continue
+		}
+		if updated := node.ReplenishFreeSlices(c.target); !updated {
+			continue
+		}
+		logger.Info("replenishing standby GPU slices", "node", name, "geometry", node.Geometry())
+		desired[name] = c.partitionCalculator.GetPartitioning(node)
+	}
+	if len(desired) == 0 {
+		return nil
+	}
+
+	_, err = c.actuator.Apply(ctx, snapshot, core.NewPartitioningPlan(desired))
+	return err
+}
+
+func (c *StandbyController) SetupWithManager(mgr ctrl.Manager, name string) error {
+	c.name = name
+	return mgr.Add(c)
+}
@@ -18,6 +18,7 @@ package migagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/nebuly-ai/nos/internal/controllers/migagent/plan"
@@ -26,33 +27,153 @@ import (
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/util/predicate"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
 	"time"
 )
 
+const (
+	// EventReasonUnsupportedMigSpec is the reason of the events emitted when the MIG profiles specified
+	// in the node spec annotations are not supported by the GPU model of the node
+	EventReasonUnsupportedMigSpec = "UnsupportedMigSpec"
+	// EventReasonInsufficientMigCapacity is the reason of the events emitted when the MIG profiles specified
+	// in the node spec annotations do not fit the capacity of the GPUs of the node
+	EventReasonInsufficientMigCapacity = "InsufficientMigCapacity"
+	// EventReasonUnknownMigGeometry is the reason of the events emitted when the named MIG geometry
+	// referenced by the node annotation is not known by the MIG agent
+	EventReasonUnknownMigGeometry = "UnknownMigGeometry"
+	// EventReasonInvalidGpuIndex is the reason of the events emitted when the node spec annotations
+	// reference GPU indexes that do not exist on the node
+	EventReasonInvalidGpuIndex = "InvalidGpuIndex"
+	// EventReasonGpuCountMismatch is the reason of the events emitted when the number of GPUs enumerated
+	// by NVML differs from the number of GPUs reported by the node labels
+	EventReasonGpuCountMismatch = "GpuCountMismatch"
+	// EventReasonMigReconfiguring is the reason of the events emitted when the MIG devices of the GPUs
+	// do not match the desired MIG config, describing the missing and extra MIG profiles of each GPU
+	EventReasonMigReconfiguring = "MigReconfiguring"
+)
+
+const (
+	// ConditionReasonMigConfigApplied is the reason of the v1alpha1.NodeConditionMigConfigApplied condition
+	// when the MIG devices of the node match the desired MIG config
+	ConditionReasonMigConfigApplied = "Applied"
+	// ConditionReasonMigConfigPartiallyApplied is the reason of the v1alpha1.NodeConditionMigConfigApplied
+	// condition when only some of the operations required by the desired MIG config have been applied
+	ConditionReasonMigConfigPartiallyApplied = "PartiallyApplied"
+	// ConditionReasonMigConfigApplyFailed is the reason of the v1alpha1.NodeConditionMigConfigApplied condition
+	// when the desired MIG config cannot be applied
+	ConditionReasonMigConfigApplyFailed = v1alpha1.NodeConditionReasonMigConfigApplyFailed
+)
+
+// MigClientProvider returns the MIG client for managing the GPUs of the node with the name provided as argument.
+type MigClientProvider func(nodeName string) (mig.Client, error)
+
 type MigActuator struct {
 	client.Client
-	migClient    mig.Client
-	sharedState  *SharedState
-	nodeName     string
-	devicePlugin gpu.DevicePluginClient
-
-	// lastAppliedPlan is the latest applied plan
-	lastAppliedPlan *plan.MigConfigPlan
-	// lastAppliedStatus is the MIG status of the GPUs at the time when the latest plan was applied
-	lastAppliedStatus *gpu.StatusAnnotationList
+	migClient mig.Client
+	// migClientProvider, if not nil, provides the MIG client of each reconciled node and takes
+	// precedence over migClient
+	migClientProvider MigClientProvider
+	// sharedState is used for waiting for the Reporter between applies, it is nil if the actuator
+	// reconciles multiple nodes
+	sharedState *SharedState
+	// nodeName is the name of the node reconciled by the actuator, if empty the actuator
+	// reconciles any node
+	nodeName      string
+	devicePlugin  gpu.DevicePluginClient
+	eventRecorder record.EventRecorder
+
+	// maxOperationsPerReconcile is the max number of MIG devices created or deleted in a single reconcile,
+	// values lower or equal than zero mean no limit
+	maxOperationsPerReconcile int
+
+	// deletePolicy defines which resources are deleted first when the operations of a reconcile are limited
+	deletePolicy plan.DeletePolicy
+
+	// namedGeometries are the MIG geometries that can be applied to all the GPUs of the node
+	// by referencing their name through the node annotation
+	namedGeometries mig.NamedGeometries
+
+	// devicePluginRestartGracePeriod is the time that must elapse since the last change of the MIG devices
+	// of a node before restarting its NVIDIA device plugin, so that subsequent changes are coalesced into
+	// a single restart. Values lower or equal than zero mean that the plugin is restarted after each change.
+	devicePluginRestartGracePeriod time.Duration
+	// pendingRestarts contains, for each node whose device plugin restart is pending,
+	// the time of the last change of its MIG devices
+	pendingRestarts map[string]time.Time
+	// devicePluginRestartStrategy defines whether the NVIDIA device plugin is restarted
+	// after changing the MIG devices of a node
+	devicePluginRestartStrategy gpu.DevicePluginRestartStrategy
+
+	// lastApplied contains, for each node, the latest applied plan and the MIG status of the GPUs
+	// at the time when the plan was applied
+	lastApplied map[string]appliedConfig
+
+	// pendingDeviceLabels contains, for each node, the labels of the created MIG devices that
+	// have not been recorded on the node yet
+	pendingDeviceLabels map[string][]pendingDeviceLabels
+
+	// geometryChanges contains, for each node whose MIG devices have been created or deleted,
+	// the time of the change that has not been recorded on the node yet
+	geometryChanges map[string]time.Time
 }
 
-func NewActuator(client client.Client, migClient mig.Client, sharedState *SharedState, nodeName string) MigActuator {
+type appliedConfig struct {
+	plan   plan.MigConfigPlan
+	status gpu.StatusAnnotationList
+}
+
+// ActuatorOptions contains the settings of a MigActuator
+type ActuatorOptions struct {
+	// MaxOperationsPerReconcile is the max number of MIG devices created or deleted in a single reconcile,
+	// values lower or equal than zero mean no limit
+	MaxOperationsPerReconcile int
+	// DeletePolicy defines which resources are deleted first when the operations of a reconcile are limited
+	DeletePolicy plan.DeletePolicy
+	// NamedGeometries are the MIG geometries that can be applied to all the GPUs of a node
+	// by referencing their name through the node annotation
+	NamedGeometries mig.NamedGeometries
+	// DevicePluginRestartGracePeriod is the time that must elapse since the last change of the MIG devices
+	// of a node before restarting its NVIDIA device plugin
+	DevicePluginRestartGracePeriod time.Duration
+	// DevicePluginRestartStrategy defines whether the NVIDIA device plugin is restarted
+	// after changing the MIG devices of a node
+	DevicePluginRestartStrategy gpu.DevicePluginRestartStrategy
+}
+
+func NewActuator(client client.Client, migClient mig.Client, sharedState *SharedState, nodeName string, opts ActuatorOptions) MigActuator {
+	actuator := newActuator(client, opts)
+	actuator.migClient = migClient
+	actuator.sharedState = sharedState
+	actuator.nodeName = nodeName
+	return actuator
+}
+
+// NewMultiNodeActuator returns an actuator that reconciles any node triggering a reconcile, instead of
+// a single one, using the MIG client returned by the provided MigClientProvider for each node. Since there
+// isn't any Reporter running alongside it, the actuator does not wait for the MIG config of a node to be
+// reported before applying a new one.
+func NewMultiNodeActuator(client client.Client, migClientProvider MigClientProvider, opts ActuatorOptions) MigActuator {
+	actuator := newActuator(client, opts)
+	actuator.migClientProvider = migClientProvider
+	return actuator
+}
+
+func newActuator(client client.Client, opts ActuatorOptions) MigActuator {
 	return MigActuator{
-		Client:       client,
-		migClient:    migClient,
-		nodeName:     nodeName,
-		sharedState:  sharedState,
-		devicePlugin: gpu.NewDevicePluginClient(client),
+		Client:                         client,
+		devicePlugin:                   gpu.NewDevicePluginClient(client),
+		maxOperationsPerReconcile:      opts.MaxOperationsPerReconcile,
+		deletePolicy:                   opts.DeletePolicy,
+		namedGeometries:                opts.NamedGeometries,
+		devicePluginRestartGracePeriod: opts.DevicePluginRestartGracePeriod,
+		devicePluginRestartStrategy:    opts.DevicePluginRestartStrategy,
 	}
 }
 
@@ -60,25 +181,60 @@ func (a *MigActuator) newLogger(ctx context.Context) logr.Logger {
 	return log.FromContext(ctx).WithName("Actuator")
 }
 
-func (a *MigActuator) updateLastApplied(currentPlan plan.MigConfigPlan, currentStatus gpu.StatusAnnotationList) {
-	a.lastAppliedPlan = &currentPlan
-	a.lastAppliedStatus = &currentStatus
+func (a *MigActuator) updateLastApplied(nodeName string, currentPlan plan.MigConfigPlan, currentStatus gpu.StatusAnnotationList) {
+	if a.lastApplied == nil {
+		a.lastApplied = make(map[string]appliedConfig)
+	}
+	a.lastApplied[nodeName] = appliedConfig{plan: currentPlan, status: currentStatus}
+}
+
+// getMigClient returns the MIG client for managing the GPUs of the node provided as argument
+func (a *MigActuator) getMigClient(nodeName string) (mig.Client, error) {
+	if a.migClientProvider != nil {
+		return a.migClientProvider(nodeName)
+	}
+	return a.migClient, nil
 }
 
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=core,resources=nodes/status,verbs=patch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (a *MigActuator) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := a.newLogger(ctx)
 
-	// If we haven't reported the last applied config, requeue and avoid acquiring lock
-	if !a.sharedState.AtLeastOneReportSinceLastApply() {
-		logger.Info("last applied config hasn't been reported yet, waiting...")
-		return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+	if a.sharedState != nil {
+		// If we haven't reported the last applied config, requeue and avoid acquiring lock
+		if !a.sharedState.AtLeastOneReportSinceLastApply() {
+			logger.Info("last applied config hasn't been reported yet, waiting...")
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+
+		a.sharedState.Lock()
+		defer a.sharedState.Unlock()
+	}
+
+	res, err := a.reconcile(ctx, req)
+	if err != nil {
+		return res, err
+	}
+
+	// Restart the NVIDIA device plugin if a restart is pending and no changes happened during the grace period,
+	// otherwise requeue for checking it again when the grace period expires
+	wait, err := a.restartNvidiaDevicePluginIfDue(ctx, req.Name)
+	if err != nil {
+		logger.Error(err, "unable to restart nvidia device plugin")
+		return ctrl.Result{}, err
+	}
+	if wait > 0 && (res.RequeueAfter == 0 || wait < res.RequeueAfter) {
+		res.RequeueAfter = wait
 	}
+	return res, nil
+}
 
-	a.sharedState.Lock()
-	defer a.sharedState.Unlock()
+func (a *MigActuator) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := a.newLogger(ctx)
 
 	// Retrieve instance
 	var instance v1.Node
@@ -86,70 +242,351 @@ func (a *MigActuator) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Res
 		return ctrl.Result{}, err
 	}
 
+	// If the MIG agent is paused on the node, leave the current MIG configuration untouched
+	if instance.Annotations[v1alpha1.AnnotationMigAgentPaused] == "true" {
+		logger.Info("MIG agent is paused on the node, skipping reconcile", "annotation", v1alpha1.AnnotationMigAgentPaused)
+		return ctrl.Result{}, nil
+	}
+
+	// Record the labels of the MIG devices created by previous reconciles, if they are advertised,
+	// and remove the labels of the devices that no longer exist
+	if len(a.pendingDeviceLabels[instance.Name]) > 0 || hasDeviceLabels(instance) {
+		if migClient, err := a.getMigClient(instance.Name); err == nil {
+			a.recordDeviceLabels(ctx, migClient, instance)
+		}
+	}
+
+	// Record the last change of the MIG geometry, if previous reconciles failed to record it
+	a.recordGeometryChange(ctx, instance)
+
 	// Update last parsed plan ID
-	a.sharedState.lastParsedPlanId = instance.Annotations[v1alpha1.AnnotationPartitioningPlan]
+	if a.sharedState != nil {
+		a.sharedState.lastParsedPlanId = instance.Annotations[v1alpha1.AnnotationPartitioningPlan]
+	}
 
 	// Check if reported status already matches spec
 	statusAnnotations, specAnnotations := gpu.ParseNodeAnnotations(instance)
+
+	// If the node references named MIG geometries, they take precedence over the spec annotations
+	if geometries, ok := instance.Annotations[v1alpha1.AnnotationMigGeometry]; ok {
+		geometryName, geometrySpec, err := a.selectNamedGeometry(ctx, instance, mig.ParseGeometryNames(geometries))
+		if err != nil {
+			logger.Error(err, "refusing to apply MIG config: cannot resolve named MIG geometry", "geometry", geometries)
+			a.recordEvent(&instance, v1.EventTypeWarning, EventReasonUnknownMigGeometry, err.Error())
+			a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
+			return ctrl.Result{}, err
+		}
+		specAnnotations = geometrySpec
+		a.recordSelectedGeometry(ctx, instance, geometryName)
+	} else {
+		a.recordSelectedGeometry(ctx, instance, "")
+	}
+
 	if mig.SpecMatchesStatus(specAnnotations, statusAnnotations) {
 		logger.Info("reported status matches desired MIG config, nothing to do")
+		a.setMigConfigCondition(ctx, instance, v1.ConditionTrue, ConditionReasonMigConfigApplied, "MIG devices match the desired MIG config")
 		return ctrl.Result{}, nil
 	}
 
+	// Check that the spec is valid for the GPU model currently reported by the node
+	if err := a.validateSpec(instance, specAnnotations); err != nil {
+		logger.Error(err, "refusing to apply MIG config: spec is not valid for the node GPU model or device plugin")
+		a.recordEvent(&instance, v1.EventTypeWarning, EventReasonUnsupportedMigSpec, err.Error())
+		a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// Get the MIG client of the node
+	migClient, err := a.getMigClient(instance.Name)
+	if err != nil {
+		logger.Error(err, "unable to get MIG client of the node")
+		return ctrl.Result{}, err
+	}
+
 	// Compute MIG config plan
-	configPlan, err := a.plan(ctx, specAnnotations)
+	configPlan, state, err := a.plan(ctx, migClient, instance, specAnnotations)
+	if errors.Is(err, plan.ErrGpuIndexOutOfRange) {
+		logger.Error(err, "refusing to apply MIG config: spec references GPUs that do not exist")
+		a.recordEvent(&instance, v1.EventTypeWarning, EventReasonInvalidGpuIndex, err.Error())
+		a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+	if errors.Is(err, plan.ErrInsufficientCapacity) {
+		logger.Error(err, "refusing to apply MIG config: plan exceeds GPU capacity")
+		a.recordEvent(&instance, v1.EventTypeWarning, EventReasonInsufficientMigCapacity, err.Error())
+		a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
+		return ctrl.Result{}, err
+	}
 	if err != nil {
+		a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	// Attach to the create operations the labels of the MIG devices they create
+	createLabels, err := parseCreateLabels(instance)
+	if err != nil {
+		logger.Error(err, "ignoring labels of the MIG devices to create")
+	}
+	configPlan = configPlan.WithCreateLabels(createLabels)
+
+	// Restrict the plan to the GPUs whose status does not match the spec, so that
+	// the MIG devices of the other GPUs are left untouched
+	configPlan = configPlan.ForGPUs(mig.GetGPUsNotMatchingSpec(specAnnotations, statusAnnotations))
+
+	// Limit the number of operations applied in a single reconcile, deleting first
+	// the resources preferred by the delete policy
+	configPlan, truncated := configPlan.WithDeletePolicy(state, a.deletePolicy).Limit(a.maxOperationsPerReconcile)
+
 	// At the end of reconcile, update last applied status information
-	defer a.updateLastApplied(configPlan, statusAnnotations)
+	defer a.updateLastApplied(instance.Name, configPlan, statusAnnotations)
 
 	// Check if plan has to be applied
 	if configPlan.IsEmpty() {
 		logger.Info("MIG config plan is empty, nothing to do")
+		a.setMigConfigCondition(ctx, instance, v1.ConditionTrue, ConditionReasonMigConfigApplied, "MIG devices match the desired MIG config")
 		return ctrl.Result{}, nil
 	}
-	if configPlan.Equal(a.lastAppliedPlan) && statusAnnotations.Equal(*a.lastAppliedStatus) {
+	if last, ok := a.lastApplied[instance.Name]; ok && configPlan.Equal(&last.plan) && statusAnnotations.Equal(last.status) {
 		logger.Info("MIG config plan already applied and state hasn't changed, nothing to do")
 		return ctrl.Result{}, nil
 	}
 
 	// Apply MIG config plan
-	res, err := a.apply(ctx, configPlan)
-	a.sharedState.OnApplyDone()
+	a.recordEvent(&instance, v1.EventTypeNormal, EventReasonMigReconfiguring, state.MatchDetails(specAnnotations).String())
+	res, err := a.apply(ctx, migClient, instance.Name, configPlan, state)
+	if a.sharedState != nil {
+		a.sharedState.OnApplyDone()
+	}
+	a.recordGeometryChange(ctx, instance)
+
+	if err != nil {
+		a.setMigConfigCondition(ctx, instance, v1.ConditionFalse, ConditionReasonMigConfigApplyFailed, err.Error())
+		return res, err
+	}
+
+	// Requeue for applying the remaining operations
+	if truncated {
+		logger.Info(
+			"max operations per reconcile reached, remaining operations will be applied in the next reconcile",
+			"maxOperationsPerReconcile",
+			a.maxOperationsPerReconcile,
+		)
+		a.setMigConfigCondition(
+			ctx,
+			instance,
+			v1.ConditionFalse,
+			ConditionReasonMigConfigPartiallyApplied,
+			fmt.Sprintf("max operations per reconcile (%d) reached, remaining operations will be applied in the next reconcile", a.maxOperationsPerReconcile),
+		)
+		return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+	}
+
+	a.setMigConfigCondition(ctx, instance, v1.ConditionTrue, ConditionReasonMigConfigApplied, "MIG config plan applied successfully")
+	return res, nil
+}
+
+// recordEvent records an event for the object provided as argument. Events are not recorded
+// if the actuator has not been set up with a manager.
+func (a *MigActuator) recordEvent(object runtime.Object, eventType, reason, message string) {
+	if a.eventRecorder == nil {
+		return
+	}
+	a.eventRecorder.Event(object, eventType, reason, message)
+}
+
+// setMigConfigCondition sets the v1alpha1.NodeConditionMigConfigApplied condition of the node provided as argument.
+// The node is patched only if the status, the reason or the message of the condition changed, and the
+// LastTransitionTime of the condition is updated only if its status changed. Errors are logged and
+// not returned, so that failing to report the outcome of a reconcile does not affect the reconcile itself.
+func (a *MigActuator) setMigConfigCondition(ctx context.Context, node v1.Node, status v1.ConditionStatus, reason, message string) {
+	logger := a.newLogger(ctx)
+	now := metav1.Now()
+	condition := v1.NodeCondition{
+		Type:               v1alpha1.NodeConditionMigConfigApplied,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+	}
 
-	return res, err
+	updated := node.DeepCopy()
+	var found bool
+	for i, c := range updated.Status.Conditions {
+		if c.Type != v1alpha1.NodeConditionMigConfigApplied {
+			continue
+		}
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return
+		}
+		if c.Status == status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+		updated.Status.Conditions[i] = condition
+		found = true
+	}
+	if !found {
+		updated.Status.Conditions = append(updated.Status.Conditions, condition)
+	}
+
+	// The node might have been fetched a while ago: a strategic merge patch merges the conditions
+	// by type, so that the conditions owned by other components (e.g. the kubelet) are not overwritten
+	if err := a.Client.Status().Patch(ctx, updated, client.StrategicMergeFrom(&node)); err != nil {
+		logger.Error(err, "unable to update node condition", "condition", condition.Type)
+	}
+}
+
+// selectNamedGeometry returns the name and the spec annotations of the first of the named MIG geometries
+// provided as argument, sorted by preference, that can be fully applied given the MIG devices currently
+// existing on the node (see plan.IsAchievable). Each geometry is applied to all the GPUs of the node.
+// If none of the geometries can be fully applied, or the current MIG devices cannot be retrieved,
+// selectNamedGeometry returns the first geometry.
+func (a *MigActuator) selectNamedGeometry(ctx context.Context, node v1.Node, names []string) (string, gpu.SpecAnnotationList, error) {
+	logger := a.newLogger(ctx)
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("annotation %s does not specify any MIG geometry", v1alpha1.AnnotationMigGeometry)
+	}
+
+	gpuCount, err := gpu.GetCount(node)
+	if err != nil {
+		return "", nil, err
+	}
+	candidates := make([]gpu.SpecAnnotationList, len(names))
+	for i, name := range names {
+		if candidates[i], err = a.namedGeometries.ToSpecAnnotations(name, gpuCount); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(candidates) == 1 {
+		return names[0], candidates[0], nil
+	}
+
+	migClient, err := a.getMigClient(node.Name)
+	if err != nil {
+		logger.Error(err, "unable to get MIG client of the node, selecting preferred MIG geometry")
+		return names[0], candidates[0], nil
+	}
+	migDevices, err := migClient.GetMigDevices(ctx)
+	if err != nil {
+		logger.Error(err, "unable to get MIG device resources, selecting preferred MIG geometry")
+		return names[0], candidates[0], nil
+	}
+	var model gpu.Model
+	if m, err := gpu.GetModel(node); err == nil {
+		model = m
+	}
+
+	selected := plan.SelectAchievable(plan.NewMigState(migDevices), candidates, model)
+	if selected > 0 {
+		logger.Info("preferred MIG geometry cannot be applied, selecting fallback", "preferred", names[0], "selected", names[selected])
+	}
+	return names[selected], candidates[selected], nil
+}
+
+// recordSelectedGeometry exposes the name of the selected MIG geometry through the v1alpha1.AnnotationMigGeometrySelected
+// annotation of the node. If the name is empty, the annotation is removed. Errors are logged and not returned,
+// so that failing to record the selected geometry does not affect the reconcile.
+func (a *MigActuator) recordSelectedGeometry(ctx context.Context, node v1.Node, name string) {
+	if node.Annotations[v1alpha1.AnnotationMigGeometrySelected] == name {
+		return
+	}
+	updated := node.DeepCopy()
+	if name == "" {
+		delete(updated.Annotations, v1alpha1.AnnotationMigGeometrySelected)
+	} else {
+		updated.Annotations[v1alpha1.AnnotationMigGeometrySelected] = name
+	}
+	if err := a.Client.Patch(ctx, updated, client.MergeFrom(&node)); err != nil {
+		a.newLogger(ctx).Error(err, "unable to record selected MIG geometry", "geometry", name)
+	}
+}
+
+// validateSpec returns an error if the spec annotations request MIG profiles that are not supported
+// by the GPU model of the node. If the node does not expose the GPU model label, or if the MIG geometries
+// of its model are not known, the check is skipped.
+// If the node exposes the version of the NVIDIA device plugin, validateSpec also returns an error if the
+// spec annotations request MIG profiles that are not advertised by such version.
+func (a *MigActuator) validateSpec(node v1.Node, specAnnotations gpu.SpecAnnotationList) error {
+	if model, ok := getKnownModel(node); ok {
+		if err := mig.ValidateSpecAnnotations(model, specAnnotations); err != nil {
+			return err
+		}
+	}
+	if devicePluginVersion, ok := node.Labels[v1alpha1.LabelDevicePluginVersion]; ok {
+		return mig.ValidateDevicePluginSupport(devicePluginVersion, specAnnotations)
+	}
+	return nil
+}
+
+// getKnownModel returns the GPU model of the node provided as argument. The returned bool is false if the
+// node does not expose the GPU model label or if the MIG geometries of its model are not known, since the
+// MIG agent does not load the known geometries configured in the GPU partitioner.
+func getKnownModel(node v1.Node) (gpu.Model, bool) {
+	model, err := gpu.GetModel(node)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := mig.GetAllowedGeometries(model); !ok {
+		return "", false
+	}
+	return model, true
 }
 
-func (a *MigActuator) plan(ctx context.Context, specAnnotations gpu.SpecAnnotationList) (plan.MigConfigPlan, error) {
+// plan computes the plan for applying the MIG config specified by the spec annotations provided as argument,
+// returning it together with the current MIG state of the GPUs.
+// If the node exposes the GPU count label, plan checks that the spec annotations only reference existing GPUs.
+// If the MIG geometries of the GPU model of the node are known, plan also checks that the create operations
+// of the plan fit the capacity of the GPUs, so that no GPU is left partially configured.
+func (a *MigActuator) plan(ctx context.Context, migClient mig.Client, node v1.Node, specAnnotations gpu.SpecAnnotationList) (plan.MigConfigPlan, plan.MigState, error) {
 	logger := a.newLogger(ctx)
 
+	// Check that the spec does not reference GPUs that do not exist
+	if gpuCount, err := gpu.GetCount(node); err == nil {
+		if err = plan.ValidateGpuIndexes(specAnnotations, gpuCount); err != nil {
+			return plan.MigConfigPlan{}, nil, err
+		}
+	}
+
 	// Compute current state
-	migDeviceResources, err := a.migClient.GetMigDevices(ctx)
+	migDeviceResources, err := migClient.GetMigDevices(ctx)
 	if gpu.IgnoreNotFound(err) != nil {
 		logger.Error(err, "unable to get MIG device resources")
-		return plan.MigConfigPlan{}, err
+		return plan.MigConfigPlan{}, nil, err
 	}
 	// If err is not found, restart the NVIDIA device plugin for updating the resources exposed to k8s
 	if gpu.IsNotFound(err) {
 		logger.Error(err, "unable to get MIG device resources")
-		return plan.MigConfigPlan{}, a.restartNvidiaDevicePlugin(ctx)
+		return plan.MigConfigPlan{}, nil, a.restartNvidiaDevicePlugin(ctx, node.Name)
 	}
 
 	state := plan.NewMigState(migDeviceResources)
 
 	// Check if actual state already matches spec
-	if state.Matches(specAnnotations) {
+	matchDetails := state.MatchDetails(specAnnotations)
+	if matchDetails.Matches() {
 		logger.Info("actual state matches desired MIG config")
-		return plan.MigConfigPlan{}, nil
+		return plan.MigConfigPlan{}, state, nil
 	}
+	logger.Info("actual state does not match desired MIG config", "diff", matchDetails.String())
 
 	// Compute MIG config plan
-	return plan.NewMigConfigPlan(state, specAnnotations), nil
+	configPlan := plan.NewMigConfigPlan(state, specAnnotations)
+
+	// Check that the plan fits the capacity of the GPUs, both once applied and after each of its steps,
+	// before mutating anything
+	if model, ok := getKnownModel(node); ok {
+		if err := configPlan.ValidateCapacity(state, model); err != nil {
+			return plan.MigConfigPlan{}, nil, err
+		}
+		if err := configPlan.ValidateOrder(state, model); err != nil {
+			return plan.MigConfigPlan{}, nil, err
+		}
+	}
+
+	return configPlan, state, nil
 }
 
-func (a *MigActuator) apply(ctx context.Context, plan plan.MigConfigPlan) (ctrl.Result, error) {
+func (a *MigActuator) apply(ctx context.Context, migClient mig.Client, nodeName string, plan plan.MigConfigPlan, state plan.MigState) (ctrl.Result, error) {
 	logger := a.newLogger(ctx)
 	logger.Info(
 		"applying MIG config plan",
@@ -161,10 +598,11 @@ func (a *MigActuator) apply(ctx context.Context, plan plan.MigConfigPlan) (ctrl.
 
 	var restartRequired bool
 	var atLeastOneErr bool
+	var deleted = make(gpu.DeviceList, 0)
 
 	// Apply delete operations first
 	for _, op := range plan.DeleteOperations {
-		status := a.applyDeleteOp(ctx, op)
+		status := a.applyDeleteOp(ctx, migClient, op)
 		if status.Err != nil {
 			logger.Error(status.Err, "unable to fulfill delete operation", "op", op)
 			atLeastOneErr = true
@@ -172,21 +610,45 @@ func (a *MigActuator) apply(ctx context.Context, plan plan.MigConfigPlan) (ctrl.
 		if status.PluginRestartRequired {
 			restartRequired = true
 		}
+		deleted = append(deleted, status.Deleted...)
+	}
+
+	if len(deleted) > 0 {
+		a.removeDeviceLabels(ctx, nodeName, deleted)
 	}
 
 	// Apply create operations
-	status := a.applyCreateOps(ctx, plan.CreateOperations)
+	status := a.applyCreateOps(ctx, migClient, plan.CreateOperations)
 	if status.Err != nil {
 		logger.Error(status.Err, "unable to fulfill create operations")
 		atLeastOneErr = true
 	}
+	a.addPendingDeviceLabels(nodeName, plan.CreateOperations, status.Created, state)
 	if status.PluginRestartRequired {
 		restartRequired = true
 	}
 
-	// Restart the NVIDIA device plugin if necessary
-	if restartRequired {
-		if err := a.restartNvidiaDevicePlugin(ctx); err != nil {
+	// Keep track of the change of the MIG geometry, if any operation has been applied
+	if len(deleted) > 0 || len(status.Created) > 0 {
+		if a.geometryChanges == nil {
+			a.geometryChanges = make(map[string]time.Time)
+		}
+		a.geometryChanges[nodeName] = time.Now()
+	}
+
+	// If the device plugin detects the changes by itself, there's no need to restart it
+	if restartRequired && a.devicePluginRestartStrategy == gpu.DevicePluginRestartStrategyNone {
+		logger.Info("skipping NVIDIA device plugin restart", "strategy", a.devicePluginRestartStrategy)
+		restartRequired = false
+	}
+
+	// Restart the NVIDIA device plugin if necessary, or delay the restart if a grace period is set
+	if restartRequired && a.devicePluginRestartGracePeriod > 0 {
+		logger.Info("NVIDIA device plugin restart delayed", "gracePeriod", a.devicePluginRestartGracePeriod)
+		a.schedulePluginRestart(nodeName)
+	}
+	if restartRequired && a.devicePluginRestartGracePeriod <= 0 {
+		if err := a.restartNvidiaDevicePlugin(ctx, nodeName); err != nil {
 			logger.Error(err, "unable to restart nvidia device plugin")
 			return ctrl.Result{}, err
 		}
@@ -200,20 +662,67 @@ func (a *MigActuator) apply(ctx context.Context, plan plan.MigConfigPlan) (ctrl.
 	return ctrl.Result{}, nil
 }
 
+// recordGeometryChange records the time of the last change of the MIG devices of the node provided as argument,
+// if not recorded yet, through the v1alpha1.AnnotationMigGeometryLastChanged annotation. Errors are logged and
+// not returned, and the change is recorded again by the next reconcile.
+func (a *MigActuator) recordGeometryChange(ctx context.Context, node v1.Node) {
+	changedAt, ok := a.geometryChanges[node.Name]
+	if !ok {
+		return
+	}
+	updated := node.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[v1alpha1.AnnotationMigGeometryLastChanged] = changedAt.UTC().Format(time.RFC3339)
+	if err := a.Client.Patch(ctx, updated, client.MergeFrom(&node)); err != nil {
+		a.newLogger(ctx).Error(err, "unable to record last change of MIG geometry")
+		return
+	}
+	delete(a.geometryChanges, node.Name)
+}
+
 // restartNvidiaDevicePlugin deletes the Nvidia Device Plugin pod and blocks until it is successfully recreated by
 // its daemonset
-func (a *MigActuator) restartNvidiaDevicePlugin(ctx context.Context) error {
+func (a *MigActuator) restartNvidiaDevicePlugin(ctx context.Context, nodeName string) error {
 	logger := log.FromContext(ctx)
 	logger.Info("restarting NVIDIA device plugin")
-	return a.devicePlugin.Restart(ctx, a.nodeName, 1*time.Minute)
+	return a.devicePlugin.Restart(ctx, nodeName, 1*time.Minute)
+}
+
+// schedulePluginRestart marks the restart of the NVIDIA device plugin of the node provided as argument as pending,
+// postponing any pending restart of the node until the grace period elapses
+func (a *MigActuator) schedulePluginRestart(nodeName string) {
+	if a.pendingRestarts == nil {
+		a.pendingRestarts = make(map[string]time.Time)
+	}
+	a.pendingRestarts[nodeName] = time.Now()
 }
 
-func (a *MigActuator) applyDeleteOp(ctx context.Context, op plan.DeleteOperation) plan.OperationStatus {
+// restartNvidiaDevicePluginIfDue restarts the NVIDIA device plugin of the node provided as argument if its restart
+// is pending and the grace period elapsed since the last change of the node MIG devices. If the restart is pending
+// but not due yet, it returns the time left before the restart is due.
+func (a *MigActuator) restartNvidiaDevicePluginIfDue(ctx context.Context, nodeName string) (time.Duration, error) {
+	lastChange, ok := a.pendingRestarts[nodeName]
+	if !ok {
+		return 0, nil
+	}
+	if wait := a.devicePluginRestartGracePeriod - time.Since(lastChange); wait > 0 {
+		return wait, nil
+	}
+	if err := a.restartNvidiaDevicePlugin(ctx, nodeName); err != nil {
+		return 0, err
+	}
+	delete(a.pendingRestarts, nodeName)
+	return 0, nil
+}
+
+func (a *MigActuator) applyDeleteOp(ctx context.Context, migClient mig.Client, op plan.DeleteOperation) plan.OperationStatus {
 	logger := a.newLogger(ctx)
 	var restartRequired bool
 
 	// Delete resources choosing from candidates
-	var nDeleted int
+	var deleted = make(gpu.DeviceList, 0)
 	var deleteErrors = make(gpu.ErrorList, 0)
 	for _, r := range op.Resources {
 		if !r.IsFree() {
@@ -221,7 +730,7 @@ func (a *MigActuator) applyDeleteOp(ctx context.Context, op plan.DeleteOperation
 			logger.Error(err, "cannot delete MIG resource", "resource", r)
 			continue
 		}
-		err := a.migClient.DeleteMigDevice(ctx, r)
+		err := migClient.DeleteMigDevice(ctx, r)
 		if gpu.IgnoreNotFound(err) != nil {
 			deleteErrors = append(deleteErrors, err)
 			logger.Error(err, "unable to delete MIG resource", "resource", r)
@@ -234,10 +743,10 @@ func (a *MigActuator) applyDeleteOp(ctx context.Context, op plan.DeleteOperation
 			continue
 		}
 		logger.Info("deleted MIG resource", "resource", r)
-		nDeleted++
+		deleted = append(deleted, r)
 	}
 
-	if nDeleted > 0 {
+	if len(deleted) > 0 {
 		restartRequired = true
 	}
 
@@ -245,20 +754,22 @@ func (a *MigActuator) applyDeleteOp(ctx context.Context, op plan.DeleteOperation
 		return plan.OperationStatus{
 			PluginRestartRequired: restartRequired,
 			Err:                   deleteErrors,
+			Deleted:               deleted,
 		}
 	}
 	return plan.OperationStatus{
 		PluginRestartRequired: restartRequired,
 		Err:                   nil,
+		Deleted:               deleted,
 	}
 }
 
-func (a *MigActuator) applyCreateOps(ctx context.Context, ops plan.CreateOperationList) plan.OperationStatus {
+func (a *MigActuator) applyCreateOps(ctx context.Context, migClient mig.Client, ops plan.CreateOperationList) plan.OperationStatus {
 	logger := a.newLogger(ctx)
 	logger.Info("applying create operations", "migProfiles", ops)
 
 	profileList := ops.Flatten()
-	created, err := a.migClient.CreateMigDevices(ctx, profileList)
+	created, err := migClient.CreateMigDevices(ctx, profileList)
 	if err != nil {
 		nCreated := len(created)
 		return plan.OperationStatus{
@@ -269,23 +780,54 @@ func (a *MigActuator) applyCreateOps(ctx context.Context, ops plan.CreateOperati
 				len(profileList),
 				err,
 			),
+			Created: created,
 		}
 	}
 	return plan.OperationStatus{
 		PluginRestartRequired: true,
 		Err:                   nil,
+		Created:               created,
 	}
 }
 
 func (a *MigActuator) SetupWithManager(mgr ctrl.Manager, controllerName string) error {
+	a.eventRecorder = mgr.GetEventRecorderFor(controllerName)
+	predicates := []ctrlpredicate.Predicate{
+		predicate.ExcludeDelete{},
+		// Ignore the annotations written by the agent for exposing information (e.g. usage history, device
+		// labels), which would otherwise trigger a reconcile on every update. Status annotations are kept,
+		// since the labels of the created devices are recorded once the devices are reported.
+		predicate.AnnotationsWithPrefixChanged{
+			Prefixes: []string{
+				v1alpha1.AnnotationGpuSpecPrefix,
+				v1alpha1.AnnotationGpuSpecLabelsPrefix,
+				v1alpha1.AnnotationGpuStatusPrefix,
+				v1alpha1.AnnotationPartitioningPlan,
+				v1alpha1.AnnotationMigGeometry,
+				v1alpha1.AnnotationMigAgentPaused,
+			},
+		},
+	}
+	// Reconcile only the node of the actuator. If the actuator reconciles multiple nodes,
+	// reconcile only the nodes with MIG partitioning enabled, like the single-node actuator which
+	// runs only on those nodes.
+	if a.nodeName != "" {
+		predicates = append(predicates, predicate.MatchingName{Name: a.nodeName})
+	} else {
+		migPredicate, err := ctrlpredicate.LabelSelectorPredicate(metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMig.String(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		predicates = append(predicates, migPredicate)
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(
 			&v1.Node{},
-			builder.WithPredicates(
-				predicate.ExcludeDelete{},
-				predicate.MatchingName{Name: a.nodeName},
-				predicate.AnnotationsChangedPredicate{},
-			),
+			builder.WithPredicates(predicates...),
 		).
 		Named(controllerName).
 		Complete(a)
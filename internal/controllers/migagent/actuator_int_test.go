@@ -50,8 +50,7 @@ var _ = Describe("MigAgent - Actuator", func() {
 		Expect(k8sClient.Patch(ctx, updated, client.MergeFrom(&node))).To(Succeed())
 
 		// Cleanup actuator state
-		actuator.lastAppliedStatus = nil
-		actuator.lastAppliedPlan = nil
+		actuator.lastApplied = nil
 
 		// Simulate configuration reported
 		actuator.sharedState.OnApplyDone()
@@ -18,14 +18,36 @@ package migagent
 
 import (
 	"context"
+	"fmt"
 	"github.com/nebuly-ai/nos/internal/controllers/migagent/plan"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
 	migtest "github.com/nebuly-ai/nos/pkg/test/mocks/mig"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
+	"time"
 )
 
+type fakeDevicePluginClient struct {
+	numCallsRestart   int
+	restartedNodeName []string
+}
+
+func (f *fakeDevicePluginClient) Restart(_ context.Context, nodeName string, _ time.Duration) error {
+	f.numCallsRestart++
+	f.restartedNodeName = append(f.restartedNodeName, nodeName)
+	return nil
+}
+
 func TestMigActuator_applyDeleteOp(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -106,7 +128,7 @@ func TestMigActuator_applyDeleteOp(t *testing.T) {
 			var migClient = migtest.Client{}
 			var actuator = MigActuator{migClient: &migClient}
 			migClient.ReturnedError = tt.clientReturnedError
-			status := actuator.applyDeleteOp(context.Background(), tt.op)
+			status := actuator.applyDeleteOp(context.Background(), &migClient, tt.op)
 			if tt.errorExpected {
 				assert.Error(t, status.Err)
 			}
@@ -190,7 +212,7 @@ func TestMigActuator_applyDeleteOp(t *testing.T) {
 //		migClient.Reset()
 //		migClient.ReturnedError = tt.clientReturnedError
 //		t.Run(tt.name, func(t *testing.T) {
-//			status := actuator.applyCreateOps(context.TODO(), tt.op)
+//			status := actuator.applyCreateOps(context.TODO(), &migClient, tt.op)
 //			if tt.errorExpected {
 //				assert.Error(t, status.Err)
 //			}
@@ -202,3 +224,893 @@ func TestMigActuator_applyDeleteOp(t *testing.T) {
 //		})
 //	}
 //}
+
+func TestMigActuator_validateSpec(t *testing.T) {
+	testCases := []struct {
+		name          string
+		node          v1.Node
+		spec          gpu.SpecAnnotationList
+		errorExpected bool
+	}{
+		{
+			name:          "Node without GPU model label, check should be skipped",
+			node:          factory.BuildNode("node-1").Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+		{
+			name: "Spec profiles supported by the node GPU model",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: gpu.GPUModel_A100_PCIe_80GB.String(),
+			}).Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+		{
+			name: "GPU model label differs from the model supporting the spec profiles",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: gpu.GPUModel_A30.String(),
+			}).Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb", Index: 0, Quantity: 1}},
+			errorExpected: true,
+		},
+		{
+			name: "GPU model without known MIG geometries, check should be skipped",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "NVIDIA-A100-40GB-PCIe",
+			}).Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.5gb", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+		{
+			name: "Media extensions profile not advertised by the device plugin version of the node",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct:       gpu.GPUModel_A100_PCIe_80GB.String(),
+				v1alpha1.LabelDevicePluginVersion: "v0.11.0",
+			}).Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb+me", Index: 0, Quantity: 1}},
+			errorExpected: true,
+		},
+		{
+			name: "Media extensions profile advertised by the device plugin version of the node",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct:       gpu.GPUModel_A100_PCIe_80GB.String(),
+				v1alpha1.LabelDevicePluginVersion: "v0.13.0",
+			}).Get(),
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb+me", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var actuator = MigActuator{}
+			err := actuator.validateSpec(tt.node, tt.spec)
+			if tt.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMigActuator_apply(t *testing.T) {
+	freeDevice := gpu.Device{
+		Device: resource.Device{
+			ResourceName: mig.Profile1g10gb.AsResourceName(),
+			DeviceId:     "uid-1",
+			Status:       resource.StatusFree,
+		},
+		GpuIndex: 0,
+	}
+
+	testCases := []struct {
+		name            string
+		plan            plan.MigConfigPlan
+		restartExpected bool
+	}{
+		{
+			name: "Delete and create of the same profile, should restart the device plugin since the device is recreated",
+			plan: plan.MigConfigPlan{
+				DeleteOperations: plan.DeleteOperationList{
+					{Resources: gpu.DeviceList{freeDevice}},
+				},
+				CreateOperations: plan.CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g10gb}, Quantity: 1},
+				},
+			},
+			restartExpected: true,
+		},
+		{
+			name: "Delete and create of different profiles, should restart the device plugin",
+			plan: plan.MigConfigPlan{
+				DeleteOperations: plan.DeleteOperationList{
+					{Resources: gpu.DeviceList{freeDevice}},
+				},
+				CreateOperations: plan.CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 1},
+				},
+			},
+			restartExpected: true,
+		},
+		{
+			name: "Same profile deleted and created on a different GPU, should restart the device plugin",
+			plan: plan.MigConfigPlan{
+				DeleteOperations: plan.DeleteOperationList{
+					{Resources: gpu.DeviceList{freeDevice}},
+				},
+				CreateOperations: plan.CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile1g10gb}, Quantity: 1},
+				},
+			},
+			restartExpected: true,
+		},
+		{
+			name: "Create only, should restart the device plugin",
+			plan: plan.MigConfigPlan{
+				DeleteOperations: plan.DeleteOperationList{},
+				CreateOperations: plan.CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g10gb}, Quantity: 1},
+				},
+			},
+			restartExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			migClient := migtest.Client{}
+			devicePlugin := fakeDevicePluginClient{}
+			actuator := MigActuator{Client: fake.NewClientBuilder().Build(), migClient: &migClient, devicePlugin: &devicePlugin}
+
+			_, err := actuator.apply(context.Background(), &migClient, "node-1", tt.plan, plan.MigState{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.restartExpected, devicePlugin.numCallsRestart > 0)
+		})
+	}
+}
+
+func TestMigActuator_apply__DevicePluginRestartStrategy(t *testing.T) {
+	createPlan := plan.MigConfigPlan{
+		DeleteOperations: plan.DeleteOperationList{},
+		CreateOperations: plan.CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g10gb}, Quantity: 1},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		strategy        gpu.DevicePluginRestartStrategy
+		restartExpected bool
+	}{
+		{
+			name:            "Pod delete strategy, should restart the device plugin",
+			strategy:        gpu.DevicePluginRestartStrategyPodDelete,
+			restartExpected: true,
+		},
+		{
+			name:            "Empty strategy defaults to pod delete",
+			strategy:        "",
+			restartExpected: true,
+		},
+		{
+			name:            "No restart strategy, should never restart the device plugin",
+			strategy:        gpu.DevicePluginRestartStrategyNone,
+			restartExpected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			migClient := migtest.Client{}
+			devicePlugin := fakeDevicePluginClient{}
+			actuator := MigActuator{
+				migClient:                   &migClient,
+				devicePlugin:                &devicePlugin,
+				devicePluginRestartStrategy: tt.strategy,
+			}
+
+			_, err := actuator.apply(context.Background(), &migClient, "node-1", createPlan, plan.MigState{})
+			assert.NoError(t, err)
+			assert.Equal(t, mig.ProfileList{{GpuIndex: 0, Name: mig.Profile1g10gb}}, migClient.CreatedMigProfiles)
+			assert.Equal(t, tt.restartExpected, devicePlugin.numCallsRestart > 0)
+			assert.Empty(t, actuator.pendingRestarts)
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__MaxOperationsPerReconcile(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		maxOperationsPerReconcile int
+		expectedCreated           int
+		expectedRequeue           bool
+	}{
+		{
+			name:                      "No limit, should apply all the operations",
+			maxOperationsPerReconcile: 0,
+			expectedCreated:           4,
+			expectedRequeue:           false,
+		},
+		{
+			name:                      "Limit lower than plan operations, should apply N operations and requeue",
+			maxOperationsPerReconcile: 3,
+			expectedCreated:           3,
+			expectedRequeue:           true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithAnnotations(map[string]string{
+					fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "4",
+				}).
+				Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+			sharedState := NewSharedState()
+			sharedState.OnReportDone()
+
+			opts := testActuatorOptions()
+			opts.MaxOperationsPerReconcile = tt.maxOperationsPerReconcile
+			actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, opts)
+			actuator.devicePlugin = &fakeDevicePluginClient{}
+
+			res, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			assert.NoError(t, err)
+			assert.Len(t, migClient.CreatedMigProfiles, tt.expectedCreated)
+			assert.Equal(t, tt.expectedRequeue, res.RequeueAfter > 0)
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__InsufficientCapacity(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: gpu.GPUModel_A100_PCIe_80GB.String(),
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "8",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	eventRecorder := record.NewFakeRecorder(1)
+	actuator.eventRecorder = eventRecorder
+
+	_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+	assert.ErrorIs(t, err, plan.ErrInsufficientCapacity)
+	assert.Zero(t, migClient.NumCallsCreateMigResources)
+	assert.Zero(t, migClient.NumCallsDeleteMigResource)
+	assert.Len(t, eventRecorder.Events, 1)
+}
+
+func TestMigActuator_Reconcile__UnknownGpuModel(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "A100-SXM4-40GB",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g5gb): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	actuator.eventRecorder = record.NewFakeRecorder(10)
+
+	_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), migClient.NumCallsCreateMigResources)
+}
+
+func TestMigActuator_Reconcile__NoEventRecorder(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: gpu.GPUModel_A100_PCIe_80GB.String(),
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "8",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	// The actuator is not set up with a manager, so it has no event recorder
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+
+	assert.NotPanics(t, func() {
+		_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+		assert.ErrorIs(t, err, plan.ErrInsufficientCapacity)
+	})
+}
+
+func TestMigActuator_Reconcile__GpuIndexOutOfRange(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: gpu.GPUModel_A100_PCIe_80GB.String(),
+			constant.LabelNvidiaCount:   "3",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 5, mig.Profile1g10gb): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	eventRecorder := record.NewFakeRecorder(1)
+	actuator.eventRecorder = eventRecorder
+
+	_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+	assert.ErrorIs(t, err, plan.ErrGpuIndexOutOfRange)
+	assert.Zero(t, migClient.NumCallsCreateMigResources)
+	assert.Zero(t, migClient.NumCallsDeleteMigResource)
+	assert.Len(t, eventRecorder.Events, 1)
+}
+
+func TestMigActuator_Reconcile__Paused(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pausedValue     string
+		expectedCreated int
+	}{
+		{
+			name:            "Paused node, should not apply any operation",
+			pausedValue:     "true",
+			expectedCreated: 0,
+		},
+		{
+			name:            "Pause annotation not set to true, should apply the operations",
+			pausedValue:     "false",
+			expectedCreated: 2,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithAnnotations(map[string]string{
+					fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "2",
+					v1alpha1.AnnotationMigAgentPaused:                                   tt.pausedValue,
+				}).
+				Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{
+				ReturnedMigDeviceResources: gpu.DeviceList{
+					{
+						Device: resource.Device{
+							ResourceName: mig.Profile2g20gb.AsResourceName(),
+							DeviceId:     "1",
+							Status:       resource.StatusFree,
+						},
+						GpuIndex: 0,
+					},
+				},
+			}
+			sharedState := NewSharedState()
+			sharedState.OnReportDone()
+
+			actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+			actuator.devicePlugin = &fakeDevicePluginClient{}
+
+			_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			assert.NoError(t, err)
+			assert.Len(t, migClient.CreatedMigProfiles, tt.expectedCreated)
+			if tt.expectedCreated == 0 {
+				assert.Zero(t, migClient.NumCallsDeleteMigResource)
+				assert.Zero(t, migClient.NumCallsGetMigDeviceResources)
+			}
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__UnchangedGPUs(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb):                        "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, mig.Profile1g10gb, resource.StatusFree): "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, mig.Profile2g20gb):                        "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	// The MIG devices of GPU 0 differ from its spec, but since its reported status
+	// matches the spec the GPU should be left untouched
+	migClient := migtest.Client{
+		ReturnedMigDeviceResources: gpu.DeviceList{
+			{
+				Device: resource.Device{
+					ResourceName: mig.Profile3g40gb.AsResourceName(),
+					DeviceId:     "1",
+					Status:       resource.StatusFree,
+				},
+				GpuIndex: 0,
+			},
+		},
+	}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+
+	_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+	assert.NoError(t, err)
+	assert.Zero(t, migClient.NumCallsDeleteMigResource)
+	assert.Equal(t, mig.ProfileList{{GpuIndex: 1, Name: mig.Profile2g20gb}}, migClient.CreatedMigProfiles)
+}
+
+func TestMigActuator_Reconcile__NamedGeometry(t *testing.T) {
+	namedGeometries := mig.NamedGeometries{
+		"all-1g.10gb": gpu.Geometry{mig.Profile1g10gb: 2},
+	}
+	testCases := []struct {
+		name            string
+		geometryName    string
+		expectedCreated mig.ProfileList
+		expectedErr     bool
+	}{
+		{
+			name:         "Known geometry, should be applied to all the GPUs overriding the spec annotations",
+			geometryName: "all-1g.10gb",
+			expectedCreated: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile1g10gb},
+			},
+			expectedErr: false,
+		},
+		{
+			name:            "Unknown geometry, should return error",
+			geometryName:    "foo",
+			expectedCreated: nil,
+			expectedErr:     true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaCount: "2",
+				}).
+				WithAnnotations(map[string]string{
+					fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile2g20gb): "1",
+					v1alpha1.AnnotationMigGeometry:                                      tt.geometryName,
+				}).
+				Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+			sharedState := NewSharedState()
+			sharedState.OnReportDone()
+
+			opts := testActuatorOptions()
+			opts.NamedGeometries = namedGeometries
+			actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, opts)
+			actuator.devicePlugin = &fakeDevicePluginClient{}
+			eventRecorder := record.NewFakeRecorder(1)
+			actuator.eventRecorder = eventRecorder
+
+			_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Len(t, eventRecorder.Events, 1)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.ElementsMatch(t, tt.expectedCreated, migClient.CreatedMigProfiles)
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__NamedGeometryFallback(t *testing.T) {
+	namedGeometries := mig.NamedGeometries{
+		"all-7g.79gb": gpu.Geometry{mig.Profile7g79gb: 1},
+		"balanced":    gpu.Geometry{mig.Profile3g40gb: 1, mig.Profile2g20gb: 1},
+	}
+	testCases := []struct {
+		name             string
+		existing         gpu.DeviceList
+		expectedCreated  mig.ProfileList
+		expectedSelected string
+	}{
+		{
+			name:     "Preferred geometry can be applied",
+			existing: gpu.DeviceList{},
+			expectedCreated: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile7g79gb},
+			},
+			expectedSelected: "all-7g.79gb",
+		},
+		{
+			name: "Preferred geometry requires deleting a used device, fallback should be applied",
+			existing: gpu.DeviceList{
+				{
+					Device: resource.Device{
+						ResourceName: mig.Profile3g40gb.AsResourceName(),
+						DeviceId:     "1",
+						Status:       resource.StatusUsed,
+					},
+					GpuIndex: 0,
+				},
+			},
+			expectedCreated: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile2g20gb},
+			},
+			expectedSelected: "balanced",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: string(gpu.GPUModel_A100_PCIe_80GB),
+					constant.LabelNvidiaCount:   "1",
+				}).
+				WithAnnotations(map[string]string{
+					v1alpha1.AnnotationMigGeometry: "all-7g.79gb, balanced",
+				}).
+				Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{ReturnedMigDeviceResources: tt.existing}
+			sharedState := NewSharedState()
+			sharedState.OnReportDone()
+
+			opts := testActuatorOptions()
+			opts.NamedGeometries = namedGeometries
+			actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, opts)
+			actuator.devicePlugin = &fakeDevicePluginClient{}
+			actuator.eventRecorder = record.NewFakeRecorder(10)
+
+			_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.expectedCreated, migClient.CreatedMigProfiles)
+
+			var updated v1.Node
+			assert.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&node), &updated))
+			assert.Equal(t, tt.expectedSelected, updated.Annotations[v1alpha1.AnnotationMigGeometrySelected])
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__GeometryLastChanged(t *testing.T) {
+	oldTimestamp := "2023-01-01T00:00:00Z"
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedUpdated bool
+	}{
+		{
+			name: "Status matches spec, timestamp should not be updated",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb):                        "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, mig.Profile1g10gb, resource.StatusFree): "1",
+				v1alpha1.AnnotationMigGeometryLastChanged:                                                  oldTimestamp,
+			},
+			expectedUpdated: false,
+		},
+		{
+			name: "Operations applied, timestamp should be updated",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+				v1alpha1.AnnotationMigGeometryLastChanged:                           oldTimestamp,
+			},
+			expectedUpdated: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithAnnotations(tt.annotations).Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+			sharedState := NewSharedState()
+			sharedState.OnReportDone()
+
+			actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+			actuator.devicePlugin = &fakeDevicePluginClient{}
+			actuator.eventRecorder = record.NewFakeRecorder(10)
+
+			before := time.Now().Add(-1 * time.Second)
+			_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			assert.NoError(t, err)
+
+			var updated v1.Node
+			assert.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&node), &updated))
+			lastChanged, found, err := mig.GetGeometryLastChanged(updated)
+			assert.NoError(t, err)
+			assert.True(t, found)
+			if !tt.expectedUpdated {
+				assert.Equal(t, oldTimestamp, updated.Annotations[v1alpha1.AnnotationMigGeometryLastChanged])
+				return
+			}
+			assert.True(t, lastChanged.After(before))
+
+			// Reconciling again without any change should be a no-op, leaving the timestamp untouched
+			patched := updated.DeepCopy()
+			patched.Annotations[v1alpha1.AnnotationMigGeometryLastChanged] = oldTimestamp
+			assert.NoError(t, k8sClient.Patch(context.Background(), patched, client.MergeFrom(&updated)))
+			sharedState.OnReportDone()
+			_, err = actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+			assert.NoError(t, err)
+			assert.Len(t, migClient.CreatedMigProfiles, 1)
+			assert.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&node), &updated))
+			assert.Equal(t, oldTimestamp, updated.Annotations[v1alpha1.AnnotationMigGeometryLastChanged])
+		})
+	}
+}
+
+func TestMigActuator_Reconcile__MultiNode(t *testing.T) {
+	node1 := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+		}).
+		Get()
+	node2 := factory.BuildNode("node-2").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile2g20gb): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node1, &node2).Build()
+	migClients := map[string]*migtest.Client{
+		node1.Name: {ReturnedMigDeviceResources: gpu.DeviceList{}},
+		node2.Name: {ReturnedMigDeviceResources: gpu.DeviceList{}},
+	}
+	migClientProvider := func(nodeName string) (mig.Client, error) {
+		c, ok := migClients[nodeName]
+		if !ok {
+			return nil, fmt.Errorf("unknown node %s", nodeName)
+		}
+		return c, nil
+	}
+
+	actuator := NewMultiNodeActuator(k8sClient, migClientProvider, testActuatorOptions())
+	devicePlugin := fakeDevicePluginClient{}
+	actuator.devicePlugin = &devicePlugin
+
+	for _, node := range []v1.Node{node1, node2} {
+		_, err := actuator.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, mig.ProfileList{{GpuIndex: 0, Name: mig.Profile1g10gb}}, migClients[node1.Name].CreatedMigProfiles)
+	assert.Equal(t, mig.ProfileList{{GpuIndex: 0, Name: mig.Profile2g20gb}}, migClients[node2.Name].CreatedMigProfiles)
+	assert.Equal(t, []string{node1.Name, node2.Name}, devicePlugin.restartedNodeName)
+}
+
+func TestMigActuator_Reconcile__DevicePluginRestartGracePeriod(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	opts := testActuatorOptions()
+	opts.DevicePluginRestartGracePeriod = 1 * time.Minute
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, opts)
+	devicePlugin := fakeDevicePluginClient{}
+	actuator.devicePlugin = &devicePlugin
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)}
+
+	// First apply: restart is delayed
+	res, err := actuator.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Zero(t, devicePlugin.numCallsRestart)
+	assert.Greater(t, res.RequeueAfter, time.Duration(0))
+	assert.LessOrEqual(t, res.RequeueAfter, 1*time.Minute)
+	nCreated := len(migClient.CreatedMigProfiles)
+
+	// Second apply within the grace period: restart is delayed again
+	sharedState.OnReportDone()
+	assert.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &node))
+	node.Annotations[fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile2g20gb)] = "1"
+	assert.NoError(t, k8sClient.Update(context.Background(), &node))
+	res, err = actuator.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Zero(t, devicePlugin.numCallsRestart)
+	assert.Greater(t, res.RequeueAfter, time.Duration(0))
+	assert.Greater(t, len(migClient.CreatedMigProfiles), nCreated)
+
+	// Grace period elapsed since the last change: the device plugin is restarted once
+	actuator.pendingRestarts[node.Name] = time.Now().Add(-2 * time.Minute)
+	sharedState.OnReportDone()
+	res, err = actuator.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, devicePlugin.numCallsRestart)
+	assert.Equal(t, []string{node.Name}, devicePlugin.restartedNodeName)
+	assert.Empty(t, actuator.pendingRestarts)
+}
+
+func TestMigActuator_Reconcile__MigConfigAppliedCondition(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{}}
+	sharedState := NewSharedState()
+	sharedState.OnReportDone()
+
+	actuator := NewActuator(k8sClient, &migClient, sharedState, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	actuator.eventRecorder = record.NewFakeRecorder(10)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)}
+
+	_, err := actuator.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+
+	var updated v1.Node
+	assert.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	var condition *v1.NodeCondition
+	for i := range updated.Status.Conditions {
+		if updated.Status.Conditions[i].Type == v1alpha1.NodeConditionMigConfigApplied {
+			condition = &updated.Status.Conditions[i]
+		}
+	}
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+		assert.Equal(t, ConditionReasonMigConfigApplied, condition.Reason)
+		assert.WithinDuration(t, time.Now(), condition.LastTransitionTime.Time, 1*time.Minute)
+	}
+}
+
+func TestMigActuator_SetMigConfigCondition__StaleNode(t *testing.T) {
+	node := factory.BuildNode("node-1").Get()
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	ctx := context.Background()
+
+	// The kubelet updates its conditions after the actuator fetched the node
+	var current v1.Node
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &current))
+	current.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+	assert.NoError(t, k8sClient.Status().Update(ctx, &current))
+
+	actuator := NewActuator(k8sClient, nil, nil, node.Name, testActuatorOptions())
+	actuator.setMigConfigCondition(ctx, node, v1.ConditionTrue, ConditionReasonMigConfigApplied, "applied")
+
+	var updated v1.Node
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &updated))
+	conditions := make(map[v1.NodeConditionType]v1.ConditionStatus)
+	for _, c := range updated.Status.Conditions {
+		conditions[c.Type] = c.Status
+	}
+	assert.Equal(t, map[v1.NodeConditionType]v1.ConditionStatus{
+		v1.NodeReady:                           v1.ConditionTrue,
+		v1alpha1.NodeConditionMigConfigApplied: v1.ConditionTrue,
+	}, conditions)
+}
+
+func TestMigActuator__DeviceLabels(t *testing.T) {
+	newDevice := func(id string, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: mig.Profile1g10gb.AsResourceName(),
+				DeviceId:     id,
+				Status:       status,
+			},
+			GpuIndex: 0,
+		}
+	}
+	existing := newDevice("MIG-existing", resource.StatusUsed)
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecLabelsFormat, 0, mig.Profile1g10gb): "team=ml,deployment=inference",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{existing}}
+
+	actuator := NewActuator(k8sClient, &migClient, nil, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	ctx := context.Background()
+
+	createLabels, err := parseCreateLabels(node)
+	assert.NoError(t, err)
+	configPlan := plan.MigConfigPlan{
+		DeleteOperations: plan.DeleteOperationList{},
+		CreateOperations: plan.CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g10gb}, Quantity: 1},
+		},
+	}.WithCreateLabels(createLabels)
+	_, err = actuator.apply(ctx, &migClient, node.Name, configPlan, plan.NewMigState(migClient.ReturnedMigDeviceResources))
+	assert.NoError(t, err)
+
+	// The created device is not advertised yet: labels are kept pending
+	actuator.recordDeviceLabels(ctx, &migClient, node)
+	assert.Len(t, actuator.pendingDeviceLabels[node.Name], 1)
+
+	// The created device gets advertised: its labels are recorded on the node
+	migClient.ReturnedMigDeviceResources = gpu.DeviceList{existing, newDevice("MIG-created", resource.StatusFree)}
+	actuator.recordDeviceLabels(ctx, &migClient, node)
+	assert.Empty(t, actuator.pendingDeviceLabels[node.Name])
+
+	var updated v1.Node
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &updated))
+	assert.Equal(
+		t,
+		"deployment=inference,team=ml",
+		updated.Annotations[fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, "MIG-created")],
+	)
+	assert.NotContains(t, updated.Annotations, fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, "MIG-existing"))
+}
+
+func TestMigActuator__DeviceLabels__Removed(t *testing.T) {
+	newDevice := func(id string) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: mig.Profile1g10gb.AsResourceName(),
+				DeviceId:     id,
+				Status:       resource.StatusFree,
+			},
+			GpuIndex: 0,
+		}
+	}
+	deleted := newDevice("MIG-deleted")
+	vanished := newDevice("MIG-vanished")
+	kept := newDevice("MIG-kept")
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, deleted.DeviceId):  "team=ml",
+			fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, vanished.DeviceId): "team=ml",
+			fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, kept.DeviceId):     "team=ml",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	migClient := migtest.Client{ReturnedMigDeviceResources: gpu.DeviceList{deleted, vanished, kept}}
+
+	actuator := NewActuator(k8sClient, &migClient, nil, node.Name, testActuatorOptions())
+	actuator.devicePlugin = &fakeDevicePluginClient{}
+	ctx := context.Background()
+
+	// The labels of the devices deleted by the actuator are removed
+	configPlan := plan.MigConfigPlan{
+		DeleteOperations: plan.DeleteOperationList{{Resources: gpu.DeviceList{deleted}}},
+		CreateOperations: plan.CreateOperationList{},
+	}
+	_, err := actuator.apply(ctx, &migClient, node.Name, configPlan, plan.NewMigState(migClient.ReturnedMigDeviceResources))
+	assert.NoError(t, err)
+
+	var updated v1.Node
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &updated))
+	assert.NotContains(t, updated.Annotations, fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, deleted.DeviceId))
+	assert.Contains(t, updated.Annotations, fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, vanished.DeviceId))
+
+	// The labels of the devices that are no longer advertised are removed
+	migClient.ReturnedMigDeviceResources = gpu.DeviceList{kept}
+	actuator.recordDeviceLabels(ctx, &migClient, updated)
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &updated))
+	assert.NotContains(t, updated.Annotations, fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, vanished.DeviceId))
+	assert.Equal(t, "team=ml", updated.Annotations[fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, kept.DeviceId)])
+}
+
+// testActuatorOptions returns the options of the actuators created by the tests
+func testActuatorOptions() ActuatorOptions {
+	return ActuatorOptions{
+		DeletePolicy:                plan.DeletePolicyConsolidate,
+		DevicePluginRestartStrategy: gpu.DevicePluginRestartStrategyPodDelete,
+	}
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/internal/controllers/migagent/plan"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+)
+
+// pendingDeviceLabels are the labels of a MIG device created by the actuator that have not been recorded yet,
+// since the device is not advertised by the device plugin until it gets restarted.
+type pendingDeviceLabels struct {
+	profile mig.Profile
+	labels  map[string]string
+	// knownDeviceIds are the IDs of the MIG devices existing before the device was created
+	knownDeviceIds map[string]bool
+}
+
+// parseCreateLabels returns, for each MIG profile of the GPUs of the node provided as argument, the labels
+// specified through the v1alpha1.AnnotationGpuSpecLabelsFormat annotations of the node.
+func parseCreateLabels(node v1.Node) (map[mig.Profile]map[string]string, error) {
+	res := make(map[mig.Profile]map[string]string)
+	prefix := v1alpha1.AnnotationGpuSpecLabelsPrefix + "-"
+	for k, v := range node.Annotations {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(k, prefix), "-", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid spec labels annotation key %q", k)
+		}
+		index, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU index in annotation %q: %s", k, err)
+		}
+		l, err := labels.ConvertSelectorToLabelsMap(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels in annotation %q: %s", k, err)
+		}
+		res[mig.Profile{GpuIndex: index, Name: mig.ProfileName(gpu.ProfileFromAnnotationKey(parts[1]))}] = l
+	}
+	return res, nil
+}
+
+// addPendingDeviceLabels records the labels of the MIG profiles created on the node by the create operations
+// provided as argument, so that they can be associated with the created devices once they are advertised.
+// Only the profiles actually created are considered, and devices existing in the state provided as argument
+// are never associated with the labels.
+func (a *MigActuator) addPendingDeviceLabels(nodeName string, ops plan.CreateOperationList, created mig.ProfileList, state plan.MigState) {
+	remaining := make(map[mig.Profile]int)
+	for _, p := range created {
+		remaining[p]++
+	}
+	var knownDeviceIds map[string]bool
+	for _, op := range ops {
+		for i := 0; i < op.Quantity && remaining[op.MigProfile] > 0; i++ {
+			remaining[op.MigProfile]--
+			if len(op.Labels) == 0 {
+				continue
+			}
+			if knownDeviceIds == nil {
+				knownDeviceIds = make(map[string]bool)
+				for _, d := range state.Flatten() {
+					knownDeviceIds[d.DeviceId] = true
+				}
+			}
+			if a.pendingDeviceLabels == nil {
+				a.pendingDeviceLabels = make(map[string][]pendingDeviceLabels)
+			}
+			a.pendingDeviceLabels[nodeName] = append(a.pendingDeviceLabels[nodeName], pendingDeviceLabels{
+				profile:        op.MigProfile,
+				labels:         op.Labels,
+				knownDeviceIds: knownDeviceIds,
+			})
+		}
+	}
+}
+
+// recordDeviceLabels associates the pending labels of the node provided as argument with the MIG devices
+// advertised on the node that were created after them, and exposes the labels of each device through the
+// v1alpha1.AnnotationMigDeviceLabelsFormat annotation of the node. Labels of devices that are not advertised
+// yet are kept pending, while the annotations of devices that are no longer advertised are removed.
+// Errors are logged and not returned, since labels are only informative.
+//
+// Pending labels are kept only in memory: the labels of the devices that are not advertised yet when the
+// MIG agent restarts are not recorded.
+func (a *MigActuator) recordDeviceLabels(ctx context.Context, migClient mig.Client, node v1.Node) {
+	logger := a.newLogger(ctx)
+	pending := a.pendingDeviceLabels[node.Name]
+	if len(pending) == 0 && !hasDeviceLabels(node) {
+		return
+	}
+
+	devices, err := migClient.GetMigDevices(ctx)
+	if err != nil {
+		logger.Error(err, "unable to get MIG devices for recording their labels")
+		return
+	}
+
+	updated := node.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+
+	// Remove the labels of the devices that no longer exist
+	advertised := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		advertised[d.DeviceId] = true
+	}
+	var removed bool
+	for k := range updated.Annotations {
+		if id, ok := parseDeviceLabelsKey(k); ok && !advertised[id] {
+			delete(updated.Annotations, k)
+			removed = true
+		}
+	}
+
+	// Record the labels of the created devices
+	stillPending := make([]pendingDeviceLabels, 0)
+	for _, p := range pending {
+		d, ok := findUnlabeledDevice(devices, p, updated.Annotations)
+		if !ok {
+			stillPending = append(stillPending, p)
+			continue
+		}
+		key := fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, d.DeviceId)
+		updated.Annotations[key] = labels.Set(p.labels).String()
+	}
+	if !removed && len(stillPending) == len(pending) {
+		return
+	}
+
+	if err := a.Client.Patch(ctx, updated, client.MergeFrom(&node)); err != nil {
+		logger.Error(err, "unable to record labels of MIG devices")
+		return
+	}
+	if len(pending) > 0 {
+		a.pendingDeviceLabels[node.Name] = stillPending
+	}
+}
+
+// removeDeviceLabels removes the annotations exposing the labels of the MIG devices provided as argument
+// from the node provided as argument. Errors are logged and not returned, since labels are only informative.
+func (a *MigActuator) removeDeviceLabels(ctx context.Context, nodeName string, devices gpu.DeviceList) {
+	logger := a.newLogger(ctx)
+	var node v1.Node
+	if err := a.Client.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
+		logger.Error(err, "unable to get node for removing labels of deleted MIG devices")
+		return
+	}
+	updated := node.DeepCopy()
+	var removed bool
+	for _, d := range devices {
+		key := fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, d.DeviceId)
+		if _, ok := updated.Annotations[key]; ok {
+			delete(updated.Annotations, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	if err := a.Client.Patch(ctx, updated, client.MergeFrom(&node)); err != nil {
+		logger.Error(err, "unable to remove labels of deleted MIG devices")
+	}
+}
+
+// hasDeviceLabels returns true if the node provided as argument exposes the labels of any MIG device
+func hasDeviceLabels(node v1.Node) bool {
+	for k := range node.Annotations {
+		if _, ok := parseDeviceLabelsKey(k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDeviceLabelsKey returns the ID of the MIG device whose labels are exposed by the annotation key
+// provided as argument, and false if the key is not a v1alpha1.AnnotationMigDeviceLabelsFormat annotation.
+func parseDeviceLabelsKey(key string) (string, bool) {
+	prefix := v1alpha1.AnnotationMigDeviceLabelsPrefix + "-"
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(key, prefix), true
+}
+
+// findUnlabeledDevice returns the first device among the ones provided as argument matching the MIG profile
+// of the pending labels, that did not exist before and whose labels are not exposed by any annotation.
+func findUnlabeledDevice(devices gpu.DeviceList, p pendingDeviceLabels, annotations map[string]string) (gpu.Device, bool) {
+	for _, d := range devices {
+		if d.GpuIndex != p.profile.GpuIndex || mig.GetMigProfileName(d) != p.profile.Name || p.knownDeviceIds[d.DeviceId] {
+			continue
+		}
+		if _, labeled := annotations[fmt.Sprintf(v1alpha1.AnnotationMigDeviceLabelsFormat, d.DeviceId)]; labeled {
+			continue
+		}
+		return d, true
+	}
+	return gpu.Device{}, false
+}
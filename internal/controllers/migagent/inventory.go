@@ -0,0 +1,184 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EventReasonGpuInventory is the reason of the event emitted at startup for publishing
+// the GPU inventory of the node
+const EventReasonGpuInventory = "GpuInventory"
+
+// Inventory describes the GPUs of a node and their current MIG partitioning
+type Inventory struct {
+	Model gpu.Model `json:"model"`
+	// GpuCount is the number of GPUs reported by the node labels
+	GpuCount int `json:"gpuCount"`
+	// NvmlGpuCount is the number of GPUs enumerated by NVML, it is nil if NVML is not available
+	NvmlGpuCount *int `json:"nvmlGpuCount,omitempty"`
+	// MemoryGB is the memory of each GPU, it is zero if the node does not report it
+	MemoryGB int `json:"memoryGB,omitempty"`
+	// Partitioning contains, for each GPU index, the quantity of each MIG profile of the GPU
+	Partitioning map[int]map[string]int `json:"partitioning"`
+	// DriverVersion is the version of the NVIDIA driver, it is empty if NVML is not available
+	DriverVersion string `json:"driverVersion,omitempty"`
+	// CudaDriverVersion is the version of CUDA supported by the NVIDIA driver, it is empty if NVML is not available
+	CudaDriverVersion string `json:"cudaDriverVersion,omitempty"`
+}
+
+// InventoryPublisher publishes once, at startup, the GPU inventory of the node as a Normal
+// event of the node. The versions of the NVIDIA driver and of CUDA are also exposed through
+// the node labels v1alpha1.LabelGpuDriverVersion and v1alpha1.LabelGpuCudaDriverVersion.
+type InventoryPublisher struct {
+	client.Client
+	nvmlClient    nvml.Client
+	nodeName      string
+	eventRecorder record.EventRecorder
+}
+
+// NewInventoryPublisher returns an InventoryPublisher. If nvmlClient is not nil, the published
+// inventory also includes the number of GPUs enumerated by NVML and the driver versions.
+func NewInventoryPublisher(client client.Client, nvmlClient nvml.Client, nodeName string) InventoryPublisher {
+	return InventoryPublisher{
+		Client:     client,
+		nvmlClient: nvmlClient,
+		nodeName:   nodeName,
+	}
+}
+
+// Start publishes the inventory and returns. Failures are only logged, since the
+// inventory is informational and must not prevent the agent from running.
+func (p *InventoryPublisher) Start(ctx context.Context) error {
+	logger := klog.FromContext(ctx).WithName("InventoryPublisher")
+	if err := p.publish(ctx); err != nil {
+		logger.Error(err, "unable to publish GPU inventory")
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the inventory is
+// published by every agent instance.
+func (p *InventoryPublisher) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *InventoryPublisher) publish(ctx context.Context) error {
+	var node v1.Node
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: p.nodeName}, &node); err != nil {
+		return err
+	}
+	inventory, err := p.getInventory(ctx, node)
+	if err != nil {
+		return err
+	}
+	msg, err := json.Marshal(inventory)
+	if err != nil {
+		return err
+	}
+	p.eventRecorder.Event(&node, v1.EventTypeNormal, EventReasonGpuInventory, string(msg))
+	return p.updateVersionLabels(ctx, node, inventory)
+}
+
+// updateVersionLabels exposes the driver versions of the inventory provided as argument through
+// the node labels. Versions that are unknown or that are not valid label values are skipped.
+func (p *InventoryPublisher) updateVersionLabels(ctx context.Context, node v1.Node, inventory Inventory) error {
+	versionLabels := map[string]string{
+		v1alpha1.LabelGpuDriverVersion:     inventory.DriverVersion,
+		v1alpha1.LabelGpuCudaDriverVersion: inventory.CudaDriverVersion,
+	}
+	updated := node.DeepCopy()
+	if updated.Labels == nil {
+		updated.Labels = make(map[string]string)
+	}
+	var changed bool
+	for k, v := range versionLabels {
+		if v == "" || len(validation.IsValidLabelValue(v)) > 0 || updated.Labels[k] == v {
+			continue
+		}
+		updated.Labels[k] = v
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return p.Client.Patch(ctx, updated, client.MergeFrom(&node))
+}
+
+func (p *InventoryPublisher) getInventory(ctx context.Context, node v1.Node) (Inventory, error) {
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	migNode, err := mig.NewNode(*nodeInfo)
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	model, err := gpu.GetModel(node)
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	inventory := Inventory{
+		Model:        model,
+		GpuCount:     len(migNode.GPUs),
+		Partitioning: make(map[int]map[string]int, len(migNode.GPUs)),
+	}
+	if memoryGB, err := gpu.GetMemoryGB(node); err == nil {
+		inventory.MemoryGB = memoryGB
+	}
+	for _, g := range migNode.GPUs {
+		profiles := make(map[string]int)
+		for profile, quantity := range g.GetGeometry() {
+			profiles[profile.String()] = quantity
+		}
+		inventory.Partitioning[g.GetIndex()] = profiles
+	}
+
+	if p.nvmlClient != nil {
+		count, err := p.nvmlClient.GetGpuCount(ctx)
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "unable to get GPU count from NVML")
+		} else {
+			inventory.NvmlGpuCount = &count
+		}
+		if inventory.DriverVersion, err = p.nvmlClient.GetDriverVersion(ctx); err != nil {
+			klog.FromContext(ctx).Error(err, "unable to get driver version from NVML")
+		}
+		if inventory.CudaDriverVersion, err = p.nvmlClient.GetCudaDriverVersion(ctx); err != nil {
+			klog.FromContext(ctx).Error(err, "unable to get CUDA driver version from NVML")
+		}
+	}
+
+	return inventory, nil
+}
+
+func (p *InventoryPublisher) SetupWithManager(mgr ctrl.Manager, name string) error {
+	p.eventRecorder = mgr.GetEventRecorderFor(name)
+	return mgr.Add(p)
+}
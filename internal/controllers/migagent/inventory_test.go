@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	mockednvml "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"testing"
+)
+
+func TestInventoryPublisher__Start(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		withNvml             bool
+		expectedNvmlGpuCount *int
+		expectedLabels       map[string]string
+	}{
+		{
+			name:                 "Without NVML client",
+			withNvml:             false,
+			expectedNvmlGpuCount: nil,
+			expectedLabels:       map[string]string{},
+		},
+		{
+			name:                 "With NVML client",
+			withNvml:             true,
+			expectedNvmlGpuCount: func() *int { c := 3; return &c }(),
+			expectedLabels: map[string]string{
+				v1alpha1.LabelGpuDriverVersion:     "535.104.05",
+				v1alpha1.LabelGpuCudaDriverVersion: "12.2",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: string(gpu.GPUModel_A100_PCIe_80GB),
+					constant.LabelNvidiaCount:   "2",
+					constant.LabelNvidiaMemory:  "81920",
+				}).
+				WithAnnotations(map[string]string{
+					fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusFree): "2",
+					fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "2g.20gb", resource.StatusUsed): "1",
+				}).
+				Get()
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			recorder := record.NewFakeRecorder(10)
+
+			var nvmlClient nvml.Client
+			if tt.withNvml {
+				mocked := mockednvml.Client{}
+				mocked.On("GetGpuCount", mock.Anything).Return(3, nil)
+				mocked.On("GetDriverVersion", mock.Anything).Return("535.104.05", nil)
+				mocked.On("GetCudaDriverVersion", mock.Anything).Return("12.2", nil)
+				nvmlClient = &mocked
+			}
+			publisher := NewInventoryPublisher(k8sClient, nvmlClient, node.Name)
+			publisher.eventRecorder = recorder
+
+			assert.NoError(t, publisher.Start(context.Background()))
+			assert.Len(t, recorder.Events, 1)
+
+			event := <-recorder.Events
+			assert.Contains(t, event, EventReasonGpuInventory)
+			var inventory Inventory
+			msg := event[strings.Index(event, "{"):]
+			assert.NoError(t, json.Unmarshal([]byte(msg), &inventory))
+			assert.Equal(t, gpu.GPUModel_A100_PCIe_80GB, inventory.Model)
+			assert.Equal(t, 2, inventory.GpuCount)
+			assert.Equal(t, tt.expectedNvmlGpuCount, inventory.NvmlGpuCount)
+			assert.Equal(t, 80, inventory.MemoryGB)
+			assert.Equal(t, map[int]map[string]int{
+				0: {"1g.10gb": 2, "2g.20gb": 1},
+				1: {},
+			}, inventory.Partitioning)
+
+			var updated v1.Node
+			assert.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&node), &updated))
+			for k, v := range tt.expectedLabels {
+				assert.Equal(t, v, updated.Labels[k])
+				assert.Equal(t, v, map[string]string{
+					v1alpha1.LabelGpuDriverVersion:     inventory.DriverVersion,
+					v1alpha1.LabelGpuCudaDriverVersion: inventory.CudaDriverVersion,
+				}[k])
+			}
+			if len(tt.expectedLabels) == 0 {
+				assert.NotContains(t, updated.Labels, v1alpha1.LabelGpuDriverVersion)
+				assert.NotContains(t, updated.Labels, v1alpha1.LabelGpuCudaDriverVersion)
+			}
+		})
+	}
+}
+
+func TestInventoryPublisher__Start__NodeWithoutGpuLabels(t *testing.T) {
+	node := factory.BuildNode("node-1").Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	recorder := record.NewFakeRecorder(10)
+	publisher := NewInventoryPublisher(k8sClient, nil, node.Name)
+	publisher.eventRecorder = recorder
+
+	assert.NoError(t, publisher.Start(context.Background()))
+	assert.Len(t, recorder.Events, 0)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"sort"
+)
+
+// DeletePolicy defines the order in which the resources to delete of a plan are deleted. Since the operations
+// applied in a single reconcile can be limited (see MigConfigPlan.Limit), the policy determines which
+// resources are deleted first.
+type DeletePolicy string
+
+const (
+	// DeletePolicyConsolidate deletes first the resources of the GPUs that will receive create operations,
+	// so that the work is consolidated on the same GPUs, and then the resources of the least fragmented GPUs,
+	// namely the ones with fewer MIG devices.
+	DeletePolicyConsolidate DeletePolicy = "consolidate"
+	// DeletePolicySpread deletes the resources alternating among the GPUs, starting from the most fragmented
+	// ones (namely the ones with more MIG devices), so that deletions are spread across all the GPUs.
+	DeletePolicySpread DeletePolicy = "spread"
+)
+
+// Validate returns an error if the policy is not valid. An empty policy is valid and
+// corresponds to DeletePolicyConsolidate.
+func (p DeletePolicy) Validate() error {
+	switch p {
+	case "", DeletePolicyConsolidate, DeletePolicySpread:
+		return nil
+	default:
+		return fmt.Errorf("invalid delete policy %q", p)
+	}
+}
+
+// OrderDeleteCandidates returns the candidate resources provided as argument ordered according to the
+// delete policy provided as argument, taking into account the current MIG state of the GPUs and the
+// create operations that will be applied after deleting the resources. An empty policy corresponds
+// to DeletePolicyConsolidate.
+//
+// The ordering is deterministic: resources that are equivalent according to the policy are
+// ordered by GPU index and device ID. The candidates provided as argument are not modified.
+func OrderDeleteCandidates(candidates gpu.DeviceList, state MigState, createOps CreateOperationList, policy DeletePolicy) gpu.DeviceList {
+	res := make(gpu.DeviceList, len(candidates))
+	copy(res, candidates)
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].GpuIndex != res[j].GpuIndex {
+			return res[i].GpuIndex < res[j].GpuIndex
+		}
+		return res[i].DeviceId < res[j].DeviceId
+	})
+
+	switch policy {
+	case DeletePolicySpread:
+		return orderSpread(res, state)
+	default:
+		return orderConsolidate(res, state, createOps)
+	}
+}
+
+// WithDeletePolicy returns a copy of the plan whose resources to delete are ordered according to the delete
+// policy provided as argument (see OrderDeleteCandidates), so that the resources preferred by the policy are
+// the ones kept when the plan is limited through MigConfigPlan.Limit. Consecutive resources of the same MIG
+// profile are grouped into a single delete operation.
+func (p MigConfigPlan) WithDeletePolicy(state MigState, policy DeletePolicy) MigConfigPlan {
+	res := MigConfigPlan{
+		DeleteOperations: make(DeleteOperationList, 0),
+		CreateOperations: p.CreateOperations,
+	}
+	for _, r := range OrderDeleteCandidates(p.getResourcesToDelete(), state, p.CreateOperations, policy) {
+		last := len(res.DeleteOperations) - 1
+		if last >= 0 && res.DeleteOperations[last].GetMigProfileName() == mig.GetMigProfileName(r) {
+			res.DeleteOperations[last].Resources = append(res.DeleteOperations[last].Resources, r)
+			continue
+		}
+		res.addDeleteOp(DeleteOperation{Resources: gpu.DeviceList{r}})
+	}
+	return res
+}
+
+func orderConsolidate(candidates gpu.DeviceList, state MigState, createOps CreateOperationList) gpu.DeviceList {
+	gpusWithCreateOps := make(map[int]bool)
+	for _, op := range createOps {
+		if op.Quantity > 0 {
+			gpusWithCreateOps[op.MigProfile.GpuIndex] = true
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		gi, gj := candidates[i].GpuIndex, candidates[j].GpuIndex
+		if gpusWithCreateOps[gi] != gpusWithCreateOps[gj] {
+			return gpusWithCreateOps[gi]
+		}
+		return len(state[gi]) < len(state[gj])
+	})
+	return candidates
+}
+
+func orderSpread(candidates gpu.DeviceList, state MigState) gpu.DeviceList {
+	byGpu := candidates.GroupByGpuIndex()
+	gpuIndexes := make([]int, 0, len(byGpu))
+	for gpuIndex := range byGpu {
+		gpuIndexes = append(gpuIndexes, gpuIndex)
+	}
+	sort.Slice(gpuIndexes, func(i, j int) bool {
+		gi, gj := gpuIndexes[i], gpuIndexes[j]
+		if len(state[gi]) != len(state[gj]) {
+			return len(state[gi]) > len(state[gj])
+		}
+		return gi < gj
+	})
+
+	res := make(gpu.DeviceList, 0, len(candidates))
+	for len(res) < len(candidates) {
+		for _, gpuIndex := range gpuIndexes {
+			if len(byGpu[gpuIndex]) == 0 {
+				continue
+			}
+			res = append(res, byGpu[gpuIndex][0])
+			byGpu[gpuIndex] = byGpu[gpuIndex][1:]
+		}
+	}
+	return res
+}
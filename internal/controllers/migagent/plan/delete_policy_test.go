@@ -0,0 +1,181 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newFreeDevice(deviceId string, gpuIndex int) gpu.Device {
+	return gpu.Device{
+		Device: resource.Device{
+			ResourceName: mig.Profile1g10gb.AsResourceName(),
+			DeviceId:     deviceId,
+			Status:       resource.StatusFree,
+		},
+		GpuIndex: gpuIndex,
+	}
+}
+
+func TestOrderDeleteCandidates(t *testing.T) {
+	// GPU 0 has 4 devices, GPU 1 has 2 devices, GPU 2 has 3 devices
+	state := NewMigState(gpu.DeviceList{
+		newFreeDevice("0-a", 0),
+		newFreeDevice("0-b", 0),
+		newFreeDevice("0-c", 0),
+		newFreeDevice("0-d", 0),
+		newFreeDevice("1-a", 1),
+		newFreeDevice("1-b", 1),
+		newFreeDevice("2-a", 2),
+		newFreeDevice("2-b", 2),
+		newFreeDevice("2-c", 2),
+	})
+	candidates := gpu.DeviceList{
+		newFreeDevice("0-b", 0),
+		newFreeDevice("1-b", 1),
+		newFreeDevice("0-a", 0),
+		newFreeDevice("2-a", 2),
+		newFreeDevice("1-a", 1),
+		newFreeDevice("2-b", 2),
+	}
+
+	testCases := []struct {
+		name      string
+		policy    DeletePolicy
+		createOps CreateOperationList
+		expected  []string
+	}{
+		{
+			name:      "Consolidate, no create ops: least fragmented GPUs first",
+			policy:    DeletePolicyConsolidate,
+			createOps: CreateOperationList{},
+			expected:  []string{"1-a", "1-b", "2-a", "2-b", "0-a", "0-b"},
+		},
+		{
+			name:   "Consolidate: GPUs receiving create ops first",
+			policy: DeletePolicyConsolidate,
+			createOps: CreateOperationList{
+				{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 1},
+			},
+			expected: []string{"0-a", "0-b", "1-a", "1-b", "2-a", "2-b"},
+		},
+		{
+			name:   "Empty policy defaults to consolidate",
+			policy: "",
+			createOps: CreateOperationList{
+				{MigProfile: mig.Profile{GpuIndex: 2, Name: mig.Profile2g20gb}, Quantity: 1},
+			},
+			expected: []string{"2-a", "2-b", "1-a", "1-b", "0-a", "0-b"},
+		},
+		{
+			name:      "Spread: alternate GPUs, most fragmented first",
+			policy:    DeletePolicySpread,
+			createOps: CreateOperationList{},
+			expected:  []string{"0-a", "2-a", "1-a", "0-b", "2-b", "1-b"},
+		},
+		{
+			name:   "Spread: create ops are ignored",
+			policy: DeletePolicySpread,
+			createOps: CreateOperationList{
+				{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile2g20gb}, Quantity: 1},
+			},
+			expected: []string{"0-a", "2-a", "1-a", "0-b", "2-b", "1-b"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			original := make(gpu.DeviceList, len(candidates))
+			copy(original, candidates)
+
+			res := OrderDeleteCandidates(candidates, state, tt.createOps, tt.policy)
+			deviceIds := make([]string, 0, len(res))
+			for _, d := range res {
+				deviceIds = append(deviceIds, d.DeviceId)
+			}
+			assert.Equal(t, tt.expected, deviceIds)
+			assert.Equal(t, original, candidates)
+		})
+	}
+}
+
+func TestMigConfigPlan__WithDeletePolicy(t *testing.T) {
+	// GPU 0 has 1 device, GPU 1 has 3 devices, none of them is included in the spec
+	state := NewMigState(gpu.DeviceList{
+		newFreeDevice("0-a", 0),
+		newFreeDevice("1-a", 1),
+		newFreeDevice("1-b", 1),
+		newFreeDevice("1-c", 1),
+	})
+	configPlan := NewMigConfigPlan(state, gpu.SpecAnnotationList{})
+
+	testCases := []struct {
+		name          string
+		policy        DeletePolicy
+		maxOperations int
+		expected      []string
+	}{
+		{
+			name:          "Consolidate: devices of the least fragmented GPU are deleted first",
+			policy:        DeletePolicyConsolidate,
+			maxOperations: 2,
+			expected:      []string{"0-a", "1-a"},
+		},
+		{
+			name:          "Spread: devices of the most fragmented GPU are deleted first",
+			policy:        DeletePolicySpread,
+			maxOperations: 2,
+			expected:      []string{"1-a", "0-a"},
+		},
+		{
+			name:          "Spread, single operation",
+			policy:        DeletePolicySpread,
+			maxOperations: 1,
+			expected:      []string{"1-a"},
+		},
+		{
+			name:          "No limit: all the devices are deleted regardless of the policy",
+			policy:        DeletePolicyConsolidate,
+			maxOperations: 0,
+			expected:      []string{"0-a", "1-a", "1-b", "1-c"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			limited, _ := configPlan.WithDeletePolicy(state, tt.policy).Limit(tt.maxOperations)
+			deviceIds := make([]string, 0)
+			for _, op := range limited.DeleteOperations {
+				for _, r := range op.Resources {
+					deviceIds = append(deviceIds, r.DeviceId)
+				}
+			}
+			assert.Equal(t, tt.expected, deviceIds)
+		})
+	}
+}
+
+func TestDeletePolicy__Validate(t *testing.T) {
+	assert.NoError(t, DeletePolicy("").Validate())
+	assert.NoError(t, DeletePolicyConsolidate.Validate())
+	assert.NoError(t, DeletePolicySpread.Validate())
+	assert.Error(t, DeletePolicy("foo").Validate())
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"errors"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+)
+
+// IsAchievable returns true if the MIG config specified by the spec annotations provided as argument can be
+// fully applied starting from the state provided as argument. Since used devices are never deleted when
+// applying a plan, a spec is not achievable if it requires deleting more devices of a MIG profile than the
+// free ones. If model is not empty, a spec is also not achievable if its plan exceeds the capacity of the GPUs.
+func IsAchievable(state MigState, desired gpu.SpecAnnotationList, model gpu.Model) bool {
+	free := make(map[int]gpu.Geometry)
+	for _, r := range state.Flatten().GetFree() {
+		if free[r.GpuIndex] == nil {
+			free[r.GpuIndex] = make(gpu.Geometry)
+		}
+		free[r.GpuIndex][mig.GetMigProfileName(r)]++
+	}
+	for gpuIndex, extra := range state.MatchDetails(desired).Extra {
+		for profile, quantity := range extra {
+			if quantity > free[gpuIndex][profile] {
+				return false
+			}
+		}
+	}
+
+	if model == "" {
+		return true
+	}
+	configPlan := NewMigConfigPlan(state, desired)
+	if err := configPlan.ValidateCapacity(state, model); errors.Is(err, ErrInsufficientCapacity) {
+		return false
+	}
+	if err := configPlan.ValidateOrder(state, model); errors.Is(err, ErrInsufficientCapacity) {
+		return false
+	}
+	return true
+}
+
+// SelectAchievable returns the index of the first spec among the ones provided as argument, sorted by
+// preference, that is achievable starting from the state provided as argument. See IsAchievable.
+// If none of the specs is achievable, SelectAchievable returns 0, so that the preferred spec is
+// applied as far as possible.
+func SelectAchievable(state MigState, candidates []gpu.SpecAnnotationList, model gpu.Model) int {
+	for i, c := range candidates {
+		if IsAchievable(state, c, model) {
+			return i
+		}
+	}
+	return 0
+}
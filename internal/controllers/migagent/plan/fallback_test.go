@@ -0,0 +1,125 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelectAchievable(t *testing.T) {
+	device := func(profile mig.ProfileName, id string, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: profile.AsResourceName(),
+				DeviceId:     id,
+				Status:       status,
+			},
+			GpuIndex: 0,
+		}
+	}
+	spec := func(quantities map[mig.ProfileName]int) gpu.SpecAnnotationList {
+		res := make(gpu.SpecAnnotationList, 0)
+		for p, q := range quantities {
+			res = append(res, gpu.SpecAnnotation{ProfileName: p.String(), Index: 0, Quantity: q})
+		}
+		return res
+	}
+
+	testCases := []struct {
+		name       string
+		state      MigState
+		candidates []gpu.SpecAnnotationList
+		model      gpu.Model
+		expected   int
+	}{
+		{
+			name:  "Primary spec is achievable",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile1g10gb, "1", resource.StatusFree)}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile1g10gb: 7}),
+				spec(map[mig.ProfileName]int{mig.Profile7g79gb: 1}),
+			},
+			model:    gpu.GPUModel_A100_PCIe_80GB,
+			expected: 0,
+		},
+		{
+			name:  "Primary spec requires deleting a used device, fallback is selected",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile3g40gb, "1", resource.StatusUsed)}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile7g79gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 1, mig.Profile2g20gb: 1}),
+			},
+			model:    gpu.GPUModel_A100_PCIe_80GB,
+			expected: 1,
+		},
+		{
+			name: "Free devices can be deleted, primary spec is achievable",
+			state: NewMigState(gpu.DeviceList{
+				device(mig.Profile3g40gb, "1", resource.StatusFree),
+				device(mig.Profile3g40gb, "2", resource.StatusUsed),
+			}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 1, mig.Profile1g10gb: 3}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 2}),
+			},
+			model:    gpu.GPUModel_A100_PCIe_80GB,
+			expected: 0,
+		},
+		{
+			name:  "Fallback exceeding GPU capacity is skipped",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile3g40gb, "1", resource.StatusUsed)}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile7g79gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 2, mig.Profile2g20gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 1, mig.Profile1g10gb: 2}),
+			},
+			model:    gpu.GPUModel_A100_PCIe_80GB,
+			expected: 2,
+		},
+		{
+			name:  "Capacity is not checked if model is empty",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile3g40gb, "1", resource.StatusUsed)}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile7g79gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 2, mig.Profile2g20gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile3g40gb: 1, mig.Profile1g10gb: 2}),
+			},
+			model:    "",
+			expected: 1,
+		},
+		{
+			name:  "No spec is achievable, primary is selected",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile3g40gb, "1", resource.StatusUsed)}),
+			candidates: []gpu.SpecAnnotationList{
+				spec(map[mig.ProfileName]int{mig.Profile7g79gb: 1}),
+				spec(map[mig.ProfileName]int{mig.Profile1g10gb: 7}),
+			},
+			model:    gpu.GPUModel_A100_PCIe_80GB,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SelectAchievable(tt.state, tt.candidates, tt.model))
+		})
+	}
+}
@@ -18,10 +18,12 @@ package plan
 
 import (
 	"fmt"
-	"github.com/google/go-cmp/cmp"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
 	"github.com/nebuly-ai/nos/pkg/util"
+	"sort"
+	"strings"
 )
 
 // MigState represents the current state in terms of MIG resources of each GPU (which index is stored as key
@@ -39,26 +41,91 @@ func NewMigState(resources gpu.DeviceList) MigState {
 	return res
 }
 
+// Matches returns true if the MIG devices of each GPU correspond to the ones specified by the spec
+// annotations provided as argument. Profiles with zero quantity are considered equivalent to absent ones.
 func (s MigState) Matches(specAnnotations gpu.SpecAnnotationList) bool {
-	getKey := func(name mig.ProfileName, gpuIndex int) string {
-		return fmt.Sprintf("%d-%s", gpuIndex, name)
-	}
+	return s.MatchDetails(specAnnotations).Matches()
+}
 
-	specGpuIndexWithMigProfileQuantities := make(map[string]int)
+// MatchDetails returns the differences between the MIG devices of each GPU and the ones specified by the
+// spec annotations provided as argument, that is the MIG profiles that are missing and the ones that are
+// in excess on each GPU. Profiles with zero quantity are considered equivalent to absent ones.
+func (s MigState) MatchDetails(specAnnotations gpu.SpecAnnotationList) MatchDetails {
+	specGeometries := make(map[int]gpu.Geometry)
 	for _, a := range specAnnotations {
-		key := getKey(mig.ProfileName(a.ProfileName), a.Index)
-		specGpuIndexWithMigProfileQuantities[key] += a.Quantity
+		if specGeometries[a.Index] == nil {
+			specGeometries[a.Index] = make(gpu.Geometry)
+		}
+		specGeometries[a.Index][mig.ProfileName(a.ProfileName)] += a.Quantity
+	}
+
+	stateGeometries := make(map[int]gpu.Geometry)
+	for _, r := range s.Flatten() {
+		if stateGeometries[r.GpuIndex] == nil {
+			stateGeometries[r.GpuIndex] = make(gpu.Geometry)
+		}
+		stateGeometries[r.GpuIndex][mig.GetMigProfileName(r)]++
+	}
+
+	res := MatchDetails{
+		Missing: make(map[int]gpu.Geometry),
+		Extra:   make(map[int]gpu.Geometry),
+	}
+	for gpuIndex, geometry := range specGeometries {
+		if missing := geometry.Subtract(stateGeometries[gpuIndex]); len(missing) > 0 {
+			res.Missing[gpuIndex] = missing
+		}
+	}
+	for gpuIndex, geometry := range stateGeometries {
+		if extra := geometry.Subtract(specGeometries[gpuIndex]); len(extra) > 0 {
+			res.Extra[gpuIndex] = extra
+		}
 	}
+	return res
+}
+
+// MatchDetails describes the differences between the MIG devices of the GPUs and the ones
+// specified by the spec annotations
+type MatchDetails struct {
+	// Missing contains, for each GPU index, the quantity of each MIG profile specified by
+	// the spec that is missing on the GPU
+	Missing map[int]gpu.Geometry
+	// Extra contains, for each GPU index, the quantity of each MIG profile existing on
+	// the GPU in excess of the spec
+	Extra map[int]gpu.Geometry
+}
+
+// Matches returns true if there are no missing or extra MIG profiles on any GPU
+func (d MatchDetails) Matches() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0
+}
 
-	stateGpuIndexWithMigProfileQuantities := make(map[string]int)
-	groupedBy := s.Flatten().GroupBy(func(r gpu.Device) string {
-		return getKey(mig.GetMigProfileName(r), r.GpuIndex)
-	})
-	for k, v := range groupedBy {
-		stateGpuIndexWithMigProfileQuantities[k] = len(v)
+// String returns a description of the missing and extra MIG profiles of each GPU, sorted by GPU index.
+// Example: "GPU 0: missing [1g.10gb:2], extra [3g.40gb:1]; GPU 1: extra [7g.79gb:1]"
+func (d MatchDetails) String() string {
+	indexes := make([]int, 0, len(d.Missing)+len(d.Extra))
+	for i := range d.Missing {
+		indexes = append(indexes, i)
 	}
+	for i := range d.Extra {
+		if _, ok := d.Missing[i]; !ok {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
 
-	return cmp.Equal(specGpuIndexWithMigProfileQuantities, stateGpuIndexWithMigProfileQuantities)
+	items := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		diffs := make([]string, 0, 2)
+		if missing, ok := d.Missing[i]; ok {
+			diffs = append(diffs, fmt.Sprintf("missing [%s]", strings.TrimSuffix(missing.String(), ", ")))
+		}
+		if extra, ok := d.Extra[i]; ok {
+			diffs = append(diffs, fmt.Sprintf("extra [%s]", strings.TrimSuffix(extra.String(), ", ")))
+		}
+		items = append(items, fmt.Sprintf("GPU %d: %s", i, strings.Join(diffs, ", ")))
+	}
+	return strings.Join(items, "; ")
 }
 
 func (s MigState) Flatten() gpu.DeviceList {
@@ -85,3 +152,45 @@ func (s MigState) WithoutMigProfiles(gpuIndex int, migProfiles []mig.ProfileName
 	}
 	return res
 }
+
+// Apply returns the state resulting from applying the plan provided as argument to a copy of the state,
+// without modifying the state itself. Like the MIG actuator, Apply never deletes resources being used.
+//
+// The resources created by the plan are free and have a simulated device ID, since the actual ID is
+// assigned by the hardware when creating the MIG device.
+func (s MigState) Apply(plan MigConfigPlan) MigState {
+	deleted := make(map[string]bool)
+	for _, op := range plan.DeleteOperations {
+		for _, r := range op.Resources {
+			if r.IsFree() {
+				deleted[r.FullResourceName()+"/"+r.DeviceId] = true
+			}
+		}
+	}
+
+	res := make(MigState)
+	for gpuIndex, resources := range s {
+		res[gpuIndex] = make(gpu.DeviceList, 0)
+		for _, r := range resources {
+			if !deleted[r.FullResourceName()+"/"+r.DeviceId] {
+				res[gpuIndex] = append(res[gpuIndex], r)
+			}
+		}
+	}
+
+	for _, op := range plan.CreateOperations {
+		for i := 0; i < op.Quantity; i++ {
+			r := gpu.Device{
+				Device: resource.Device{
+					ResourceName: op.MigProfile.Name.AsResourceName(),
+					DeviceId:     fmt.Sprintf("simulated-%d-%d", op.MigProfile.GpuIndex, len(res[op.MigProfile.GpuIndex])),
+					Status:       resource.StatusFree,
+				},
+				GpuIndex: op.MigProfile.GpuIndex,
+			}
+			res[r.GpuIndex] = append(res[r.GpuIndex], r)
+		}
+	}
+
+	return res
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/resource"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -80,6 +81,38 @@ func TestMigState_Matches(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "Zero-quantity spec profiles are equivalent to absent ones",
+			stateResources: []gpu.Device{
+				{
+					Device: resource.Device{
+						ResourceName: v1.ResourceName("nvidia.com/mig-1g.10gb"),
+					},
+					GpuIndex: 0,
+				},
+			},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "2g.20gb"): "0",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, "1g.10gb"): "0",
+			},
+			expected: true,
+		},
+		{
+			name: "Does not match",
+			stateResources: []gpu.Device{
+				{
+					Device: resource.Device{
+						ResourceName: v1.ResourceName("nvidia.com/mig-1g.10gb"),
+					},
+					GpuIndex: 0,
+				},
+			},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, "1g.10gb"): "1",
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -95,3 +128,133 @@ func TestMigState_Matches(t *testing.T) {
 		})
 	}
 }
+
+func TestMigState_MatchDetails(t *testing.T) {
+	device := func(profile string, gpuIndex int) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: v1.ResourceName("nvidia.com/mig-" + profile),
+			},
+			GpuIndex: gpuIndex,
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		stateResources  []gpu.Device
+		spec            map[string]string
+		expectedMissing map[int]gpu.Geometry
+		expectedExtra   map[int]gpu.Geometry
+		expectedString  string
+	}{
+		{
+			name: "Matches",
+			stateResources: []gpu.Device{
+				device("1g.10gb", 0),
+				device("1g.10gb", 0),
+			},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, "1g.10gb"): "0",
+			},
+			expectedMissing: map[int]gpu.Geometry{},
+			expectedExtra:   map[int]gpu.Geometry{},
+			expectedString:  "",
+		},
+		{
+			name: "Missing and extra profiles on multiple GPUs",
+			stateResources: []gpu.Device{
+				device("1g.10gb", 0),
+				device("3g.40gb", 0),
+				device("7g.79gb", 1),
+			},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"): "3",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 2, "2g.20gb"): "1",
+			},
+			expectedMissing: map[int]gpu.Geometry{
+				0: {mig.Profile1g10gb: 2},
+				2: {mig.Profile2g20gb: 1},
+			},
+			expectedExtra: map[int]gpu.Geometry{
+				0: {mig.Profile3g40gb: 1},
+				1: {mig.Profile7g79gb: 1},
+			},
+			expectedString: "GPU 0: missing [1g.10gb:2], extra [3g.40gb:1]; GPU 1: extra [7g.79gb:1]; GPU 2: missing [2g.20gb:1]",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			specAnnotations := make(gpu.SpecAnnotationList, 0)
+			for k, v := range tt.spec {
+				a, _ := gpu.ParseSpecAnnotation(k, v)
+				specAnnotations = append(specAnnotations, a)
+			}
+			state := NewMigState(tt.stateResources)
+			details := state.MatchDetails(specAnnotations)
+			assert.Equal(t, tt.expectedMissing, details.Missing)
+			assert.Equal(t, tt.expectedExtra, details.Extra)
+			assert.Equal(t, tt.expectedString, details.String())
+			assert.Equal(t, len(tt.expectedMissing) == 0 && len(tt.expectedExtra) == 0, state.Matches(specAnnotations))
+		})
+	}
+}
+
+func TestMigState_Apply(t *testing.T) {
+	device := func(gpuIndex int, profile string, id string, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: v1.ResourceName("nvidia.com/mig-" + profile),
+				DeviceId:     id,
+				Status:       status,
+			},
+			GpuIndex: gpuIndex,
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		stateResources gpu.DeviceList
+		spec           map[string]string
+	}{
+		{
+			name:           "Empty state",
+			stateResources: gpu.DeviceList{},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, "2g.20gb"): "1",
+			},
+		},
+		{
+			name: "Free resources are replaced, used ones are kept",
+			stateResources: gpu.DeviceList{
+				device(0, "1g.10gb", "1", resource.StatusUsed),
+				device(0, "1g.10gb", "2", resource.StatusFree),
+				device(0, "3g.40gb", "3", resource.StatusFree),
+				device(1, "7g.79gb", "4", resource.StatusFree),
+			},
+			spec: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "2g.20gb"): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, "1g.10gb"): "3",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := v1.Node{}
+			node.Annotations = tt.spec
+			_, specAnnotations := gpu.ParseNodeAnnotations(node)
+
+			state := NewMigState(tt.stateResources)
+			original := state.DeepCopy()
+			plan := NewMigConfigPlan(state, specAnnotations)
+
+			res := state.DeepCopy().Apply(plan)
+			assert.True(t, res.Matches(specAnnotations))
+			assert.Equal(t, original, state)
+		})
+	}
+}
@@ -27,6 +27,8 @@ type CreateOperation struct {
 	MigProfile mig.Profile
 	// Quantity is the amount of MigProfiles that need to be created
 	Quantity int
+	// Labels are optional metadata recorded by the MIG agent for each MIG device created by the operation
+	Labels map[string]string
 }
 
 type DeleteOperation struct {
@@ -51,6 +53,10 @@ type OperationStatus struct {
 	PluginRestartRequired bool
 	// Err corresponds to any error generated by the operation execution
 	Err error
+	// Deleted are the MIG resources actually deleted by the operation execution
+	Deleted gpu.DeviceList
+	// Created are the MIG profiles actually created by the operation execution
+	Created mig.ProfileList
 }
 
 type CreateOperationList []CreateOperation
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+)
+
+// Step is a single operation of an ordered sequence of MIG operations: either the deletion
+// of a MIG device or the creation of a MIG profile. Exactly one of its fields is set.
+type Step struct {
+	// Delete is the MIG device deleted by the step
+	Delete *gpu.Device
+	// Create is the MIG profile created by the step
+	Create *mig.Profile
+}
+
+// Steps returns the operations of the plan in the order in which the MIG agent applies them:
+// all the delete operations first, then all the create operations. Create operations with
+// quantity greater than one are expanded into one step for each MIG profile to create.
+func (p MigConfigPlan) Steps() []Step {
+	res := make([]Step, 0)
+	for _, op := range p.DeleteOperations {
+		for i := range op.Resources {
+			res = append(res, Step{Delete: &op.Resources[i]})
+		}
+	}
+	for _, profile := range p.CreateOperations.Flatten() {
+		profile := profile
+		res = append(res, Step{Create: &profile})
+	}
+	return res
+}
+
+// ValidateOrder checks that the steps of the plan, applied in order starting from the state provided
+// as argument, never exceed the capacity of the GPUs. See ValidateSteps.
+func (p MigConfigPlan) ValidateOrder(state MigState, model gpu.Model) error {
+	return ValidateSteps(state, model, p.Steps())
+}
+
+// ValidateSteps checks that, applying the steps provided as argument in order starting from the state
+// provided as argument, the MIG profiles existing on each GPU after each step never require more GI slices
+// or memory than the ones provided by the GPU model. Deleting a device releases its capacity only if the
+// device is free, since used devices are never deleted when applying a plan.
+//
+// If a step would exceed the capacity of its GPU, ValidateSteps returns an error wrapping ErrInsufficientCapacity
+// that reports the position of the step. The state provided as argument is never modified.
+func ValidateSteps(state MigState, model gpu.Model, steps []Step) error {
+	capacity, ok := mig.GetCapacity(model)
+	if !ok {
+		return fmt.Errorf("model %q is not associated with any known GPU", model)
+	}
+
+	profilesByGpu := make(map[int]map[mig.ProfileName]int)
+	addProfile := func(gpuIndex int, profile mig.ProfileName, quantity int) {
+		if profilesByGpu[gpuIndex] == nil {
+			profilesByGpu[gpuIndex] = make(map[mig.ProfileName]int)
+		}
+		profilesByGpu[gpuIndex][profile] += quantity
+	}
+	existing := make(map[string]bool)
+	for _, r := range state.Flatten() {
+		addProfile(r.GpuIndex, mig.GetMigProfileName(r), 1)
+		existing[r.FullResourceName()+"/"+r.DeviceId] = true
+	}
+
+	for i, s := range steps {
+		if s.Delete != nil {
+			key := s.Delete.FullResourceName() + "/" + s.Delete.DeviceId
+			if s.Delete.IsFree() && existing[key] {
+				addProfile(s.Delete.GpuIndex, mig.GetMigProfileName(*s.Delete), -1)
+				delete(existing, key)
+			}
+			continue
+		}
+		if s.Create == nil {
+			continue
+		}
+		addProfile(s.Create.GpuIndex, s.Create.Name, 1)
+		required := mig.GetRequiredCapacity(profilesByGpu[s.Create.GpuIndex])
+		if !capacity.Covers(required) {
+			return fmt.Errorf(
+				"%w: step %d (create %s) would require %s on GPU %d, but model %s only provides %s",
+				ErrInsufficientCapacity,
+				i,
+				s.Create.Name,
+				required,
+				s.Create.GpuIndex,
+				model,
+				capacity,
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateSteps(t *testing.T) {
+	device := func(profile mig.ProfileName, id string, gpuIndex int, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: profile.AsResourceName(),
+				DeviceId:     id,
+				Status:       status,
+			},
+			GpuIndex: gpuIndex,
+		}
+	}
+	free1g6gb := device(mig.Profile1g6gb, "1", 0, resource.StatusFree)
+	used1g6gb := device(mig.Profile1g6gb, "2", 0, resource.StatusUsed)
+	create4g24gb := mig.Profile{GpuIndex: 0, Name: mig.Profile4g24gb}
+
+	testCases := []struct {
+		name     string
+		state    MigState
+		steps    []Step
+		model    gpu.Model
+		expected error
+	}{
+		{
+			name:     "Unknown GPU model",
+			state:    MigState{},
+			steps:    []Step{{Create: &create4g24gb}},
+			model:    "foo",
+			expected: assert.AnError,
+		},
+		{
+			name:     "Delete precedes dependent create: satisfiable",
+			state:    NewMigState(gpu.DeviceList{free1g6gb}),
+			steps:    []Step{{Delete: &free1g6gb}, {Create: &create4g24gb}},
+			model:    gpu.GPUModel_A30,
+			expected: nil,
+		},
+		{
+			name:     "Create precedes the delete freeing its capacity: unsatisfiable",
+			state:    NewMigState(gpu.DeviceList{free1g6gb}),
+			steps:    []Step{{Create: &create4g24gb}, {Delete: &free1g6gb}},
+			model:    gpu.GPUModel_A30,
+			expected: ErrInsufficientCapacity,
+		},
+		{
+			name:     "Used devices are never deleted: unsatisfiable",
+			state:    NewMigState(gpu.DeviceList{used1g6gb}),
+			steps:    []Step{{Delete: &used1g6gb}, {Create: &create4g24gb}},
+			model:    gpu.GPUModel_A30,
+			expected: ErrInsufficientCapacity,
+		},
+		{
+			name:  "Capacity of other GPUs is not affected",
+			state: NewMigState(gpu.DeviceList{device(mig.Profile1g6gb, "3", 1, resource.StatusUsed)}),
+			steps: []Step{{Create: &create4g24gb}},
+			model: gpu.GPUModel_A30,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.state.DeepCopy()
+			err := ValidateSteps(tt.state, tt.model, tt.steps)
+			switch tt.expected {
+			case nil:
+				assert.NoError(t, err)
+			case assert.AnError:
+				assert.Error(t, err)
+			default:
+				assert.ErrorIs(t, err, tt.expected)
+			}
+			assert.Equal(t, original, tt.state)
+		})
+	}
+}
+
+func TestMigConfigPlan__Steps(t *testing.T) {
+	free1g6gb := gpu.Device{
+		Device: resource.Device{
+			ResourceName: mig.Profile1g6gb.AsResourceName(),
+			DeviceId:     "1",
+			Status:       resource.StatusFree,
+		},
+		GpuIndex: 0,
+	}
+	p := MigConfigPlan{
+		CreateOperations: CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g12gb}, Quantity: 2},
+		},
+		DeleteOperations: DeleteOperationList{
+			{Resources: gpu.DeviceList{free1g6gb}},
+		},
+	}
+
+	steps := p.Steps()
+	assert.Len(t, steps, 3)
+	assert.Equal(t, &free1g6gb, steps[0].Delete)
+	assert.Nil(t, steps[0].Create)
+	for _, s := range steps[1:] {
+		assert.Nil(t, s.Delete)
+		assert.Equal(t, &mig.Profile{GpuIndex: 0, Name: mig.Profile2g12gb}, s.Create)
+	}
+	assert.NoError(t, p.ValidateOrder(NewMigState(gpu.DeviceList{free1g6gb}), gpu.GPUModel_A30))
+}
@@ -17,12 +17,22 @@
 package plan
 
 import (
+	"errors"
+	"fmt"
 	"github.com/google/go-cmp/cmp"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/util"
 )
 
+// ErrInsufficientCapacity is returned when a GPU does not have enough capacity for
+// the MIG profiles that a plan would create on it
+var ErrInsufficientCapacity = errors.New("insufficient GPU capacity")
+
+// ErrGpuIndexOutOfRange is returned when the spec annotations reference a GPU index
+// that does not exist on the node
+var ErrGpuIndexOutOfRange = errors.New("GPU index out of range")
+
 type MigConfigPlan struct {
 	DeleteOperations DeleteOperationList
 	CreateOperations CreateOperationList
@@ -43,35 +53,30 @@ func NewMigConfigPlan(state MigState, desired gpu.SpecAnnotationList) MigConfigP
 	// Compute plan for resources contained in spec annotations
 	stateResourcesByGpu := state.Flatten().SortByDeviceId().GroupByGpuIndex()
 	for gpuIndex, gpuAnnotations := range desired.GroupByGpuIndex() {
-		gpuStateResources := mig.GroupDevicesByMigProfile(stateResourcesByGpu[gpuIndex])
-		nCreateOp := 0
-		for migProfile, migProfileAnnotations := range mig.GroupSpecAnnotationsByMigProfile(gpuAnnotations) {
-			// init actual resources of current GPU and current MIG profile
-			actualMigProfileResources := gpuStateResources[migProfile]
-			if actualMigProfileResources == nil {
-				actualMigProfileResources = make(gpu.DeviceList, 0)
-			}
+		// compute total desired quantity of each MIG profile
+		desiredGeometry := make(gpu.Geometry)
+		for _, a := range gpuAnnotations {
+			desiredGeometry[mig.ProfileName(a.ProfileName)] += a.Quantity
+		}
 
-			// compute total desired quantity
-			totalDesiredQuantity := 0
-			for _, a := range migProfileAnnotations {
-				totalDesiredQuantity += a.Quantity
+		// resources of MIG profiles not included in spec are already deleted above
+		actualResources := make(gpu.DeviceList, 0)
+		for migProfile, resources := range mig.GroupDevicesByMigProfile(stateResourcesByGpu[gpuIndex]) {
+			if _, ok := desiredGeometry[migProfile.Name]; ok {
+				actualResources = append(actualResources, resources...)
 			}
+		}
 
-			diff := totalDesiredQuantity - len(actualMigProfileResources)
-			if diff > 0 {
-				plan.addCreateOp(CreateOperation{MigProfile: migProfile, Quantity: diff})
-				nCreateOp++
-			}
-			if diff < 0 {
-				toDelete := extractCandidatesForDeletion(actualMigProfileResources, util.Abs(diff))
-				op := DeleteOperation{Resources: toDelete}
-				plan.addDeleteOp(op)
-			}
+		deleteOps, createOps := ComputeTransition(gpuIndex, actualResources, desiredGeometry)
+		for _, op := range deleteOps {
+			plan.addDeleteOp(op)
+		}
+		for _, op := range createOps {
+			plan.addCreateOp(op)
 		}
 
 		// no create operations on this GPU, we don't need to clean up free devices
-		if nCreateOp == 0 {
+		if len(createOps) == 0 {
 			continue
 		}
 
@@ -149,6 +154,170 @@ func (p *MigConfigPlan) getResourcesToDelete() gpu.DeviceList {
 	return resources
 }
 
+// Limit returns a plan containing at most maxOperations operations of the plan, where each resource to delete
+// and each MIG profile to create count as one operation. Delete operations are kept first, since they are
+// applied before the create operations.
+//
+// The returned bool is true if some operations have been left out of the returned plan.
+// If maxOperations is not positive, the plan is returned unchanged.
+func (p MigConfigPlan) Limit(maxOperations int) (MigConfigPlan, bool) {
+	if maxOperations <= 0 {
+		return p, false
+	}
+
+	res := MigConfigPlan{
+		DeleteOperations: make(DeleteOperationList, 0),
+		CreateOperations: make(CreateOperationList, 0),
+	}
+	budget := maxOperations
+	var truncated bool
+	for _, op := range p.DeleteOperations {
+		if len(op.Resources) == 0 {
+			continue
+		}
+		if budget == 0 {
+			truncated = true
+			break
+		}
+		n := util.Min(budget, len(op.Resources))
+		res.addDeleteOp(DeleteOperation{Resources: op.Resources[:n]})
+		truncated = truncated || n < len(op.Resources)
+		budget -= n
+	}
+	for _, op := range p.CreateOperations {
+		if op.Quantity <= 0 {
+			continue
+		}
+		if budget == 0 {
+			truncated = true
+			break
+		}
+		n := util.Min(budget, op.Quantity)
+		res.addCreateOp(CreateOperation{MigProfile: op.MigProfile, Quantity: n, Labels: op.Labels})
+		truncated = truncated || n < op.Quantity
+		budget -= n
+	}
+
+	return res, truncated
+}
+
+// ForGPUs returns a plan containing only the operations of the plan that involve the GPUs
+// with the indexes provided as argument.
+func (p MigConfigPlan) ForGPUs(gpuIndexes []int) MigConfigPlan {
+	res := MigConfigPlan{
+		DeleteOperations: make(DeleteOperationList, 0),
+		CreateOperations: make(CreateOperationList, 0),
+	}
+	for _, op := range p.DeleteOperations {
+		resources := make(gpu.DeviceList, 0)
+		for _, r := range op.Resources {
+			if util.InSlice(r.GpuIndex, gpuIndexes) {
+				resources = append(resources, r)
+			}
+		}
+		if len(resources) > 0 {
+			res.addDeleteOp(DeleteOperation{Resources: resources})
+		}
+	}
+	for _, op := range p.CreateOperations {
+		if util.InSlice(op.MigProfile.GpuIndex, gpuIndexes) {
+			res.addCreateOp(op)
+		}
+	}
+	return res
+}
+
+// WithCreateLabels returns a copy of the plan in which each create operation has the labels associated
+// with its MIG profile in the map provided as argument. Create operations of MIG profiles not included
+// in the map are left unchanged.
+func (p MigConfigPlan) WithCreateLabels(labels map[mig.Profile]map[string]string) MigConfigPlan {
+	res := MigConfigPlan{
+		DeleteOperations: p.DeleteOperations,
+		CreateOperations: make(CreateOperationList, 0, len(p.CreateOperations)),
+	}
+	for _, op := range p.CreateOperations {
+		if l, ok := labels[op.MigProfile]; ok {
+			op.Labels = l
+		}
+		res.addCreateOp(op)
+	}
+	return res
+}
+
+// ValidateCapacity checks that, once the delete operations of the plan are applied, each GPU has enough
+// free GI slices and memory for the MIG profiles that the create operations would create on it, given the
+// current state and the model of the GPUs. Resources that are not free are not deleted when applying the plan,
+// so they are considered as still allocated.
+//
+// If any GPU does not have enough capacity, ValidateCapacity returns an error wrapping ErrInsufficientCapacity.
+func (p MigConfigPlan) ValidateCapacity(state MigState, model gpu.Model) error {
+	capacity, ok := mig.GetCapacity(model)
+	if !ok {
+		return fmt.Errorf("model %q is not associated with any known GPU", model)
+	}
+
+	deleted := make(map[string]bool)
+	for _, op := range p.DeleteOperations {
+		for _, r := range op.Resources {
+			if r.IsFree() {
+				deleted[r.FullResourceName()+"/"+r.DeviceId] = true
+			}
+		}
+	}
+
+	profilesByGpu := make(map[int]map[mig.ProfileName]int)
+	addProfile := func(gpuIndex int, profile mig.ProfileName, quantity int) {
+		if profilesByGpu[gpuIndex] == nil {
+			profilesByGpu[gpuIndex] = make(map[mig.ProfileName]int)
+		}
+		profilesByGpu[gpuIndex][profile] += quantity
+	}
+	for _, r := range state.Flatten() {
+		if !deleted[r.FullResourceName()+"/"+r.DeviceId] {
+			addProfile(r.GpuIndex, mig.GetMigProfileName(r), 1)
+		}
+	}
+	for _, op := range p.CreateOperations {
+		addProfile(op.MigProfile.GpuIndex, op.MigProfile.Name, op.Quantity)
+	}
+
+	for gpuIndex, profiles := range profilesByGpu {
+		required := mig.GetRequiredCapacity(profiles)
+		if !capacity.Covers(required) {
+			return fmt.Errorf(
+				"%w: GPU %d would require %s, but model %s only provides %s",
+				ErrInsufficientCapacity,
+				gpuIndex,
+				required,
+				model,
+				capacity,
+			)
+		}
+	}
+
+	return nil
+}
+
+// ValidateGpuIndexes checks that all the GPU indexes referenced by the spec annotations provided as argument
+// are lower than the number of GPUs of the node, so that the plan does not include operations on GPUs
+// that do not exist.
+//
+// If any index is out of range, ValidateGpuIndexes returns an error wrapping ErrGpuIndexOutOfRange.
+func ValidateGpuIndexes(desired gpu.SpecAnnotationList, gpuCount int) error {
+	for _, a := range desired {
+		if a.Index < 0 || a.Index >= gpuCount {
+			return fmt.Errorf(
+				"%w: annotation %s references GPU %d, but the node only has %d GPUs",
+				ErrGpuIndexOutOfRange,
+				a,
+				a.Index,
+				gpuCount,
+			)
+		}
+	}
+	return nil
+}
+
 func (p *MigConfigPlan) IsEmpty() bool {
 	return len(p.DeleteOperations) == 0 && len(p.CreateOperations) == 0
 }
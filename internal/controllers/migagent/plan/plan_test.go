@@ -615,3 +615,301 @@ func TestMigConfigPlan__Equals(t *testing.T) {
 		})
 	}
 }
+
+func TestMigConfigPlan__Limit(t *testing.T) {
+	newDevice := func(id string) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: mig.Profile1g10gb.AsResourceName(),
+				DeviceId:     id,
+				Status:       resource.StatusFree,
+			},
+			GpuIndex: 0,
+		}
+	}
+	p := MigConfigPlan{
+		DeleteOperations: DeleteOperationList{
+			{Resources: gpu.DeviceList{newDevice("1"), newDevice("2")}},
+		},
+		CreateOperations: CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 2},
+			{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile1g10gb}, Quantity: 3},
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		maxOperations     int
+		expected          MigConfigPlan
+		expectedTruncated bool
+	}{
+		{
+			name:              "No limit",
+			maxOperations:     0,
+			expected:          p,
+			expectedTruncated: false,
+		},
+		{
+			name:              "Limit greater than plan operations",
+			maxOperations:     10,
+			expected:          p,
+			expectedTruncated: false,
+		},
+		{
+			name:          "Limit lower than delete operations",
+			maxOperations: 1,
+			expected: MigConfigPlan{
+				DeleteOperations: DeleteOperationList{
+					{Resources: gpu.DeviceList{newDevice("1")}},
+				},
+				CreateOperations: CreateOperationList{},
+			},
+			expectedTruncated: true,
+		},
+		{
+			name:          "Limit including delete and part of create operations",
+			maxOperations: 5,
+			expected: MigConfigPlan{
+				DeleteOperations: DeleteOperationList{
+					{Resources: gpu.DeviceList{newDevice("1"), newDevice("2")}},
+				},
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 2},
+					{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile1g10gb}, Quantity: 1},
+				},
+			},
+			expectedTruncated: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			limited, truncated := p.Limit(tt.maxOperations)
+			assert.Equal(t, tt.expectedTruncated, truncated)
+			assert.Equal(t, tt.expected, limited)
+		})
+	}
+}
+
+func TestMigConfigPlan__WithCreateLabels(t *testing.T) {
+	labels := map[string]string{"team": "ml"}
+	p := MigConfigPlan{
+		DeleteOperations: DeleteOperationList{},
+		CreateOperations: CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 2},
+			{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile1g10gb}, Quantity: 3},
+		},
+	}
+
+	labeled := p.WithCreateLabels(map[mig.Profile]map[string]string{
+		{GpuIndex: 0, Name: mig.Profile2g20gb}: labels,
+	})
+	assert.Equal(t, CreateOperationList{
+		{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 2, Labels: labels},
+		{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile1g10gb}, Quantity: 3},
+	}, labeled.CreateOperations)
+	assert.Nil(t, p.CreateOperations[0].Labels)
+
+	// Labels are preserved when limiting the plan
+	limited, truncated := labeled.Limit(1)
+	assert.True(t, truncated)
+	assert.Equal(t, CreateOperationList{
+		{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 1, Labels: labels},
+	}, limited.CreateOperations)
+}
+
+func TestMigConfigPlan__ValidateCapacity(t *testing.T) {
+	freeDevice := func(profile mig.ProfileName, id string) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: profile.AsResourceName(),
+				DeviceId:     id,
+				Status:       resource.StatusFree,
+			},
+			GpuIndex: 0,
+		}
+	}
+	usedDevice := func(profile mig.ProfileName, id string) gpu.Device {
+		d := freeDevice(profile, id)
+		d.Status = resource.StatusUsed
+		return d
+	}
+
+	testCases := []struct {
+		name     string
+		state    MigState
+		plan     MigConfigPlan
+		model    gpu.Model
+		expected error
+	}{
+		{
+			name:  "Unknown GPU model",
+			state: MigState{},
+			plan: MigConfigPlan{
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g5gb}, Quantity: 1},
+				},
+			},
+			model:    "foo",
+			expected: fmt.Errorf("model \"foo\" is not associated with any known GPU"),
+		},
+		{
+			name:  "Create operations fit an empty GPU",
+			state: MigState{},
+			plan: MigConfigPlan{
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile3g20gb}, Quantity: 2},
+				},
+			},
+			model:    gpu.GPUModel_A100_SXM4_40GB,
+			expected: nil,
+		},
+		{
+			name:  "Create operation exceeds GPU capacity",
+			state: MigState{},
+			plan: MigConfigPlan{
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g5gb}, Quantity: 8},
+				},
+			},
+			model:    gpu.GPUModel_A100_SXM4_40GB,
+			expected: ErrInsufficientCapacity,
+		},
+		{
+			name: "Deleted free devices release capacity",
+			state: MigState{
+				0: {freeDevice(mig.Profile7g40gb, "1")},
+			},
+			plan: MigConfigPlan{
+				DeleteOperations: DeleteOperationList{
+					{Resources: gpu.DeviceList{freeDevice(mig.Profile7g40gb, "1")}},
+				},
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g5gb}, Quantity: 7},
+				},
+			},
+			model:    gpu.GPUModel_A100_SXM4_40GB,
+			expected: nil,
+		},
+		{
+			name: "Used devices are not deleted, create operations exceed remaining capacity",
+			state: MigState{
+				0: {usedDevice(mig.Profile4g20gb, "1")},
+			},
+			plan: MigConfigPlan{
+				DeleteOperations: DeleteOperationList{
+					{Resources: gpu.DeviceList{usedDevice(mig.Profile4g20gb, "1")}},
+				},
+				CreateOperations: CreateOperationList{
+					{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile3g20gb}, Quantity: 2},
+				},
+			},
+			model:    gpu.GPUModel_A100_SXM4_40GB,
+			expected: ErrInsufficientCapacity,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.plan.ValidateCapacity(tt.state, tt.model)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tt.expected == ErrInsufficientCapacity {
+				assert.ErrorIs(t, err, ErrInsufficientCapacity)
+			} else {
+				assert.NotErrorIs(t, err, ErrInsufficientCapacity)
+			}
+		})
+	}
+}
+
+func TestValidateGpuIndexes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		desired     gpu.SpecAnnotationList
+		gpuCount    int
+		expectedErr bool
+	}{
+		{
+			name:        "Empty spec",
+			desired:     gpu.SpecAnnotationList{},
+			gpuCount:    3,
+			expectedErr: false,
+		},
+		{
+			name: "All indexes within range",
+			desired: gpu.SpecAnnotationList{
+				{ProfileName: mig.Profile1g10gb.String(), Index: 0, Quantity: 1},
+				{ProfileName: mig.Profile1g10gb.String(), Index: 2, Quantity: 1},
+			},
+			gpuCount:    3,
+			expectedErr: false,
+		},
+		{
+			name: "Index equal to GPU count",
+			desired: gpu.SpecAnnotationList{
+				{ProfileName: mig.Profile1g10gb.String(), Index: 0, Quantity: 1},
+				{ProfileName: mig.Profile1g10gb.String(), Index: 3, Quantity: 1},
+			},
+			gpuCount:    3,
+			expectedErr: true,
+		},
+		{
+			name: "Index greater than GPU count",
+			desired: gpu.SpecAnnotationList{
+				{ProfileName: mig.Profile1g10gb.String(), Index: 5, Quantity: 1},
+			},
+			gpuCount:    3,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGpuIndexes(tt.desired, tt.gpuCount)
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrGpuIndexOutOfRange)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMigConfigPlan__ForGPUs(t *testing.T) {
+	device := func(gpuIndex int, id string) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: mig.Profile1g10gb.AsResourceName(),
+				DeviceId:     id,
+				Status:       resource.StatusFree,
+			},
+			GpuIndex: gpuIndex,
+		}
+	}
+	plan := MigConfigPlan{
+		DeleteOperations: DeleteOperationList{
+			{Resources: gpu.DeviceList{device(0, "1"), device(0, "2")}},
+			{Resources: gpu.DeviceList{device(2, "3")}},
+		},
+		CreateOperations: CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 1, Name: mig.Profile2g20gb}, Quantity: 1},
+			{MigProfile: mig.Profile{GpuIndex: 2, Name: mig.Profile2g20gb}, Quantity: 2},
+		},
+	}
+
+	expected := MigConfigPlan{
+		DeleteOperations: DeleteOperationList{
+			{Resources: gpu.DeviceList{device(2, "3")}},
+		},
+		CreateOperations: CreateOperationList{
+			{MigProfile: mig.Profile{GpuIndex: 2, Name: mig.Profile2g20gb}, Quantity: 2},
+		},
+	}
+	assert.Equal(t, expected, plan.ForGPUs([]int{2, 3}))
+	empty := plan.ForGPUs([]int{})
+	assert.True(t, empty.IsEmpty())
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"sort"
+)
+
+// ComputeTransition returns the minimal operations required for changing the MIG devices currently
+// existing on the GPU with the index provided as argument into the desired geometry.
+//
+// Profiles whose quantity does not change are left untouched. When the quantity of a profile decreases,
+// free devices are deleted before used ones. The function does not interact with NVML, the returned
+// operations are expected to be applied by deleting resources first and creating them afterwards.
+func ComputeTransition(gpuIndex int, current gpu.DeviceList, desired gpu.Geometry) (DeleteOperationList, CreateOperationList) {
+	deleteOps := make(DeleteOperationList, 0)
+	createOps := make(CreateOperationList, 0)
+
+	currentDevices := make(map[mig.ProfileName]gpu.DeviceList)
+	for profile, devices := range mig.GroupDevicesByMigProfile(current) {
+		if profile.GpuIndex == gpuIndex {
+			currentDevices[profile.Name] = devices
+		}
+	}
+	desiredQuantities := make(map[mig.ProfileName]int)
+	for slice, quantity := range desired {
+		desiredQuantities[mig.ProfileName(slice.String())] = quantity
+	}
+
+	// Sort profiles for producing deterministic plans
+	profiles := make([]mig.ProfileName, 0, len(currentDevices)+len(desiredQuantities))
+	for p := range currentDevices {
+		profiles = append(profiles, p)
+	}
+	for p := range desiredQuantities {
+		if _, ok := currentDevices[p]; !ok {
+			profiles = append(profiles, p)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i] < profiles[j]
+	})
+
+	for _, p := range profiles {
+		diff := desiredQuantities[p] - len(currentDevices[p])
+		if diff > 0 {
+			op := CreateOperation{
+				MigProfile: mig.Profile{GpuIndex: gpuIndex, Name: p},
+				Quantity:   diff,
+			}
+			createOps = append(createOps, op)
+		}
+		if diff < 0 {
+			toDelete := extractCandidatesForDeletion(currentDevices[p], -diff)
+			deleteOps = append(deleteOps, DeleteOperation{Resources: toDelete})
+		}
+	}
+
+	return deleteOps, createOps
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plan
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestComputeTransition(t *testing.T) {
+	newDevice := func(profile mig.ProfileName, id string, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: profile.AsResourceName(),
+				DeviceId:     id,
+				Status:       status,
+			},
+			GpuIndex: 0,
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		current           gpu.DeviceList
+		desired           gpu.Geometry
+		expectedDeleteOps DeleteOperationList
+		expectedCreateOps CreateOperationList
+	}{
+		{
+			name: "Unchanged geometry, no-op",
+			current: gpu.DeviceList{
+				newDevice(mig.Profile1g10gb, "1", resource.StatusUsed),
+				newDevice(mig.Profile1g10gb, "2", resource.StatusFree),
+			},
+			desired:           gpu.Geometry{mig.Profile1g10gb: 2},
+			expectedDeleteOps: DeleteOperationList{},
+			expectedCreateOps: CreateOperationList{},
+		},
+		{
+			name: "Add only",
+			current: gpu.DeviceList{
+				newDevice(mig.Profile1g10gb, "1", resource.StatusUsed),
+			},
+			desired: gpu.Geometry{
+				mig.Profile1g10gb: 3,
+				mig.Profile2g20gb: 1,
+			},
+			expectedDeleteOps: DeleteOperationList{},
+			expectedCreateOps: CreateOperationList{
+				{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile1g10gb}, Quantity: 2},
+				{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 1},
+			},
+		},
+		{
+			name: "Delete only, free devices are deleted first",
+			current: gpu.DeviceList{
+				newDevice(mig.Profile1g10gb, "1", resource.StatusUsed),
+				newDevice(mig.Profile1g10gb, "2", resource.StatusFree),
+				newDevice(mig.Profile1g10gb, "3", resource.StatusUsed),
+				newDevice(mig.Profile2g20gb, "4", resource.StatusFree),
+			},
+			desired: gpu.Geometry{mig.Profile1g10gb: 1},
+			expectedDeleteOps: DeleteOperationList{
+				{
+					Resources: gpu.DeviceList{
+						newDevice(mig.Profile1g10gb, "2", resource.StatusFree),
+						newDevice(mig.Profile1g10gb, "1", resource.StatusUsed),
+					},
+				},
+				{
+					Resources: gpu.DeviceList{
+						newDevice(mig.Profile2g20gb, "4", resource.StatusFree),
+					},
+				},
+			},
+			expectedCreateOps: CreateOperationList{},
+		},
+		{
+			name: "Swap profiles",
+			current: gpu.DeviceList{
+				newDevice(mig.Profile1g10gb, "1", resource.StatusFree),
+				newDevice(mig.Profile1g10gb, "2", resource.StatusFree),
+				newDevice(mig.Profile3g40gb, "3", resource.StatusUsed),
+			},
+			desired: gpu.Geometry{
+				mig.Profile2g20gb: 1,
+				mig.Profile3g40gb: 1,
+			},
+			expectedDeleteOps: DeleteOperationList{
+				{
+					Resources: gpu.DeviceList{
+						newDevice(mig.Profile1g10gb, "1", resource.StatusFree),
+						newDevice(mig.Profile1g10gb, "2", resource.StatusFree),
+					},
+				},
+			},
+			expectedCreateOps: CreateOperationList{
+				{MigProfile: mig.Profile{GpuIndex: 0, Name: mig.Profile2g20gb}, Quantity: 1},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			deleteOps, createOps := ComputeTransition(0, tt.current, tt.desired)
+			assert.Equal(t, tt.expectedDeleteOps, deleteOps)
+			assert.Equal(t, tt.expectedCreateOps, createOps)
+		})
+	}
+}
@@ -21,8 +21,10 @@ import (
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
 	"github.com/nebuly-ai/nos/pkg/util/predicate"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -34,16 +36,31 @@ import (
 type MigReporter struct {
 	client.Client
 	migClient       mig.Client
+	nvmlClient      nvml.Client
 	refreshInterval time.Duration
+	batchWindow     time.Duration
+	lastUpdate      time.Time
 	sharedState     *SharedState
+	eventRecorder   record.EventRecorder
+	// gpuCountMismatch describes the GPU count mismatch detected by the last check,
+	// it is empty if the GPU count matched
+	gpuCountMismatch string
 }
 
-func NewReporter(client client.Client, migClient mig.Client, sharedState *SharedState, refreshInterval time.Duration) MigReporter {
+// NewReporter returns a MigReporter. If nvmlClient is not nil, at each reconcile the reporter checks
+// that the number of GPUs enumerated by NVML matches the GPU count reported by the node labels.
+//
+// All the status changes detected by a reconcile are reported with a single patch of the node. If batchWindow
+// is positive, the reporter patches the node at most once per batchWindow: the changes detected before the
+// window has elapsed since the last patch are coalesced and reported together when the window expires.
+func NewReporter(client client.Client, migClient mig.Client, nvmlClient nvml.Client, sharedState *SharedState, refreshInterval time.Duration, batchWindow time.Duration) MigReporter {
 	reporter := MigReporter{
 		Client:          client,
 		migClient:       migClient,
+		nvmlClient:      nvmlClient,
 		sharedState:     sharedState,
 		refreshInterval: refreshInterval,
+		batchWindow:     batchWindow,
 	}
 	return reporter
 }
@@ -56,13 +73,24 @@ func (r *MigReporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Res
 
 	r.sharedState.Lock()
 	defer r.sharedState.Unlock()
-	defer r.sharedState.OnReportDone()
+
+	// The actuator is notified of the report only if the status has been reported, that is
+	// unless the update is postponed for coalescing it with the following changes
+	var postponed bool
+	defer func() {
+		if !postponed {
+			r.sharedState.OnReportDone()
+		}
+	}()
 
 	var instance v1.Node
 	if err := r.Client.Get(ctx, client.ObjectKey{Name: req.Name, Namespace: req.Namespace}, &instance); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Check that the GPU count label is not stale
+	r.checkGpuCount(ctx, instance)
+
 	// Compute new status annotations
 	migResources, err := r.migClient.GetMigDevices(ctx)
 	if err != nil {
@@ -75,15 +103,28 @@ func (r *MigReporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Res
 	logger.V(3).Info("loaded used MIG devices", "usedMIGs", usedMigs)
 	newStatusAnnotations := migResources.AsStatusAnnotation(mig.ExtractProfileNameStr)
 
+	// Compute MIG devices created on the GPUs but not yet advertised by the device plugin
+	pendingAdvertisement := r.getPendingAdvertisement(ctx, instance)
+	if pendingAdvertisement != "" {
+		logger.Info("some MIG devices are not yet advertised by the device plugin", "pending", pendingAdvertisement)
+	}
+
 	// Get current status annotations and compare with new ones
 	oldStatusAnnotations, _ := gpu.ParseNodeAnnotations(instance)
-	if newStatusAnnotations.Equal(oldStatusAnnotations) {
+	if newStatusAnnotations.Equal(oldStatusAnnotations) && instance.Annotations[v1alpha1.AnnotationMigPendingAdvertisement] == pendingAdvertisement {
 		if instance.Annotations[v1alpha1.AnnotationReportedPartitioningPlan] == r.sharedState.lastParsedPlanId {
 			logger.Info("current status is equal to last reported status, nothing to do")
 			return ctrl.Result{RequeueAfter: r.refreshInterval}, nil
 		}
 	}
 
+	// Coalesce the changes detected within the batch window since the last update
+	if wait := r.getBatchWindowRemaining(); wait > 0 {
+		logger.Info("status changed - postponing update for batching it with following changes", "wait", wait)
+		postponed = true
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	// Update node
 	logger.Info("status changed - reporting it by updating node annotations")
 	updated := instance.DeepCopy()
@@ -98,17 +139,77 @@ func (r *MigReporter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Res
 	for _, a := range newStatusAnnotations {
 		updated.Annotations[a.String()] = a.GetValue()
 	}
+	delete(updated.Annotations, v1alpha1.AnnotationMigPendingAdvertisement)
+	if pendingAdvertisement != "" {
+		updated.Annotations[v1alpha1.AnnotationMigPendingAdvertisement] = pendingAdvertisement
+	}
 	updated.Annotations[v1alpha1.AnnotationReportedPartitioningPlan] = r.sharedState.lastParsedPlanId
 	if err := r.Client.Patch(ctx, updated, client.MergeFrom(&instance)); err != nil {
 		logger.Error(err, "unable to update node status annotations", "annotations", updated.Annotations)
 		return ctrl.Result{}, err
 	}
+	r.lastUpdate = time.Now()
 	logger.Info("updated reported status - node annotations updated successfully")
 
 	return ctrl.Result{RequeueAfter: r.refreshInterval}, nil
 }
 
+// getBatchWindowRemaining returns the time left before the batch window started by the last update
+// of the node expires, or zero if the window has already expired or batching is disabled
+func (r *MigReporter) getBatchWindowRemaining() time.Duration {
+	if r.batchWindow <= 0 || r.lastUpdate.IsZero() {
+		return 0
+	}
+	remaining := r.batchWindow - time.Since(r.lastUpdate)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// getPendingAdvertisement returns the value of the v1alpha1.AnnotationMigPendingAdvertisement annotation
+// describing the MIG devices created on the GPUs of the node but not yet included in its capacity, or an
+// empty string if all the created devices are advertised. If the created devices cannot be retrieved,
+// the error is logged and an empty string is returned, so that reporting the MIG status is not blocked.
+func (r *MigReporter) getPendingAdvertisement(ctx context.Context, node v1.Node) string {
+	logger := klog.FromContext(ctx).WithName("Reporter")
+	created, err := r.migClient.CountCreatedMigDevices(ctx)
+	if err != nil {
+		logger.Error(err, "unable to count MIG devices created on the GPUs")
+		return ""
+	}
+	return mig.FormatPendingAdvertisement(mig.GetPendingAdvertisement(node, created))
+}
+
+// checkGpuCount compares the number of GPUs enumerated by NVML with the GPU count reported by the
+// node labels and, if they differ, emits a warning event on the node. The GPU count label is used for
+// computing the GPUs of the node, so a stale label would make nos ignore some of the GPUs.
+//
+// The event is emitted only when the mismatch changes, so that a persisting mismatch is not
+// reported again at each reconcile.
+func (r *MigReporter) checkGpuCount(ctx context.Context, node v1.Node) {
+	if r.nvmlClient == nil {
+		return
+	}
+	logger := klog.FromContext(ctx).WithName("Reporter")
+	count, err := r.nvmlClient.GetGpuCount(ctx)
+	if err != nil {
+		logger.Error(err, "unable to get GPU count from NVML")
+		return
+	}
+	if err := gpu.CheckCount(node, count); err != nil {
+		logger.Info("GPU count mismatch", "reason", err.Error())
+		if err.Error() != r.gpuCountMismatch && r.eventRecorder != nil {
+			r.eventRecorder.Event(&node, v1.EventTypeWarning, EventReasonGpuCountMismatch, err.Error())
+		}
+		r.gpuCountMismatch = err.Error()
+		return
+	}
+	r.gpuCountMismatch = ""
+}
+
 func (r *MigReporter) SetupWithManager(mgr ctrl.Manager, controllerName string, nodeName string) error {
+	r.eventRecorder = mgr.GetEventRecorderFor(controllerName)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(
 			&v1.Node{},
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	migtest "github.com/nebuly-ai/nos/pkg/test/mocks/mig"
+	mockednvml "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+// patchCountingClient is a client.Client counting the number of patches it issues
+type patchCountingClient struct {
+	client.Client
+	patches int
+}
+
+func (c *patchCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.patches++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestMigReporter_Reconcile__Batching(t *testing.T) {
+	newDevice := func(profile mig.ProfileName, gpuIndex int, status resource.Status) gpu.Device {
+		return gpu.Device{
+			Device: resource.Device{
+				ResourceName: profile.AsResourceName(),
+				DeviceId:     fmt.Sprintf("%s-%d-%s", profile, gpuIndex, status),
+				Status:       status,
+			},
+			GpuIndex: gpuIndex,
+		}
+	}
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, mig.Profile1g10gb, resource.StatusFree): "1",
+		}).
+		Get()
+	k8sClient := &patchCountingClient{Client: fake.NewClientBuilder().WithObjects(&node).Build()}
+	migClient := migtest.Client{
+		// Three profiles change with respect to the status annotations of the node
+		ReturnedMigDeviceResources: gpu.DeviceList{
+			newDevice(mig.Profile1g10gb, 0, resource.StatusUsed),
+			newDevice(mig.Profile2g20gb, 0, resource.StatusFree),
+			newDevice(mig.Profile3g40gb, 1, resource.StatusFree),
+		},
+	}
+	reporter := NewReporter(k8sClient, &migClient, nil, NewSharedState(), 10*time.Second, time.Minute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)}
+
+	// All the changes are reported with a single patch
+	res, err := reporter.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, k8sClient.patches)
+	assert.Equal(t, 10*time.Second, res.RequeueAfter)
+	assert.True(t, reporter.sharedState.AtLeastOneReportSinceLastApply())
+	var updated v1.Node
+	assert.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	statusAnnotations, _ := gpu.ParseNodeAnnotations(updated)
+	assert.Len(t, statusAnnotations, 3)
+
+	// Changes detected within the batch window are postponed
+	migClient.ReturnedMigDeviceResources = gpu.DeviceList{
+		newDevice(mig.Profile1g10gb, 0, resource.StatusFree),
+	}
+	res, err = reporter.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, k8sClient.patches)
+	assert.Greater(t, res.RequeueAfter, time.Duration(0))
+	assert.LessOrEqual(t, res.RequeueAfter, time.Minute)
+	assert.False(t, reporter.sharedState.AtLeastOneReportSinceLastApply())
+
+	// Once the window expires, the changes are reported
+	reporter.lastUpdate = time.Now().Add(-time.Minute)
+	_, err = reporter.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, k8sClient.patches)
+	assert.True(t, reporter.sharedState.AtLeastOneReportSinceLastApply())
+}
+
+func TestMigReporter_checkGpuCount(t *testing.T) {
+	testCases := []struct {
+		name           string
+		labelCount     string
+		nvmlCount      int
+		expectedEvents int
+	}{
+		{
+			name:           "NVML count matches label: no event",
+			labelCount:     "2",
+			nvmlCount:      2,
+			expectedEvents: 0,
+		},
+		{
+			name:           "NVML reports more GPUs than label: warning event",
+			labelCount:     "2",
+			nvmlCount:      4,
+			expectedEvents: 1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaCount: tt.labelCount,
+			}).Get()
+			nvmlClient := mockednvml.Client{}
+			nvmlClient.On("GetGpuCount", mock.Anything).Return(tt.nvmlCount, nil)
+			recorder := record.NewFakeRecorder(10)
+			reporter := MigReporter{
+				nvmlClient:    &nvmlClient,
+				eventRecorder: recorder,
+			}
+
+			reporter.checkGpuCount(context.Background(), node)
+
+			assert.Len(t, recorder.Events, tt.expectedEvents)
+			if tt.expectedEvents > 0 {
+				event := <-recorder.Events
+				assert.Contains(t, event, EventReasonGpuCountMismatch)
+			}
+		})
+	}
+}
+
+func TestMigReporter_checkGpuCount__EventOnlyOnMismatchChange(t *testing.T) {
+	node := factory.BuildNode("node-1").WithLabels(map[string]string{
+		constant.LabelNvidiaCount: "2",
+	}).Get()
+	recorder := record.NewFakeRecorder(10)
+	reporter := MigReporter{eventRecorder: recorder}
+	check := func(nvmlCount int) {
+		nvmlClient := mockednvml.Client{}
+		nvmlClient.On("GetGpuCount", mock.Anything).Return(nvmlCount, nil)
+		reporter.nvmlClient = &nvmlClient
+		reporter.checkGpuCount(context.Background(), node)
+	}
+
+	// Persisting mismatch: a single event
+	check(4)
+	check(4)
+	assert.Len(t, recorder.Events, 1)
+
+	// Different mismatch: new event
+	check(3)
+	assert.Len(t, recorder.Events, 2)
+
+	// Mismatch resolved and then detected again: new event
+	check(2)
+	check(3)
+	assert.Len(t, recorder.Events, 3)
+}
+
+func TestMigReporter_checkGpuCount__NilNvmlClient(t *testing.T) {
+	node := factory.BuildNode("node-1").Get()
+	recorder := record.NewFakeRecorder(10)
+	reporter := MigReporter{eventRecorder: recorder}
+
+	reporter.checkGpuCount(context.Background(), node)
+
+	assert.Len(t, recorder.Events, 0)
+}
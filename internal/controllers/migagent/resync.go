@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// StatusResync reconciles, at startup, the MIG status annotations of the node with the MIG devices
+// actually existing on its GPUs. After a node reboot the MIG geometry of the GPUs may be reset, while
+// the status annotations still report the old one, misleading the scheduler until the next report.
+type StatusResync struct {
+	client.Client
+	migClient mig.Client
+	nodeName  string
+}
+
+// NewStatusResync returns a StatusResync. The client provided as argument must not rely on
+// the cache of the manager, since the resync is meant to run before the manager is started.
+func NewStatusResync(client client.Client, migClient mig.Client, nodeName string) StatusResync {
+	return StatusResync{
+		Client:    client,
+		migClient: migClient,
+		nodeName:  nodeName,
+	}
+}
+
+// Resync replaces the MIG status annotations of the node with the ones computed from the MIG devices
+// currently existing on its GPUs, and records the boot ID of the node in the v1alpha1.AnnotationMigAgentBootId
+// annotation. If the boot ID differs from the recorded one, the node has been rebooted and a full resync is
+// performed: the annotations reporting the last applied partitioning plan and the MIG devices pending
+// advertisement are removed as well, since they refer to the state of the node before the reboot.
+func (r *StatusResync) Resync(ctx context.Context) error {
+	logger := klog.FromContext(ctx).WithName("StatusResync")
+
+	var instance v1.Node
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: r.nodeName}, &instance); err != nil {
+		return err
+	}
+
+	migResources, err := r.migClient.GetMigDevices(ctx)
+	if err != nil {
+		return err
+	}
+	newStatusAnnotations := migResources.AsStatusAnnotation(mig.ExtractProfileNameStr)
+	oldStatusAnnotations, _ := gpu.ParseNodeAnnotations(instance)
+
+	bootId := instance.Status.NodeInfo.BootID
+	lastBootId, found := instance.Annotations[v1alpha1.AnnotationMigAgentBootId]
+	rebooted := found && lastBootId != bootId
+	if rebooted {
+		logger.Info("node rebooted since last start, performing full MIG status resync", "bootId", bootId, "lastBootId", lastBootId)
+	}
+	if !rebooted && lastBootId == bootId && newStatusAnnotations.Equal(oldStatusAnnotations) {
+		logger.Info("MIG status annotations match the MIG devices of the GPUs, nothing to do")
+		return nil
+	}
+
+	updated := instance.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	for k := range updated.Annotations {
+		if strings.HasPrefix(k, v1alpha1.AnnotationGpuStatusPrefix) {
+			delete(updated.Annotations, k)
+		}
+	}
+	for _, a := range newStatusAnnotations {
+		updated.Annotations[a.String()] = a.GetValue()
+	}
+	if rebooted {
+		delete(updated.Annotations, v1alpha1.AnnotationReportedPartitioningPlan)
+		delete(updated.Annotations, v1alpha1.AnnotationMigPendingAdvertisement)
+	}
+	if bootId != "" {
+		updated.Annotations[v1alpha1.AnnotationMigAgentBootId] = bootId
+	}
+	if err := r.Client.Patch(ctx, updated, client.MergeFrom(&instance)); err != nil {
+		return err
+	}
+	logger.Info("MIG status annotations reconciled with the MIG devices of the GPUs", "rebooted", rebooted)
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migagent
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	migtest "github.com/nebuly-ai/nos/pkg/test/mocks/mig"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestStatusResync__Resync(t *testing.T) {
+	staleStatusAnnotation := fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusFree)
+	actualStatusAnnotation := fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "3g.40gb", resource.StatusFree)
+
+	testCases := []struct {
+		name                string
+		lastBootId          string
+		bootId              string
+		expectedAnnotations map[string]string
+		expectedRemoved     []string
+	}{
+		{
+			name:       "Node rebooted, NVML state differs from annotations: full resync",
+			lastBootId: "boot-1",
+			bootId:     "boot-2",
+			expectedAnnotations: map[string]string{
+				actualStatusAnnotation:            "1",
+				v1alpha1.AnnotationMigAgentBootId: "boot-2",
+			},
+			expectedRemoved: []string{
+				staleStatusAnnotation,
+				v1alpha1.AnnotationReportedPartitioningPlan,
+				v1alpha1.AnnotationMigPendingAdvertisement,
+			},
+		},
+		{
+			name:       "Agent restarted without reboot: status annotations are corrected",
+			lastBootId: "boot-1",
+			bootId:     "boot-1",
+			expectedAnnotations: map[string]string{
+				actualStatusAnnotation:                      "1",
+				v1alpha1.AnnotationMigAgentBootId:           "boot-1",
+				v1alpha1.AnnotationReportedPartitioningPlan: "plan-1",
+				v1alpha1.AnnotationMigPendingAdvertisement:  "1g.10gb x1",
+			},
+			expectedRemoved: []string{
+				staleStatusAnnotation,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithAnnotations(map[string]string{
+					staleStatusAnnotation:                       "2",
+					v1alpha1.AnnotationMigAgentBootId:           tt.lastBootId,
+					v1alpha1.AnnotationReportedPartitioningPlan: "plan-1",
+					v1alpha1.AnnotationMigPendingAdvertisement:  "1g.10gb x1",
+				}).
+				Get()
+			node.Status.NodeInfo.BootID = tt.bootId
+			k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+			migClient := migtest.Client{
+				ReturnedMigDeviceResources: gpu.DeviceList{
+					{
+						Device: resource.Device{
+							ResourceName: mig.Profile3g40gb.AsResourceName(),
+							DeviceId:     "uid-1",
+							Status:       resource.StatusFree,
+						},
+						GpuIndex: 0,
+					},
+				},
+			}
+			resync := NewStatusResync(k8sClient, &migClient, node.Name)
+
+			assert.NoError(t, resync.Resync(context.Background()))
+
+			var updated v1.Node
+			assert.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&node), &updated))
+			for k, v := range tt.expectedAnnotations {
+				assert.Equal(t, v, updated.Annotations[k], k)
+			}
+			for _, k := range tt.expectedRemoved {
+				assert.NotContains(t, updated.Annotations, k)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usagehistory
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/util/predicate"
+	v1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
+	"time"
+)
+
+// Recorder periodically takes a sample of the free and used GPU slices reported by the status annotations
+// of a node, and stores the last samples in the node annotation v1alpha1.AnnotationGpuUsageHistory.
+type Recorder struct {
+	client.Client
+	// interval is the time between two consecutive samples
+	interval time.Duration
+	// maxSamples is the max number of samples kept in the history, older samples are evicted first
+	maxSamples int
+	// now returns the current time
+	now func() time.Time
+}
+
+func NewRecorder(k8sClient client.Client, interval time.Duration, maxSamples int) Recorder {
+	return Recorder{
+		Client:     k8sClient,
+		interval:   interval,
+		maxSamples: maxSamples,
+		now:        time.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+
+func (r *Recorder) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("UsageHistoryRecorder")
+
+	var instance v1.Node
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: req.Name, Namespace: req.Namespace}, &instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// If the annotation is not valid, start a new history
+	history, err := gpu.ParseUsageHistory(instance)
+	if err != nil {
+		logger.Error(err, "unable to parse GPU usage history, discarding it")
+		history = gpu.UsageHistory{}
+	}
+
+	// Wait until the interval elapses since the last sample
+	now := r.now()
+	if last, ok := history.Last(); ok {
+		if wait := r.interval - now.Sub(last.Timestamp.Time); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	statusAnnotations, _ := gpu.ParseNodeAnnotations(instance)
+	history = history.Add(gpu.NewUsageSample(statusAnnotations, now), r.maxSamples)
+
+	updated := instance.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[v1alpha1.AnnotationGpuUsageHistory] = history.String()
+	if err = r.Client.Patch(ctx, updated, client.MergeFrom(&instance)); err != nil {
+		logger.Error(err, "unable to update GPU usage history")
+		return ctrl.Result{}, err
+	}
+	logger.V(1).Info("recorded GPU usage sample", "samples", len(history))
+
+	return ctrl.Result{RequeueAfter: r.interval}, nil
+}
+
+func (r *Recorder) SetupWithManager(mgr ctrl.Manager, controllerName string, nodeName string) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(
+			&v1.Node{},
+			builder.WithPredicates(
+				predicate.ExcludeDelete{},
+				predicate.MatchingName{Name: nodeName},
+				// Samples are taken periodically, so node updates don't need to trigger reconciles
+				ctrlpredicate.Funcs{UpdateFunc: func(_ event.UpdateEvent) bool { return false }},
+			),
+		).
+		Named(controllerName).
+		Complete(r)
+}
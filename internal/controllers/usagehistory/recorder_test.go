@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usagehistory
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Reconcile(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusUsed): "1",
+		}).
+		Get()
+	k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&node)}
+
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	recorder := NewRecorder(k8sClient, 1*time.Minute, 2)
+	recorder.now = func() time.Time { return now }
+
+	getHistory := func() gpu.UsageHistory {
+		var updated v1.Node
+		assert.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+		history, err := gpu.ParseUsageHistory(updated)
+		assert.NoError(t, err)
+		return history
+	}
+
+	// First sample
+	res, err := recorder.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1*time.Minute, res.RequeueAfter)
+	history := getHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, map[string]int{"1g.10gb": 2}, history[0].Free)
+	assert.Equal(t, map[string]int{"1g.10gb": 1}, history[0].Used)
+
+	// Interval not elapsed: no new sample
+	now = start.Add(20 * time.Second)
+	res, err = recorder.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 40*time.Second, res.RequeueAfter)
+	assert.Len(t, getHistory(), 1)
+
+	// Samples accumulate
+	now = start.Add(1 * time.Minute)
+	_, err = recorder.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, getHistory(), 2)
+
+	// Oldest sample is evicted
+	now = start.Add(2 * time.Minute)
+	_, err = recorder.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	history = getHistory()
+	assert.Len(t, history, 2)
+	assert.True(t, history[0].Timestamp.Time.Equal(start.Add(1*time.Minute)))
+	assert.True(t, history[1].Timestamp.Time.Equal(start.Add(2*time.Minute)))
+}
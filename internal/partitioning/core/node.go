@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// NewPartitionableNode returns the node model corresponding to the partitioning kind specified
+// by the v1alpha1.LabelGpuPartitioning label of the node provided as argument: a mig.Node for
+// MIG partitioning and a slicing.Node for MPS partitioning.
+//
+// NewPartitionableNode returns an error if the label is missing or if it does not specify a
+// partitioning kind that has a node model.
+func NewPartitionableNode(nodeInfo framework.NodeInfo) (PartitionableNode, error) {
+	if nodeInfo.Node() == nil {
+		return nil, fmt.Errorf("node is nil")
+	}
+	node := *nodeInfo.Node()
+	kindStr, ok := node.Labels[v1alpha1.LabelGpuPartitioning]
+	if !ok {
+		return nil, fmt.Errorf("node %s does not have label %s", node.Name, v1alpha1.LabelGpuPartitioning)
+	}
+	kind, ok := gpu.GetPartitioningKind(node)
+	if !ok {
+		return nil, fmt.Errorf(
+			"node %s has unknown partitioning kind %q in label %s",
+			node.Name,
+			kindStr,
+			v1alpha1.LabelGpuPartitioning,
+		)
+	}
+
+	switch kind {
+	case gpu.PartitioningKindMig:
+		migNode, err := mig.NewNode(nodeInfo)
+		if err != nil {
+			return nil, err
+		}
+		return &migNode, nil
+	case gpu.PartitioningKindMps:
+		slicingNode, err := slicing.NewNode(nodeInfo)
+		if err != nil {
+			return nil, err
+		}
+		return &slicingNode, nil
+	default:
+		return nil, fmt.Errorf("partitioning kind %q of node %s is not supported", kind, node.Name)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core_test
+
+import (
+	"github.com/nebuly-ai/nos/internal/partitioning/core"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"testing"
+)
+
+func TestNewPartitionableNode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		labels       map[string]string
+		expectedType core.PartitionableNode
+		expectedErr  bool
+	}{
+		{
+			name:        "Missing partitioning label",
+			labels:      map[string]string{},
+			expectedErr: true,
+		},
+		{
+			name: "Unknown partitioning kind",
+			labels: map[string]string{
+				v1alpha1.LabelGpuPartitioning: "foo",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Partitioning kind without node model",
+			labels: map[string]string{
+				v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindHybrid.String(),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "MIG partitioning",
+			labels: map[string]string{
+				v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMig.String(),
+				constant.LabelNvidiaProduct:   gpu.GPUModel_A100_PCIe_80GB.String(),
+				constant.LabelNvidiaCount:     "1",
+			},
+			expectedType: &mig.Node{},
+			expectedErr:  false,
+		},
+		{
+			name: "MPS partitioning",
+			labels: map[string]string{
+				v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMps.String(),
+				constant.LabelNvidiaProduct:   gpu.GPUModel_A100_PCIe_80GB.String(),
+				constant.LabelNvidiaCount:     "1",
+				constant.LabelNvidiaMemory:    "81920",
+			},
+			expectedType: &slicing.Node{},
+			expectedErr:  false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(tt.labels).Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+
+			res, err := core.NewPartitionableNode(*nodeInfo)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.expectedType, res)
+			assert.Equal(t, node.Name, res.GetName())
+		})
+	}
+}
@@ -52,11 +52,17 @@ type planner struct {
 }
 
 func NewPlanner(partitioner PartitionCalculator, sliceCalculator gpu.SliceCalculator, schedulerFramework framework.Framework) Planner {
+	return NewPlannerWithSorter(partitioner, sliceCalculator, NewPodSorter(sliceCalculator), schedulerFramework)
+}
+
+// NewPlannerWithSorter returns a Planner that considers the candidate Pods in the order defined by
+// the Sorter provided as argument
+func NewPlannerWithSorter(partitioner PartitionCalculator, sliceCalculator gpu.SliceCalculator, sorter Sorter, schedulerFramework framework.Framework) Planner {
 	return planner{
 		partitioner:        partitioner,
 		sliceCalculator:    sliceCalculator,
 		schedulerFramework: schedulerFramework,
-		sorter:             NewPodSorter(sliceCalculator),
+		sorter:             sorter,
 	}
 }
 
@@ -735,7 +735,7 @@ func TestPlanner__Plan__MPS(t *testing.T) {
 					WithLabels(map[string]string{
 						constant.LabelNvidiaProduct:   string(gpu.GPUModel_A100_PCIe_80GB),
 						constant.LabelNvidiaCount:     strconv.Itoa(1),
-						constant.LabelNvidiaMemory:    strconv.Itoa(50000),
+						constant.LabelNvidiaMemory:    strconv.Itoa(51200),
 						v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMps.String(),
 					}).
 					WithAllocatableResources(v1.ResourceList{
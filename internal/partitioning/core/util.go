@@ -32,6 +32,15 @@ func (f SorterAdapter) Sort(pods []v1.Pod) []v1.Pod {
 }
 
 func NewPodSorter(sliceCalculator gpu.SliceCalculator) SorterAdapter {
+	return NewPodSorterWithOrder(sliceCalculator, func(a, b gpu.Slice) bool {
+		return a.SmallerThan(b)
+	})
+}
+
+// NewPodSorterWithOrder returns a Sorter that sorts the Pods by priority (higher first) and then by
+// requested slices, placing first the Pods requesting the slices that must be allocated first according
+// to the function provided as argument.
+func NewPodSorterWithOrder(sliceCalculator gpu.SliceCalculator, before func(a, b gpu.Slice) bool) SorterAdapter {
 	var sorter = func(pods []v1.Pod) []v1.Pod {
 		sorted := make([]v1.Pod, len(pods))
 		copy(sorted, pods)
@@ -44,9 +53,9 @@ func NewPodSorter(sliceCalculator gpu.SliceCalculator) SorterAdapter {
 				return firstPodPriority > secondPodPriority
 			}
 
-			// if priority is equal, sort by requested MIG resources, placing first
-			// the pods that require smaller MIG profiles in order to
-			// maximize the number of pods that can be scheduled
+			// if priority is equal, sort by requested slices, placing first
+			// the pods that require the slices to allocate first (by default the
+			// smaller ones, in order to maximize the number of pods that can be scheduled)
 			firstPodMigResources := sliceCalculator.GetRequestedSlices(sorted[i])
 			if len(firstPodMigResources) == 0 {
 				return false
@@ -58,7 +67,7 @@ func NewPodSorter(sliceCalculator gpu.SliceCalculator) SorterAdapter {
 			for firstPodProfile := range firstPodMigResources {
 				for secondPodProfile := range secondPodMigResources {
 					// we assume that a Pod requests at most one MIG profile
-					return firstPodProfile.SmallerThan(secondPodProfile)
+					return before(firstPodProfile, secondPodProfile)
 				}
 			}
 
@@ -19,7 +19,9 @@ package core_test
 import (
 	"github.com/nebuly-ai/nos/internal/partitioning/core"
 	mig_partitioner "github.com/nebuly-ai/nos/internal/partitioning/mig"
+	mps_partitioner "github.com/nebuly-ai/nos/internal/partitioning/mps"
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/test/factory"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -133,3 +135,47 @@ func TestPodSorter(t *testing.T) {
 		})
 	}
 }
+
+func TestPodSorterWithOrder(t *testing.T) {
+	buildPod := func(name string, profile slicing.ProfileName) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithContainer(
+			factory.BuildContainer("c1", "test").
+				WithScalarResourceRequest(profile.AsResourceName(), 1).
+				Get(),
+		).Get()
+	}
+	pods := []v1.Pod{
+		buildPod("pd-1", "20gb"),
+		buildPod("pd-2", "10gb"),
+		buildPod("pd-3", "40gb"),
+	}
+
+	testCases := []struct {
+		name     string
+		order    slicing.AllocationOrder
+		expected []string
+	}{
+		{
+			name:     "Smallest first",
+			order:    slicing.AllocationOrderSmallestFirst,
+			expected: []string{"pd-2", "pd-1", "pd-3"},
+		},
+		{
+			name:     "Largest first",
+			order:    slicing.AllocationOrderLargestFirst,
+			expected: []string{"pd-3", "pd-1", "pd-2"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sliceCalculator := mps_partitioner.NewSliceCalculator()
+			res := core.NewPodSorterWithOrder(sliceCalculator, tt.order.Before).Sort(pods)
+			names := make([]string, 0, len(res))
+			for _, p := range res {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/nebuly-ai/nos/internal/partitioning/core"
 	"github.com/nebuly-ai/nos/internal/partitioning/state"
 	"github.com/nebuly-ai/nos/pkg/gpu"
-	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 )
 
 var _ core.SnapshotTaker = snapshotTaker{}
@@ -37,11 +36,11 @@ func (s snapshotTaker) TakeSnapshot(clusterState *state.ClusterState) (core.Snap
 		if !gpu.IsMigPartitioningEnabled(*v.Node()) {
 			continue
 		}
-		migNode, err := mig.NewNode(v)
+		node, err := core.NewPartitionableNode(v)
 		if err != nil {
 			return nil, err
 		}
-		nodes[k] = &migNode
+		nodes[k] = node
 	}
 	snapshot := core.NewClusterSnapshot(
 		nodes,
@@ -21,6 +21,7 @@ import (
 	"github.com/nebuly-ai/nos/internal/partitioning/core"
 	"github.com/nebuly-ai/nos/internal/partitioning/state"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/util"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -41,10 +42,17 @@ func NewActuator(client client.Client, devicePluginCM types.NamespacedName, devi
 	)
 }
 
+// NewPlanner returns a Planner that considers the candidate Pods in the order in which their slices are
+// allocated according to slicing.GetAllocationOrder
 func NewPlanner(scheduler framework.Framework) core.Planner {
-	return core.NewPlanner(
+	sliceCalculator := NewSliceCalculator()
+	sorter := core.NewPodSorterWithOrder(sliceCalculator, func(a, b gpu.Slice) bool {
+		return slicing.GetAllocationOrder().Before(a, b)
+	})
+	return core.NewPlannerWithSorter(
 		NewPartitionCalculator(),
-		NewSliceCalculator(),
+		sliceCalculator,
+		sorter,
 		scheduler,
 	)
 }
@@ -70,3 +78,27 @@ func NewController(
 		NewSnapshotTaker(),
 	)
 }
+
+// NewStandbyController returns a controller that keeps, on each node with MPS partitioning, at least
+// the free slices specified by the target provided as argument. The plans of the controller are
+// serialized with the ones of the MPS partitioner controller provided as argument.
+func NewStandbyController(
+	client client.Client,
+	clusterState *state.ClusterState,
+	partitionerController *gpupartitioner.Controller,
+	target map[slicing.ProfileName]int,
+	interval time.Duration,
+	devicePluginCM types.NamespacedName,
+	devicePluginDelay time.Duration,
+) gpupartitioner.StandbyController {
+
+	return gpupartitioner.NewStandbyController(
+		clusterState,
+		NewSnapshotTaker(),
+		NewPartitionCalculator(),
+		NewActuator(client, devicePluginCM, devicePluginDelay),
+		partitionerController.PlanLocker(),
+		target,
+		interval,
+	)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/nebuly-ai/nos/internal/partitioning/core"
 	"github.com/nebuly-ai/nos/internal/partitioning/state"
 	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/util"
 	v1 "k8s.io/api/core/v1"
@@ -82,7 +83,9 @@ func (p partitioner) ApplyPartitioning(ctx context.Context, node v1.Node, planId
 
 	// Update ConfigMap with new node config
 	key := fmt.Sprintf(DevicePluginConfigKeyFormat, node.Name, planId)
-	pluginConfig, err := ToPluginConfig(partitioning)
+	// The GPU memory is required only by fractional slices, ToPluginConfig fails if they need it but it is unknown
+	gpuMemoryGB, _ := gpu.GetMemoryGB(node)
+	pluginConfig, err := ToPluginConfig(partitioning, gpuMemoryGB)
 	if err != nil {
 		return fmt.Errorf("unable to convert node partitioning state to device plugin config: %v", err)
 	}
@@ -120,7 +123,13 @@ func (p partitioner) getDevicePluginCM(ctx context.Context) (v1.ConfigMap, error
 	return res, err
 }
 
-func ToPluginConfig(partitioning state.NodePartitioning) (nvidiav1.Config, error) {
+// ToPluginConfig returns the config of the NVIDIA device plugin providing the MPS resources of the
+// partitioning provided as argument. The memory limit of fractional slices is the fraction of the GPU
+// memory provided as argument, rounded down to GB.
+//
+// ToPluginConfig returns an error if the partitioning contains invalid slices, or if it contains
+// fractional slices whose memory limit would be lower than 1 GB (e.g. because the GPU memory is unknown).
+func ToPluginConfig(partitioning state.NodePartitioning, gpuMemoryGB int) (nvidiav1.Config, error) {
 	replicatedResources := make([]nvidiav1.MPSResource, 0)
 	for _, g := range partitioning.GPUs {
 		for r, q := range g.Resources {
@@ -128,10 +137,17 @@ func ToPluginConfig(partitioning state.NodePartitioning) (nvidiav1.Config, error
 			if err != nil {
 				return nvidiav1.Config{}, err
 			}
+			memoryGB := slicingProfile.GetMemorySizeGB()
+			if slicingProfile.IsFractional() {
+				memoryGB = int(slicingProfile.GetFraction() * float64(gpuMemoryGB))
+			}
+			if memoryGB < 1 {
+				return nvidiav1.Config{}, fmt.Errorf("cannot compute memory limit of slice %s with %d GB of GPU memory", slicingProfile, gpuMemoryGB)
+			}
 			mpsResource := nvidiav1.MPSResource{
 				Name:     nvidiav1.ResourceName(constant.ResourceNvidiaGPU),
 				Rename:   nvidiav1.ResourceName(strings.TrimPrefix(r.String(), constant.NvidiaResourcePrefix)),
-				MemoryGB: slicingProfile.GetMemorySizeGB(),
+				MemoryGB: memoryGB,
 				Devices: []nvidiav1.ReplicatedDeviceRef{
 					nvidiav1.ReplicatedDeviceRef(strconv.Itoa(g.GPUIndex)),
 				},
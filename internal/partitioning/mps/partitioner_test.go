@@ -36,7 +36,7 @@ import (
 func TestToPluginConfig(t *testing.T) {
 	t.Run("Empty node partitioning", func(t *testing.T) {
 		nodePartitioning := state.NodePartitioning{GPUs: []state.GPUPartitioning{}}
-		config, err := mps.ToPluginConfig(nodePartitioning)
+		config, err := mps.ToPluginConfig(nodePartitioning, 40)
 		assert.NoError(t, err)
 		assert.Empty(t, config.Sharing.MPS.Resources)
 	})
@@ -60,11 +60,44 @@ func TestToPluginConfig(t *testing.T) {
 				},
 			},
 		}
-		config, err := mps.ToPluginConfig(nodePartitioning)
+		config, err := mps.ToPluginConfig(nodePartitioning, 40)
 		assert.NoError(t, err)
 		assert.Len(t, config.Sharing.MPS.Resources, 4)
 	})
 
+	t.Run("Fractional resources, memory limit should be the fraction of the GPU memory", func(t *testing.T) {
+		nodePartitioning := state.NodePartitioning{
+			GPUs: []state.GPUPartitioning{
+				{
+					GPUIndex: 0,
+					Resources: map[v1.ResourceName]int{
+						"nvidia.com/gpu-0.25": 4,
+					},
+				},
+			},
+		}
+		config, err := mps.ToPluginConfig(nodePartitioning, 40)
+		assert.NoError(t, err)
+		assert.Len(t, config.Sharing.MPS.Resources, 1)
+		assert.Equal(t, 10, config.Sharing.MPS.Resources[0].MemoryGB)
+	})
+
+	t.Run("Fractional resources, unknown GPU memory should return error", func(t *testing.T) {
+		nodePartitioning := state.NodePartitioning{
+			GPUs: []state.GPUPartitioning{
+				{
+					GPUIndex: 0,
+					Resources: map[v1.ResourceName]int{
+						"nvidia.com/gpu-0.25": 4,
+					},
+				},
+			},
+		}
+		config, err := mps.ToPluginConfig(nodePartitioning, 0)
+		assert.Error(t, err)
+		assert.Empty(t, config.Sharing.MPS.Resources)
+	})
+
 	t.Run("Invalid resources in GPU partitioning, should return error", func(t *testing.T) {
 		nodePartitioning := state.NodePartitioning{GPUs: []state.GPUPartitioning{
 			{
@@ -74,7 +107,7 @@ func TestToPluginConfig(t *testing.T) {
 				},
 			},
 		}}
-		config, err := mps.ToPluginConfig(nodePartitioning)
+		config, err := mps.ToPluginConfig(nodePartitioning, 40)
 		assert.Error(t, err)
 		assert.Empty(t, config.Sharing.MPS.Resources)
 	})
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package state
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+var (
+	freeSlicesDesc = prometheus.NewDesc(
+		"nos_gpu_free_slices",
+		"Number of free GPU slices of each profile on each GPU of the node.",
+		[]string{"node", "gpu", "profile"},
+		nil,
+	)
+	usedSlicesDesc = prometheus.NewDesc(
+		"nos_gpu_used_slices",
+		"Number of used GPU slices of each profile on each GPU of the node.",
+		[]string{"node", "gpu", "profile"},
+		nil,
+	)
+)
+
+// GPUMetricsCollector is a prometheus.Collector exposing the free and used GPU slices (MIG or MPS)
+// of each GPU of the nodes of the cluster state, as reported by the status annotations of the nodes.
+type GPUMetricsCollector struct {
+	state *ClusterState
+}
+
+func NewGPUMetricsCollector(state *ClusterState) *GPUMetricsCollector {
+	return &GPUMetricsCollector{state: state}
+}
+
+var _ prometheus.Collector = &GPUMetricsCollector{}
+
+func (c *GPUMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- freeSlicesDesc
+	ch <- usedSlicesDesc
+}
+
+func (c *GPUMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.state.mtx.RLock()
+	defer c.state.mtx.RUnlock()
+
+	for name, nodeInfo := range c.state.nodes {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		statusAnnotations, _ := gpu.ParseNodeAnnotations(*node)
+		for _, a := range statusAnnotations {
+			desc := freeSlicesDesc
+			if a.IsUsed() {
+				desc = usedSlicesDesc
+			}
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue,
+				float64(a.Quantity),
+				name,
+				strconv.Itoa(a.Index),
+				a.ProfileName,
+			)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package state
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"strings"
+	"testing"
+)
+
+func TestGPUMetricsCollector(t *testing.T) {
+	migNode := factory.BuildNode("node-1").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1g.10gb", resource.StatusUsed): "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "3g.40gb", resource.StatusUsed): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "1g.10gb"):                        "3",
+		}).
+		Get()
+	mpsNode := factory.BuildNode("node-2").
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "4",
+		}).
+		Get()
+	migNodeInfo := framework.NewNodeInfo()
+	migNodeInfo.SetNode(&migNode)
+	mpsNodeInfo := framework.NewNodeInfo()
+	mpsNodeInfo.SetNode(&mpsNode)
+	state := NewClusterState(map[string]framework.NodeInfo{
+		migNode.Name: *migNodeInfo,
+		mpsNode.Name: *mpsNodeInfo,
+		"node-3":     *framework.NewNodeInfo(),
+	})
+
+	expected := `
+# HELP nos_gpu_free_slices Number of free GPU slices of each profile on each GPU of the node.
+# TYPE nos_gpu_free_slices gauge
+nos_gpu_free_slices{gpu="0",node="node-1",profile="1g.10gb"} 2
+nos_gpu_free_slices{gpu="0",node="node-2",profile="10gb"} 4
+# HELP nos_gpu_used_slices Number of used GPU slices of each profile on each GPU of the node.
+# TYPE nos_gpu_used_slices gauge
+nos_gpu_used_slices{gpu="0",node="node-1",profile="1g.10gb"} 1
+nos_gpu_used_slices{gpu="1",node="node-1",profile="3g.40gb"} 2
+`
+	err := testutil.CollectAndCompare(NewGPUMetricsCollector(state), strings.NewReader(expected))
+	assert.NoError(t, err)
+}
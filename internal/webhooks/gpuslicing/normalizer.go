@@ -0,0 +1,133 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpuslicing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	v1 "k8s.io/api/core/v1"
+	"net/http"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// WebhookPath is the path at which the ProfileNormalizer webhook is served
+const WebhookPath = "/mutate-v1-pod-gpu-slices"
+
+var normalizerLog = logf.Log.WithName("gpu-slice-normalizer")
+
+//+kubebuilder:webhook:path=/mutate-v1-pod-gpu-slices,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-gpu-slices.nos.nebuly.com,admissionReviewVersions=v1
+
+// ProfileNormalizer is a mutating webhook that rewrites the GPU slice resources requested by
+// the Pods (e.g. "nvidia.com/gpu-10GB") to their canonical form (e.g. "nvidia.com/gpu-10gb"),
+// so that they match the resources advertised by the nodes. Pods requesting GPU slices
+// whose profile cannot be parsed are rejected.
+type ProfileNormalizer struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ProfileNormalizer{}
+var _ admission.DecoderInjector = &ProfileNormalizer{}
+
+// InjectDecoder implements admission.DecoderInjector
+func (n *ProfileNormalizer) InjectDecoder(decoder *admission.Decoder) error {
+	n.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler
+func (n *ProfileNormalizer) Handle(_ context.Context, req admission.Request) admission.Response {
+	var pod v1.Pod
+	if err := n.decoder.Decode(req, &pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	changed, err := NormalizePod(&pod)
+	if err != nil {
+		normalizerLog.V(1).Info("rejecting pod", "namespace", req.Namespace, "name", req.Name, "reason", err.Error())
+		return admission.Denied(err.Error())
+	}
+	if !changed {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// SetupWebhookWithManager registers the webhook to the webhook server of the manager, which
+// takes care of injecting the decoder.
+func (n *ProfileNormalizer) SetupWebhookWithManager(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(WebhookPath, &webhook.Admission{Handler: n})
+}
+
+// NormalizePod rewrites in place the GPU slice resources requested by the containers and
+// init containers of the Pod to their canonical form, and returns true if any resource
+// has been changed. It returns an error if any GPU slice resource cannot be parsed.
+func NormalizePod(pod *v1.Pod) (bool, error) {
+	var changed bool
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			requestsChanged, err := normalizeResourceList(c.Resources.Requests)
+			if err != nil {
+				return false, fmt.Errorf("container %q: %v", c.Name, err)
+			}
+			limitsChanged, err := normalizeResourceList(c.Resources.Limits)
+			if err != nil {
+				return false, fmt.Errorf("container %q: %v", c.Name, err)
+			}
+			changed = changed || requestsChanged || limitsChanged
+		}
+	}
+	return changed, nil
+}
+
+// normalizeResourceList rewrites in place the GPU slice resources of the list to their
+// canonical form. Quantities of resources that normalize to the same name are summed.
+// The list is left unchanged if any GPU slice resource cannot be parsed.
+func normalizeResourceList(resources v1.ResourceList) (bool, error) {
+	// Collect the renames first, since adding keys to a map while ranging over it
+	// may or may not visit them
+	renames := make(map[v1.ResourceName]v1.ResourceName)
+	for resourceName := range resources {
+		normalized, err := slicing.NormalizeResourceName(resourceName)
+		if err != nil {
+			return false, err
+		}
+		if normalized != resourceName {
+			renames[resourceName] = normalized
+		}
+	}
+
+	for resourceName, normalized := range renames {
+		quantity := resources[resourceName]
+		delete(resources, resourceName)
+		if existing, ok := resources[normalized]; ok {
+			quantity.Add(existing)
+		}
+		resources[normalized] = quantity
+	}
+	return len(renames) > 0, nil
+}
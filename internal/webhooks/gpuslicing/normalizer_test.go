@@ -0,0 +1,227 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpuslicing
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"testing"
+)
+
+func TestNormalizePod(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pod             v1.Pod
+		expectedChanged bool
+		expectedPod     v1.Pod
+		errExpected     bool
+	}{
+		{
+			name: "Pod without GPU slices",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithCPUMilliRequest(100).
+					WithNvidiaGPULimit(1).
+					Get(),
+			).Get(),
+			expectedChanged: false,
+			expectedPod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithCPUMilliRequest(100).
+					WithNvidiaGPULimit(1).
+					Get(),
+			).Get(),
+		},
+		{
+			name: "Canonical GPU slices are not changed",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceRequest("nvidia.com/gpu-10gb", 1).
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 1).
+					Get(),
+			).Get(),
+			expectedChanged: false,
+			expectedPod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceRequest("nvidia.com/gpu-10gb", 1).
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 1).
+					Get(),
+			).Get(),
+		},
+		{
+			name: "Casing and format variants are normalized",
+			pod: factory.BuildPod("ns-1", "pd-1").
+				WithInitContainer(
+					factory.BuildContainer("init-1", "test").
+						WithScalarResourceLimit("nvidia.com/gpu-.25", 1).
+						Get(),
+				).
+				WithContainer(
+					factory.BuildContainer("c-1", "test").
+						WithScalarResourceRequest("nvidia.com/gpu-10Gb", 1).
+						WithScalarResourceLimit("nvidia.com/gpu-10GB", 1).
+						Get(),
+				).
+				WithContainer(
+					factory.BuildContainer("c-2", "test").
+						WithScalarResourceLimit("nvidia.com/gpu-020gb", 2).
+						WithScalarResourceLimit("nvidia.com/gpu-0.50", 1).
+						Get(),
+				).
+				Get(),
+			expectedChanged: true,
+			expectedPod: factory.BuildPod("ns-1", "pd-1").
+				WithInitContainer(
+					factory.BuildContainer("init-1", "test").
+						WithScalarResourceLimit("nvidia.com/gpu-0.25", 1).
+						Get(),
+				).
+				WithContainer(
+					factory.BuildContainer("c-1", "test").
+						WithScalarResourceRequest("nvidia.com/gpu-10gb", 1).
+						WithScalarResourceLimit("nvidia.com/gpu-10gb", 1).
+						Get(),
+				).
+				WithContainer(
+					factory.BuildContainer("c-2", "test").
+						WithScalarResourceLimit("nvidia.com/gpu-20gb", 2).
+						WithScalarResourceLimit("nvidia.com/gpu-0.5", 1).
+						Get(),
+				).
+				Get(),
+		},
+		{
+			name: "Variants of the same profile are summed",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 1).
+					WithScalarResourceLimit("nvidia.com/gpu-10GB", 2).
+					Get(),
+			).Get(),
+			expectedChanged: true,
+			expectedPod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 3).
+					Get(),
+			).Get(),
+		},
+		{
+			name: "Non-canonical variants of the same profile are summed",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10Gb", 1).
+					WithScalarResourceLimit("nvidia.com/gpu-10GB", 2).
+					WithScalarResourceLimit("nvidia.com/gpu-010gb", 4).
+					Get(),
+			).Get(),
+			expectedChanged: true,
+			expectedPod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 7).
+					Get(),
+			).Get(),
+		},
+		{
+			name: "Unparseable GPU slice, should return error",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-tengb", 1).
+					Get(),
+			).Get(),
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := NormalizePod(&tt.pod)
+			if tt.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedChanged, changed)
+			assert.Equal(t, tt.expectedPod, tt.pod)
+		})
+	}
+}
+
+func TestProfileNormalizer__Handle(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pod             v1.Pod
+		expectedAllowed bool
+		expectedPatches int
+	}{
+		{
+			name: "Canonical GPU slice, should allow without patches",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10gb", 1).
+					Get(),
+			).Get(),
+			expectedAllowed: true,
+			expectedPatches: 0,
+		},
+		{
+			name: "Non canonical GPU slice, should allow with patches",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-10GB", 1).
+					Get(),
+			).Get(),
+			expectedAllowed: true,
+			expectedPatches: 2,
+		},
+		{
+			name: "Unparseable GPU slice, should deny",
+			pod: factory.BuildPod("ns-1", "pd-1").WithContainer(
+				factory.BuildContainer("c-1", "test").
+					WithScalarResourceLimit("nvidia.com/gpu-foo", 1).
+					Get(),
+			).Get(),
+			expectedAllowed: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := admission.NewDecoder(scheme.Scheme)
+			assert.NoError(t, err)
+			normalizer := ProfileNormalizer{}
+			assert.NoError(t, normalizer.InjectDecoder(decoder))
+
+			raw, err := json.Marshal(tt.pod)
+			assert.NoError(t, err)
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}}
+
+			resp := normalizer.Handle(context.Background(), req)
+			assert.Equal(t, tt.expectedAllowed, resp.Allowed)
+			assert.Len(t, resp.Patches, tt.expectedPatches)
+		})
+	}
+}
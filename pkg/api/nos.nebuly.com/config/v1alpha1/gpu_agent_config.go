@@ -17,6 +17,8 @@
 package v1alpha1
 
 import (
+	"errors"
+	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
 	"time"
@@ -28,4 +30,64 @@ type GpuAgentConfig struct {
 	metav1.TypeMeta                        `json:",inline"`
 	cfg.ControllerManagerConfigurationSpec `json:",inline"`
 	ReportConfigIntervalSeconds            time.Duration `json:"reportConfigIntervalSeconds"`
+	// UsageHistorySize is the number of samples of the free and used GPU slices kept in the node annotation
+	// "nos.nebuly.com/gpu-usage-history", at most MaxUsageHistorySize. Zero disables the recording of the usage history.
+	UsageHistorySize int `json:"usageHistorySize,omitempty"`
+	// UsageHistoryIntervalSeconds is the interval between two consecutive samples of the usage history
+	UsageHistoryIntervalSeconds time.Duration `json:"usageHistoryIntervalSeconds,omitempty"`
+	// TimeSlicingReplicas is the number of time-slicing replicas advertised by the device plugin for each GPU slice
+	// of the node, exposed through the node annotations "nos.nebuly.com/gpu-replicas-<gpu-index>".
+	// Zero means that the GPU slices are not time-shared.
+	TimeSlicingReplicas int `json:"timeSlicingReplicas,omitempty"`
+	// TimeSlicingReplicasPerGpu overrides TimeSlicingReplicas for the GPUs whose indexes are used as keys,
+	// for nodes on which the device plugin advertises a different number of replicas for each GPU.
+	TimeSlicingReplicasPerGpu map[int]int `json:"timeSlicingReplicasPerGpu,omitempty"`
+	// GpuMemoryDerating is the fraction, between 0 (included) and 1 (excluded), of the memory of the GPUs of the node
+	// that is not usable for creating slices (e.g. because of ECC overhead), exposed through the node annotations
+	// "nos.nebuly.com/gpu-memory-derating-<gpu-index>".
+	GpuMemoryDerating float64 `json:"gpuMemoryDerating,omitempty"`
+}
+
+func (c *GpuAgentConfig) Validate() error {
+	if err := validateUsageHistory(c.UsageHistorySize, c.UsageHistoryIntervalSeconds); err != nil {
+		return err
+	}
+	if c.TimeSlicingReplicas < 0 {
+		return errors.New("timeSlicingReplicas must be greater or equal than 0")
+	}
+	for gpuIndex, replicas := range c.TimeSlicingReplicasPerGpu {
+		if gpuIndex < 0 {
+			return fmt.Errorf("timeSlicingReplicasPerGpu: invalid GPU index %d", gpuIndex)
+		}
+		if replicas < 0 {
+			return fmt.Errorf("timeSlicingReplicasPerGpu: replicas of GPU %d must be greater or equal than 0", gpuIndex)
+		}
+	}
+	if c.GpuMemoryDerating < 0 || c.GpuMemoryDerating >= 1 {
+		return errors.New("gpuMemoryDerating must be between 0 (included) and 1 (excluded)")
+	}
+	return nil
+}
+
+// GetTimeSlicingReplicas returns the number of time-slicing replicas advertised for each slice of the GPU
+// with the index provided as argument
+func (c *GpuAgentConfig) GetTimeSlicingReplicas(gpuIndex int) int {
+	if replicas, ok := c.TimeSlicingReplicasPerGpu[gpuIndex]; ok {
+		return replicas
+	}
+	return c.TimeSlicingReplicas
+}
+
+// MaxUsageHistorySize is the max number of samples of the GPU usage history, which is limited
+// since the total size of the annotations of a node cannot exceed 256 KB
+const MaxUsageHistorySize = 100
+
+func validateUsageHistory(size int, interval time.Duration) error {
+	if size < 0 || size > MaxUsageHistorySize {
+		return fmt.Errorf("usageHistorySize must be between 0 and %d", MaxUsageHistorySize)
+	}
+	if size > 0 && interval.Seconds() <= 0 {
+		return errors.New("usageHistoryIntervalSeconds must be greater than 0 when usageHistorySize is set")
+	}
+	return nil
 }
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
 	"time"
@@ -34,6 +35,17 @@ type GpuPartitionerConfig struct {
 	BatchWindowIdleSeconds                 time.Duration    `json:"batchWindowIdleSeconds"`
 	DevicePluginConfigMap                  NamespacedObject `json:"devicePluginConfigMap,omitempty"`
 	DevicePluginDelaySeconds               time.Duration    `json:"devicePluginDelaySeconds"`
+	// MigResourceNamePrefix is the prefix of the resources advertised by the device plugin for MIG devices.
+	// If empty, the default prefix "nvidia.com/mig-" is used.
+	MigResourceNamePrefix string `json:"migResourceNamePrefix,omitempty"`
+	// SliceAllocationOrder is the order in which the GPU slices are allocated when a GPU cannot provide
+	// all the required ones, either "smallest-first" or "largest-first". If empty, "smallest-first" is used.
+	SliceAllocationOrder string `json:"sliceAllocationOrder,omitempty"`
+	// MpsStandbySlices is the number of free slices of each profile (e.g. "10gb") that the GPU Partitioner
+	// keeps ready on each node with MPS partitioning, capacity allowing. If empty, no standby slice is kept.
+	MpsStandbySlices map[string]int `json:"mpsStandbySlices,omitempty"`
+	// MpsStandbyIntervalSeconds is the interval between two consecutive checks of the standby slices of a node
+	MpsStandbyIntervalSeconds time.Duration `json:"mpsStandbyIntervalSeconds,omitempty"`
 }
 
 func (c *GpuPartitionerConfig) Validate() error {
@@ -46,6 +58,14 @@ func (c *GpuPartitionerConfig) Validate() error {
 	if c.DevicePluginDelaySeconds.Seconds() <= 0 {
 		return errors.New("devicePluginDelaySeconds must be greater than 0")
 	}
+	if len(c.MpsStandbySlices) > 0 && c.MpsStandbyIntervalSeconds.Seconds() <= 0 {
+		return errors.New("mpsStandbyIntervalSeconds must be greater than 0 when mpsStandbySlices is set")
+	}
+	for profile, quantity := range c.MpsStandbySlices {
+		if quantity < 0 {
+			return fmt.Errorf("mpsStandbySlices: quantity of profile %s must not be negative", profile)
+		}
+	}
 	return nil
 }
 
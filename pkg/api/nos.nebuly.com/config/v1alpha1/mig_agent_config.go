@@ -28,4 +28,38 @@ type MigAgentConfig struct {
 	metav1.TypeMeta                        `json:",inline"`
 	cfg.ControllerManagerConfigurationSpec `json:",inline"`
 	ReportConfigIntervalSeconds            time.Duration `json:"reportConfigIntervalSeconds"`
+	// StatusUpdateBatchWindowSeconds is the minimum time between two consecutive updates of the MIG status
+	// annotations of the node: the status changes detected within the window are reported with a single
+	// update when the window expires. Zero means that each change is reported right away.
+	StatusUpdateBatchWindowSeconds time.Duration `json:"statusUpdateBatchWindowSeconds,omitempty"`
+	// MaxOperationsPerReconcile is the maximum number of MIG devices that the agent creates or deletes
+	// in a single reconcile. Remaining operations are applied in the following reconciles.
+	// Zero or negative values mean no limit.
+	MaxOperationsPerReconcile int `json:"maxOperationsPerReconcile,omitempty"`
+	// DeletePolicy defines the order in which the agent deletes the MIG devices when the operations of a
+	// reconcile are limited by MaxOperationsPerReconcile, either "consolidate" or "spread".
+	// If empty, "consolidate" is used.
+	DeletePolicy string `json:"deletePolicy,omitempty"`
+	// NamedMigGeometriesFile is the path to the file containing the named MIG geometries that can be
+	// applied to the node through the "nos.nebuly.com/mig-geometry" annotation.
+	NamedMigGeometriesFile string `json:"namedMigGeometriesFile,omitempty"`
+	// MigResourceNamePrefix is the prefix of the resources advertised by the device plugin for MIG devices.
+	// If empty, the default prefix "nvidia.com/mig-" is used.
+	MigResourceNamePrefix string `json:"migResourceNamePrefix,omitempty"`
+	// DevicePluginRestartGracePeriodSeconds is the time that must elapse since the last change of the MIG devices
+	// before restarting the NVIDIA device plugin, so that changes applied in quick succession trigger a single restart.
+	// Zero means that the device plugin is restarted right after each change.
+	DevicePluginRestartGracePeriodSeconds time.Duration `json:"devicePluginRestartGracePeriodSeconds,omitempty"`
+	// DevicePluginRestartStrategy defines how the NVIDIA device plugin is made aware of the changes of the
+	// MIG devices, either "podDelete" or "none". If empty, "podDelete" is used.
+	DevicePluginRestartStrategy string `json:"devicePluginRestartStrategy,omitempty"`
+	// UsageHistorySize is the number of samples of the free and used GPU slices kept in the node annotation
+	// "nos.nebuly.com/gpu-usage-history", at most MaxUsageHistorySize. Zero disables the recording of the usage history.
+	UsageHistorySize int `json:"usageHistorySize,omitempty"`
+	// UsageHistoryIntervalSeconds is the interval between two consecutive samples of the usage history
+	UsageHistoryIntervalSeconds time.Duration `json:"usageHistoryIntervalSeconds,omitempty"`
+}
+
+func (c *MigAgentConfig) Validate() error {
+	return validateUsageHistory(c.UsageHistorySize, c.UsageHistoryIntervalSeconds)
 }
@@ -30,6 +30,13 @@ func (in *GpuAgentConfig) DeepCopyInto(out *GpuAgentConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
+	if in.TimeSlicingReplicasPerGpu != nil {
+		in, out := &in.TimeSlicingReplicasPerGpu, &out.TimeSlicingReplicasPerGpu
+		*out = make(map[int]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GpuAgentConfig.
@@ -56,6 +63,13 @@ func (in *GpuPartitionerConfig) DeepCopyInto(out *GpuPartitionerConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
 	out.DevicePluginConfigMap = in.DevicePluginConfigMap
+	if in.MpsStandbySlices != nil {
+		in, out := &in.MpsStandbySlices, &out.MpsStandbySlices
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GpuPartitionerConfig.
@@ -21,11 +21,69 @@ import "fmt"
 const (
 	AnnotationGpuSpecPrefix   = "nos.nebuly.com/spec-gpu"
 	AnnotationGpuStatusPrefix = "nos.nebuly.com/status-gpu"
+	// AnnotationGpuReplicasPrefix is the prefix of the annotations exposing the number of time-slicing
+	// replicas advertised by the device plugin for each slice of a GPU.
+	AnnotationGpuReplicasPrefix = "nos.nebuly.com/gpu-replicas"
+	// AnnotationGpuHealthPrefix is the prefix of the annotations exposing the health status of the GPUs of a node.
+	AnnotationGpuHealthPrefix = "nos.nebuly.com/gpu-health"
+	// AnnotationGpuUnhealthy is the value of the GPU health annotation marking a GPU as unhealthy.
+	AnnotationGpuUnhealthy = "unhealthy"
+	// AnnotationGpuNumaNodePrefix is the prefix of the annotations exposing the NUMA node of the GPUs of a node.
+	AnnotationGpuNumaNodePrefix = "nos.nebuly.com/gpu-numa-node"
+	// AnnotationGpuNVLinkPeersPrefix is the prefix of the annotations exposing the indexes of the GPUs
+	// directly connected through NVLink to each GPU of a node.
+	AnnotationGpuNVLinkPeersPrefix = "nos.nebuly.com/gpu-nvlink-peers"
+	// AnnotationGpuMemoryDeratingPrefix is the prefix of the annotations specifying the fraction of the memory
+	// of the GPUs of a node that is not usable (e.g. because of ECC overhead).
+	AnnotationGpuMemoryDeratingPrefix = "nos.nebuly.com/gpu-memory-derating"
+	// AnnotationGpuSpecLabelsPrefix is the prefix of the annotations specifying the labels of the MIG devices
+	// that the MIG agent creates for satisfying the spec annotations of a node.
+	AnnotationGpuSpecLabelsPrefix = "nos.nebuly.com/spec-labels-gpu"
+	// AnnotationMigDeviceLabelsPrefix is the prefix of the annotations exposing the labels of the MIG devices
+	// created by the MIG agent, keyed by the UUID of the devices.
+	AnnotationMigDeviceLabelsPrefix = "nos.nebuly.com/mig-device-labels"
 
 	// AnnotationPartitioningPlan indicates the partitioning plan that was applied to the node.
 	AnnotationPartitioningPlan = "nos.nebuly.com/spec-partitioning-plan"
 	// AnnotationReportedPartitioningPlan indicates the last partitioning plan reported by the node.
 	AnnotationReportedPartitioningPlan = "nos.nebuly.com/status-partitioning-plan"
+	// AnnotationMigPendingAdvertisement reports the MIG devices created on the GPUs of a node that are not yet
+	// advertised by the device plugin as node resources, in the format "<profile> x<quantity>[, ...]".
+	// Example: "1g.10gb x2, 2g.20gb x1". The annotation is removed once all the MIG devices are advertised.
+	AnnotationMigPendingAdvertisement = "nos.nebuly.com/status-mig-pending-advertisement"
+	// AnnotationGpuUsageHistory exposes the last samples of the free and used GPU slices of a node, aggregated
+	// by profile, as a JSON list ordered from the oldest to the newest sample.
+	// Example: [{"timestamp":"2023-01-01T10:00:00Z","free":{"1g.10gb":2},"used":{"1g.10gb":1}}]
+	AnnotationGpuUsageHistory = "nos.nebuly.com/gpu-usage-history"
+	// AnnotationMigAgentPaused, when set to "true" on a node, prevents the MIG agent from changing
+	// the MIG configuration of the GPUs of the node.
+	AnnotationMigAgentPaused = "nos.nebuly.com/mig-agent-paused"
+	// AnnotationMigAgentBootId records the boot ID of the node observed by the MIG agent the last time it started.
+	// A different boot ID means that the node has been rebooted, so the MIG geometry of its GPUs may have been reset.
+	AnnotationMigAgentBootId = "nos.nebuly.com/mig-agent-boot-id"
+	// AnnotationMigGeometry is the node annotation that can be used for applying to all the GPUs of a node
+	// one of the named MIG geometries known by the MIG agent, instead of specifying the MIG profiles of each GPU.
+	// Its value can also be a comma-separated list of geometry names sorted by preference: the MIG agent applies
+	// the first geometry that can be fully applied given the MIG devices currently in use.
+	AnnotationMigGeometry = "nos.nebuly.com/mig-geometry"
+	// AnnotationMigGeometrySelected is the node annotation set by the MIG agent for exposing which of the
+	// geometries listed in the AnnotationMigGeometry annotation has been selected.
+	AnnotationMigGeometrySelected = "nos.nebuly.com/status-mig-geometry"
+	// AnnotationMigGeometryLastChanged is the node annotation set by the MIG agent every time it creates or deletes
+	// MIG devices on the GPUs of the node, recording the time of the change in RFC 3339 format.
+	AnnotationMigGeometryLastChanged = "nos.nebuly.com/status-mig-geometry-last-changed"
+	// AnnotationGpuSliceRequest is the Pod annotation that can be used for requesting GPU slices as an alternative
+	// to container resource requests, in the format "<profile> x<quantity>[, <profile> x<quantity>...]".
+	// Example: "10gb x2, 20gb x1".
+	AnnotationGpuSliceRequest = "nos.nebuly.com/gpu-slice-request"
+	// AnnotationMpsActiveThreadPercentage is the Pod annotation that can be used for limiting the percentage
+	// (between 1 and 100) of the threads of each GPU that the MPS clients of the Pod can use, as done by the
+	// CUDA_MPS_ACTIVE_THREAD_PERCENTAGE environment variable. The percentages of the Pods sharing a GPU
+	// cannot exceed 100 in total. Example: "30".
+	AnnotationMpsActiveThreadPercentage = "nos.nebuly.com/mps-active-thread-percentage"
+	// AnnotationDefaultGpuSlice is the Namespace annotation that specifies the profile of the GPU slice requested
+	// by default by the Pods of the namespace that do not request any GPU resource. Example: "10gb".
+	AnnotationDefaultGpuSlice = "nos.nebuly.com/default-gpu-slice"
 )
 
 // AnnotationGpuStatusFormat is the format of the annotation used to expose the profiles the GPUs of a node
@@ -37,6 +95,9 @@ const (
 // Example:
 //
 //	"nos.nebuly.com/status-gpu-0-1g.10gb-free"
+//
+// The suffix "+me" of the MIG profiles including media extensions is written as ".me"
+// (e.g. "nos.nebuly.com/status-gpu-0-1g.5gb.me-free"), since "+" is not allowed in annotation keys.
 var AnnotationGpuStatusFormat = fmt.Sprintf(
 	"%s-%%d-%%s-%%s",
 	AnnotationGpuStatusPrefix,
@@ -52,7 +113,117 @@ var AnnotationGpuStatusFormat = fmt.Sprintf(
 // Example:
 //
 //	"nos.nebuly.com/spec-gpu-0-1g.10gb"
+//
+// As for AnnotationGpuStatusFormat, the suffix "+me" of the MIG profiles is written as ".me".
 var AnnotationGpuSpecFormat = fmt.Sprintf(
 	"%s-%%d-%%s",
 	AnnotationGpuSpecPrefix,
 )
+
+// AnnotationGpuReplicasFormat is the format of the annotation used to expose the number of time-slicing
+// replicas advertised for each slice of a GPU of a node
+//
+// Format:
+//
+//	"nos.nebuly.com/gpu-replicas-<gpu-index>"
+//
+// Example:
+//
+//	"nos.nebuly.com/gpu-replicas-0": "4"
+var AnnotationGpuReplicasFormat = fmt.Sprintf(
+	"%s-%%d",
+	AnnotationGpuReplicasPrefix,
+)
+
+// AnnotationGpuHealthFormat is the format of the annotation used to expose the health status of a GPU of a node.
+// GPUs annotated as AnnotationGpuUnhealthy are not considered for hosting Pods.
+//
+// Format:
+//
+//	"nos.nebuly.com/gpu-health-<gpu-index>"
+//
+// Example:
+//
+//	"nos.nebuly.com/gpu-health-0": "unhealthy"
+var AnnotationGpuHealthFormat = fmt.Sprintf(
+	"%s-%%d",
+	AnnotationGpuHealthPrefix,
+)
+
+// AnnotationGpuNumaNodeFormat is the format of the annotation used to expose the NUMA node a GPU
+// of a node is attached to
+//
+// Format:
+//
+//	"nos.nebuly.com/gpu-numa-node-<gpu-index>"
+//
+// Example:
+//
+//	"nos.nebuly.com/gpu-numa-node-0": "1"
+var AnnotationGpuNumaNodeFormat = fmt.Sprintf(
+	"%s-%%d",
+	AnnotationGpuNumaNodePrefix,
+)
+
+// AnnotationGpuNVLinkPeersFormat is the format of the annotation used to expose the comma-separated indexes
+// of the GPUs directly connected through NVLink to a GPU of a node
+//
+// Format:
+//
+//	"nos.nebuly.com/gpu-nvlink-peers-<gpu-index>"
+//
+// Example:
+//
+//	"nos.nebuly.com/gpu-nvlink-peers-0": "1,2,3"
+var AnnotationGpuNVLinkPeersFormat = fmt.Sprintf(
+	"%s-%%d",
+	AnnotationGpuNVLinkPeersPrefix,
+)
+
+// AnnotationGpuMemoryDeratingFormat is the format of the annotation used to specify the fraction, between 0
+// (included) and 1 (excluded), of the memory reported for a GPU of a node that is not actually usable
+//
+// Format:
+//
+//	"nos.nebuly.com/gpu-memory-derating-<gpu-index>"
+//
+// Example:
+//
+//	"nos.nebuly.com/gpu-memory-derating-0": "0.0125"
+var AnnotationGpuMemoryDeratingFormat = fmt.Sprintf(
+	"%s-%%d",
+	AnnotationGpuMemoryDeratingPrefix,
+)
+
+// AnnotationGpuSpecLabelsFormat is the format of the annotation used to specify the comma-separated labels
+// of the MIG devices of a certain profile created on a GPU of a node (e.g. the team or the deployment that
+// requested them)
+//
+// Format:
+//
+//	"nos.nebuly.com/spec-labels-gpu-<gpu-index>-<profile>"
+//
+// Example:
+//
+//	"nos.nebuly.com/spec-labels-gpu-0-1g.10gb": "team=ml,deployment=inference"
+//
+// As for AnnotationGpuStatusFormat, the suffix "+me" of the MIG profiles is written as ".me".
+var AnnotationGpuSpecLabelsFormat = fmt.Sprintf(
+	"%s-%%d-%%s",
+	AnnotationGpuSpecLabelsPrefix,
+)
+
+// AnnotationMigDeviceLabelsFormat is the format of the annotation used to expose the comma-separated labels
+// of a MIG device created on a node, which can be used for attributing the MIG devices to their requesters
+//
+// Format:
+//
+//	"nos.nebuly.com/mig-device-labels-<device-uuid>"
+//
+// Example:
+//
+//	"nos.nebuly.com/mig-device-labels-MIG-d6e3c4a1-7b0f-5e2a-9c1d-3f4b5a6c7d8e": "deployment=inference,team=ml"
+var AnnotationMigDeviceLabelsFormat = fmt.Sprintf(
+	"%s-%%s",
+	AnnotationMigDeviceLabelsPrefix,
+)
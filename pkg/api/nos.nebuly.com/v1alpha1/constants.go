@@ -25,3 +25,13 @@ const (
 	// ResourceGPUMemory is the name of the custom resource used by nos for specifying GPU memory GigaBytes
 	ResourceGPUMemory v1.ResourceName = "nos.nebuly.com/gpu-memory"
 )
+
+// Node conditions
+const (
+	// NodeConditionMigConfigApplied is the type of the node condition reporting the outcome of the
+	// last attempt of the MIG agent to apply the desired MIG configuration of the node
+	NodeConditionMigConfigApplied v1.NodeConditionType = "MigConfigApplied"
+	// NodeConditionReasonMigConfigApplyFailed is the reason of the NodeConditionMigConfigApplied condition
+	// when the MIG agent could not apply the desired MIG configuration of the node
+	NodeConditionReasonMigConfigApplyFailed = "ApplyFailed"
+)
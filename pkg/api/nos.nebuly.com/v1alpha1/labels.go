@@ -21,4 +21,39 @@ const (
 	LabelCapacityInfo = "nos.nebuly.com/capacity"
 	// LabelGpuPartitioning specifies the PartitioningKind that should be performed on the GPUs of a node
 	LabelGpuPartitioning = "nos.nebuly.com/gpu-partitioning"
+	// LabelGpuWorkloadClass specifies the class of a Pod requesting GPUs, which determines the GPU models
+	// preferred by the scheduler
+	LabelGpuWorkloadClass = "nos.nebuly.com/gpu-workload-class"
+	// LabelGpuSlicePlacement specifies how the GPU slices requested by a Pod should be placed
+	// on the GPUs of a node
+	LabelGpuSlicePlacement = "nos.nebuly.com/gpu-slice-placement"
+	// LabelGpuDriverVersion exposes the version of the NVIDIA driver installed on a node
+	LabelGpuDriverVersion = "nos.nebuly.com/gpu-driver-version"
+	// LabelGpuCudaDriverVersion exposes the version of CUDA supported by the NVIDIA driver installed on a node
+	LabelGpuCudaDriverVersion = "nos.nebuly.com/gpu-cuda-driver-version"
+	// LabelDevicePluginVersion specifies the version of the NVIDIA device plugin installed on a node (e.g. v0.13.0).
+	// If set, the MIG agent refuses to create MIG profiles that are not advertised by such version.
+	LabelDevicePluginVersion = "nos.nebuly.com/device-plugin-version"
+	// LabelGpuVendor specifies the vendor of the GPUs of a node (e.g. "nvidia"). Nodes without
+	// this label are assumed to have NVIDIA GPUs.
+	LabelGpuVendor = "nos.nebuly.com/gpu.vendor"
+	// LabelGpuProduct is the vendor-neutral label specifying the model of the GPUs of a node
+	LabelGpuProduct = "nos.nebuly.com/gpu.product"
+	// LabelGpuCount is the vendor-neutral label specifying the number of GPUs of a node
+	LabelGpuCount = "nos.nebuly.com/gpu.count"
+	// LabelGpuMemory is the vendor-neutral label specifying the memory of each GPU of a node, in MiB
+	LabelGpuMemory = "nos.nebuly.com/gpu.memory"
+)
+
+const (
+	// GpuWorkloadClassLatency identifies latency-sensitive Pods, which should preferably run on faster GPUs
+	GpuWorkloadClassLatency = "latency"
+	// GpuWorkloadClassBatch identifies batch Pods, which should preferably run on slower GPUs
+	GpuWorkloadClassBatch = "batch"
+)
+
+const (
+	// GpuSlicePlacementSpread places the slices of the Pods having the same controller (e.g. the replicas of a
+	// ReplicaSet) on different GPUs when possible, for fault isolation
+	GpuSlicePlacementSpread = "spread"
 )
@@ -24,4 +24,33 @@ type CapacitySchedulingArgs struct {
 	metav1.TypeMeta
 
 	NvidiaGpuResourceMemoryGB int64
+
+	// MigResourceNamePrefix is the prefix of the resources advertised by the device plugin for MIG devices.
+	// If empty, the default prefix "nvidia.com/mig-" is used.
+	MigResourceNamePrefix string
+}
+
+//+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type GpuModelScoringArgs struct {
+	metav1.TypeMeta
+
+	// ModelTiers maps GPU models, as exposed by the nvidia.com/gpu.product node label,
+	// to their performance tier. Higher tiers correspond to faster GPUs.
+	ModelTiers map[string]int64
+}
+
+//+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type GpuPartitioningFilterArgs struct {
+	metav1.TypeMeta
+
+	// ConsiderPendingMigReconfiguration makes the plugin check the MIG resources requested by a Pod against
+	// the MIG geometry requested by the spec annotations of the nodes whose reconfiguration is pending,
+	// instead of against the MIG resources currently advertised by the nodes.
+	ConsiderPendingMigReconfiguration bool
+
+	// MigResourceNamePrefix is the prefix of the resources advertised by the device plugin for MIG devices.
+	// If empty, the default prefix "nvidia.com/mig-" is used.
+	MigResourceNamePrefix string
 }
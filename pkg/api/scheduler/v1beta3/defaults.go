@@ -16,6 +16,34 @@
 
 package v1beta3
 
+import "github.com/nebuly-ai/nos/pkg/gpu"
+
+// defaultModelTiers are the GPU model tiers used when no tiers are specified in the plugin args.
+// Higher tiers correspond to faster GPUs.
+var defaultModelTiers = map[string]int64{
+	gpu.GPUModel_T4.String():             1,
+	gpu.GPUModel_V100_SXM2_16GB.String(): 2,
+	gpu.GPUModel_A30.String():            3,
+	gpu.GPUModel_A100_SXM4_40GB.String(): 4,
+	gpu.GPUModel_A100_PCIe_80GB.String(): 4,
+}
+
 func SetDefaults_CapacitySchedulingArgs(args *CapacitySchedulingArgs) {
 
 }
+
+func SetDefaults_GpuModelScoringArgs(args *GpuModelScoringArgs) {
+	if len(args.ModelTiers) == 0 {
+		args.ModelTiers = make(map[string]int64, len(defaultModelTiers))
+		for model, tier := range defaultModelTiers {
+			args.ModelTiers[model] = tier
+		}
+	}
+}
+
+func SetDefaults_GpuPartitioningFilterArgs(args *GpuPartitioningFilterArgs) {
+	if args.ConsiderPendingMigReconfiguration == nil {
+		considerPendingMigReconfiguration := false
+		args.ConsiderPendingMigReconfiguration = &considerPendingMigReconfiguration
+	}
+}
@@ -27,4 +27,24 @@ type CapacitySchedulingArgs struct {
 	metav1.TypeMeta `json:",inline"`
 
 	NvidiaGpuResourceMemoryGB *int64 `json:"nvidiaGpuResourceMemoryGB,omitempty"`
+	MigResourceNamePrefix     string `json:"migResourceNamePrefix,omitempty"`
+}
+
+//+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+//+k8s:defaulter-gen=true
+
+type GpuModelScoringArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	ModelTiers map[string]int64 `json:"modelTiers,omitempty"`
+}
+
+//+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+//+k8s:defaulter-gen=true
+
+type GpuPartitioningFilterArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	ConsiderPendingMigReconfiguration *bool  `json:"considerPendingMigReconfiguration,omitempty"`
+	MigResourceNamePrefix             string `json:"migResourceNamePrefix,omitempty"`
 }
@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	unsafe "unsafe"
 )
 
 func init() {
@@ -45,6 +46,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*GpuModelScoringArgs)(nil), (*scheduler.GpuModelScoringArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta3_GpuModelScoringArgs_To_scheduler_GpuModelScoringArgs(a.(*GpuModelScoringArgs), b.(*scheduler.GpuModelScoringArgs), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*scheduler.GpuModelScoringArgs)(nil), (*GpuModelScoringArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_scheduler_GpuModelScoringArgs_To_v1beta3_GpuModelScoringArgs(a.(*scheduler.GpuModelScoringArgs), b.(*GpuModelScoringArgs), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*GpuPartitioningFilterArgs)(nil), (*scheduler.GpuPartitioningFilterArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta3_GpuPartitioningFilterArgs_To_scheduler_GpuPartitioningFilterArgs(a.(*GpuPartitioningFilterArgs), b.(*scheduler.GpuPartitioningFilterArgs), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*scheduler.GpuPartitioningFilterArgs)(nil), (*GpuPartitioningFilterArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_scheduler_GpuPartitioningFilterArgs_To_v1beta3_GpuPartitioningFilterArgs(a.(*scheduler.GpuPartitioningFilterArgs), b.(*GpuPartitioningFilterArgs), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -52,6 +73,7 @@ func autoConvert_v1beta3_CapacitySchedulingArgs_To_scheduler_CapacitySchedulingA
 	if err := v1.Convert_Pointer_int64_To_int64(&in.NvidiaGpuResourceMemoryGB, &out.NvidiaGpuResourceMemoryGB, s); err != nil {
 		return err
 	}
+	out.MigResourceNamePrefix = in.MigResourceNamePrefix
 	return nil
 }
 
@@ -64,6 +86,7 @@ func autoConvert_scheduler_CapacitySchedulingArgs_To_v1beta3_CapacitySchedulingA
 	if err := v1.Convert_int64_To_Pointer_int64(&in.NvidiaGpuResourceMemoryGB, &out.NvidiaGpuResourceMemoryGB, s); err != nil {
 		return err
 	}
+	out.MigResourceNamePrefix = in.MigResourceNamePrefix
 	return nil
 }
 
@@ -71,3 +94,49 @@ func autoConvert_scheduler_CapacitySchedulingArgs_To_v1beta3_CapacitySchedulingA
 func Convert_scheduler_CapacitySchedulingArgs_To_v1beta3_CapacitySchedulingArgs(in *scheduler.CapacitySchedulingArgs, out *CapacitySchedulingArgs, s conversion.Scope) error {
 	return autoConvert_scheduler_CapacitySchedulingArgs_To_v1beta3_CapacitySchedulingArgs(in, out, s)
 }
+
+func autoConvert_v1beta3_GpuModelScoringArgs_To_scheduler_GpuModelScoringArgs(in *GpuModelScoringArgs, out *scheduler.GpuModelScoringArgs, s conversion.Scope) error {
+	out.ModelTiers = *(*map[string]int64)(unsafe.Pointer(&in.ModelTiers))
+	return nil
+}
+
+// Convert_v1beta3_GpuModelScoringArgs_To_scheduler_GpuModelScoringArgs is an autogenerated conversion function.
+func Convert_v1beta3_GpuModelScoringArgs_To_scheduler_GpuModelScoringArgs(in *GpuModelScoringArgs, out *scheduler.GpuModelScoringArgs, s conversion.Scope) error {
+	return autoConvert_v1beta3_GpuModelScoringArgs_To_scheduler_GpuModelScoringArgs(in, out, s)
+}
+
+func autoConvert_scheduler_GpuModelScoringArgs_To_v1beta3_GpuModelScoringArgs(in *scheduler.GpuModelScoringArgs, out *GpuModelScoringArgs, s conversion.Scope) error {
+	out.ModelTiers = *(*map[string]int64)(unsafe.Pointer(&in.ModelTiers))
+	return nil
+}
+
+// Convert_scheduler_GpuModelScoringArgs_To_v1beta3_GpuModelScoringArgs is an autogenerated conversion function.
+func Convert_scheduler_GpuModelScoringArgs_To_v1beta3_GpuModelScoringArgs(in *scheduler.GpuModelScoringArgs, out *GpuModelScoringArgs, s conversion.Scope) error {
+	return autoConvert_scheduler_GpuModelScoringArgs_To_v1beta3_GpuModelScoringArgs(in, out, s)
+}
+
+func autoConvert_v1beta3_GpuPartitioningFilterArgs_To_scheduler_GpuPartitioningFilterArgs(in *GpuPartitioningFilterArgs, out *scheduler.GpuPartitioningFilterArgs, s conversion.Scope) error {
+	if err := v1.Convert_Pointer_bool_To_bool(&in.ConsiderPendingMigReconfiguration, &out.ConsiderPendingMigReconfiguration, s); err != nil {
+		return err
+	}
+	out.MigResourceNamePrefix = in.MigResourceNamePrefix
+	return nil
+}
+
+// Convert_v1beta3_GpuPartitioningFilterArgs_To_scheduler_GpuPartitioningFilterArgs is an autogenerated conversion function.
+func Convert_v1beta3_GpuPartitioningFilterArgs_To_scheduler_GpuPartitioningFilterArgs(in *GpuPartitioningFilterArgs, out *scheduler.GpuPartitioningFilterArgs, s conversion.Scope) error {
+	return autoConvert_v1beta3_GpuPartitioningFilterArgs_To_scheduler_GpuPartitioningFilterArgs(in, out, s)
+}
+
+func autoConvert_scheduler_GpuPartitioningFilterArgs_To_v1beta3_GpuPartitioningFilterArgs(in *scheduler.GpuPartitioningFilterArgs, out *GpuPartitioningFilterArgs, s conversion.Scope) error {
+	if err := v1.Convert_bool_To_Pointer_bool(&in.ConsiderPendingMigReconfiguration, &out.ConsiderPendingMigReconfiguration, s); err != nil {
+		return err
+	}
+	out.MigResourceNamePrefix = in.MigResourceNamePrefix
+	return nil
+}
+
+// Convert_scheduler_GpuPartitioningFilterArgs_To_v1beta3_GpuPartitioningFilterArgs is an autogenerated conversion function.
+func Convert_scheduler_GpuPartitioningFilterArgs_To_v1beta3_GpuPartitioningFilterArgs(in *scheduler.GpuPartitioningFilterArgs, out *GpuPartitioningFilterArgs, s conversion.Scope) error {
+	return autoConvert_scheduler_GpuPartitioningFilterArgs_To_v1beta3_GpuPartitioningFilterArgs(in, out, s)
+}
@@ -30,9 +30,19 @@ import (
 // All generated defaulters are covering - they call all nested defaulters.
 func RegisterDefaults(scheme *runtime.Scheme) error {
 	scheme.AddTypeDefaultingFunc(&CapacitySchedulingArgs{}, func(obj interface{}) { SetObjectDefaults_CapacitySchedulingArgs(obj.(*CapacitySchedulingArgs)) })
+	scheme.AddTypeDefaultingFunc(&GpuModelScoringArgs{}, func(obj interface{}) { SetObjectDefaults_GpuModelScoringArgs(obj.(*GpuModelScoringArgs)) })
+	scheme.AddTypeDefaultingFunc(&GpuPartitioningFilterArgs{}, func(obj interface{}) { SetObjectDefaults_GpuPartitioningFilterArgs(obj.(*GpuPartitioningFilterArgs)) })
 	return nil
 }
 
 func SetObjectDefaults_CapacitySchedulingArgs(in *CapacitySchedulingArgs) {
 	SetDefaults_CapacitySchedulingArgs(in)
 }
+
+func SetObjectDefaults_GpuModelScoringArgs(in *GpuModelScoringArgs) {
+	SetDefaults_GpuModelScoringArgs(in)
+}
+
+func SetObjectDefaults_GpuPartitioningFilterArgs(in *GpuPartitioningFilterArgs) {
+	SetDefaults_GpuPartitioningFilterArgs(in)
+}
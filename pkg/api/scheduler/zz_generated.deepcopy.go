@@ -48,3 +48,58 @@ func (in *CapacitySchedulingArgs) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GpuModelScoringArgs) DeepCopyInto(out *GpuModelScoringArgs) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	if in.ModelTiers != nil {
+		in, out := &in.ModelTiers, &out.ModelTiers
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GpuModelScoringArgs.
+func (in *GpuModelScoringArgs) DeepCopy() *GpuModelScoringArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(GpuModelScoringArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GpuModelScoringArgs) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GpuPartitioningFilterArgs) DeepCopyInto(out *GpuPartitioningFilterArgs) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GpuPartitioningFilterArgs.
+func (in *GpuPartitioningFilterArgs) DeepCopy() *GpuPartitioningFilterArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(GpuPartitioningFilterArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GpuPartitioningFilterArgs) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
@@ -36,6 +36,7 @@ const (
 	ClusterStatePodControllerName       = "clusterstate-pod-controller"
 	MigPartitionerControllerName        = "mig-partitioner-controller"
 	MpsPartitionerControllerName        = "mps-partitioner-controller"
+	MpsStandbyControllerName            = "mps-standby-controller"
 )
 
 // Error messages
@@ -47,8 +48,8 @@ const (
 // Common RegEx
 const (
 	// RegexNvidiaMigResource is a regex matching the name of the MIG devices exposed by the NVIDIA device plugin
-	RegexNvidiaMigResource     = `nvidia\.com\/mig-\d+g\.\d+gb`
-	RegexNvidiaMigProfile      = `\d+g\.\d+gb`
+	RegexNvidiaMigResource     = `nvidia\.com\/mig-(\d+c\.)?\d+g\.\d+gb(\.me)?`
+	RegexNvidiaMigProfile      = `(\d+c\.)?\d+g\.\d+gb(\+me)?`
 	RegexNvidiaMigFormatMemory = `\d+gb`
 )
 
@@ -80,7 +81,7 @@ const (
 	// the number of NVIDIA GPUs on a certain node
 	LabelNvidiaCount = "nvidia.com/gpu.count"
 	// LabelNvidiaMemory is the name of the label assigned by the NVIDIA GPU Operator that identifies
-	// the amount of memory of the GPUs of a node
+	// the amount of memory of the GPUs of a node, expressed in MiB
 	LabelNvidiaMemory = "nvidia.com/gpu.memory"
 	// LabelNvidiaDevicePluginConfig is the label used by the NVIDIA k8s device plugin for determining the
 	// which plugin config to apply choosing from the respective ConfigMap
@@ -26,6 +26,44 @@ import (
 	"strings"
 )
 
+const (
+	// mediaExtensionsProfileSuffix is the suffix of the names of the MIG profiles including media
+	// extensions (e.g. 1g.5gb+me), "+" is not a valid character in annotation keys
+	mediaExtensionsProfileSuffix = "+me"
+	// mediaExtensionsAnnotationSuffix is the suffix that replaces mediaExtensionsProfileSuffix
+	// in the annotation keys (e.g. 1g.5gb.me)
+	mediaExtensionsAnnotationSuffix = ".me"
+)
+
+// ProfileToAnnotationKey returns the form of the profile provided as argument that can be used in
+// annotation keys. Since annotation keys cannot contain "+", the media extensions suffix "+me"
+// becomes ".me".
+//
+// Example:
+//
+//	1g.5gb+me => 1g.5gb.me
+//	1g.5gb => 1g.5gb
+func ProfileToAnnotationKey(profile string) string {
+	if strings.HasSuffix(profile, mediaExtensionsProfileSuffix) {
+		return strings.TrimSuffix(profile, mediaExtensionsProfileSuffix) + mediaExtensionsAnnotationSuffix
+	}
+	return profile
+}
+
+// ProfileFromAnnotationKey is the inverse of ProfileToAnnotationKey, it returns the profile
+// corresponding to the form used in annotation keys provided as argument.
+//
+// Example:
+//
+//	1g.5gb.me => 1g.5gb+me
+//	1g.5gb => 1g.5gb
+func ProfileFromAnnotationKey(s string) string {
+	if strings.HasSuffix(s, mediaExtensionsAnnotationSuffix) {
+		return strings.TrimSuffix(s, mediaExtensionsAnnotationSuffix) + mediaExtensionsProfileSuffix
+	}
+	return s
+}
+
 func ParseSpecAnnotation(key, value string) (SpecAnnotation, error) {
 	if !strings.HasPrefix(key, v1alpha1.AnnotationGpuSpecPrefix) {
 		err := fmt.Errorf(
@@ -48,7 +86,7 @@ func ParseSpecAnnotation(key, value string) (SpecAnnotation, error) {
 		return SpecAnnotation{}, fmt.Errorf("invalid GPU index: %s", err)
 	}
 	return SpecAnnotation{
-		ProfileName: parts[len(parts)-1],
+		ProfileName: ProfileFromAnnotationKey(parts[len(parts)-1]),
 		Quantity:    quantity,
 		Index:       index,
 	}, nil
@@ -78,7 +116,7 @@ func ParseStatusAnnotation(key, value string) (StatusAnnotation, error) {
 
 	return StatusAnnotation{
 		Index:       index,
-		ProfileName: parts[3],
+		ProfileName: ProfileFromAnnotationKey(parts[3]),
 		Status:      status,
 		Quantity:    quantity,
 	}, nil
@@ -118,7 +156,7 @@ func (a StatusAnnotation) IsFree() bool {
 }
 
 func (a StatusAnnotation) String() string {
-	return fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, a.Index, a.ProfileName, a.Status)
+	return fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, a.Index, ProfileToAnnotationKey(a.ProfileName), a.Status)
 }
 
 func (a StatusAnnotation) GetValue() string {
@@ -146,7 +184,7 @@ type SpecAnnotation struct {
 }
 
 func (a SpecAnnotation) String() string {
-	return fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, a.Index, a.ProfileName)
+	return fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, a.Index, ProfileToAnnotationKey(a.ProfileName))
 }
 
 func (a SpecAnnotation) GetValue() string {
@@ -24,6 +24,7 @@ import (
 	"github.com/nebuly-ai/nos/pkg/test/factory"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"testing"
 )
 
@@ -447,3 +448,52 @@ func TestParseSpecAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestAnnotation__MediaExtensionsProfile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		profileName string
+	}{
+		{
+			name:        "Profile without media extensions",
+			profileName: "1g.5gb",
+		},
+		{
+			name:        "Profile with media extensions",
+			profileName: "1g.5gb+me",
+		},
+		{
+			name:        "Profile with compute instance slices",
+			profileName: "1c.3g.20gb",
+		},
+		{
+			name:        "Fractional profile",
+			profileName: "0.25",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			statusAnnotation := gpu.StatusAnnotation{
+				ProfileName: tt.profileName,
+				Index:       0,
+				Status:      resource.StatusFree,
+				Quantity:    1,
+			}
+			assert.Empty(t, validation.IsQualifiedName(statusAnnotation.String()))
+			parsedStatus, err := gpu.ParseStatusAnnotation(statusAnnotation.String(), statusAnnotation.GetValue())
+			assert.NoError(t, err)
+			assert.Equal(t, statusAnnotation, parsedStatus)
+
+			specAnnotation := gpu.SpecAnnotation{
+				ProfileName: tt.profileName,
+				Index:       1,
+				Quantity:    2,
+			}
+			assert.Empty(t, validation.IsQualifiedName(specAnnotation.String()))
+			parsedSpec, err := gpu.ParseSpecAnnotation(specAnnotation.String(), specAnnotation.GetValue())
+			assert.NoError(t, err)
+			assert.Equal(t, specAnnotation, parsedSpec)
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"github.com/nebuly-ai/nos/pkg/constant"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"math"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"time"
@@ -40,18 +42,76 @@ type DevicePluginClient interface {
 	Restart(ctx context.Context, nodeName string, timeout time.Duration) error
 }
 
+// DevicePluginRestartStrategy defines how the NVIDIA device plugin is made aware of changes of the GPU devices
+type DevicePluginRestartStrategy string
+
+const (
+	// DevicePluginRestartStrategyPodDelete restarts the device plugin by deleting its pod and waiting
+	// for it to be recreated by its daemonset
+	DevicePluginRestartStrategyPodDelete DevicePluginRestartStrategy = "podDelete"
+	// DevicePluginRestartStrategyNone never restarts the device plugin, relying on the plugin itself
+	// to detect the changes of the GPU devices (e.g. through signals or file watches)
+	DevicePluginRestartStrategyNone DevicePluginRestartStrategy = "none"
+)
+
+// Validate returns an error if the strategy is not valid. An empty strategy is valid and
+// corresponds to DevicePluginRestartStrategyPodDelete.
+func (s DevicePluginRestartStrategy) Validate() error {
+	switch s {
+	case "", DevicePluginRestartStrategyPodDelete, DevicePluginRestartStrategyNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid device plugin restart strategy %q", s)
+	}
+}
+
+const (
+	// devicePluginPollInterval is the initial interval at which the NVIDIA device plugin pod is checked
+	// while waiting for it to be recreated
+	devicePluginPollInterval = 5 * time.Second
+	// devicePluginMaxPollInterval is the max interval at which the NVIDIA device plugin pod is checked,
+	// reached by progressively increasing the poll interval by devicePluginPollBackoffFactor
+	devicePluginMaxPollInterval = 30 * time.Second
+	// devicePluginPollBackoffFactor is the factor by which the poll interval is multiplied after each check
+	devicePluginPollBackoffFactor = 1.5
+	// devicePluginPollJitter is the max fraction of the poll interval randomly added to each interval,
+	// so that agents restarting the device plugin at the same time do not poll the API server in sync
+	devicePluginPollJitter = 0.5
+	// devicePluginMissingPodTimeout is the max amount of time to wait for the NVIDIA device plugin pod
+	// to show up when restarting it, since the pod might be transiently missing (e.g. during a rollout)
+	devicePluginMissingPodTimeout = 10 * time.Second
+)
+
 func NewDevicePluginClient(k8sClient client.Client) DevicePluginClient {
-	return devicePluginClient{Client: k8sClient}
+	return devicePluginClient{
+		Client:            k8sClient,
+		pollInterval:      devicePluginPollInterval,
+		maxPollInterval:   devicePluginMaxPollInterval,
+		missingPodTimeout: devicePluginMissingPodTimeout,
+	}
 }
 
 type devicePluginClient struct {
 	client.Client
+	pollInterval      time.Duration
+	maxPollInterval   time.Duration
+	missingPodTimeout time.Duration
 }
 
-func (d devicePluginClient) Restart(ctx context.Context, nodeName string, timeout time.Duration) error {
-	logger := log.FromContext(ctx)
+// newPollBackoff returns the backoff providing the intervals between two consecutive checks of the
+// NVIDIA device plugin pods: starting from pollInterval, each interval is increased by
+// devicePluginPollBackoffFactor up to maxPollInterval, and a random jitter is added to it.
+func (d devicePluginClient) newPollBackoff() *wait.Backoff {
+	return &wait.Backoff{
+		Duration: d.pollInterval,
+		Factor:   devicePluginPollBackoffFactor,
+		Jitter:   devicePluginPollJitter,
+		Steps:    math.MaxInt32,
+		Cap:      d.maxPollInterval,
+	}
+}
 
-	// Get pod
+func (d devicePluginClient) listPods(ctx context.Context, nodeName string) ([]v1.Pod, error) {
 	var podList v1.PodList
 	if err := d.List(
 		ctx,
@@ -59,24 +119,76 @@ func (d devicePluginClient) Restart(ctx context.Context, nodeName string, timeou
 		client.MatchingLabels{"app": "nvidia-device-plugin-daemonset"},
 		client.MatchingFields{constant.PodNodeNameKey: nodeName},
 	); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// waitForPods returns the NVIDIA device plugin pods of the node, waiting up to missingPodTimeout
+// if there isn't any pod yet
+func (d devicePluginClient) waitForPods(ctx context.Context, nodeName string) ([]v1.Pod, error) {
+	logger := log.FromContext(ctx)
+	deadline := time.Now().Add(d.missingPodTimeout)
+	backoff := d.newPollBackoff()
+	for {
+		pods, err := d.listPods(ctx, nodeName)
+		if err != nil {
+			return nil, err
+		}
+		if len(pods) > 0 || !time.Now().Before(deadline) {
+			return pods, nil
+		}
+		logger.V(1).Info("NVIDIA device plugin Pod not found, waiting for it to show up")
+		time.Sleep(backoff.Step())
+	}
+}
+
+func (d devicePluginClient) Restart(ctx context.Context, nodeName string, timeout time.Duration) error {
+	logger := log.FromContext(ctx)
+
+	// Get pod
+	pods, err := d.waitForPods(ctx, nodeName)
+	if err != nil {
 		return err
 	}
-	if len(podList.Items) != 1 {
+	if len(pods) == 0 {
+		return fmt.Errorf(
+			"error getting nvidia device plugin pod on node %s: expected exactly 1 but got 0",
+			nodeName,
+		)
+	}
+
+	// If a pod is already terminating, the deletion is already in progress:
+	// just wait for the new pod to be running
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			logger.V(1).Info(
+				"NVIDIA device plugin Pod is already terminating, waiting for the new one",
+				"pod",
+				pod.Name,
+				"namespace",
+				pod.Namespace,
+			)
+			return d.WaitUntilRunning(ctx, nodeName, timeout)
+		}
+	}
+
+	if len(pods) != 1 {
 		return fmt.Errorf(
 			"error getting nvidia device plugin pod on node %s: expected exactly 1 but got %d",
 			nodeName,
-			len(podList.Items),
+			len(pods),
 		)
 	}
 	// Delete pod
 	logger.V(1).Info(
 		"deleting NVIDIA device plugin Pod",
 		"pod",
-		podList.Items[0].Name,
+		pods[0].Name,
 		"namespace",
-		podList.Items[0].Namespace,
+		pods[0].Namespace,
 	)
-	if err := d.Delete(ctx, &podList.Items[0]); err != nil {
+	if err = d.Delete(ctx, &pods[0]); client.IgnoreNotFound(err) != nil {
 		return fmt.Errorf("error deleting nvidia device plugin pod: %s", err.Error())
 	}
 	// Wait until the Pods gets recreated
@@ -89,29 +201,26 @@ func (d devicePluginClient) WaitUntilRunning(ctx context.Context, nodeName strin
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var podList v1.PodList
 	checkPodRecreated := func() (bool, error) {
-		if err := d.List(
-			ctx,
-			&podList,
-			client.MatchingLabels{"app": "nvidia-device-plugin-daemonset"},
-			client.MatchingFields{constant.PodNodeNameKey: nodeName},
-		); err != nil {
+		pods, err := d.listPods(ctx, nodeName)
+		if err != nil {
 			return false, err
 		}
-		if len(podList.Items) != 1 {
-			return false, nil
-		}
-		pod := podList.Items[0]
-		if pod.DeletionTimestamp != nil {
-			return false, nil
-		}
-		if pod.Status.Phase != v1.PodRunning {
-			return false, nil
+		// Pods being terminated are not taken into account
+		var running int
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			if pod.Status.Phase != v1.PodRunning {
+				return false, nil
+			}
+			running++
 		}
-		return true, nil
+		return running == 1, nil
 	}
 
+	backoff := d.newPollBackoff()
 	for {
 		logger.V(1).Info("waiting for NVIDIA device plugin Pod to be recreated")
 		recreated, err := checkPodRecreated()
@@ -125,7 +234,7 @@ func (d devicePluginClient) WaitUntilRunning(ctx context.Context, nodeName strin
 		if ctx.Err() != nil {
 			return fmt.Errorf("error waiting for NVIDIA device plugin Pod on node %s: timeout", nodeName)
 		}
-		time.Sleep(5 * time.Second)
+		time.Sleep(backoff.Step())
 	}
 
 	return nil
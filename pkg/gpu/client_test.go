@@ -0,0 +1,168 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+// fakeDaemonSetClient simulates the NVIDIA device plugin daemonset, recreating
+// a running pod whenever the current one gets deleted
+type fakeDaemonSetClient struct {
+	client.Client
+	// numMissingPodLists is the number of List calls that do not return any pod
+	numMissingPodLists int
+	numDeleteCalls     int
+}
+
+func (c *fakeDaemonSetClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if c.numMissingPodLists > 0 {
+		c.numMissingPodLists--
+		return nil
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *fakeDaemonSetClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.numDeleteCalls++
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	pod := newDevicePluginPod(obj.GetName() + "-recreated")
+	return c.Client.Create(ctx, &pod)
+}
+
+func newDevicePluginPod(name string) v1.Pod {
+	return factory.BuildPod("nvidia", name).
+		WithLabel("app", "nvidia-device-plugin-daemonset").
+		WithNodeName("node-1").
+		WithPhase(v1.PodRunning).
+		Get()
+}
+
+func newTerminatingDevicePluginPod(name string) v1.Pod {
+	pod := newDevicePluginPod(name)
+	now := metav1.Now()
+	pod.DeletionTimestamp = &now
+	pod.Finalizers = []string{"test"}
+	return pod
+}
+
+func TestDevicePluginClient_Restart(t *testing.T) {
+	testCases := []struct {
+		name                string
+		pods                []v1.Pod
+		numMissingPodLists  int
+		expectedDeleteCalls int
+		expectedErr         bool
+	}{
+		{
+			name:                "Running pod, should be deleted and recreated",
+			pods:                []v1.Pod{newDevicePluginPod("plugin")},
+			expectedDeleteCalls: 1,
+			expectedErr:         false,
+		},
+		{
+			name: "Pod already terminating and new pod running, should not delete any pod",
+			pods: []v1.Pod{
+				newTerminatingDevicePluginPod("plugin-old"),
+				newDevicePluginPod("plugin-new"),
+			},
+			expectedDeleteCalls: 0,
+			expectedErr:         false,
+		},
+		{
+			name:                "Pod already terminating and not recreated, should time out without deleting it",
+			pods:                []v1.Pod{newTerminatingDevicePluginPod("plugin-old")},
+			expectedDeleteCalls: 0,
+			expectedErr:         true,
+		},
+		{
+			name:                "Pod transiently missing, should wait for it and restart it",
+			pods:                []v1.Pod{newDevicePluginPod("plugin")},
+			numMissingPodLists:  2,
+			expectedDeleteCalls: 1,
+			expectedErr:         false,
+		},
+		{
+			name:                "Pod missing, should return error",
+			pods:                []v1.Pod{},
+			expectedDeleteCalls: 0,
+			expectedErr:         true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := make([]client.Object, 0, len(tt.pods))
+			for i := range tt.pods {
+				objs = append(objs, &tt.pods[i])
+			}
+			k8sClient := &fakeDaemonSetClient{
+				Client:             fake.NewClientBuilder().WithObjects(objs...).Build(),
+				numMissingPodLists: tt.numMissingPodLists,
+			}
+			devicePlugin := devicePluginClient{
+				Client:            k8sClient,
+				pollInterval:      10 * time.Millisecond,
+				maxPollInterval:   20 * time.Millisecond,
+				missingPodTimeout: 100 * time.Millisecond,
+			}
+
+			err := devicePlugin.Restart(context.Background(), "node-1", 100*time.Millisecond)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedDeleteCalls, k8sClient.numDeleteCalls)
+		})
+	}
+}
+
+func TestDevicePluginClient_PollBackoff(t *testing.T) {
+	devicePlugin := devicePluginClient{
+		pollInterval:    devicePluginPollInterval,
+		maxPollInterval: devicePluginMaxPollInterval,
+	}
+
+	var intervals = make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		backoff := devicePlugin.newPollBackoff()
+		expectedBase := float64(devicePluginPollInterval)
+		for step := 0; step < 10; step++ {
+			interval := backoff.Step()
+			minInterval := time.Duration(expectedBase)
+			maxInterval := time.Duration(expectedBase * (1 + devicePluginPollJitter))
+			assert.GreaterOrEqual(t, interval, minInterval, "step %d", step)
+			assert.LessOrEqual(t, interval, maxInterval, "step %d", step)
+			intervals[interval] = struct{}{}
+			expectedBase = math.Min(expectedBase*devicePluginPollBackoffFactor, float64(devicePluginMaxPollInterval))
+		}
+	}
+	// Jitter should make the intervals vary
+	assert.Greater(t, len(intervals), 1)
+}
@@ -41,6 +41,18 @@ func (m Device) String() string {
 
 type DeviceList []Device
 
+// Info contains the health and the topology of a GPU of a node
+type Info struct {
+	// Index is the index of the GPU
+	Index int
+	// Healthy is false if the GPU cannot be accessed (e.g. because it fell off the bus)
+	Healthy bool
+	// NumaNode is the NUMA node the GPU is attached to, nil if unknown
+	NumaNode *int
+	// NVLinkPeers are the indexes of the GPUs directly connected to the GPU through active NVLinks
+	NVLinkPeers []int
+}
+
 func (l DeviceList) GroupBy(keyFunc func(resource Device) string) map[string]DeviceList {
 	result := make(map[string]DeviceList)
 	for _, r := range l {
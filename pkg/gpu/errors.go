@@ -24,18 +24,21 @@ import (
 type errorCode string
 
 const (
-	errorCodeNotFound = "resource-not-found"
-	errorCodeGeneric  = "generic"
+	errorCodeNotFound  = "resource-not-found"
+	errorCodeTransient = "transient"
+	errorCodeGeneric   = "generic"
 )
 
 var (
-	NotFoundErr = errorImpl{code: errorCodeNotFound}
-	GenericErr  = errorImpl{code: errorCodeGeneric}
+	NotFoundErr  = errorImpl{code: errorCodeNotFound}
+	TransientErr = errorImpl{code: errorCodeTransient}
+	GenericErr   = errorImpl{code: errorCodeGeneric}
 )
 
 type Error interface {
 	error
 	IsNotFound() bool
+	IsTransient() bool
 }
 
 type ErrorList []Error
@@ -65,6 +68,10 @@ func (e errorImpl) IsNotFound() bool {
 	return e.code == errorCodeNotFound
 }
 
+func (e errorImpl) IsTransient() bool {
+	return e.code == errorCodeTransient
+}
+
 func (e errorImpl) Errorf(format string, args ...any) Error {
 	e.err = fmt.Errorf(format, args...)
 	return e
@@ -91,6 +98,19 @@ func IsNotFound(err error) bool {
 	return gpuErr.IsNotFound()
 }
 
+// IsTransient returns true if the error is caused by a temporary condition (for instance, a GPU
+// that is being reset), so that the operation that caused it can be retried.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	gpuErr, ok := err.(Error)
+	if !ok {
+		return false
+	}
+	return gpuErr.IsTransient()
+}
+
 func NewGenericError(err error) Error {
 	return errorImpl{
 		err:  err,
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	"sort"
+	"strings"
+)
+
+// GetPendingAdvertisement compares the MIG devices created on the GPUs of the node, provided as argument as number
+// of devices for each profile, with the extended resources included in the capacity of the node, and returns the
+// number of created devices of each profile that are not yet advertised by the device plugin.
+//
+// Profiles whose created devices are all advertised are not included in the result.
+func GetPendingAdvertisement(node v1.Node, created map[ProfileName]int) map[ProfileName]int {
+	res := make(map[ProfileName]int)
+	for profile, quantity := range created {
+		advertised := node.Status.Capacity[profile.AsResourceName()]
+		if pending := quantity - int(advertised.Value()); pending > 0 {
+			res[profile] = pending
+		}
+	}
+	return res
+}
+
+// FormatPendingAdvertisement returns the value of the v1alpha1.AnnotationMigPendingAdvertisement annotation
+// corresponding to the pending MIG devices provided as argument, sorted by profile name.
+func FormatPendingAdvertisement(pending map[ProfileName]int) string {
+	profiles := make([]ProfileName, 0, len(pending))
+	for p := range pending {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i] < profiles[j]
+	})
+	items := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		items = append(items, fmt.Sprintf("%s x%d", p, pending[p]))
+	}
+	return strings.Join(items, ", ")
+}
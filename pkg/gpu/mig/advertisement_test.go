@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"testing"
+)
+
+func TestGetPendingAdvertisement(t *testing.T) {
+	testCases := []struct {
+		name              string
+		capacity          v1.ResourceList
+		created           map[mig.ProfileName]int
+		expected          map[mig.ProfileName]int
+		expectedFormatted string
+	}{
+		{
+			name:              "No MIG devices",
+			capacity:          v1.ResourceList{},
+			created:           map[mig.ProfileName]int{},
+			expected:          map[mig.ProfileName]int{},
+			expectedFormatted: "",
+		},
+		{
+			name: "All created devices are advertised",
+			capacity: v1.ResourceList{
+				mig.Profile1g10gb.AsResourceName(): *resource.NewQuantity(2, resource.DecimalSI),
+				mig.Profile2g20gb.AsResourceName(): *resource.NewQuantity(1, resource.DecimalSI),
+			},
+			created: map[mig.ProfileName]int{
+				mig.Profile1g10gb: 2,
+				mig.Profile2g20gb: 1,
+			},
+			expected:          map[mig.ProfileName]int{},
+			expectedFormatted: "",
+		},
+		{
+			name: "Created devices not yet in node capacity are pending advertisement",
+			capacity: v1.ResourceList{
+				mig.Profile1g10gb.AsResourceName(): *resource.NewQuantity(1, resource.DecimalSI),
+			},
+			created: map[mig.ProfileName]int{
+				mig.Profile1g10gb:   3,
+				mig.Profile2g20gb:   1,
+				mig.Profile1g10gbMe: 1,
+			},
+			expected: map[mig.ProfileName]int{
+				mig.Profile1g10gb:   2,
+				mig.Profile2g20gb:   1,
+				mig.Profile1g10gbMe: 1,
+			},
+			expectedFormatted: "1g.10gb x2, 1g.10gb+me x1, 2g.20gb x1",
+		},
+		{
+			name: "Advertised devices not created anymore are ignored",
+			capacity: v1.ResourceList{
+				mig.Profile1g10gb.AsResourceName(): *resource.NewQuantity(3, resource.DecimalSI),
+			},
+			created: map[mig.ProfileName]int{
+				mig.Profile1g10gb: 1,
+			},
+			expected:          map[mig.ProfileName]int{},
+			expectedFormatted: "",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").Get()
+			node.Status.Capacity = tt.capacity
+
+			pending := mig.GetPendingAdvertisement(node, tt.created)
+			assert.Equal(t, tt.expected, pending)
+			assert.Equal(t, tt.expectedFormatted, mig.FormatPendingAdvertisement(pending))
+		})
+	}
+}
@@ -17,8 +17,13 @@
 package mig
 
 import (
+	"fmt"
 	"github.com/google/go-cmp/cmp"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	v1 "k8s.io/api/core/v1"
+	"sort"
+	"time"
 )
 
 func SpecMatchesStatus(specAnnotations gpu.SpecAnnotationList, statusAnnotations gpu.StatusAnnotationList) bool {
@@ -34,6 +39,29 @@ func SpecMatchesStatus(specAnnotations gpu.SpecAnnotationList, statusAnnotations
 	return cmp.Equal(specMigProfilesWithQuantity, statusMigProfilesWithQuantity)
 }
 
+// GetGPUsNotMatchingSpec returns the sorted indexes of the GPUs whose status annotations do not
+// match their spec annotations.
+func GetGPUsNotMatchingSpec(specAnnotations gpu.SpecAnnotationList, statusAnnotations gpu.StatusAnnotationList) []int {
+	specByGpu := specAnnotations.GroupByGpuIndex()
+	statusByGpu := statusAnnotations.GroupByGpuIndex()
+	indexes := make(map[int]struct{})
+	for i := range specByGpu {
+		indexes[i] = struct{}{}
+	}
+	for i := range statusByGpu {
+		indexes[i] = struct{}{}
+	}
+
+	res := make([]int, 0)
+	for i := range indexes {
+		if !SpecMatchesStatus(specByGpu[i], statusByGpu[i]) {
+			res = append(res, i)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
 func GroupSpecAnnotationsByMigProfile(annotations gpu.SpecAnnotationList) map[Profile]gpu.SpecAnnotationList {
 	result := make(map[Profile]gpu.SpecAnnotationList)
 	for _, a := range annotations {
@@ -48,3 +76,85 @@ func GroupSpecAnnotationsByMigProfile(annotations gpu.SpecAnnotationList) map[Pr
 	}
 	return result
 }
+
+// ValidateSpecAnnotations returns an error if any of the MIG profiles requested by the spec annotations provided
+// as argument is not supported by the GPU model provided as argument.
+//
+// This allows to detect when the GPUs of a node have been replaced with a different model (and the
+// respective labels updated), since the spec annotations computed for the old model might
+// request MIG profiles that cannot be created on the new GPUs.
+func ValidateSpecAnnotations(model gpu.Model, specAnnotations gpu.SpecAnnotationList) error {
+	allowedGeometries, ok := GetAllowedGeometries(model)
+	if !ok {
+		return fmt.Errorf("model %q is not associated with any known GPU", model)
+	}
+	allowedProfiles := make(map[gpu.Slice]bool)
+	for _, g := range allowedGeometries {
+		for p := range g {
+			allowedProfiles[p] = true
+		}
+	}
+	for _, a := range specAnnotations {
+		profile := ProfileName(a.ProfileName)
+		if profile.HasMediaExtensions() && SupportsMediaExtensionsProfile(model, profile) {
+			continue
+		}
+		if !allowedProfiles[profile] {
+			return fmt.Errorf(
+				"MIG profile %s requested on GPU %d is not supported by GPU model %s",
+				a.ProfileName,
+				a.Index,
+				model,
+			)
+		}
+	}
+	return nil
+}
+
+// GetGeometryLastChanged returns the time when the MIG devices of the GPUs of the node provided as argument
+// were last created or deleted by the MIG agent, as recorded by the v1alpha1.AnnotationMigGeometryLastChanged
+// annotation. The returned bool is false if the node does not have the annotation, while an error is
+// returned if the annotation is malformed.
+func GetGeometryLastChanged(node v1.Node) (time.Time, bool, error) {
+	value, ok := node.Annotations[v1alpha1.AnnotationMigGeometryLastChanged]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	lastChanged, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s annotation %q: %w", v1alpha1.AnnotationMigGeometryLastChanged, value, err)
+	}
+	return lastChanged, true, nil
+}
+
+// IsReconfigurationPending returns true if the MIG geometry requested by the spec annotations of the node provided
+// as argument does not match the one reported by its status annotations, and the MIG agent has not failed to apply
+// it, as reported by the v1alpha1.NodeConditionMigConfigApplied condition of the node.
+func IsReconfigurationPending(node v1.Node) bool {
+	statusAnnotations, specAnnotations := gpu.ParseNodeAnnotations(node)
+	if SpecMatchesStatus(specAnnotations, statusAnnotations) {
+		return false
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type != v1alpha1.NodeConditionMigConfigApplied {
+			continue
+		}
+		if c.Status == v1.ConditionFalse && c.Reason == v1alpha1.NodeConditionReasonMigConfigApplyFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSpecResources returns the quantity of each MIG resource that the node provided as argument
+// will expose once the MIG geometry requested by its spec annotations is applied.
+func GetSpecResources(node v1.Node) map[v1.ResourceName]int64 {
+	_, specAnnotations := gpu.ParseNodeAnnotations(node)
+	res := make(map[v1.ResourceName]int64)
+	for _, a := range specAnnotations {
+		if a.Quantity > 0 {
+			res[ProfileName(a.ProfileName).AsResourceName()] += int64(a.Quantity)
+		}
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetGPUsNotMatchingSpec(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     gpu.SpecAnnotationList
+		status   gpu.StatusAnnotationList
+		expected []int
+	}{
+		{
+			name:     "Empty spec and status",
+			spec:     gpu.SpecAnnotationList{},
+			status:   gpu.StatusAnnotationList{},
+			expected: []int{},
+		},
+		{
+			name: "Status matches spec",
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Quantity: 2},
+			},
+			status: gpu.StatusAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+				{ProfileName: "1g.10gb", Index: 0, Status: resource.StatusUsed, Quantity: 1},
+			},
+			expected: []int{},
+		},
+		{
+			name: "Only some GPUs do not match",
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Quantity: 1},
+				{ProfileName: "1g.10gb", Index: 2, Quantity: 1},
+			},
+			status: gpu.StatusAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+				{ProfileName: "2g.20gb", Index: 2, Status: resource.StatusFree, Quantity: 1},
+				{ProfileName: "2g.20gb", Index: 1, Status: resource.StatusFree, Quantity: 1},
+			},
+			expected: []int{1, 2},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mig.GetGPUsNotMatchingSpec(tt.spec, tt.status))
+		})
+	}
+}
+
+func TestGetGeometryLastChanged(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		expected      time.Time
+		expectedFound bool
+		expectedErr   bool
+	}{
+		{
+			name:          "Node without annotation",
+			annotations:   map[string]string{},
+			expected:      time.Time{},
+			expectedFound: false,
+			expectedErr:   false,
+		},
+		{
+			name:          "Valid timestamp",
+			annotations:   map[string]string{v1alpha1.AnnotationMigGeometryLastChanged: "2023-03-01T10:00:00Z"},
+			expected:      time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+			expectedFound: true,
+			expectedErr:   false,
+		},
+		{
+			name:          "Malformed timestamp",
+			annotations:   map[string]string{v1alpha1.AnnotationMigGeometryLastChanged: "yesterday"},
+			expected:      time.Time{},
+			expectedFound: false,
+			expectedErr:   true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithAnnotations(tt.annotations).Get()
+			lastChanged, found, err := mig.GetGeometryLastChanged(node)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedFound, found)
+			assert.True(t, tt.expected.Equal(lastChanged))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// Capacity is the amount of GPU Instance (GI) slices and of memory (in GB) required by a set
+// of MIG profiles or available on a GPU.
+type Capacity struct {
+	GiSlices int
+	MemoryGB int
+}
+
+func (c Capacity) String() string {
+	return fmt.Sprintf("%dg.%dgb", c.GiSlices, c.MemoryGB)
+}
+
+// Covers returns true if the capacity is greater or equal than the one provided as argument
+// both in terms of GI slices and memory.
+func (c Capacity) Covers(other Capacity) bool {
+	return c.GiSlices >= other.GiSlices && c.MemoryGB >= other.MemoryGB
+}
+
+// GetCapacity returns the capacity of a GPU of the model provided as argument, computed as the
+// highest amount of GI slices and memory allocated by any of the MIG geometries allowed by the model.
+//
+// The returned bool is false if the model does not have any known MIG geometry.
+func GetCapacity(model gpu.Model) (Capacity, bool) {
+	allowedGeometries, ok := GetAllowedGeometries(model)
+	if !ok {
+		return Capacity{}, false
+	}
+	var res Capacity
+	for _, geometry := range allowedGeometries {
+		profiles := make(map[ProfileName]int, len(geometry))
+		for p, q := range geometry {
+			if migProfile, ok := p.(ProfileName); ok {
+				profiles[migProfile] = q
+			}
+		}
+		required := GetRequiredCapacity(profiles)
+		if required.GiSlices > res.GiSlices {
+			res.GiSlices = required.GiSlices
+		}
+		if required.MemoryGB > res.MemoryGB {
+			res.MemoryGB = required.MemoryGB
+		}
+	}
+	return res, true
+}
+
+// GetRequiredCapacity returns the capacity required for creating the MIG profiles provided as argument,
+// where the keys are the profiles and the values their quantities.
+func GetRequiredCapacity(profiles map[ProfileName]int) Capacity {
+	var res Capacity
+	for p, q := range profiles {
+		res.GiSlices += p.getGiSlices() * q
+		res.MemoryGB += p.getMemorySlices() * q
+	}
+	return res
+}
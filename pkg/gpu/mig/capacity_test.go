@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetCapacity(t *testing.T) {
+	testCases := []struct {
+		name       string
+		model      gpu.Model
+		expected   mig.Capacity
+		expectedOk bool
+	}{
+		{
+			name:       "Unknown model",
+			model:      "foo",
+			expected:   mig.Capacity{},
+			expectedOk: false,
+		},
+		{
+			name:       "A30",
+			model:      gpu.GPUModel_A30,
+			expected:   mig.Capacity{GiSlices: 4, MemoryGB: 24},
+			expectedOk: true,
+		},
+		{
+			name:       "A100 40GB",
+			model:      gpu.GPUModel_A100_SXM4_40GB,
+			expected:   mig.Capacity{GiSlices: 7, MemoryGB: 40},
+			expectedOk: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity, ok := mig.GetCapacity(tt.model)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expected, capacity)
+		})
+	}
+}
+
+func TestGetRequiredCapacity(t *testing.T) {
+	required := mig.GetRequiredCapacity(map[mig.ProfileName]int{
+		mig.Profile1g5gb:  2,
+		mig.Profile3g20gb: 1,
+	})
+	assert.Equal(t, mig.Capacity{GiSlices: 5, MemoryGB: 30}, required)
+	assert.True(t, mig.Capacity{GiSlices: 7, MemoryGB: 40}.Covers(required))
+	assert.False(t, mig.Capacity{GiSlices: 4, MemoryGB: 40}.Covers(required))
+	assert.False(t, mig.Capacity{GiSlices: 7, MemoryGB: 20}.Covers(required))
+}
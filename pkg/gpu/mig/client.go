@@ -18,6 +18,7 @@ package mig
 
 import (
 	"context"
+	"fmt"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
 	"github.com/nebuly-ai/nos/pkg/resource"
@@ -25,13 +26,25 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// DeviceInfo contains the metadata of a MIG device
+type DeviceInfo struct {
+	gpu.Device
+	// GpuInstanceId is the ID of the GPU Instance of the MIG device
+	GpuInstanceId int
+	// ComputeInstanceId is the ID of the Compute Instance of the MIG device
+	ComputeInstanceId int
+}
+
 type Client interface {
 	GetMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Error)
+	GetMigDeviceById(ctx context.Context, deviceId string) (DeviceInfo, gpu.Error)
+	CountCreatedMigDevices(ctx context.Context) (map[ProfileName]int, gpu.Error)
 	GetUsedMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Error)
 	GetAllocatableMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Error)
 	CreateMigDevices(ctx context.Context, profileList ProfileList) (ProfileList, error)
 	DeleteMigDevice(ctx context.Context, device gpu.Device) gpu.Error
 	DeleteAllExcept(ctx context.Context, resources gpu.DeviceList) error
+	ClearGpu(ctx context.Context, gpuIndex int) (int, error)
 }
 
 type clientImpl struct {
@@ -53,7 +66,7 @@ func NewClient(resourceClient resource.Client, nvmlClient nvml.Client) Client {
 // CreateMigResources still tries to create the resources on the other GPUs and returns the ones that
 // it possible to create. This means that if any error happens, the returned ProfileList will be a subset
 // of the input list, otherwise the two lists will have the same length and items.
-func (c clientImpl) CreateMigDevices(_ context.Context, profileList ProfileList) (ProfileList, error) {
+func (c clientImpl) CreateMigDevices(ctx context.Context, profileList ProfileList) (ProfileList, error) {
 	var errors = make(gpu.ErrorList, 0)
 	var createdProfiles = make(ProfileList, 0)
 	for gpuIndex, profiles := range profileList.GroupByGPU() {
@@ -61,7 +74,7 @@ func (c clientImpl) CreateMigDevices(_ context.Context, profileList ProfileList)
 		for _, p := range profiles {
 			profileNames = append(profileNames, p.Name.String())
 		}
-		if err := c.nvmlClient.CreateMigDevices(profileNames, gpuIndex); err != nil {
+		if err := c.nvmlClient.CreateMigDevices(ctx, profileNames, gpuIndex); err != nil {
 			errors = append(errors, err)
 			continue
 		}
@@ -73,8 +86,8 @@ func (c clientImpl) CreateMigDevices(_ context.Context, profileList ProfileList)
 	return createdProfiles, nil
 }
 
-func (c clientImpl) DeleteMigDevice(_ context.Context, resource gpu.Device) gpu.Error {
-	return c.nvmlClient.DeleteMigDevice(resource.DeviceId)
+func (c clientImpl) DeleteMigDevice(ctx context.Context, resource gpu.Device) gpu.Error {
+	return c.nvmlClient.DeleteMigDevice(ctx, resource.DeviceId)
 }
 
 func (c clientImpl) GetMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Error) {
@@ -94,6 +107,43 @@ func (c clientImpl) GetMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Erro
 	return append(used, free...), nil
 }
 
+// GetMigDeviceById returns the metadata of the MIG device with the ID (namely, the UUID) provided as argument,
+// including its profile, status, GPU index and GPU/Compute Instance IDs.
+//
+// GetMigDeviceById returns a gpu.NotFoundErr error if the device is not exposed as a resource or
+// if it does not exist.
+func (c clientImpl) GetMigDeviceById(ctx context.Context, deviceId string) (DeviceInfo, gpu.Error) {
+	devices, err := c.GetMigDevices(ctx)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+	for _, d := range devices {
+		if d.DeviceId != deviceId {
+			continue
+		}
+		giId, ciId, err := c.nvmlClient.GetMigDeviceInstanceIds(ctx, deviceId)
+		if err != nil {
+			return DeviceInfo{}, err
+		}
+		return DeviceInfo{Device: d, GpuInstanceId: giId, ComputeInstanceId: ciId}, nil
+	}
+	return DeviceInfo{}, gpu.NotFoundErr.Errorf("MIG device %s not found", deviceId)
+}
+
+// CountCreatedMigDevices returns the number of MIG devices created on the GPUs for each MIG profile,
+// including the ones not yet advertised by the NVIDIA device plugin.
+func (c clientImpl) CountCreatedMigDevices(ctx context.Context) (map[ProfileName]int, gpu.Error) {
+	counts, err := c.nvmlClient.CountMigDevicesByProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[ProfileName]int, len(counts))
+	for profile, quantity := range counts {
+		res[ProfileName(profile)] += quantity
+	}
+	return res, nil
+}
+
 func (c clientImpl) GetUsedMigDevices(ctx context.Context) (gpu.DeviceList, gpu.Error) {
 	// Fetch used devices
 	usedResources, err := c.resourceClient.GetUsedDevices(ctx)
@@ -129,13 +179,51 @@ func (c clientImpl) GetAllocatableMigDevices(ctx context.Context) (gpu.DeviceLis
 }
 
 // DeleteAllExcept deletes all the devices that are not in the list of devices to keep.
-func (c clientImpl) DeleteAllExcept(_ context.Context, resourcesToKeep gpu.DeviceList) error {
+func (c clientImpl) DeleteAllExcept(ctx context.Context, resourcesToKeep gpu.DeviceList) error {
 	nResources := len(resourcesToKeep)
 	idsToKeep := make([]string, nResources)
 	for i, r := range resourcesToKeep {
 		idsToKeep[i] = r.DeviceId
 	}
-	return c.nvmlClient.DeleteAllMigDevicesExcept(idsToKeep)
+	return c.nvmlClient.DeleteAllMigDevicesExcept(ctx, idsToKeep)
+}
+
+// ClearGpu deletes all the free MIG devices of the GPU with the index provided as argument and returns
+// the number of deleted devices.
+//
+// MIG devices being used are never deleted: if the GPU has any, ClearGpu deletes the free devices
+// anyway and returns an error listing the ones still in use.
+func (c clientImpl) ClearGpu(ctx context.Context, gpuIndex int) (int, error) {
+	devices, err := c.GetMigDevices(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var nDeleted int
+	var deleteErrors = make(gpu.ErrorList, 0)
+	var used = make(gpu.DeviceList, 0)
+	for _, d := range devices {
+		if d.GpuIndex != gpuIndex {
+			continue
+		}
+		if d.IsUsed() {
+			used = append(used, d)
+			continue
+		}
+		if err := c.nvmlClient.DeleteMigDevice(ctx, d.DeviceId); err != nil {
+			deleteErrors = append(deleteErrors, err)
+			continue
+		}
+		nDeleted++
+	}
+
+	if len(deleteErrors) > 0 {
+		return nDeleted, deleteErrors
+	}
+	if len(used) > 0 {
+		return nDeleted, fmt.Errorf("cannot delete MIG devices of GPU %d being used: %v", gpuIndex, used)
+	}
+	return nDeleted, nil
 }
 
 func (c clientImpl) extractMigDevices(ctx context.Context, devices []resource.Device) ([]gpu.Device, gpu.Error) {
@@ -147,7 +235,7 @@ func (c clientImpl) extractMigDevices(ctx context.Context, devices []resource.De
 		if !IsNvidiaMigDevice(r.ResourceName) {
 			continue
 		}
-		gpuIndex, err := c.nvmlClient.GetMigDeviceGpuIndex(r.DeviceId)
+		gpuIndex, err := c.nvmlClient.GetMigDeviceGpuIndex(ctx, r.DeviceId)
 		if gpu.IgnoreNotFound(err) != nil {
 			logger.Error(
 				err,
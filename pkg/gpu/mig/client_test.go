@@ -24,6 +24,7 @@ import (
 	"github.com/nebuly-ai/nos/pkg/resource"
 	mockednvml "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
 	pdrv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
 	"testing"
@@ -231,7 +232,7 @@ func TestClient_GetUsedMigDevices(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			nvmlClient := mockednvml.Client{}
 			for migDevice, index := range tt.deviceIdToGPUIndex {
-				nvmlClient.On("GetMigDeviceGpuIndex", migDevice).Return(index, tt.getGpuIndexErr).Maybe()
+				nvmlClient.On("GetMigDeviceGpuIndex", mock.Anything, migDevice).Return(index, tt.getGpuIndexErr).Maybe()
 			}
 			lister := MockedPodResourcesListerClient{
 				ListResp:  tt.listPodResourcesResp,
@@ -360,7 +361,7 @@ func TestClient_GetAllocatableMigDevices(t *testing.T) {
 				GetAllocatableError: tt.allocatableResourcesErr,
 			}
 			for migDevice, index := range tt.deviceIdToGPUIndex {
-				nvmlClient.On("GetMigDeviceGpuIndex", migDevice).Return(index, tt.getGpuIndexErr).Maybe()
+				nvmlClient.On("GetMigDeviceGpuIndex", mock.Anything, migDevice).Return(index, tt.getGpuIndexErr).Maybe()
 			}
 			resourceClient := resource.NewClient(lister)
 			client := mig.NewClient(resourceClient, &nvmlClient)
@@ -375,3 +376,255 @@ func TestClient_GetAllocatableMigDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_CreateMigDevices(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		profiles             mig.ProfileList
+		gpuIndexToErr        map[int]gpu.Error
+		expectedNvmlRequests map[int][]string
+		expectedCreated      mig.ProfileList
+		expectedError        bool
+	}{
+		{
+			name:                 "Empty profile list",
+			profiles:             mig.ProfileList{},
+			expectedNvmlRequests: map[int][]string{},
+			expectedCreated:      mig.ProfileList{},
+		},
+		{
+			name: "Mixed geometries, each profile is created on its own GPU",
+			profiles: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile3g40gb},
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile2g20gb},
+			},
+			expectedNvmlRequests: map[int][]string{
+				0: {mig.Profile1g10gb.String(), mig.Profile1g10gb.String()},
+				1: {mig.Profile3g40gb.String(), mig.Profile2g20gb.String()},
+			},
+			expectedCreated: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile3g40gb},
+				{GpuIndex: 1, Name: mig.Profile2g20gb},
+			},
+		},
+		{
+			name: "Creation fails on one GPU, profiles of the other GPU are still created",
+			profiles: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+				{GpuIndex: 1, Name: mig.Profile3g40gb},
+			},
+			gpuIndexToErr: map[int]gpu.Error{
+				1: gpu.GenericErr.Errorf("error"),
+			},
+			expectedNvmlRequests: map[int][]string{
+				0: {mig.Profile1g10gb.String()},
+				1: {mig.Profile3g40gb.String()},
+			},
+			expectedCreated: mig.ProfileList{
+				{GpuIndex: 0, Name: mig.Profile1g10gb},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			nvmlClient := mockednvml.Client{}
+			for gpuIndex, profiles := range tt.expectedNvmlRequests {
+				nvmlClient.On("CreateMigDevices", mock.Anything, profiles, gpuIndex).Return(tt.gpuIndexToErr[gpuIndex]).Once()
+			}
+			client := mig.NewClient(resource.NewClient(MockedPodResourcesListerClient{}), &nvmlClient)
+
+			created, err := client.CreateMigDevices(context.TODO(), tt.profiles)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.ElementsMatch(t, tt.expectedCreated, created)
+			nvmlClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClient_ClearGpu(t *testing.T) {
+	testCases := []struct {
+		name               string
+		listResp           pdrv1.ListPodResourcesResponse
+		allocatableResp    pdrv1.AllocatableResourcesResponse
+		deviceIdToGPUIndex map[string]int
+		gpuIndex           int
+
+		expectedDeleted   []string
+		expectedNDeleted  int
+		expectedErrorUsed bool
+	}{
+		{
+			name:     "GPU without MIG devices",
+			listResp: pdrv1.ListPodResourcesResponse{},
+			allocatableResp: pdrv1.AllocatableResourcesResponse{
+				Devices: []*pdrv1.ContainerDevices{
+					{
+						ResourceName: "nvidia.com/mig-1g.10gb",
+						DeviceIds:    []string{"mig-device-1"},
+					},
+				},
+			},
+			deviceIdToGPUIndex: map[string]int{"mig-device-1": 1},
+			gpuIndex:           0,
+			expectedDeleted:    []string{},
+			expectedNDeleted:   0,
+		},
+		{
+			name: "All free devices of the target GPU are deleted, used ones are reported",
+			listResp: pdrv1.ListPodResourcesResponse{
+				PodResources: []*pdrv1.PodResources{
+					{
+						Name:      "pod-1",
+						Namespace: "ns-1",
+						Containers: []*pdrv1.ContainerResources{
+							{
+								Name: "container-1",
+								Devices: []*pdrv1.ContainerDevices{
+									{
+										ResourceName: "nvidia.com/mig-1g.10gb",
+										DeviceIds:    []string{"mig-device-3"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			allocatableResp: pdrv1.AllocatableResourcesResponse{
+				Devices: []*pdrv1.ContainerDevices{
+					{
+						ResourceName: "nvidia.com/mig-1g.10gb",
+						DeviceIds:    []string{"mig-device-1", "mig-device-2", "mig-device-3", "mig-device-4"},
+					},
+				},
+			},
+			deviceIdToGPUIndex: map[string]int{
+				"mig-device-1": 0,
+				"mig-device-2": 0,
+				"mig-device-3": 0,
+				"mig-device-4": 1,
+			},
+			gpuIndex:          0,
+			expectedDeleted:   []string{"mig-device-1", "mig-device-2"},
+			expectedNDeleted:  2,
+			expectedErrorUsed: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			nvmlClient := mockednvml.Client{}
+			for migDevice, index := range tt.deviceIdToGPUIndex {
+				nvmlClient.On("GetMigDeviceGpuIndex", mock.Anything, migDevice).Return(index, nil).Maybe()
+			}
+			for _, id := range tt.expectedDeleted {
+				nvmlClient.On("DeleteMigDevice", mock.Anything, id).Return(nil).Once()
+			}
+			lister := MockedPodResourcesListerClient{
+				ListResp:           tt.listResp,
+				GetAllocatableResp: tt.allocatableResp,
+			}
+			client := mig.NewClient(resource.NewClient(lister), &nvmlClient)
+
+			nDeleted, err := client.ClearGpu(context.Background(), tt.gpuIndex)
+			if tt.expectedErrorUsed {
+				assert.ErrorContains(t, err, "mig-device-3")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedNDeleted, nDeleted)
+			nvmlClient.AssertExpectations(t)
+			nvmlClient.AssertNumberOfCalls(t, "DeleteMigDevice", len(tt.expectedDeleted))
+		})
+	}
+}
+
+func TestClient_GetMigDeviceById(t *testing.T) {
+	allocatableResp := pdrv1.AllocatableResourcesResponse{
+		Devices: []*pdrv1.ContainerDevices{
+			{
+				ResourceName: "nvidia.com/mig-1g.10gb",
+				DeviceIds:    []string{"mig-device-1", "mig-device-2"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		deviceId           string
+		instanceIdsErr     gpu.Error
+		expectedDeviceInfo mig.DeviceInfo
+		expectedErr        bool
+		expectedNotFound   bool
+	}{
+		{
+			name:     "Device exists",
+			deviceId: "mig-device-2",
+			expectedDeviceInfo: mig.DeviceInfo{
+				Device: gpu.Device{
+					Device: resource.Device{
+						ResourceName: "nvidia.com/mig-1g.10gb",
+						DeviceId:     "mig-device-2",
+						Status:       resource.StatusFree,
+					},
+					GpuIndex: 1,
+				},
+				GpuInstanceId:     3,
+				ComputeInstanceId: 0,
+			},
+		},
+		{
+			name:             "Device does not exist",
+			deviceId:         "mig-device-3",
+			expectedErr:      true,
+			expectedNotFound: true,
+		},
+		{
+			name:           "Error fetching instance IDs",
+			deviceId:       "mig-device-1",
+			instanceIdsErr: gpu.GenericErr.Errorf("error"),
+			expectedErr:    true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			nvmlClient := mockednvml.Client{}
+			nvmlClient.On("GetMigDeviceGpuIndex", mock.Anything, "mig-device-1").Return(0, nil).Maybe()
+			nvmlClient.On("GetMigDeviceGpuIndex", mock.Anything, "mig-device-2").Return(1, nil).Maybe()
+			nvmlClient.On("GetMigDeviceInstanceIds", mock.Anything, tt.deviceId).Return(3, 0, tt.instanceIdsErr).Maybe()
+			lister := MockedPodResourcesListerClient{GetAllocatableResp: allocatableResp}
+			client := mig.NewClient(resource.NewClient(lister), &nvmlClient)
+
+			deviceInfo, err := client.GetMigDeviceById(context.Background(), tt.deviceId)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedNotFound, gpu.IsNotFound(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDeviceInfo, deviceInfo)
+		})
+	}
+}
+
+func TestClient_CountCreatedMigDevices(t *testing.T) {
+	nvmlClient := mockednvml.Client{}
+	nvmlClient.On("CountMigDevicesByProfile", mock.Anything).Return(map[string]int{"1g.10gb": 2, "2g.20gb": 1}, nil).Once()
+	client := mig.NewClient(resource.NewClient(MockedPodResourcesListerClient{}), &nvmlClient)
+
+	counts, err := client.CountCreatedMigDevices(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[mig.ProfileName]int{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1}, counts)
+	nvmlClient.AssertExpectations(t)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// minDevicePluginVersionMediaExtensions is the first version of the NVIDIA device plugin that
+// advertises the resources corresponding to the MIG profiles including media extensions
+var minDevicePluginVersionMediaExtensions = version.MustParseGeneric("v0.12.0")
+
+// IsAdvertisedByDevicePlugin returns true if the version of the NVIDIA device plugin provided as argument
+// advertises the resources corresponding to the MIG profile provided as argument. Creating a MIG device
+// whose resource is not advertised by the device plugin results in GPU capacity that cannot be allocated.
+//
+// The version must be in the format "v<major>.<minor>.<patch>" (e.g. v0.13.0), otherwise an error is returned.
+func IsAdvertisedByDevicePlugin(profile ProfileName, devicePluginVersion string) (bool, error) {
+	v, err := version.ParseGeneric(devicePluginVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid device plugin version %q: %w", devicePluginVersion, err)
+	}
+	if profile.HasMediaExtensions() {
+		return v.AtLeast(minDevicePluginVersionMediaExtensions), nil
+	}
+	return true, nil
+}
+
+// ValidateDevicePluginSupport returns an error if any of the spec annotations provided as argument
+// requests a MIG profile that is not advertised by the version of the NVIDIA device plugin provided
+// as argument. See IsAdvertisedByDevicePlugin.
+func ValidateDevicePluginSupport(devicePluginVersion string, specAnnotations gpu.SpecAnnotationList) error {
+	for _, a := range specAnnotations {
+		if a.Quantity == 0 {
+			continue
+		}
+		advertised, err := IsAdvertisedByDevicePlugin(ProfileName(a.ProfileName), devicePluginVersion)
+		if err != nil {
+			return err
+		}
+		if !advertised {
+			return fmt.Errorf(
+				"MIG profile %s requested on GPU %d is not advertised by device plugin version %s",
+				a.ProfileName,
+				a.Index,
+				devicePluginVersion,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateDevicePluginSupport(t *testing.T) {
+	testCases := []struct {
+		name          string
+		version       string
+		spec          gpu.SpecAnnotationList
+		errorExpected bool
+	}{
+		{
+			name:          "Invalid version",
+			version:       "latest",
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb", Index: 0, Quantity: 1}},
+			errorExpected: true,
+		},
+		{
+			name:          "Profile without media extensions is advertised by any version",
+			version:       "v0.9.0",
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+		{
+			name:    "Media extensions profile is not advertised by old versions",
+			version: "v0.11.0",
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Quantity: 1},
+				{ProfileName: "1g.10gb+me", Index: 1, Quantity: 1},
+			},
+			errorExpected: true,
+		},
+		{
+			name:          "Media extensions profile with zero quantity is ignored",
+			version:       "v0.11.0",
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb+me", Index: 0, Quantity: 0}},
+			errorExpected: false,
+		},
+		{
+			name:          "Media extensions profile is advertised by recent versions",
+			version:       "v0.13.0",
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb+me", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+		{
+			name:          "Version without v prefix",
+			version:       "0.12.0",
+			spec:          gpu.SpecAnnotationList{{ProfileName: "1g.10gb+me", Index: 0, Quantity: 1}},
+			errorExpected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mig.ValidateDevicePluginSupport(tt.version, tt.spec)
+			if tt.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// maxGiSlices is the max number of GPU Instance (GI) slices of any GPU supporting MIG
+const maxGiSlices = 7
+
+// GeometryBuilder builds MIG geometries, validating the profiles as they are added.
+// The first error encountered is returned by Build, and the profiles added afterwards are ignored.
+//
+// Example:
+//
+//	geometry, err := mig.NewGeometry().With(mig.Profile1g6gb, 2).With(mig.Profile2g12gb, 1).Build()
+type GeometryBuilder struct {
+	model    gpu.Model
+	profiles map[ProfileName]int
+	err      error
+}
+
+// NewGeometry returns a GeometryBuilder for an empty MIG geometry
+func NewGeometry() *GeometryBuilder {
+	return &GeometryBuilder{
+		profiles: make(map[ProfileName]int),
+	}
+}
+
+// ForModel makes the builder check that the geometry is allowed by the GPU model provided as argument,
+// instead of just checking that its profiles fit the GI slices of the largest MIG GPU.
+func (b *GeometryBuilder) ForModel(model gpu.Model) *GeometryBuilder {
+	if b.err != nil {
+		return b
+	}
+	if _, ok := GetAllowedGeometries(model); !ok {
+		b.err = fmt.Errorf("GPU model %s does not have any known MIG geometry", model)
+		return b
+	}
+	b.model = model
+	return b
+}
+
+// With adds the quantity of the MIG profile provided as argument to the geometry
+func (b *GeometryBuilder) With(profile ProfileName, quantity int) *GeometryBuilder {
+	if b.err != nil {
+		return b
+	}
+	if !profile.isValid() {
+		b.err = fmt.Errorf("invalid MIG profile %q", profile)
+		return b
+	}
+	if err := profile.validateComputeInstanceSlices(); err != nil {
+		b.err = err
+		return b
+	}
+	if quantity <= 0 {
+		b.err = fmt.Errorf("invalid quantity %d for MIG profile %s: must be greater than 0", quantity, profile)
+		return b
+	}
+	b.profiles[profile] += quantity
+	if required := b.requiredCapacity(); required.GiSlices > maxGiSlices {
+		b.err = fmt.Errorf(
+			"adding %d x %s exceeds the budget of %d GI slices (required %d)",
+			quantity,
+			profile,
+			maxGiSlices,
+			required.GiSlices,
+		)
+	}
+	return b
+}
+
+// Build returns the built geometry, or the first error encountered while building it. If a GPU model was set
+// through ForModel, an error is returned also if the geometry is not allowed by the model.
+func (b *GeometryBuilder) Build() (gpu.Geometry, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	res := b.asGeometry()
+	if b.model != "" {
+		g, err := NewGPU(b.model, 0, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !g.AllowsGeometry(res) {
+			return nil, fmt.Errorf("geometry %s is not allowed by GPU model %s", res, b.model)
+		}
+	}
+	return res, nil
+}
+
+// MustBuild is like Build, but it panics if the geometry is not valid
+func (b *GeometryBuilder) MustBuild() gpu.Geometry {
+	res, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// requiredCapacity returns the capacity required by the profiles added to the builder,
+// where the compute instance profiles are counted as the GPU instances hosting them
+func (b *GeometryBuilder) requiredCapacity() Capacity {
+	profiles := make(map[ProfileName]int, len(b.profiles))
+	for p, q := range withoutComputeInstances(b.asGeometry()) {
+		profiles[p.(ProfileName).withoutMediaExtensions()] += q
+	}
+	return GetRequiredCapacity(profiles)
+}
+
+func (b *GeometryBuilder) asGeometry() gpu.Geometry {
+	res := make(gpu.Geometry, len(b.profiles))
+	for p, q := range b.profiles {
+		res[p] = q
+	}
+	return res
+}
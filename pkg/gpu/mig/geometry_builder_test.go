@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGeometryBuilder(t *testing.T) {
+	testCases := []struct {
+		name        string
+		builder     *mig.GeometryBuilder
+		expected    gpu.Geometry
+		expectedErr bool
+	}{
+		{
+			name:        "Empty geometry",
+			builder:     mig.NewGeometry(),
+			expected:    gpu.Geometry{},
+			expectedErr: false,
+		},
+		{
+			name:        "Valid geometry",
+			builder:     mig.NewGeometry().With(mig.Profile1g6gb, 2).With(mig.Profile2g12gb, 1),
+			expected:    gpu.Geometry{mig.Profile1g6gb: 2, mig.Profile2g12gb: 1},
+			expectedErr: false,
+		},
+		{
+			name:        "Quantities of the same profile are summed",
+			builder:     mig.NewGeometry().With(mig.Profile1g10gb, 2).With(mig.Profile1g10gb, 3),
+			expected:    gpu.Geometry{mig.Profile1g10gb: 5},
+			expectedErr: false,
+		},
+		{
+			name:        "Compute instance profiles are counted as their GPU instances",
+			builder:     mig.NewGeometry().With("1c.3g.20gb", 3).With(mig.Profile4g20gb, 1),
+			expected:    gpu.Geometry{mig.ProfileName("1c.3g.20gb"): 3, mig.Profile4g20gb: 1},
+			expectedErr: false,
+		},
+		{
+			name:        "Valid geometry for GPU model",
+			builder:     mig.NewGeometry().ForModel(gpu.GPUModel_A30).With(mig.Profile1g6gb, 4),
+			expected:    gpu.Geometry{mig.Profile1g6gb: 4},
+			expectedErr: false,
+		},
+		{
+			name:        "Invalid profile",
+			builder:     mig.NewGeometry().With("foo", 1),
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid quantity",
+			builder:     mig.NewGeometry().With(mig.Profile1g10gb, 0),
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid compute instance profile",
+			builder:     mig.NewGeometry().With("4c.3g.20gb", 1),
+			expectedErr: true,
+		},
+		{
+			name:        "Over budget: too many GI slices",
+			builder:     mig.NewGeometry().With(mig.Profile4g20gb, 1).With(mig.Profile2g10gb, 2),
+			expectedErr: true,
+		},
+		{
+			name:        "Errors are kept after adding valid profiles",
+			builder:     mig.NewGeometry().With(mig.Profile7g40gb, 2).With(mig.Profile1g5gb, 1),
+			expectedErr: true,
+		},
+		{
+			name:        "Geometry not allowed by GPU model",
+			builder:     mig.NewGeometry().ForModel(gpu.GPUModel_A30).With(mig.Profile1g10gb, 1),
+			expectedErr: true,
+		},
+		{
+			name:        "Over budget for GPU model",
+			builder:     mig.NewGeometry().ForModel(gpu.GPUModel_A30).With(mig.Profile1g6gb, 5),
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown GPU model",
+			builder:     mig.NewGeometry().ForModel("foo").With(mig.Profile1g10gb, 1),
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			geometry, err := tt.builder.Build()
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Panics(t, func() { tt.builder.MustBuild() })
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, geometry)
+		})
+	}
+}
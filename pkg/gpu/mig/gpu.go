@@ -95,6 +95,14 @@ func (g *GPU) GetGeometry() gpu.Geometry {
 // CanApplyGeometry returns true if the geometry provided as argument can be applied to the GPU, otherwise it
 // returns false and the reason why the geometry cannot be applied.
 func (g *GPU) CanApplyGeometry(geometry gpu.Geometry) (bool, string) {
+	// Check if compute instances fit their GPU instances
+	for profile := range geometry {
+		if migProfile, ok := profile.(ProfileName); ok {
+			if err := migProfile.validateComputeInstanceSlices(); err != nil {
+				return false, err.Error()
+			}
+		}
+	}
 	// Check if geometry is allowed
 	if !g.AllowsGeometry(geometry) {
 		return false, fmt.Sprintf("GPU model %s does not allow the provided MIG geometry", g.model)
@@ -194,8 +202,16 @@ func (g *GPU) UpdateGeometryFor(requiredProfiles map[gpu.Slice]int) bool {
 	return true
 }
 
-// AllowsGeometry returns true if the geometry provided as argument is allowed by the GPU model
+// AllowsGeometry returns true if the geometry provided as argument is allowed by the GPU model.
+//
+// Profiles including media extensions (e.g. 1g.5gb+me) are allowed only if supported by the GPU model
+// and only up to one per GPU, and they take the same slices of the respective profiles without media
+// extensions.
 func (g *GPU) AllowsGeometry(geometry gpu.Geometry) bool {
+	geometry, ok := g.withoutMediaExtensions(withoutComputeInstances(geometry))
+	if !ok {
+		return false
+	}
 	for _, allowedGeometry := range g.GetAllowedGeometries() {
 		if cmp.Equal(geometry, allowedGeometry) {
 			return true
@@ -204,6 +220,54 @@ func (g *GPU) AllowsGeometry(geometry gpu.Geometry) bool {
 	return false
 }
 
+// withoutComputeInstances returns the geometry provided as argument with the profiles specifying explicit
+// compute slices (e.g. 1c.3g.20gb) replaced by the GPU instance profiles required for hosting them
+// (e.g. 3 x 1c.3g.20gb => 1 x 3g.20gb). Profiles with compute slices exceeding the slices of their
+// GPU instance are left unchanged, so that the resulting geometry is not allowed.
+func withoutComputeInstances(geometry gpu.Geometry) gpu.Geometry {
+	res := make(gpu.Geometry, len(geometry))
+	for profile, quantity := range geometry {
+		migProfile, ok := profile.(ProfileName)
+		if !ok || !migProfile.HasComputeInstanceSlices() || migProfile.validateComputeInstanceSlices() != nil {
+			res[profile] += quantity
+			continue
+		}
+		if quantity == 0 {
+			continue
+		}
+		perGpuInstance := migProfile.getGiSlices() / migProfile.getCiSlices()
+		res[migProfile.GpuInstanceProfile()] += (quantity + perGpuInstance - 1) / perGpuInstance
+	}
+	return res
+}
+
+// withoutMediaExtensions returns the geometry provided as argument with the profiles including media extensions
+// replaced by the respective profiles without media extensions. It returns false if the geometry includes
+// profiles with media extensions that are not supported by the GPU model.
+func (g *GPU) withoutMediaExtensions(geometry gpu.Geometry) (gpu.Geometry, bool) {
+	var nMediaExtensions int
+	res := make(gpu.Geometry, len(geometry))
+	for profile, quantity := range geometry {
+		migProfile, ok := profile.(ProfileName)
+		if !ok || !migProfile.HasMediaExtensions() {
+			res[profile] += quantity
+			continue
+		}
+		if quantity == 0 {
+			continue
+		}
+		if !SupportsMediaExtensionsProfile(g.model, migProfile) {
+			return nil, false
+		}
+		nMediaExtensions += quantity
+		res[migProfile.withoutMediaExtensions()] += quantity
+	}
+	if nMediaExtensions > maxMediaExtensionsProfiles {
+		return nil, false
+	}
+	return res, true
+}
+
 // GetAllowedGeometries returns the MIG geometries allowed by the GPU model
 func (g *GPU) GetAllowedGeometries() []gpu.Geometry {
 	return g.allowedMigGeometries
@@ -240,3 +304,15 @@ func (g *GPU) GetFreeMigDevices() map[ProfileName]int {
 func (g *GPU) GetUsedMigDevices() map[ProfileName]int {
 	return g.usedMigDevices
 }
+
+// NumFree returns the number of free MIG devices of the profile provided as argument,
+// or 0 if the GPU does not have any MIG device of such profile.
+func (g *GPU) NumFree(profile ProfileName) int {
+	return g.freeMigDevices[profile]
+}
+
+// NumUsed returns the number of used MIG devices of the profile provided as argument,
+// or 0 if the GPU does not have any MIG device of such profile.
+func (g *GPU) NumUsed(profile ProfileName) int {
+	return g.usedMigDevices[profile]
+}
@@ -293,6 +293,117 @@ func TestGPU__ApplyGeometry(t *testing.T) {
 	}
 }
 
+func TestGPU__ApplyGeometry__MediaExtensions(t *testing.T) {
+	testCases := []struct {
+		name            string
+		model           gpu.Model
+		geometryToApply gpu.Geometry
+		expectedErr     bool
+	}{
+		{
+			name:  "Media extensions profile supported by the model",
+			model: gpu.GPUModel_A100_SXM4_40GB,
+			geometryToApply: gpu.Geometry{
+				mig.Profile1g5gbMe: 1,
+				mig.Profile1g5gb:   6,
+			},
+			expectedErr: false,
+		},
+		{
+			name:  "Media extensions profile not supported by the model",
+			model: gpu.GPUModel_A30,
+			geometryToApply: gpu.Geometry{
+				mig.Profile1g5gbMe: 1,
+			},
+			expectedErr: true,
+		},
+		{
+			name:  "More than one media extensions profile",
+			model: gpu.GPUModel_A100_SXM4_40GB,
+			geometryToApply: gpu.Geometry{
+				mig.Profile1g5gbMe: 2,
+				mig.Profile1g5gb:   5,
+			},
+			expectedErr: true,
+		},
+		{
+			name:  "Geometry not allowed once media extensions are taken into account",
+			model: gpu.GPUModel_A100_SXM4_40GB,
+			geometryToApply: gpu.Geometry{
+				mig.Profile1g5gbMe: 1,
+				mig.Profile7g40gb:  1,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mig.NewGpuOrPanic(tt.model, 0, make(map[mig.ProfileName]int), make(map[mig.ProfileName]int))
+			err := g.ApplyGeometry(tt.geometryToApply)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Empty(t, g.GetGeometry())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.geometryToApply, g.GetGeometry())
+			}
+		})
+	}
+}
+
+func TestGPU__ApplyGeometry__ComputeInstances(t *testing.T) {
+	testCases := []struct {
+		name            string
+		geometryToApply gpu.Geometry
+		expectedErr     bool
+	}{
+		{
+			name: "Compute instances sharing a GPU instance",
+			geometryToApply: gpu.Geometry{
+				mig.ProfileName("1c.3g.20gb"): 3,
+				mig.Profile2g10gb:             2,
+			},
+			expectedErr: false,
+		},
+		{
+			name: "Compute slices exceeding GPU instance slices",
+			geometryToApply: gpu.Geometry{
+				mig.ProfileName("4c.3g.20gb"): 1,
+				mig.Profile2g10gb:             2,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Compute instances requiring a GPU instance not allowed by the geometry",
+			geometryToApply: gpu.Geometry{
+				mig.ProfileName("2c.3g.20gb"): 2,
+				mig.Profile2g10gb:             2,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				0,
+				make(map[mig.ProfileName]int),
+				make(map[mig.ProfileName]int),
+			)
+			err := g.ApplyGeometry(tt.geometryToApply)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Empty(t, g.GetGeometry())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.geometryToApply, g.GetGeometry())
+			}
+		})
+	}
+}
+
 func TestGPU__UpdateGeometryFor(t *testing.T) {
 	testCases := []struct {
 		name             string
@@ -452,3 +563,52 @@ func TestGeometry__AsResources(t *testing.T) {
 		})
 	}
 }
+
+func TestGPU__NumFreeNumUsed(t *testing.T) {
+	testCases := []struct {
+		name         string
+		gpu          mig.GPU
+		profile      mig.ProfileName
+		expectedFree int
+		expectedUsed int
+	}{
+		{
+			name: "Profile present",
+			gpu: mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				0,
+				map[mig.ProfileName]int{mig.Profile1g5gb: 2},
+				map[mig.ProfileName]int{mig.Profile1g5gb: 1},
+			),
+			profile:      mig.Profile1g5gb,
+			expectedFree: 1,
+			expectedUsed: 2,
+		},
+		{
+			name: "Profile absent",
+			gpu: mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				0,
+				map[mig.ProfileName]int{mig.Profile1g5gb: 2},
+				map[mig.ProfileName]int{mig.Profile1g5gb: 1},
+			),
+			profile:      mig.Profile3g20gb,
+			expectedFree: 0,
+			expectedUsed: 0,
+		},
+		{
+			name:         "GPU without MIG devices",
+			gpu:          mig.GPU{},
+			profile:      mig.Profile1g5gb,
+			expectedFree: 0,
+			expectedUsed: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFree, tt.gpu.NumFree(tt.profile))
+			assert.Equal(t, tt.expectedUsed, tt.gpu.NumUsed(tt.profile))
+		})
+	}
+}
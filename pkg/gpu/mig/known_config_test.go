@@ -28,6 +28,14 @@ func TestValidateDefaultKnownConfigs(t *testing.T) {
 	assert.NoError(t, mig.ValidateConfigs(mig.GetKnownGeometries()))
 }
 
+func TestDefaultKnownConfigs__MatchValidGeometries(t *testing.T) {
+	known := mig.GetKnownGeometries()
+	assert.NotEmpty(t, known)
+	for model, geometries := range known {
+		assert.ElementsMatch(t, mig.ValidGeometries(model), geometries, "model %s", model)
+	}
+}
+
 func TestValidateConfigs(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -22,123 +22,9 @@ import (
 )
 
 var (
-	defaultKnownMigGeometries = map[gpu.Model][]gpu.Geometry{
-		gpu.GPUModel_A30: {
-			{
-				Profile4g24gb: 1,
-			},
-			{
-				Profile2g12gb: 2,
-			},
-			{
-				Profile2g12gb: 1,
-				Profile1g6gb:  2,
-			},
-			{
-				Profile1g6gb: 4,
-			},
-		},
-		gpu.GPUModel_A100_SXM4_40GB: {
-			{
-				Profile7g40gb: 1,
-			},
-			{
-				Profile4g20gb: 1,
-				Profile2g10gb: 1,
-				Profile1g5gb:  1,
-			},
-			{
-				Profile4g20gb: 1,
-				Profile1g5gb:  3,
-			},
-			{
-				Profile3g20gb: 2,
-			},
-			{
-				Profile3g20gb: 1,
-				Profile2g10gb: 1,
-				Profile1g5gb:  1,
-			},
-			{
-				Profile3g20gb: 1,
-				Profile1g5gb:  3,
-			},
-			{
-				Profile2g10gb: 2,
-				Profile3g20gb: 1,
-			},
-			{
-				Profile2g10gb: 1,
-				Profile1g5gb:  2,
-				Profile3g20gb: 1,
-			},
-			{
-				Profile2g10gb: 3,
-				Profile1g5gb:  1,
-			},
-			{
-				Profile2g10gb: 2,
-				Profile1g5gb:  3,
-			},
-			{
-				Profile2g10gb: 1,
-				Profile1g5gb:  5,
-			},
-			{
-				Profile1g5gb: 7,
-			},
-		},
-		gpu.GPUModel_A100_PCIe_80GB: {
-			{
-				Profile7g79gb: 1,
-			},
-			{
-				Profile4g40gb: 1,
-				Profile2g20gb: 1,
-				Profile1g10gb: 1,
-			},
-			{
-				Profile4g40gb: 1,
-				Profile1g10gb: 3,
-			},
-			{
-				Profile3g40gb: 2,
-			},
-			{
-				Profile3g40gb: 1,
-				Profile2g20gb: 1,
-				Profile1g10gb: 1,
-			},
-			{
-				Profile3g40gb: 1,
-				Profile1g10gb: 3,
-			},
-			{
-				Profile2g20gb: 2,
-				Profile3g20gb: 1,
-			},
-			{
-				Profile2g10gb: 1,
-				Profile1g10gb: 2,
-				Profile3g40gb: 1,
-			},
-			{
-				Profile2g20gb: 3,
-				Profile1g10gb: 1,
-			},
-			{
-				Profile2g20gb: 2,
-				Profile1g10gb: 3,
-			},
-			{
-				Profile2g20gb: 1,
-				Profile1g10gb: 5,
-			},
-			{
-				Profile1g10gb: 7,
-			},
-		},
-	}
+	// defaultKnownMigGeometries are the MIG geometries known by default, namely the geometries
+	// supported by NVIDIA for each GPU model
+	defaultKnownMigGeometries = copyGeometries(validMigGeometries)
 )
 
 func SetKnownGeometries(configs map[gpu.Model][]gpu.Geometry) error {
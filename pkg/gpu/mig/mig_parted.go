@@ -0,0 +1,146 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"sigs.k8s.io/yaml"
+	"sort"
+)
+
+// MigStrategy is the strategy used by the NVIDIA device plugin for exposing MIG devices
+type MigStrategy string
+
+const (
+	// MigStrategySingle exposes MIG devices as nvidia.com/gpu resources, and requires all the
+	// MIG devices of a node to have the same profile
+	MigStrategySingle MigStrategy = "single"
+	// MigStrategyMixed exposes each MIG profile as a different nvidia.com/mig-<profile> resource
+	MigStrategyMixed MigStrategy = "mixed"
+)
+
+const (
+	// MigPartedConfigVersion is the version of the NVIDIA mig-parted configuration file format
+	MigPartedConfigVersion = "v1"
+)
+
+// MigPartedConfigSpec corresponds to the configuration file of NVIDIA mig-parted
+// (https://github.com/NVIDIA/mig-parted).
+type MigPartedConfigSpec struct {
+	Version    string                       `json:"version"`
+	MigConfigs map[string][]MigPartedConfig `json:"mig-configs"`
+}
+
+// MigPartedConfig is the MIG configuration applied by mig-parted to a set of GPUs
+type MigPartedConfig struct {
+	Devices    MigPartedDevices `json:"devices"`
+	MigEnabled bool             `json:"mig-enabled"`
+	MigDevices map[string]int   `json:"mig-devices"`
+}
+
+// MigPartedDevices contains the indexes of the GPUs a MigPartedConfig applies to.
+// A nil value corresponds to all the GPUs of the node.
+type MigPartedDevices []int
+
+// MarshalJSON encodes a nil MigPartedDevices as "all", as expected by mig-parted
+func (d MigPartedDevices) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return json.Marshal("all")
+	}
+	return json.Marshal([]int(d))
+}
+
+// ToYAML returns the YAML encoding of the config spec
+func (c MigPartedConfigSpec) ToYAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// ToMigPartedConfig converts the geometries of the GPUs of the node provided as argument into a
+// mig-parted config spec, including a single MIG config named after the node.
+func (n *Node) ToMigPartedConfig(strategy MigStrategy) (MigPartedConfigSpec, error) {
+	geometries := make(map[int]gpu.Geometry, len(n.GPUs))
+	for _, g := range n.GPUs {
+		geometries[g.GetIndex()] = g.GetGeometry()
+	}
+	return ToMigPartedConfig(n.Name, geometries, strategy)
+}
+
+// ToMigPartedConfig converts the geometries provided as argument, indexed by GPU index, into a mig-parted
+// config spec including a single MIG config with the name provided as argument.
+//
+// GPUs with the same geometry are grouped in the same MigPartedConfig, which applies to all the
+// devices if all the GPUs have the same geometry. Slices with zero quantity are ignored.
+//
+// If the strategy is MigStrategySingle, ToMigPartedConfig returns an error if the geometries include
+// more than one MIG profile, since the single strategy requires all the MIG devices to be the same.
+func ToMigPartedConfig(name string, geometries map[int]gpu.Geometry, strategy MigStrategy) (MigPartedConfigSpec, error) {
+	if strategy != MigStrategySingle && strategy != MigStrategyMixed {
+		return MigPartedConfigSpec{}, fmt.Errorf("invalid MIG strategy %q", strategy)
+	}
+
+	// Group GPUs with the same geometry
+	gpuIndexes := make([]int, 0, len(geometries))
+	for i := range geometries {
+		gpuIndexes = append(gpuIndexes, i)
+	}
+	sort.Ints(gpuIndexes)
+	profiles := make(map[string]struct{})
+	configs := make([]MigPartedConfig, 0)
+	for _, i := range gpuIndexes {
+		migDevices := make(map[string]int)
+		for slice, quantity := range geometries[i] {
+			if quantity > 0 {
+				migDevices[slice.String()] = quantity
+				profiles[slice.String()] = struct{}{}
+			}
+		}
+		var grouped bool
+		for j := range configs {
+			if cmp.Equal(configs[j].MigDevices, migDevices) {
+				configs[j].Devices = append(configs[j].Devices, i)
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			configs = append(configs, MigPartedConfig{
+				Devices:    MigPartedDevices{i},
+				MigEnabled: true,
+				MigDevices: migDevices,
+			})
+		}
+	}
+
+	if strategy == MigStrategySingle && len(profiles) > 1 {
+		return MigPartedConfigSpec{}, fmt.Errorf(
+			"MIG strategy %q requires all MIG devices to have the same profile, but found %d profiles",
+			strategy,
+			len(profiles),
+		)
+	}
+	if len(configs) == 1 {
+		configs[0].Devices = nil
+	}
+
+	return MigPartedConfigSpec{
+		Version:    MigPartedConfigVersion,
+		MigConfigs: map[string][]MigPartedConfig{name: configs},
+	}, nil
+}
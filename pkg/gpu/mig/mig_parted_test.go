@@ -0,0 +1,153 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNode__ToMigPartedConfig__Golden(t *testing.T) {
+	node := mig.Node{
+		Name: "node-1",
+		GPUs: []mig.GPU{
+			mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				0,
+				map[mig.ProfileName]int{mig.Profile3g20gb: 1},
+				map[mig.ProfileName]int{mig.Profile2g10gb: 1, mig.Profile1g5gb: 1},
+			),
+			mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				1,
+				map[mig.ProfileName]int{},
+				map[mig.ProfileName]int{mig.Profile7g40gb: 1},
+			),
+			mig.NewGpuOrPanic(
+				gpu.GPUModel_A100_SXM4_40GB,
+				2,
+				map[mig.ProfileName]int{mig.Profile1g5gb: 1, mig.Profile2g10gb: 1},
+				map[mig.ProfileName]int{mig.Profile3g20gb: 1},
+			),
+		},
+	}
+
+	config, err := node.ToMigPartedConfig(mig.MigStrategyMixed)
+	assert.NoError(t, err)
+	actual, err := config.ToYAML()
+	assert.NoError(t, err)
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "mig_parted_a100_mixed.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestToMigPartedConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		geometries  map[int]gpu.Geometry
+		strategy    mig.MigStrategy
+		expected    mig.MigPartedConfigSpec
+		expectedErr bool
+	}{
+		{
+			name:        "Invalid strategy",
+			geometries:  map[int]gpu.Geometry{},
+			strategy:    "invalid",
+			expectedErr: true,
+		},
+		{
+			name: "Single strategy, all GPUs with the same profile",
+			geometries: map[int]gpu.Geometry{
+				0: {mig.Profile1g5gb: 7},
+				1: {mig.Profile1g5gb: 7, mig.Profile7g40gb: 0},
+			},
+			strategy: mig.MigStrategySingle,
+			expected: mig.MigPartedConfigSpec{
+				Version: mig.MigPartedConfigVersion,
+				MigConfigs: map[string][]mig.MigPartedConfig{
+					"config": {
+						{
+							Devices:    nil,
+							MigEnabled: true,
+							MigDevices: map[string]int{mig.Profile1g5gb.String(): 7},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Single strategy, GPUs with different profiles",
+			geometries: map[int]gpu.Geometry{
+				0: {mig.Profile1g5gb: 7},
+				1: {mig.Profile7g40gb: 1},
+			},
+			strategy:    mig.MigStrategySingle,
+			expectedErr: true,
+		},
+		{
+			name: "Single strategy, GPU with mixed profiles",
+			geometries: map[int]gpu.Geometry{
+				0: {mig.Profile1g5gb: 1, mig.Profile3g20gb: 1},
+			},
+			strategy:    mig.MigStrategySingle,
+			expectedErr: true,
+		},
+		{
+			name: "Mixed strategy, GPUs with different geometries",
+			geometries: map[int]gpu.Geometry{
+				0: {mig.Profile1g5gb: 7},
+				1: {mig.Profile7g40gb: 1},
+				2: {mig.Profile1g5gb: 7},
+			},
+			strategy: mig.MigStrategyMixed,
+			expected: mig.MigPartedConfigSpec{
+				Version: mig.MigPartedConfigVersion,
+				MigConfigs: map[string][]mig.MigPartedConfig{
+					"config": {
+						{
+							Devices:    mig.MigPartedDevices{0, 2},
+							MigEnabled: true,
+							MigDevices: map[string]int{mig.Profile1g5gb.String(): 7},
+						},
+						{
+							Devices:    mig.MigPartedDevices{1},
+							MigEnabled: true,
+							MigDevices: map[string]int{mig.Profile7g40gb.String(): 1},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := mig.ToMigPartedConfig("config", tt.geometries, tt.strategy)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 nebuly.com
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"strings"
+)
+
+// NamedGeometries maps the names of MIG geometry presets (e.g. "all-1g.10gb", "balanced") to the
+// MIG geometry that each GPU of a node referencing the preset should have.
+type NamedGeometries map[string]gpu.Geometry
+
+func (n *NamedGeometries) UnmarshalJSON(b []byte) error {
+	migGeometries := make(map[string]map[ProfileName]int)
+	if err := json.Unmarshal(b, &migGeometries); err != nil {
+		return err
+	}
+	res := make(NamedGeometries, len(migGeometries))
+	for name, g := range migGeometries {
+		geometry := make(gpu.Geometry)
+		for p, q := range g {
+			geometry[p] = q
+		}
+		res[name] = geometry
+	}
+	*n = res
+	return nil
+}
+
+// GetGeometry returns the geometry with the name provided as argument, or an error if there
+// isn't any geometry with such name.
+func (n NamedGeometries) GetGeometry(name string) (gpu.Geometry, error) {
+	geometry, ok := n[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown MIG geometry %q", name)
+	}
+	return geometry, nil
+}
+
+// ToSpecAnnotations returns the spec annotations corresponding to applying the geometry with the
+// name provided as argument to each of the gpuCount GPUs of a node.
+func (n NamedGeometries) ToSpecAnnotations(name string, gpuCount int) (gpu.SpecAnnotationList, error) {
+	geometry, err := n.GetGeometry(name)
+	if err != nil {
+		return nil, err
+	}
+	res := make(gpu.SpecAnnotationList, 0)
+	for i := 0; i < gpuCount; i++ {
+		for p, q := range geometry {
+			res = append(res, gpu.SpecAnnotation{
+				ProfileName: p.String(),
+				Index:       i,
+				Quantity:    q,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ParseGeometryNames parses the value of the v1alpha1.AnnotationMigGeometry annotation, which is either the
+// name of a single geometry or a comma-separated list of geometry names sorted by preference
+// (e.g. "all-1g.10gb, balanced"), returning the names in the same order.
+func ParseGeometryNames(value string) []string {
+	res := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2023 nebuly.com
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+	"testing"
+)
+
+func TestNamedGeometries(t *testing.T) {
+	data := `
+all-1g.10gb:
+  1g.10gb: 7
+balanced:
+  1g.10gb: 2
+  2g.20gb: 1
+  3g.40gb: 1
+`
+	var namedGeometries mig.NamedGeometries
+	assert.NoError(t, yaml.Unmarshal([]byte(data), &namedGeometries))
+
+	t.Run("Resolve named geometry", func(t *testing.T) {
+		geometry, err := namedGeometries.GetGeometry("balanced")
+		assert.NoError(t, err)
+		assert.Equal(
+			t,
+			gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1, mig.Profile3g40gb: 1},
+			geometry,
+		)
+	})
+
+	t.Run("Unknown name", func(t *testing.T) {
+		_, err := namedGeometries.GetGeometry("foo")
+		assert.Error(t, err)
+		_, err = namedGeometries.ToSpecAnnotations("foo", 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Spec annotations for each GPU", func(t *testing.T) {
+		specAnnotations, err := namedGeometries.ToSpecAnnotations("all-1g.10gb", 2)
+		assert.NoError(t, err)
+		assert.ElementsMatch(
+			t,
+			gpu.SpecAnnotationList{
+				{ProfileName: "1g.10gb", Index: 0, Quantity: 7},
+				{ProfileName: "1g.10gb", Index: 1, Quantity: 7},
+			},
+			specAnnotations,
+		)
+	})
+}
+
+func TestParseGeometryNames(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "Empty value",
+			value:    "",
+			expected: []string{},
+		},
+		{
+			name:     "Single name",
+			value:    "balanced",
+			expected: []string{"balanced"},
+		},
+		{
+			name:     "List of names, order is preserved and spaces are trimmed",
+			value:    "all-1g.10gb, balanced ,all-7g.79gb",
+			expected: []string{"all-1g.10gb", "balanced", "all-7g.79gb"},
+		},
+		{
+			name:     "Empty items are ignored",
+			value:    "balanced,, ",
+			expected: []string{"balanced"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mig.ParseGeometryNames(tt.value))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// NVML GPU instance profile IDs (NVML_GPU_INSTANCE_PROFILE_*). They are mirrored here so that they can
+// be used without depending on the NVML library, which requires the nvml build tag.
+const (
+	nvmlGpuInstanceProfile1Slice     = 0
+	nvmlGpuInstanceProfile2Slice     = 1
+	nvmlGpuInstanceProfile3Slice     = 2
+	nvmlGpuInstanceProfile4Slice     = 3
+	nvmlGpuInstanceProfile7Slice     = 4
+	nvmlGpuInstanceProfile1SliceRev1 = 7
+)
+
+// NVML compute instance profile IDs (NVML_COMPUTE_INSTANCE_PROFILE_*)
+const (
+	nvmlComputeInstanceProfile1Slice = 0
+	nvmlComputeInstanceProfile2Slice = 1
+	nvmlComputeInstanceProfile3Slice = 2
+	nvmlComputeInstanceProfile4Slice = 3
+	nvmlComputeInstanceProfile7Slice = 4
+)
+
+// nvmlGpuInstanceProfileIds maps the number of slices of a GPU instance to its NVML profile ID
+var nvmlGpuInstanceProfileIds = map[int]int{
+	1: nvmlGpuInstanceProfile1Slice,
+	2: nvmlGpuInstanceProfile2Slice,
+	3: nvmlGpuInstanceProfile3Slice,
+	4: nvmlGpuInstanceProfile4Slice,
+	7: nvmlGpuInstanceProfile7Slice,
+}
+
+// nvmlComputeInstanceProfileIds maps the number of compute slices of a compute instance to its NVML profile ID
+var nvmlComputeInstanceProfileIds = map[int]int{
+	1: nvmlComputeInstanceProfile1Slice,
+	2: nvmlComputeInstanceProfile2Slice,
+	3: nvmlComputeInstanceProfile3Slice,
+	4: nvmlComputeInstanceProfile4Slice,
+	7: nvmlComputeInstanceProfile7Slice,
+}
+
+// ProfileToNvmlIds returns the NVML GPU instance profile ID and compute instance profile ID used for creating
+// a MIG device of the profile provided as argument on a GPU of the model provided as argument. The IDs are
+// the same computed by the NVML library when parsing the profile name while creating MIG devices.
+//
+// ProfileToNvmlIds returns an error if the profile is invalid, if the model is unknown, or if the profile
+// is not supported by the model, that is if none of the MIG geometries allowed by the model include it.
+func ProfileToNvmlIds(model gpu.Model, profile ProfileName) (giProfileId, ciProfileId int, err error) {
+	if !profile.isValid() {
+		return 0, 0, fmt.Errorf("invalid MIG profile %q", profile)
+	}
+	if err = profile.validateComputeInstanceSlices(); err != nil {
+		return 0, 0, err
+	}
+	if err = checkProfileSupported(model, profile); err != nil {
+		return 0, 0, err
+	}
+
+	giProfileId, ok := nvmlGpuInstanceProfileIds[profile.getGiSlices()]
+	if !ok {
+		return 0, 0, fmt.Errorf("MIG profile %s has no NVML GPU instance profile", profile)
+	}
+	if profile.HasMediaExtensions() {
+		giProfileId = nvmlGpuInstanceProfile1SliceRev1
+	}
+	ciProfileId, ok = nvmlComputeInstanceProfileIds[profile.getCiSlices()]
+	if !ok {
+		return 0, 0, fmt.Errorf("MIG profile %s has no NVML compute instance profile", profile)
+	}
+	return giProfileId, ciProfileId, nil
+}
+
+// checkProfileSupported returns an error if the GPU instance profile of the profile provided
+// as argument is not included in any of the MIG geometries allowed by the model provided as argument
+func checkProfileSupported(model gpu.Model, profile ProfileName) error {
+	allowedGeometries, ok := GetAllowedGeometries(model)
+	if !ok {
+		return fmt.Errorf("model %q is not associated with any known GPU", model)
+	}
+	if profile.HasMediaExtensions() {
+		if !SupportsMediaExtensionsProfile(model, profile) {
+			return fmt.Errorf("MIG profile %s is not supported by model %q", profile, model)
+		}
+		return nil
+	}
+	giProfile := profile.GpuInstanceProfile()
+	for _, geometry := range allowedGeometries {
+		if geometry[giProfile] > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("MIG profile %s is not supported by model %q", profile, model)
+}
@@ -0,0 +1,66 @@
+//go:build nvml
+
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	nvlibdevice "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"testing"
+)
+
+// TestProfileToNvmlIds__MatchesNvlib checks that the NVML IDs returned by ProfileToNvmlIds are the same
+// ones used by the NVML client for creating the MIG devices, which are computed by nvlib when parsing
+// the profile names.
+func TestProfileToNvmlIds__MatchesNvlib(t *testing.T) {
+	type modelProfile struct {
+		model   gpu.Model
+		profile mig.ProfileName
+	}
+
+	// All the profiles of the known geometries, plus the compute instance and media extensions profiles
+	cases := []modelProfile{
+		{model: gpu.GPUModel_A100_PCIe_80GB, profile: "1c.3g.40gb"},
+		{model: gpu.GPUModel_A100_PCIe_80GB, profile: "2c.4g.40gb"},
+		{model: gpu.GPUModel_A100_PCIe_80GB, profile: mig.Profile1g10gbMe},
+		{model: gpu.GPUModel_A30, profile: mig.Profile1g6gbMe},
+	}
+	for model, geometries := range mig.GetKnownGeometries() {
+		for _, geometry := range geometries {
+			for slice := range geometry {
+				cases = append(cases, modelProfile{model: model, profile: slice.(mig.ProfileName)})
+			}
+		}
+	}
+
+	nvlib := nvlibdevice.New()
+	for _, c := range cases {
+		giProfileId, ciProfileId, err := mig.ProfileToNvmlIds(c.model, c.profile)
+		if !assert.NoError(t, err, "model %s, profile %s", c.model, c.profile) {
+			continue
+		}
+		nvlibProfile, err := nvlib.ParseMigProfile(c.profile.String())
+		if !assert.NoError(t, err, "profile %s", c.profile) {
+			continue
+		}
+		assert.Equal(t, nvlibProfile.GetInfo().GIProfileID, giProfileId, "model %s, profile %s", c.model, c.profile)
+		assert.Equal(t, nvlibProfile.GetInfo().CIProfileID, ciProfileId, "model %s, profile %s", c.model, c.profile)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProfileToNvmlIds(t *testing.T) {
+	testCases := []struct {
+		name                string
+		model               gpu.Model
+		profile             mig.ProfileName
+		expectedGiProfileId int
+		expectedCiProfileId int
+		expectedErr         bool
+	}{
+		{
+			name:                "A30, 1g.6gb",
+			model:               gpu.GPUModel_A30,
+			profile:             mig.Profile1g6gb,
+			expectedGiProfileId: 0,
+			expectedCiProfileId: 0,
+		},
+		{
+			name:                "A30, 2g.12gb",
+			model:               gpu.GPUModel_A30,
+			profile:             mig.Profile2g12gb,
+			expectedGiProfileId: 1,
+			expectedCiProfileId: 1,
+		},
+		{
+			name:                "A30, 4g.24gb",
+			model:               gpu.GPUModel_A30,
+			profile:             mig.Profile4g24gb,
+			expectedGiProfileId: 3,
+			expectedCiProfileId: 3,
+		},
+		{
+			name:                "A30, 1g.6gb with media extensions",
+			model:               gpu.GPUModel_A30,
+			profile:             mig.Profile1g6gbMe,
+			expectedGiProfileId: 7,
+			expectedCiProfileId: 0,
+		},
+		{
+			name:                "A100 40GB, 3g.20gb",
+			model:               gpu.GPUModel_A100_SXM4_40GB,
+			profile:             mig.Profile3g20gb,
+			expectedGiProfileId: 2,
+			expectedCiProfileId: 2,
+		},
+		{
+			name:                "A100 40GB, 7g.40gb",
+			model:               gpu.GPUModel_A100_SXM4_40GB,
+			profile:             mig.Profile7g40gb,
+			expectedGiProfileId: 4,
+			expectedCiProfileId: 4,
+		},
+		{
+			name:                "A100 80GB, compute instance of a 3g.40gb GPU instance",
+			model:               gpu.GPUModel_A100_PCIe_80GB,
+			profile:             "1c.3g.40gb",
+			expectedGiProfileId: 2,
+			expectedCiProfileId: 0,
+		},
+		{
+			name:        "Profile not supported by the model",
+			model:       gpu.GPUModel_A30,
+			profile:     mig.Profile7g40gb,
+			expectedErr: true,
+		},
+		{
+			name:        "Media extensions not supported by the model",
+			model:       gpu.GPUModel_A30,
+			profile:     mig.Profile1g5gbMe,
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown model",
+			model:       gpu.GPUModel_T4,
+			profile:     mig.Profile1g5gb,
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid profile",
+			model:       gpu.GPUModel_A30,
+			profile:     "foo",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			giProfileId, ciProfileId, err := mig.ProfileToNvmlIds(tt.model, tt.profile)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGiProfileId, giProfileId)
+			assert.Equal(t, tt.expectedCiProfileId, ciProfileId)
+		})
+	}
+}
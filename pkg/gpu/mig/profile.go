@@ -44,12 +44,37 @@ const (
 	Profile3g40gb ProfileName = "3g.40gb"
 	Profile4g40gb ProfileName = "4g.40gb"
 	Profile7g79gb ProfileName = "7g.79gb"
+
+	Profile1g6gbMe  ProfileName = "1g.6gb+me"
+	Profile1g5gbMe  ProfileName = "1g.5gb+me"
+	Profile1g10gbMe ProfileName = "1g.10gb+me"
+)
+
+const (
+	// mediaExtensionsSuffix is the suffix of the names of the MIG profiles including media extensions
+	// (e.g. 1g.5gb+me)
+	mediaExtensionsSuffix = "+me"
+	// mediaExtensionsResourceSuffix is the suffix used by the NVIDIA device plugin for the resources
+	// corresponding to MIG profiles including media extensions (e.g. nvidia.com/mig-1g.5gb.me)
+	mediaExtensionsResourceSuffix = ".me"
+	// maxMediaExtensionsProfiles is the max number of MIG profiles including media extensions
+	// that can be created on a single GPU
+	maxMediaExtensionsProfiles = 1
 )
 
+// mediaExtensionsProfiles contains, for each GPU model, the MIG profiles including media extensions
+// supported by the model
+var mediaExtensionsProfiles = map[gpu.Model]ProfileName{
+	gpu.GPUModel_A30:            Profile1g6gbMe,
+	gpu.GPUModel_A100_SXM4_40GB: Profile1g5gbMe,
+	gpu.GPUModel_A100_PCIe_80GB: Profile1g10gbMe,
+}
+
 var (
 	migProfileRegex = regexp.MustCompile(constant.RegexNvidiaMigProfile)
-	migGiRegex      = regexp.MustCompile(`\d+g`)
-	migMemoryRegex  = regexp.MustCompile(`\d+gb`)
+	migCiRegex      = regexp.MustCompile(`^(\d+)c\.`)
+	migGiRegex      = regexp.MustCompile(`(\d+)g\.`)
+	migMemoryRegex  = regexp.MustCompile(`(\d+)gb`)
 )
 
 type ProfileName string
@@ -58,26 +83,99 @@ func (p ProfileName) isValid() bool {
 	return migProfileRegex.MatchString(string(p))
 }
 
+// HasMediaExtensions returns true if the profile includes media extensions (e.g. 1g.5gb+me)
+func (p ProfileName) HasMediaExtensions() bool {
+	return strings.HasSuffix(string(p), mediaExtensionsSuffix)
+}
+
+// computeInstancePrefix is the prefix of the names of the MIG profiles corresponding to compute instances
+// that use only some of the slices of their GPU instance (e.g. 1c.3g.20gb)
+const computeInstancePrefix = "c."
+
+// HasComputeInstanceSlices returns true if the profile explicitly specifies the number of compute slices
+// of its compute instance (e.g. 1c.3g.20gb). Profiles without the compute slices (e.g. 3g.20gb) correspond
+// to a single compute instance using all the slices of the GPU instance.
+func (p ProfileName) HasComputeInstanceSlices() bool {
+	return migCiRegex.MatchString(string(p))
+}
+
+// GpuInstanceProfile returns the profile of the GPU instance hosting the compute instances of the profile
+// (e.g. 1c.3g.20gb => 3g.20gb). Profiles without explicit compute slices are returned unchanged.
+func (p ProfileName) GpuInstanceProfile() ProfileName {
+	if !p.HasComputeInstanceSlices() {
+		return p
+	}
+	name := string(p)
+	return ProfileName(name[strings.Index(name, computeInstancePrefix)+len(computeInstancePrefix):])
+}
+
+// withoutMediaExtensions returns the profile without media extensions corresponding to the profile,
+// which takes the same GPU slices (e.g. 1g.5gb+me => 1g.5gb)
+func (p ProfileName) withoutMediaExtensions() ProfileName {
+	return ProfileName(strings.TrimSuffix(string(p), mediaExtensionsSuffix))
+}
+
+// SupportsMediaExtensionsProfile returns true if the GPU model provided as argument supports the
+// MIG profile including media extensions provided as argument
+func SupportsMediaExtensionsProfile(model gpu.Model, profile ProfileName) bool {
+	supported, ok := mediaExtensionsProfiles[model]
+	return ok && supported == profile
+}
+
 func (p ProfileName) String() string {
 	return string(p)
 }
 
+// AsResourceName returns the name of the resource exposed by the NVIDIA device plugin for the profile,
+// which has the prefix set through SetResourceNamePrefix (nvidia.com/mig- by default). Since resource names
+// cannot contain "+", the media extensions suffix "+me" becomes ".me".
+//
+// Example:
+//
+//	1g.5gb+me => nvidia.com/mig-1g.5gb.me
 func (p ProfileName) AsResourceName() v1.ResourceName {
-	resourceNameStr := fmt.Sprintf("%s%s", constant.NvidiaMigResourcePrefix, p)
+	name := string(p)
+	if p.HasMediaExtensions() {
+		name = p.withoutMediaExtensions().String() + mediaExtensionsResourceSuffix
+	}
+	resourceNameStr := fmt.Sprintf("%s%s", GetResourceNamePrefix(), name)
 	return v1.ResourceName(resourceNameStr)
 }
 
+// getMemorySlices returns the memory (in GB) of the GPU instance of the profile
 func (p ProfileName) getMemorySlices() int {
-	asString := migMemoryRegex.FindString(string(p))
-	asString = strings.TrimSuffix(asString, "gb")
-	asInt, _ := strconv.Atoi(asString)
-	return asInt
+	return submatchAsInt(migMemoryRegex, p)
 }
 
+// getGiSlices returns the number of slices of the GPU instance of the profile
 func (p ProfileName) getGiSlices() int {
-	asString := migGiRegex.FindString(string(p))
-	asString = strings.TrimSuffix(asString, "g")
-	asInt, _ := strconv.Atoi(asString)
+	return submatchAsInt(migGiRegex, p)
+}
+
+// getCiSlices returns the number of compute slices of the compute instance of the profile. If the
+// profile does not explicitly specify them, the compute instance uses all the slices of the GPU instance.
+func (p ProfileName) getCiSlices() int {
+	if !p.HasComputeInstanceSlices() {
+		return p.getGiSlices()
+	}
+	return submatchAsInt(migCiRegex, p)
+}
+
+// validateComputeInstanceSlices returns an error if the compute slices of the profile
+// exceed the slices of its GPU instance
+func (p ProfileName) validateComputeInstanceSlices() error {
+	if ci, gi := p.getCiSlices(), p.getGiSlices(); ci < 1 || ci > gi {
+		return fmt.Errorf("invalid MIG profile %s: compute slices (%d) must be between 1 and GPU instance slices (%d)", p, ci, gi)
+	}
+	return nil
+}
+
+func submatchAsInt(r *regexp.Regexp, p ProfileName) int {
+	matches := r.FindStringSubmatch(string(p))
+	if len(matches) < 2 {
+		return 0
+	}
+	asInt, _ := strconv.Atoi(matches[1])
 	return asInt
 }
 
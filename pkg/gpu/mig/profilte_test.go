@@ -23,10 +23,117 @@ import (
 
 func TestProfileName__getMemorySlices(t *testing.T) {
 	assert.Equal(t, 20, Profile3g20gb.getMemorySlices())
+	assert.Equal(t, 5, Profile1g5gbMe.getMemorySlices())
 }
 
 func TestProfileName__getGiSlices(t *testing.T) {
 	assert.Equal(t, 3, Profile3g20gb.getGiSlices())
+	assert.Equal(t, 1, Profile1g5gbMe.getGiSlices())
+	assert.Equal(t, 3, ProfileName("1c.3g.20gb").getGiSlices())
+}
+
+func TestProfileName__ComputeInstances(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		profile                    ProfileName
+		expectedValid              bool
+		expectedCiSlices           int
+		expectedGiSlices           int
+		expectedMemorySlices       int
+		expectedGpuInstanceProfile ProfileName
+		expectedErr                bool
+	}{
+		{
+			name:                       "Profile without compute slices",
+			profile:                    Profile3g20gb,
+			expectedValid:              true,
+			expectedCiSlices:           3,
+			expectedGiSlices:           3,
+			expectedMemorySlices:       20,
+			expectedGpuInstanceProfile: Profile3g20gb,
+			expectedErr:                false,
+		},
+		{
+			name:                       "Compute slices lower than GPU instance slices",
+			profile:                    "1c.3g.20gb",
+			expectedValid:              true,
+			expectedCiSlices:           1,
+			expectedGiSlices:           3,
+			expectedMemorySlices:       20,
+			expectedGpuInstanceProfile: Profile3g20gb,
+			expectedErr:                false,
+		},
+		{
+			name:                       "Compute slices greater than GPU instance slices",
+			profile:                    "4c.3g.20gb",
+			expectedValid:              true,
+			expectedCiSlices:           4,
+			expectedGiSlices:           3,
+			expectedMemorySlices:       20,
+			expectedGpuInstanceProfile: Profile3g20gb,
+			expectedErr:                true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedValid, tt.profile.isValid())
+			assert.Equal(t, tt.expectedCiSlices, tt.profile.getCiSlices())
+			assert.Equal(t, tt.expectedGiSlices, tt.profile.getGiSlices())
+			assert.Equal(t, tt.expectedMemorySlices, tt.profile.getMemorySlices())
+			assert.Equal(t, tt.expectedGpuInstanceProfile, tt.profile.GpuInstanceProfile())
+			if tt.expectedErr {
+				assert.Error(t, tt.profile.validateComputeInstanceSlices())
+			} else {
+				assert.NoError(t, tt.profile.validateComputeInstanceSlices())
+			}
+
+			extracted, err := ExtractProfileName(tt.profile.AsResourceName())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.profile, extracted)
+		})
+	}
+}
+
+func TestProfileName__MediaExtensions(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		profile                   ProfileName
+		expectedValid             bool
+		expectedMediaExtensions   bool
+		expectedResourceName      string
+		expectedWithoutExtensions ProfileName
+	}{
+		{
+			name:                      "Profile without media extensions",
+			profile:                   Profile1g5gb,
+			expectedValid:             true,
+			expectedMediaExtensions:   false,
+			expectedResourceName:      "nvidia.com/mig-1g.5gb",
+			expectedWithoutExtensions: Profile1g5gb,
+		},
+		{
+			name:                      "Profile with media extensions",
+			profile:                   "1g.5gb+me",
+			expectedValid:             true,
+			expectedMediaExtensions:   true,
+			expectedResourceName:      "nvidia.com/mig-1g.5gb.me",
+			expectedWithoutExtensions: Profile1g5gb,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedValid, tt.profile.isValid())
+			assert.Equal(t, tt.expectedMediaExtensions, tt.profile.HasMediaExtensions())
+			assert.Equal(t, tt.expectedWithoutExtensions, tt.profile.withoutMediaExtensions())
+			assert.Equal(t, tt.expectedResourceName, tt.profile.AsResourceName().String())
+
+			extracted, err := ExtractProfileName(tt.profile.AsResourceName())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.profile, extracted)
+		})
+	}
 }
 
 func TestProfileList__GroupByGpuIndex(t *testing.T) {
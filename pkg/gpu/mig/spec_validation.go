@@ -0,0 +1,125 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	v1 "k8s.io/api/core/v1"
+	"sort"
+	"strings"
+)
+
+// ValidateNodeSpec validates the MIG spec annotations of the node provided as argument without requiring
+// access to the node, so that they can be checked offline (e.g. in CI before applying them through GitOps).
+//
+// ValidateNodeSpec returns all the problems found, or an empty list if the spec is valid. It reports:
+//   - spec annotations that cannot be parsed, or that request invalid MIG profiles or negative quantities
+//   - GPU indexes greater or equal than the number of GPUs of the node
+//   - MIG profiles not supported by the GPU model of the node
+//   - GPUs whose MIG profiles do not fit the capacity of the GPU model
+//
+// Checks depending on the GPU count or on the GPU model of the node are skipped, and a problem is reported,
+// if the node does not expose the respective labels.
+func ValidateNodeSpec(node v1.Node) []error {
+	errs := make([]error, 0)
+
+	// Parse spec annotations, sorted by key so that problems are always reported in the same order
+	keys := make([]string, 0, len(node.Annotations))
+	for k := range node.Annotations {
+		if strings.HasPrefix(k, v1alpha1.AnnotationGpuSpecPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	specAnnotations := make(gpu.SpecAnnotationList, 0, len(keys))
+	for _, k := range keys {
+		a, err := gpu.ParseSpecAnnotation(k, node.Annotations[k])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec annotation %q: %w", k, err))
+			continue
+		}
+		if !ProfileName(a.ProfileName).isValid() {
+			errs = append(errs, fmt.Errorf("invalid spec annotation %q: invalid MIG profile %q", k, a.ProfileName))
+			continue
+		}
+		if a.Quantity < 0 {
+			errs = append(errs, fmt.Errorf("invalid spec annotation %q: quantity cannot be negative", k))
+			continue
+		}
+		specAnnotations = append(specAnnotations, a)
+	}
+
+	// Check GPU indexes
+	if gpuCount, err := gpu.GetCount(node); err != nil {
+		errs = append(errs, fmt.Errorf("cannot validate GPU indexes: %w", err))
+	} else {
+		for _, a := range specAnnotations {
+			if a.Index < 0 || a.Index >= gpuCount {
+				errs = append(errs, fmt.Errorf(
+					"spec annotation %s references GPU %d, but the node only has %d GPUs",
+					a,
+					a.Index,
+					gpuCount,
+				))
+			}
+		}
+	}
+
+	// Check MIG profiles and capacity against the GPU model
+	model, err := gpu.GetModel(node)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("cannot validate MIG profiles: %w", err))
+		return errs
+	}
+	capacity, ok := GetCapacity(model)
+	if !ok {
+		errs = append(errs, fmt.Errorf("model %q is not associated with any known GPU", model))
+		return errs
+	}
+	for _, a := range specAnnotations {
+		if err = ValidateSpecAnnotations(model, gpu.SpecAnnotationList{a}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	profilesByGpu := make(map[int]map[ProfileName]int)
+	for _, a := range specAnnotations {
+		if profilesByGpu[a.Index] == nil {
+			profilesByGpu[a.Index] = make(map[ProfileName]int)
+		}
+		profilesByGpu[a.Index][ProfileName(a.ProfileName)] += a.Quantity
+	}
+	gpuIndexes := make([]int, 0, len(profilesByGpu))
+	for i := range profilesByGpu {
+		gpuIndexes = append(gpuIndexes, i)
+	}
+	sort.Ints(gpuIndexes)
+	for _, i := range gpuIndexes {
+		if required := GetRequiredCapacity(profilesByGpu[i]); !capacity.Covers(required) {
+			errs = append(errs, fmt.Errorf(
+				"MIG profiles of GPU %d require %s, but model %s only provides %s",
+				i,
+				required,
+				model,
+				capacity,
+			))
+		}
+	}
+
+	return errs
+}
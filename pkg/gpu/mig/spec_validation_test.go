@@ -0,0 +1,137 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateNodeSpec(t *testing.T) {
+	labels := map[string]string{
+		constant.LabelNvidiaProduct: string(gpu.GPUModel_A100_PCIe_80GB),
+		constant.LabelNvidiaCount:   "2",
+	}
+
+	testCases := []struct {
+		name           string
+		labels         map[string]string
+		annotations    map[string]string
+		expectedErrors []string
+	}{
+		{
+			name:   "Valid spec",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "7",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, mig.Profile3g40gb): "2",
+				v1alpha1.AnnotationPartitioningPlan:                                 "plan-1",
+			},
+			expectedErrors: []string{},
+		},
+		{
+			name:   "Invalid quantity",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "foo",
+			},
+			expectedErrors: []string{"invalid spec annotation"},
+		},
+		{
+			name:   "Invalid MIG profile",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, "foo"): "1",
+			},
+			expectedErrors: []string{"invalid MIG profile"},
+		},
+		{
+			name:   "Negative quantity",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "-1",
+			},
+			expectedErrors: []string{"cannot be negative"},
+		},
+		{
+			name:   "GPU index out of range",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 2, mig.Profile1g10gb): "1",
+			},
+			expectedErrors: []string{"references GPU 2, but the node only has 2 GPUs"},
+		},
+		{
+			name:   "MIG profile not supported by the GPU model",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g5gb): "1",
+			},
+			expectedErrors: []string{"is not supported by GPU model"},
+		},
+		{
+			name:   "MIG profiles exceed GPU capacity",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, mig.Profile3g40gb): "3",
+			},
+			expectedErrors: []string{"MIG profiles of GPU 1 require"},
+		},
+		{
+			name:   "All the problems are reported",
+			labels: labels,
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "foo",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g5gb):  "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 1, mig.Profile3g40gb): "3",
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 3, mig.Profile1g10gb): "1",
+			},
+			expectedErrors: []string{
+				"invalid spec annotation",
+				"references GPU 3, but the node only has 2 GPUs",
+				"is not supported by GPU model",
+				"MIG profiles of GPU 1 require",
+			},
+		},
+		{
+			name:   "Missing GPU labels",
+			labels: map[string]string{},
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb): "1",
+			},
+			expectedErrors: []string{"cannot validate GPU indexes", "cannot validate MIG profiles"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(tt.labels).WithAnnotations(tt.annotations).Get()
+			errs := mig.ValidateNodeSpec(node)
+			if assert.Len(t, errs, len(tt.expectedErrors)) {
+				for i, expected := range tt.expectedErrors {
+					assert.ErrorContains(t, errs[i], expected)
+				}
+			}
+		})
+	}
+}
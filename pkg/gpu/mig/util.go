@@ -25,16 +25,58 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
-	resourceRegexp        = regexp.MustCompile(constant.RegexNvidiaMigResource)
+	// resourceNamePrefix and resourceRegexp are guarded by resourceNameMtx, since the plugins of the
+	// scheduler set the prefix while other plugins may already be parsing resource names
+	resourceNameMtx       sync.RWMutex
+	resourceNamePrefix    = constant.NvidiaMigResourcePrefix
+	resourceRegexp        = newResourceRegexp(constant.NvidiaMigResourcePrefix)
 	migDeviceMemoryRegexp = regexp.MustCompile(constant.RegexNvidiaMigFormatMemory)
 	numberRegexp          = regexp.MustCompile(`\d+`)
 )
 
+// newResourceRegexp returns the regex matching the names of the MIG resources with the prefix provided as argument
+func newResourceRegexp(prefix string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^%s(\d+c\.)?\d+g\.\d+gb(\.me)?$`, regexp.QuoteMeta(prefix)))
+}
+
+// SetResourceNamePrefix sets the prefix of the names of the resources corresponding to MIG profiles,
+// for clusters where the device plugin advertises MIG devices with a prefix different from the
+// default one (nvidia.com/mig-). The prefix is used both for building and for parsing resource names.
+//
+// SetResourceNamePrefix is safe for concurrent use, but the prefix is global: all the components of
+// the same process building or parsing MIG resource names must be configured with the same prefix.
+func SetResourceNamePrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("MIG resource name prefix cannot be empty")
+	}
+	re := newResourceRegexp(prefix)
+	resourceNameMtx.Lock()
+	defer resourceNameMtx.Unlock()
+	resourceNamePrefix = prefix
+	resourceRegexp = re
+	return nil
+}
+
+// GetResourceNamePrefix returns the prefix of the names of the resources corresponding to MIG profiles
+func GetResourceNamePrefix() string {
+	resourceNameMtx.RLock()
+	defer resourceNameMtx.RUnlock()
+	return resourceNamePrefix
+}
+
+// getResourceRegexp returns the regex matching the names of the resources corresponding to MIG profiles
+func getResourceRegexp() *regexp.Regexp {
+	resourceNameMtx.RLock()
+	defer resourceNameMtx.RUnlock()
+	return resourceRegexp
+}
+
 func IsNvidiaMigDevice(resourceName v1.ResourceName) bool {
-	return resourceRegexp.MatchString(string(resourceName))
+	return getResourceRegexp().MatchString(string(resourceName))
 }
 
 // ExtractProfileName extracts the Name of the MIG profile from the provided resource Name, and returns an error
@@ -43,11 +85,18 @@ func IsNvidiaMigDevice(resourceName v1.ResourceName) bool {
 // Example:
 //
 //	nvidia.com/mig-1g.10gb => 1g.10gb
+//	nvidia.com/mig-1g.5gb.me => 1g.5gb+me
 func ExtractProfileName(resourceName v1.ResourceName) (ProfileName, error) {
-	if isMigResource := resourceRegexp.MatchString(string(resourceName)); !isMigResource {
-		return "", fmt.Errorf("invalid input string, required format is %s", resourceRegexp.String())
+	resourceNameMtx.RLock()
+	re, prefix := resourceRegexp, resourceNamePrefix
+	resourceNameMtx.RUnlock()
+	if isMigResource := re.MatchString(string(resourceName)); !isMigResource {
+		return "", fmt.Errorf("invalid input string, required format is %s", re.String())
+	}
+	name := strings.TrimPrefix(string(resourceName), prefix)
+	if strings.HasSuffix(name, mediaExtensionsResourceSuffix) {
+		name = strings.TrimSuffix(name, mediaExtensionsResourceSuffix) + mediaExtensionsSuffix
 	}
-	name := strings.TrimPrefix(string(resourceName), "nvidia.com/mig-")
 	return ProfileName(name), nil
 }
 
@@ -69,8 +118,9 @@ func ExtractMemoryGBFromMigFormat(migFormatResourceName v1.ResourceName) (int64,
 	var err error
 	var res int64
 
-	if isMigResource := resourceRegexp.MatchString(string(migFormatResourceName)); !isMigResource {
-		return res, fmt.Errorf("invalid input string, required format is %s", resourceRegexp.String())
+	re := getResourceRegexp()
+	if isMigResource := re.MatchString(string(migFormatResourceName)); !isMigResource {
+		return res, fmt.Errorf("invalid input string, required format is %s", re.String())
 	}
 
 	matches := migDeviceMemoryRegexp.FindAllString(string(migFormatResourceName), -1)
@@ -125,8 +175,7 @@ func GroupDevicesByMigProfile(l gpu.DeviceList) map[Profile]gpu.DeviceList {
 func AsResources(g gpu.Geometry) map[v1.ResourceName]int {
 	res := make(map[v1.ResourceName]int)
 	for p, v := range g {
-		resourceName := v1.ResourceName(fmt.Sprintf("%s%s", constant.NvidiaMigResourcePrefix, p))
-		res[resourceName] += v
+		res[ProfileName(p.String()).AsResourceName()] += v
 	}
 	return res
 }
@@ -183,3 +183,103 @@ func TestSpecMatchesStatusAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSpecAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		model       gpu.Model
+		spec        gpu.SpecAnnotationList
+		expectedErr bool
+	}{
+		{
+			name:        "Empty spec",
+			model:       gpu.GPUModel_A30,
+			spec:        gpu.SpecAnnotationList{},
+			expectedErr: false,
+		},
+		{
+			name:  "Unknown model",
+			model: "unknown",
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: Profile1g6gb.String(), Index: 0, Quantity: 1},
+			},
+			expectedErr: true,
+		},
+		{
+			name:  "Profiles supported by model",
+			model: gpu.GPUModel_A30,
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: Profile1g6gb.String(), Index: 0, Quantity: 2},
+				{ProfileName: Profile2g12gb.String(), Index: 0, Quantity: 1},
+				{ProfileName: Profile4g24gb.String(), Index: 1, Quantity: 1},
+			},
+			expectedErr: false,
+		},
+		{
+			name:  "Model label differs from the model supporting the profiles",
+			model: gpu.GPUModel_A30,
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: Profile1g6gb.String(), Index: 0, Quantity: 2},
+				{ProfileName: Profile1g10gb.String(), Index: 1, Quantity: 1},
+			},
+			expectedErr: true,
+		},
+		{
+			name:  "Media extensions profile supported by model",
+			model: gpu.GPUModel_A100_SXM4_40GB,
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: Profile1g5gbMe.String(), Index: 0, Quantity: 1},
+			},
+			expectedErr: false,
+		},
+		{
+			name:  "Media extensions profile not supported by model",
+			model: gpu.GPUModel_A30,
+			spec: gpu.SpecAnnotationList{
+				{ProfileName: Profile1g5gbMe.String(), Index: 0, Quantity: 1},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSpecAnnotations(tt.model, tt.spec)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetResourceNamePrefix(t *testing.T) {
+	defer func() {
+		assert.NoError(t, SetResourceNamePrefix("nvidia.com/mig-"))
+	}()
+
+	assert.Error(t, SetResourceNamePrefix(""))
+	assert.Equal(t, "nvidia.com/mig-", GetResourceNamePrefix())
+
+	assert.NoError(t, SetResourceNamePrefix("example.com/mig-"))
+	assert.Equal(t, "example.com/mig-", GetResourceNamePrefix())
+
+	for _, profile := range []ProfileName{Profile1g10gb, Profile1g10gbMe, "1c.3g.20gb"} {
+		resourceName := profile.AsResourceName()
+		assert.True(t, IsNvidiaMigDevice(resourceName))
+		extracted, err := ExtractProfileName(resourceName)
+		assert.NoError(t, err)
+		assert.Equal(t, profile, extracted)
+	}
+	assert.Equal(t, v1.ResourceName("example.com/mig-1g.10gb.me"), Profile1g10gbMe.AsResourceName())
+
+	memory, err := ExtractMemoryGBFromMigFormat("example.com/mig-2g.20gb")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), memory)
+
+	// Resources with the default prefix are not MIG resources anymore
+	assert.False(t, IsNvidiaMigDevice("nvidia.com/mig-1g.10gb"))
+	_, err = ExtractProfileName("nvidia.com/mig-1g.10gb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// validMigGeometries contains, for each GPU model, the combinations of MIG profiles supported by NVIDIA,
+// as documented in the NVIDIA MIG User Guide. Combinations that only differ in the placement of the
+// profiles on the GPU are included only once.
+var validMigGeometries = map[gpu.Model][]gpu.Geometry{
+	gpu.GPUModel_A30: {
+		{Profile4g24gb: 1},
+		{Profile2g12gb: 2},
+		{Profile2g12gb: 1, Profile1g6gb: 2},
+		{Profile1g6gb: 4},
+	},
+	gpu.GPUModel_A100_SXM4_40GB: {
+		{Profile7g40gb: 1},
+		{Profile4g20gb: 1, Profile2g10gb: 1, Profile1g5gb: 1},
+		{Profile4g20gb: 1, Profile1g5gb: 3},
+		{Profile3g20gb: 2},
+		{Profile3g20gb: 1, Profile2g10gb: 2},
+		{Profile3g20gb: 1, Profile2g10gb: 1, Profile1g5gb: 2},
+		{Profile3g20gb: 1, Profile1g5gb: 4},
+		{Profile3g20gb: 1, Profile2g10gb: 1, Profile1g5gb: 1},
+		{Profile3g20gb: 1, Profile1g5gb: 3},
+		{Profile2g10gb: 3, Profile1g5gb: 1},
+		{Profile2g10gb: 2, Profile1g5gb: 3},
+		{Profile2g10gb: 1, Profile1g5gb: 5},
+		{Profile1g5gb: 7},
+	},
+	gpu.GPUModel_A100_PCIe_80GB: {
+		{Profile7g79gb: 1},
+		{Profile4g40gb: 1, Profile2g20gb: 1, Profile1g10gb: 1},
+		{Profile4g40gb: 1, Profile1g10gb: 3},
+		{Profile3g40gb: 2},
+		{Profile3g40gb: 1, Profile2g20gb: 2},
+		{Profile3g40gb: 1, Profile2g20gb: 1, Profile1g10gb: 2},
+		{Profile3g40gb: 1, Profile1g10gb: 4},
+		{Profile3g40gb: 1, Profile2g20gb: 1, Profile1g10gb: 1},
+		{Profile3g40gb: 1, Profile1g10gb: 3},
+		{Profile2g20gb: 3, Profile1g10gb: 1},
+		{Profile2g20gb: 2, Profile1g10gb: 3},
+		{Profile2g20gb: 1, Profile1g10gb: 5},
+		{Profile1g10gb: 7},
+	},
+}
+
+// ValidGeometries returns the canonical set of MIG geometries supported by NVIDIA for the GPU model
+// provided as argument, or nil if the model is unknown. Geometries that do not use the whole GPU
+// (e.g. a single 3g.20gb profile on an A100) are not included, since they are subsets of the returned ones.
+//
+// Unlike GetAllowedGeometries, the result does not depend on the known geometries set through
+// SetKnownGeometries, so it can be used for validating them. Profiles with media extensions are not included.
+func ValidGeometries(model gpu.Model) []gpu.Geometry {
+	geometries, ok := validMigGeometries[model]
+	if !ok {
+		return nil
+	}
+	return copyGeometryList(geometries)
+}
+
+// copyGeometries returns a deep copy of the geometries of each GPU model provided as argument
+func copyGeometries(geometries map[gpu.Model][]gpu.Geometry) map[gpu.Model][]gpu.Geometry {
+	res := make(map[gpu.Model][]gpu.Geometry, len(geometries))
+	for model, l := range geometries {
+		res[model] = copyGeometryList(l)
+	}
+	return res
+}
+
+func copyGeometryList(geometries []gpu.Geometry) []gpu.Geometry {
+	res := make([]gpu.Geometry, len(geometries))
+	for i, g := range geometries {
+		res[i] = make(gpu.Geometry, len(g))
+		for profile, quantity := range g {
+			res[i][profile] = quantity
+		}
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mig_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidGeometries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		model    gpu.Model
+		expected []gpu.Geometry
+	}{
+		{
+			name:     "Unknown model",
+			model:    "foo",
+			expected: nil,
+		},
+		{
+			name:  "A30",
+			model: gpu.GPUModel_A30,
+			expected: []gpu.Geometry{
+				{mig.Profile4g24gb: 1},
+				{mig.Profile2g12gb: 2},
+				{mig.Profile2g12gb: 1, mig.Profile1g6gb: 2},
+				{mig.Profile1g6gb: 4},
+			},
+		},
+		{
+			name:  "A100 40GB",
+			model: gpu.GPUModel_A100_SXM4_40GB,
+			expected: []gpu.Geometry{
+				{mig.Profile7g40gb: 1},
+				{mig.Profile4g20gb: 1, mig.Profile2g10gb: 1, mig.Profile1g5gb: 1},
+				{mig.Profile4g20gb: 1, mig.Profile1g5gb: 3},
+				{mig.Profile3g20gb: 2},
+				{mig.Profile3g20gb: 1, mig.Profile2g10gb: 2},
+				{mig.Profile3g20gb: 1, mig.Profile2g10gb: 1, mig.Profile1g5gb: 2},
+				{mig.Profile3g20gb: 1, mig.Profile1g5gb: 4},
+				{mig.Profile3g20gb: 1, mig.Profile2g10gb: 1, mig.Profile1g5gb: 1},
+				{mig.Profile3g20gb: 1, mig.Profile1g5gb: 3},
+				{mig.Profile2g10gb: 3, mig.Profile1g5gb: 1},
+				{mig.Profile2g10gb: 2, mig.Profile1g5gb: 3},
+				{mig.Profile2g10gb: 1, mig.Profile1g5gb: 5},
+				{mig.Profile1g5gb: 7},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res := mig.ValidGeometries(tt.model)
+			if tt.expected == nil {
+				assert.Nil(t, res)
+				return
+			}
+			assert.ElementsMatch(t, tt.expected, res)
+		})
+	}
+}
+
+func TestValidGeometries__FitCapacity(t *testing.T) {
+	for _, model := range []gpu.Model{gpu.GPUModel_A30, gpu.GPUModel_A100_SXM4_40GB, gpu.GPUModel_A100_PCIe_80GB} {
+		capacity, ok := mig.GetCapacity(model)
+		assert.True(t, ok)
+		for _, geometry := range mig.ValidGeometries(model) {
+			profiles := make(map[mig.ProfileName]int)
+			for p, q := range geometry {
+				profiles[p.(mig.ProfileName)] = q
+			}
+			assert.True(t, capacity.Covers(mig.GetRequiredCapacity(profiles)), "model %s, geometry %s", model, geometry)
+		}
+	}
+}
+
+func TestValidGeometries__ReturnsCopy(t *testing.T) {
+	geometries := mig.ValidGeometries(gpu.GPUModel_A30)
+	geometries[0][mig.Profile4g24gb] = 10
+	assert.Equal(t, 1, mig.ValidGeometries(gpu.GPUModel_A30)[0][mig.Profile4g24gb])
+}
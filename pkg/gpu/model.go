@@ -23,6 +23,8 @@ func (m Model) String() string {
 }
 
 const (
+	GPUModel_T4             Model = "Tesla-T4"
+	GPUModel_V100_SXM2_16GB Model = "Tesla-V100-SXM2-16GB"
 	GPUModel_A30            Model = "A30"
 	GPUModel_A100_SXM4_40GB Model = "NVIDIA-A100-40GB-SXM4"
 	GPUModel_A100_PCIe_80GB Model = "NVIDIA-A100-80GB-PCIe"
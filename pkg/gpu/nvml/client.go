@@ -19,6 +19,8 @@
 package nvml
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"github.com/go-logr/logr"
@@ -26,6 +28,7 @@ import (
 	"github.com/nebuly-ai/nos/pkg/util"
 	nvlibdevice "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
 	nvlibNvml "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+	"sort"
 )
 
 type clientImpl struct {
@@ -34,29 +37,75 @@ type clientImpl struct {
 	logger      logr.Logger
 }
 
+// NewClient returns a Client whose operations are serialized through the shared NVML access lock
 func NewClient(logger logr.Logger) Client {
 	nvmlClient := nvlibNvml.New()
-	return &clientImpl{
+	return WithAccessLock(&clientImpl{
 		nvmlClient:  nvmlClient,
 		nvlibClient: nvlibdevice.New(nvlibdevice.WithNvml(nvmlClient)),
 		logger:      logger,
-	}
+	})
 }
 
 func (c *clientImpl) init() gpu.Error {
 	if ret := c.nvmlClient.Init(); ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf("unable to initialize NVML: %s", ret.Error())
+		return newError(ret, "unable to initialize NVML: %s", ret.Error())
 	}
 	return nil
 }
 
+// isTransient returns true if the NVML return code provided as argument corresponds to a temporary
+// condition, such as the ERROR_UNKNOWN returned by NVML for a few seconds after a GPU reset.
+func isTransient(ret nvlibNvml.Return) bool {
+	return ret == nvlibNvml.ERROR_UNKNOWN
+}
+
+// newError returns a transient error if the NVML return code provided as argument is transient,
+// otherwise it returns a generic error.
+func newError(ret nvlibNvml.Return, format string, args ...any) gpu.Error {
+	if isTransient(ret) {
+		return gpu.TransientErr.Errorf(format, args...)
+	}
+	return gpu.GenericErr.Errorf(format, args...)
+}
+
 func (c *clientImpl) shutdown() {
 	if ret := c.nvmlClient.Shutdown(); ret != nvlibNvml.SUCCESS {
 		c.logger.Error(gpu.GenericErr.Errorf(ret.Error()), "unable to shut down NVML")
 	}
 }
 
-func (c *clientImpl) GetGpuIndex(deviceId string) (int, gpu.Error) {
+// GetGpuCount returns the number of GPU devices enumerated by NVML
+func (c *clientImpl) GetGpuCount(ctx context.Context) (int, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return 0, err
+	}
+	r := nvml.Init()
+	if r != nvml.SUCCESS {
+		return 0, gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	count, r := nvml.DeviceGetCount()
+	if r != nvml.SUCCESS {
+		return 0, gpu.GenericErr.Errorf("error getting device count: %s", nvml.ErrorString(r))
+	}
+	return count, nil
+}
+
+// GetGpuIndex returns the index of the GPU with the UUID provided as argument.
+// If NVML fails with a transient error, NVML is re-initialized and the lookup is retried.
+func (c *clientImpl) GetGpuIndex(ctx context.Context, deviceId string) (int, gpu.Error) {
+	var result int
+	err := retryOnTransientError(ctx, maxTransientRetries, transientRetryInterval, func() gpu.Error {
+		var err gpu.Error
+		result, err = c.getGpuIndex(ctx, deviceId)
+		return err
+	})
+	return result, err
+}
+
+func (c *clientImpl) getGpuIndex(ctx context.Context, deviceId string) (int, gpu.Error) {
 	if err := c.init(); err != nil {
 		return 0, err
 	}
@@ -70,9 +119,13 @@ func (c *clientImpl) GetGpuIndex(deviceId string) (int, gpu.Error) {
 		if found {
 			return nil
 		}
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
 		uuid, ret := d.GetUUID()
 		if ret != nvlibNvml.SUCCESS {
-			return fmt.Errorf(
+			return newError(
+				ret,
 				"error getting UUID of device with index %d on GPU %v: %s",
 				gpuIndex,
 				deviceId,
@@ -86,6 +139,10 @@ func (c *clientImpl) GetGpuIndex(deviceId string) (int, gpu.Error) {
 		}
 		return nil
 	})
+	var gpuErr gpu.Error
+	if errors.As(err, &gpuErr) {
+		return 0, gpuErr
+	}
 	if err != nil {
 		return 0, gpu.NewGenericError(err)
 	}
@@ -98,7 +155,10 @@ func (c *clientImpl) GetGpuIndex(deviceId string) (int, gpu.Error) {
 // GetMigDeviceGpuIndex returns the index of the GPU associated to the
 // MIG device provided as arg. Returns err if the device
 // is not found or any error occurs while retrieving it.
-func (c *clientImpl) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
+func (c *clientImpl) GetMigDeviceGpuIndex(ctx context.Context, migDeviceId string) (int, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return 0, err
+	}
 	if err := c.init(); err != nil {
 		return 0, err
 	}
@@ -112,6 +172,9 @@ func (c *clientImpl) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
 		if found {
 			return nil
 		}
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
 		uuid, ret := m.GetUUID()
 		if ret != nvlibNvml.SUCCESS {
 			return fmt.Errorf(
@@ -136,6 +199,10 @@ func (c *clientImpl) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
 		}
 		return nil
 	})
+	var gpuErr gpu.Error
+	if errors.As(err, &gpuErr) {
+		return 0, gpuErr
+	}
 	if err != nil {
 		return 0, gpu.NewGenericError(err)
 	}
@@ -145,7 +212,66 @@ func (c *clientImpl) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
 	return result, nil
 }
 
-func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
+// GetMigDeviceInstanceIds returns the IDs of the GPU Instance and of the Compute Instance of the
+// MIG device with the UUID provided as argument.
+// If NVML fails with a transient error, NVML is re-initialized and the lookup is retried.
+func (c *clientImpl) GetMigDeviceInstanceIds(ctx context.Context, migDeviceId string) (int, int, gpu.Error) {
+	var giId, ciId int
+	err := retryOnTransientError(ctx, maxTransientRetries, transientRetryInterval, func() gpu.Error {
+		var err gpu.Error
+		giId, ciId, err = c.getMigDeviceInstanceIds(migDeviceId)
+		return err
+	})
+	return giId, ciId, err
+}
+
+func (c *clientImpl) getMigDeviceInstanceIds(migDeviceId string) (int, int, gpu.Error) {
+	if err := c.init(); err != nil {
+		return 0, 0, err
+	}
+	defer c.shutdown()
+
+	d, ret := c.nvmlClient.DeviceGetHandleByUUID(migDeviceId)
+	if ret == nvlibNvml.ERROR_NOT_FOUND {
+		return 0, 0, gpu.NotFoundErr.Errorf("MIG device %s not found", migDeviceId)
+	}
+	if ret != nvlibNvml.SUCCESS {
+		return 0, 0, newError(ret, "error getting MIG device with UUID %s: %s", migDeviceId, ret.Error())
+	}
+	isMig, ret := d.IsMigDeviceHandle()
+	if ret != nvlibNvml.SUCCESS {
+		return 0, 0, newError(
+			ret,
+			"error determining whether the device with UUID %s is a MIG device: %s",
+			migDeviceId,
+			ret.Error(),
+		)
+	}
+	if !isMig {
+		return 0, 0, gpu.GenericErr.Errorf("device with UUID %s is not a MIG device", migDeviceId)
+	}
+
+	giId, ret := d.GetGpuInstanceId()
+	if ret != nvlibNvml.SUCCESS {
+		return 0, 0, newError(ret, "error getting GPU Instance ID: %s", ret.Error())
+	}
+	ciId, ret := d.GetComputeInstanceId()
+	if ret != nvlibNvml.SUCCESS {
+		return 0, 0, newError(ret, "error getting Compute Instance ID: %s", ret.Error())
+	}
+	return giId, ciId, nil
+}
+
+// DeleteMigDevice deletes the MIG device with the UUID provided as argument.
+// If NVML fails with a transient error while looking up the device, before deleting anything,
+// NVML is re-initialized and the deletion is retried.
+func (c *clientImpl) DeleteMigDevice(ctx context.Context, id string) gpu.Error {
+	return retryOnTransientError(ctx, maxTransientRetries, transientRetryInterval, func() gpu.Error {
+		return c.deleteMigDevice(ctx, id)
+	})
+}
+
+func (c *clientImpl) deleteMigDevice(ctx context.Context, id string) gpu.Error {
 	if err := c.init(); err != nil {
 		return err
 	}
@@ -157,11 +283,12 @@ func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
 		return gpu.NotFoundErr.Errorf("MIG device %s not found", id)
 	}
 	if ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf("error getting MIG device with UUID %s: %s", id, ret.Error())
+		return newError(ret, "error getting MIG device with UUID %s: %s", id, ret.Error())
 	}
 	isMig, ret := d.IsMigDeviceHandle()
 	if ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf(
+		return newError(
+			ret,
 			"error determining whether the device with UUID %s is a MIG device: %s",
 			id,
 			ret.Error(),
@@ -174,21 +301,25 @@ func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
 	// Fetch GPU Instance and Compute Instances
 	giId, ret := d.GetGpuInstanceId()
 	if ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf("error getting GPU Instance ID: %s", ret.Error())
+		return newError(ret, "error getting GPU Instance ID: %s", ret.Error())
 	}
 	parentGpu, ret := d.GetDeviceHandleFromMigDeviceHandle()
 	if ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf("error getting device handle from MIG device: %s", ret.Error())
+		return newError(ret, "error getting device handle from MIG device: %s", ret.Error())
 	}
 	gi, ret := parentGpu.GetGpuInstanceById(giId)
 	if ret == nvlibNvml.ERROR_NOT_FOUND {
 		return gpu.NotFoundErr.Errorf("GPU instance %s not found", giId)
 	}
 	if ret != nvlibNvml.SUCCESS {
-		return gpu.GenericErr.Errorf("error getting GPU Instance %d: %s", giId, ret.Error())
+		return newError(ret, "error getting GPU Instance %d: %s", giId, ret.Error())
 	}
 
-	// Delete Compute Instances
+	// Delete Compute Instances. From now on errors are never transient, since retrying after
+	// a partial deletion would fail anyway.
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	var numVisitedCi uint8
 	err := visitComputeInstances(gi, func(ci nvlibNvml.ComputeInstance, ciProfileId int, ciEngProfileId int, ciProfileInfo nvlibNvml.ComputeInstanceProfileInfo) error {
 		numVisitedCi++
@@ -201,6 +332,9 @@ func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
 			"engProfileId",
 			ciEngProfileId,
 		)
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
 		if r := ci.Destroy(); r != nvlibNvml.SUCCESS {
 			return gpu.GenericErr.Errorf("error deleting compute instance: %s", r.Error())
 		}
@@ -214,6 +348,9 @@ func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
 	}
 
 	// Delete GPU Instance
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	c.logger.V(1).Info("deleting GPU instance")
 	if ret = gi.Destroy(); ret != nvlibNvml.SUCCESS {
 		return gpu.GenericErr.Errorf("error deleting GPU instance: %s", ret.Error())
@@ -222,7 +359,10 @@ func (c *clientImpl) DeleteMigDevice(id string) gpu.Error {
 	return nil
 }
 
-func (c *clientImpl) CreateMigDevices(migProfileNames []string, gpuIndex int) gpu.Error {
+func (c *clientImpl) CreateMigDevices(ctx context.Context, migProfileNames []string, gpuIndex int) gpu.Error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	r := nvml.Init()
 	if r != nvml.SUCCESS {
 		return gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
@@ -293,6 +433,9 @@ func (c *clientImpl) CreateMigDevices(migProfileNames []string, gpuIndex int) gp
 		if nAttempts > maxAttempts {
 			return false, fmt.Errorf("could not find a valid permutation for creating MIG profiles: too many attempts")
 		}
+		if err := checkContext(ctx); err != nil {
+			return false, err
+		}
 		c.logger.V(1).Info("trying to create MIG profiles", "permutation", mps)
 		nAttempts++
 		createdGIs := make([]nvlibNvml.GpuInstance, 0)
@@ -340,7 +483,10 @@ func (c *clientImpl) CreateMigDevices(migProfileNames []string, gpuIndex int) gp
 }
 
 // GetMigEnabledGPUs returns the indexes of the GPUs that have MIG mode enabled
-func (c *clientImpl) GetMigEnabledGPUs() ([]int, gpu.Error) {
+func (c *clientImpl) GetMigEnabledGPUs(ctx context.Context) ([]int, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
 	r := nvml.Init()
 	if r != nvml.SUCCESS {
 		return nil, gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
@@ -354,6 +500,9 @@ func (c *clientImpl) GetMigEnabledGPUs() ([]int, gpu.Error) {
 
 	indexes := make([]int, 0)
 	for _, d := range devices {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
 		isEnabled, err := d.IsMigEnabled()
 		if err != nil {
 			return nil, gpu.NewGenericError(err)
@@ -371,9 +520,185 @@ func (c *clientImpl) GetMigEnabledGPUs() ([]int, gpu.Error) {
 	return indexes, nil
 }
 
+// CountMigDevicesByProfile returns the number of MIG devices existing on the GPUs for each MIG profile.
+// If NVML fails with a transient error, NVML is re-initialized and the lookup is retried.
+func (c *clientImpl) CountMigDevicesByProfile(ctx context.Context) (map[string]int, gpu.Error) {
+	var res map[string]int
+	err := retryOnTransientError(ctx, maxTransientRetries, transientRetryInterval, func() gpu.Error {
+		var err gpu.Error
+		res, err = c.countMigDevicesByProfile(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (c *clientImpl) countMigDevicesByProfile(ctx context.Context) (map[string]int, gpu.Error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	defer c.shutdown()
+
+	res := make(map[string]int)
+	err := c.nvlibClient.VisitMigDevices(func(gpuIndex int, _ nvlibdevice.Device, migIndex int, m nvlibdevice.MigDevice) error {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+		profile, err := m.GetProfile()
+		if err != nil {
+			return fmt.Errorf(
+				"error getting profile of MIG device with index %d on GPU %v: %s",
+				migIndex,
+				gpuIndex,
+				err,
+			)
+		}
+		res[profile.String()]++
+		return nil
+	})
+	var gpuErr gpu.Error
+	if errors.As(err, &gpuErr) {
+		return nil, gpuErr
+	}
+	if err != nil {
+		return nil, gpu.NewGenericError(err)
+	}
+	return res, nil
+}
+
+// GetDriverVersion returns the version of the NVIDIA driver installed on the node
+func (c *clientImpl) GetDriverVersion(ctx context.Context) (string, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
+	r := nvml.Init()
+	if r != nvml.SUCCESS {
+		return "", gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	version, r := nvml.SystemGetDriverVersion()
+	if r != nvml.SUCCESS {
+		return "", gpu.GenericErr.Errorf("error getting driver version: %s", nvml.ErrorString(r))
+	}
+	return version, nil
+}
+
+// GetCudaDriverVersion returns the version of CUDA supported by the NVIDIA driver installed on the node
+func (c *clientImpl) GetCudaDriverVersion(ctx context.Context) (string, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
+	r := nvml.Init()
+	if r != nvml.SUCCESS {
+		return "", gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	version, r := nvml.SystemGetCudaDriverVersion()
+	if r != nvml.SUCCESS {
+		return "", gpu.GenericErr.Errorf("error getting CUDA driver version: %s", nvml.ErrorString(r))
+	}
+	return FormatCudaVersion(version), nil
+}
+
+// HealthCheck initializes NVML and retrieves the number of GPU devices, returning an error if any of
+// these steps fail (e.g. the driver crashed or a device was reset).
+func (c *clientImpl) HealthCheck(ctx context.Context) gpu.Error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	r := nvml.Init()
+	if r != nvml.SUCCESS {
+		return gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	if _, r = nvml.DeviceGetCount(); r != nvml.SUCCESS {
+		return gpu.GenericErr.Errorf("error getting device count: %s", nvml.ErrorString(r))
+	}
+	return nil
+}
+
+// GetGpuInfo returns the health and the topology of the GPU devices enumerated by NVML. GPUs whose
+// handle or PCI information cannot be retrieved are reported as unhealthy. The NUMA node of each GPU
+// is read from sysfs, while its NVLink peers are the GPUs connected to its active NVLinks.
+func (c *clientImpl) GetGpuInfo(ctx context.Context) ([]gpu.Info, gpu.Error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	r := nvml.Init()
+	if r != nvml.SUCCESS {
+		return nil, gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	count, r := nvml.DeviceGetCount()
+	if r != nvml.SUCCESS {
+		return nil, gpu.GenericErr.Errorf("error getting device count: %s", nvml.ErrorString(r))
+	}
+
+	res := make([]gpu.Info, count)
+	devices := make(map[int]nvml.Device, count)
+	gpuIndexes := make(map[string]int, count)
+	for i := 0; i < count; i++ {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
+		res[i] = gpu.Info{Index: i}
+		device, r := nvml.DeviceGetHandleByIndex(i)
+		if r != nvml.SUCCESS {
+			c.logger.Info("unable to get device handle, reporting GPU as unhealthy", "GPUIndex", i, "error", nvml.ErrorString(r))
+			continue
+		}
+		pciInfo, r := device.GetPciInfo()
+		if r != nvml.SUCCESS {
+			c.logger.Info("unable to get PCI info, reporting GPU as unhealthy", "GPUIndex", i, "error", nvml.ErrorString(r))
+			continue
+		}
+		address := pciAddress(pciInfo.Domain, pciInfo.Bus, pciInfo.Device)
+		res[i].Healthy = true
+		res[i].NumaNode = readNumaNode(sysfsPciDevicesPath, address)
+		devices[i] = device
+		gpuIndexes[address] = i
+	}
+
+	for i, device := range devices {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
+		peers := make(map[int]struct{})
+		for link := 0; link < nvml.NVLINK_MAX_LINKS; link++ {
+			state, r := device.GetNvLinkState(link)
+			if r != nvml.SUCCESS || state != nvml.FEATURE_ENABLED {
+				continue
+			}
+			remote, r := device.GetNvLinkRemotePciInfo(link)
+			if r != nvml.SUCCESS {
+				continue
+			}
+			if peer, ok := gpuIndexes[pciAddress(remote.Domain, remote.Bus, remote.Device)]; ok && peer != i {
+				peers[peer] = struct{}{}
+			}
+		}
+		if len(peers) == 0 {
+			continue
+		}
+		res[i].NVLinkPeers = make([]int, 0, len(peers))
+		for peer := range peers {
+			res[i].NVLinkPeers = append(res[i].NVLinkPeers, peer)
+		}
+		sort.Ints(res[i].NVLinkPeers)
+	}
+
+	return res, nil
+}
+
 // DeleteAllMigDevicesExcept deletes all the MIG resources (Compute Instances and GPU Instances) except the ones
 // associated with the MIG devices with the provided IDs
-func (c *clientImpl) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
+func (c *clientImpl) DeleteAllMigDevicesExcept(ctx context.Context, migDeviceIds []string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	r := nvml.Init()
 	if r != nvml.SUCCESS {
 		return gpu.GenericErr.Errorf("error initializing nvml client: %s", nvml.ErrorString(r))
@@ -381,6 +706,9 @@ func (c *clientImpl) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
 	defer nvml.Shutdown()
 
 	err := c.nvlibClient.VisitDevices(func(i int, device nvlibdevice.Device) error {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
 		// Check if device is MIG-enabled
 		isMig, err := device.IsMigEnabled()
 		if err != nil {
@@ -417,6 +745,9 @@ func (c *clientImpl) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
 				if util.InSlice(ciDeviceId, migDeviceIds) {
 					return nil
 				}
+				if err := checkContext(ctx); err != nil {
+					return err
+				}
 				ret = ci.Destroy()
 				if ret == nvlibNvml.ERROR_INVALID_ARGUMENT {
 					return nil
@@ -432,6 +763,9 @@ func (c *clientImpl) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
 			}
 
 			// Delete GPU instance
+			if err := checkContext(ctx); err != nil {
+				return err
+			}
 			ret = gi.Destroy()
 			if ret == nvlibNvml.ERROR_INVALID_ARGUMENT {
 				return nil
@@ -0,0 +1,88 @@
+//go:build !nvml
+
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// errNvmlUnavailable is the error returned by the client of binaries built without the nvml build tag
+var errNvmlUnavailable = gpu.GenericErr.Errorf("NVML is not available: binary built without the nvml build tag")
+
+// unavailableClient is the Client used when the binary is built without NVML support: all its methods
+// return an error.
+type unavailableClient struct{}
+
+func NewClient(_ logr.Logger) Client {
+	return unavailableClient{}
+}
+
+func (unavailableClient) GetGpuCount(_ context.Context) (int, gpu.Error) {
+	return 0, errNvmlUnavailable
+}
+
+func (unavailableClient) GetGpuIndex(_ context.Context, _ string) (int, gpu.Error) {
+	return 0, errNvmlUnavailable
+}
+
+func (unavailableClient) GetMigDeviceGpuIndex(_ context.Context, _ string) (int, gpu.Error) {
+	return 0, errNvmlUnavailable
+}
+
+func (unavailableClient) GetMigDeviceInstanceIds(_ context.Context, _ string) (int, int, gpu.Error) {
+	return 0, 0, errNvmlUnavailable
+}
+
+func (unavailableClient) DeleteMigDevice(_ context.Context, _ string) gpu.Error {
+	return errNvmlUnavailable
+}
+
+func (unavailableClient) CreateMigDevices(_ context.Context, _ []string, _ int) gpu.Error {
+	return errNvmlUnavailable
+}
+
+func (unavailableClient) GetMigEnabledGPUs(_ context.Context) ([]int, gpu.Error) {
+	return nil, errNvmlUnavailable
+}
+
+func (unavailableClient) CountMigDevicesByProfile(_ context.Context) (map[string]int, gpu.Error) {
+	return nil, errNvmlUnavailable
+}
+
+func (unavailableClient) DeleteAllMigDevicesExcept(_ context.Context, _ []string) error {
+	return errNvmlUnavailable
+}
+
+func (unavailableClient) GetDriverVersion(_ context.Context) (string, gpu.Error) {
+	return "", errNvmlUnavailable
+}
+
+func (unavailableClient) GetCudaDriverVersion(_ context.Context) (string, gpu.Error) {
+	return "", errNvmlUnavailable
+}
+
+func (unavailableClient) HealthCheck(_ context.Context) gpu.Error {
+	return errNvmlUnavailable
+}
+
+func (unavailableClient) GetGpuInfo(_ context.Context) ([]gpu.Info, gpu.Error) {
+	return nil, errNvmlUnavailable
+}
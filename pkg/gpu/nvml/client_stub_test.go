@@ -0,0 +1,45 @@
+//go:build !nvml
+
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml_test
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnavailableClient__HealthCheck(t *testing.T) {
+	client := nvml.NewClient(logr.Discard())
+	err := client.HealthCheck(context.Background())
+	assert.Error(t, err)
+}
+
+func TestUnavailableClient__Versions(t *testing.T) {
+	client := nvml.NewClient(logr.Discard())
+
+	driverVersion, err := client.GetDriverVersion(context.Background())
+	assert.Error(t, err)
+	assert.Empty(t, driverVersion)
+
+	cudaVersion, err := client.GetCudaDriverVersion(context.Background())
+	assert.Error(t, err)
+	assert.Empty(t, cudaVersion)
+}
@@ -0,0 +1,206 @@
+//go:build nvml
+
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/stretchr/testify/assert"
+	nvlibdevice "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	nvlibNvml "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+	"testing"
+	"time"
+)
+
+// fakeDeviceLib is a nvlibdevice.Interface whose VisitMigDevices visits the MIG devices it contains,
+// all belonging to the GPU with index 0
+type fakeDeviceLib struct {
+	nvlibdevice.Interface
+	migDevices []nvlibdevice.MigDevice
+}
+
+func (f fakeDeviceLib) VisitMigDevices(visit func(i int, d nvlibdevice.Device, j int, m nvlibdevice.MigDevice) error) error {
+	for j, m := range f.migDevices {
+		if err := visit(0, nil, j, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeMigProfile struct {
+	nvlibdevice.MigProfile
+}
+
+func (fakeMigProfile) String() string {
+	return "1g.10gb"
+}
+
+type fakeMigDevice struct {
+	*nvlibNvml.DeviceMock
+	getProfile func() (nvlibdevice.MigProfile, error)
+}
+
+func (m fakeMigDevice) GetProfile() (nvlibdevice.MigProfile, error) {
+	return m.getProfile()
+}
+
+// newCancellingClient returns a client visiting the number of MIG devices provided as argument,
+// which cancels the context provided as argument when the MIG device with index cancelAt is visited.
+// The returned slice contains the number of times each MIG device has been visited.
+func newCancellingClient(cancel context.CancelFunc, nDevices, cancelAt int) (*clientImpl, []int) {
+	visits := make([]int, nDevices)
+	migDevices := make([]nvlibdevice.MigDevice, nDevices)
+	for i := range migDevices {
+		i := i
+		visit := func() {
+			visits[i]++
+			if i == cancelAt {
+				cancel()
+			}
+		}
+		migDevices[i] = fakeMigDevice{
+			DeviceMock: &nvlibNvml.DeviceMock{
+				GetUUIDFunc: func() (string, nvlibNvml.Return) {
+					visit()
+					return fmt.Sprintf("mig-%d", i), nvlibNvml.SUCCESS
+				},
+			},
+			getProfile: func() (nvlibdevice.MigProfile, error) {
+				visit()
+				return fakeMigProfile{}, nil
+			},
+		}
+	}
+	client := &clientImpl{
+		nvmlClient: &nvlibNvml.InterfaceMock{
+			InitFunc: func() nvlibNvml.Return {
+				return nvlibNvml.SUCCESS
+			},
+			ShutdownFunc: func() nvlibNvml.Return {
+				return nvlibNvml.SUCCESS
+			},
+		},
+		nvlibClient: fakeDeviceLib{migDevices: migDevices},
+		logger:      logr.Discard(),
+	}
+	return client, visits
+}
+
+func TestClient__CancelledContextStopsVisitingMigDevices(t *testing.T) {
+	t.Run("GetMigDeviceGpuIndex", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client, visits := newCancellingClient(cancel, 5, 1)
+
+		// The looked up device is the last one, so it is found only if the visit is not stopped
+		_, err := client.GetMigDeviceGpuIndex(ctx, "mig-4")
+		assert.Error(t, err)
+		assert.Equal(t, []int{1, 1, 0, 0, 0}, visits)
+	})
+
+	t.Run("CountMigDevicesByProfile", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client, visits := newCancellingClient(cancel, 5, 1)
+
+		res, err := client.CountMigDevicesByProfile(ctx)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, []int{1, 1, 0, 0, 0}, visits)
+	})
+}
+
+// newFlakyClient returns a client whose NVML lookup of a MIG device fails with the return codes
+// provided as argument, in order, and then succeeds. The returned pointer refers to the number of
+// times NVML has been initialized.
+func newFlakyClient(failures ...nvlibNvml.Return) (*clientImpl, *int) {
+	var inits, lookups int
+	migDevice := &nvlibNvml.DeviceMock{
+		IsMigDeviceHandleFunc: func() (bool, nvlibNvml.Return) {
+			return true, nvlibNvml.SUCCESS
+		},
+		GetGpuInstanceIdFunc: func() (int, nvlibNvml.Return) {
+			return 2, nvlibNvml.SUCCESS
+		},
+		GetComputeInstanceIdFunc: func() (int, nvlibNvml.Return) {
+			return 1, nvlibNvml.SUCCESS
+		},
+	}
+	client := &clientImpl{
+		nvmlClient: &nvlibNvml.InterfaceMock{
+			InitFunc: func() nvlibNvml.Return {
+				inits++
+				return nvlibNvml.SUCCESS
+			},
+			ShutdownFunc: func() nvlibNvml.Return {
+				return nvlibNvml.SUCCESS
+			},
+			DeviceGetHandleByUUIDFunc: func(string) (nvlibNvml.Device, nvlibNvml.Return) {
+				defer func() { lookups++ }()
+				if lookups < len(failures) {
+					return nil, failures[lookups]
+				}
+				return migDevice, nvlibNvml.SUCCESS
+			},
+		},
+		logger: logr.Discard(),
+	}
+	return client, &inits
+}
+
+func TestClient__TransientErrorsAreRetried(t *testing.T) {
+	defer func(interval time.Duration) { transientRetryInterval = interval }(transientRetryInterval)
+	transientRetryInterval = 0
+
+	t.Run("ERROR_UNKNOWN once, then SUCCESS", func(t *testing.T) {
+		client, inits := newFlakyClient(nvlibNvml.ERROR_UNKNOWN)
+
+		giId, ciId, err := client.GetMigDeviceInstanceIds(context.Background(), "mig-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, giId)
+		assert.Equal(t, 1, ciId)
+		// NVML is re-initialized at each attempt
+		assert.Equal(t, 2, *inits)
+	})
+
+	t.Run("ERROR_UNKNOWN persists: retries are bounded", func(t *testing.T) {
+		failures := make([]nvlibNvml.Return, maxTransientRetries+1)
+		for i := range failures {
+			failures[i] = nvlibNvml.ERROR_UNKNOWN
+		}
+		client, inits := newFlakyClient(failures...)
+
+		_, _, err := client.GetMigDeviceInstanceIds(context.Background(), "mig-1")
+		assert.Error(t, err)
+		assert.True(t, gpu.IsTransient(err))
+		assert.Equal(t, maxTransientRetries+1, *inits)
+	})
+
+	t.Run("Permanent errors are not retried", func(t *testing.T) {
+		client, inits := newFlakyClient(nvlibNvml.ERROR_INVALID_ARGUMENT)
+
+		_, _, err := client.GetMigDeviceInstanceIds(context.Background(), "mig-1")
+		assert.Error(t, err)
+		assert.False(t, gpu.IsTransient(err))
+		assert.Equal(t, 1, *inits)
+	})
+}
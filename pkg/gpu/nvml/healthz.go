@@ -0,0 +1,33 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// ReadyzCheck returns a readiness check that fails when NVML is not available
+func ReadyzCheck(client Client) healthz.Checker {
+	return func(req *http.Request) error {
+		// gpu.Error is an interface: return an untyped nil so that the check does not fail on success
+		if err := client.HealthCheck(req.Context()); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml_test
+
+import (
+	"errors"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/nvml"
+	mockednvml "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyzCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		healthErr   gpu.Error
+		expectedErr bool
+	}{
+		{
+			name:        "NVML is healthy",
+			healthErr:   nil,
+			expectedErr: false,
+		},
+		{
+			name:        "NVML is not available",
+			healthErr:   gpu.NewGenericError(errors.New("error")),
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mockednvml.Client{}
+			client.On("HealthCheck", mock.Anything).Return(tt.healthErr).Once()
+
+			err := nvml.ReadyzCheck(&client)(httptest.NewRequest("GET", "/readyz", nil))
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			client.AssertExpectations(t)
+		})
+	}
+}
@@ -17,19 +17,47 @@
 package nvml
 
 import (
+	"context"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 )
 
+// Client performs operations on the GPUs through NVML. Long-running operations check the context provided
+// as argument between the visits of the devices and before each destructive NVML call, and they are aborted
+// with an error as soon as the context is cancelled.
 type Client interface {
-	GetGpuIndex(gpuId string) (int, gpu.Error)
+	// GetGpuCount returns the number of GPU devices enumerated by NVML
+	GetGpuCount(ctx context.Context) (int, gpu.Error)
 
-	GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error)
+	GetGpuIndex(ctx context.Context, gpuId string) (int, gpu.Error)
 
-	DeleteMigDevice(id string) gpu.Error
+	GetMigDeviceGpuIndex(ctx context.Context, migDeviceId string) (int, gpu.Error)
 
-	CreateMigDevices(migProfileNames []string, gpuIndex int) gpu.Error
+	// GetMigDeviceInstanceIds returns the IDs of the GPU Instance and of the Compute Instance
+	// of the MIG device with the UUID provided as argument
+	GetMigDeviceInstanceIds(ctx context.Context, migDeviceId string) (int, int, gpu.Error)
 
-	GetMigEnabledGPUs() ([]int, gpu.Error)
+	DeleteMigDevice(ctx context.Context, id string) gpu.Error
 
-	DeleteAllMigDevicesExcept(migDeviceIds []string) error
+	CreateMigDevices(ctx context.Context, migProfileNames []string, gpuIndex int) gpu.Error
+
+	GetMigEnabledGPUs(ctx context.Context) ([]int, gpu.Error)
+
+	// CountMigDevicesByProfile returns the number of MIG devices existing on the GPUs for each
+	// MIG profile (e.g. "1g.10gb"), regardless of whether they are advertised by the device plugin
+	CountMigDevicesByProfile(ctx context.Context) (map[string]int, gpu.Error)
+
+	DeleteAllMigDevicesExcept(ctx context.Context, migDeviceIds []string) error
+
+	// GetDriverVersion returns the version of the NVIDIA driver installed on the node (e.g. "535.104.05")
+	GetDriverVersion(ctx context.Context) (string, gpu.Error)
+
+	// GetCudaDriverVersion returns the version of CUDA supported by the NVIDIA driver
+	// installed on the node, in the format "<major>.<minor>" (e.g. "12.2")
+	GetCudaDriverVersion(ctx context.Context) (string, gpu.Error)
+
+	// HealthCheck returns an error if NVML cannot be initialized or cannot access the GPU devices
+	HealthCheck(ctx context.Context) gpu.Error
+
+	// GetGpuInfo returns the health and the topology of the GPU devices enumerated by NVML
+	GetGpuInfo(ctx context.Context) ([]gpu.Info, gpu.Error)
 }
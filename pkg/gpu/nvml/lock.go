@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// accessLock is the lock shared by all the clients returned by WithAccessLock. Since each Client operation
+// initializes and shuts down NVML, concurrent operations performed by different components of the same
+// process (e.g. the MIG actuator and a health checker) could shut down NVML while another one is using it.
+// A channel is used instead of a mutex so that waiting for the lock can be aborted through the context.
+var accessLock = make(chan struct{}, 1)
+
+// acquireAccessLock blocks until the shared NVML access lock is acquired, or returns an error
+// if the context provided as argument is cancelled before.
+func acquireAccessLock(ctx context.Context) gpu.Error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	select {
+	case accessLock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return checkContext(ctx)
+	}
+}
+
+func releaseAccessLock() {
+	<-accessLock
+}
+
+// lockedClient is a Client that performs each operation of the wrapped Client while holding
+// the shared NVML access lock.
+type lockedClient struct {
+	client Client
+}
+
+// WithAccessLock returns a Client that serializes the operations of the Client provided as argument with
+// the operations of all the other clients returned by WithAccessLock, so that at most one NVML operation
+// is performed at a time by the process. The clients returned by NewClient are already serialized.
+func WithAccessLock(client Client) Client {
+	if l, ok := client.(*lockedClient); ok {
+		return l
+	}
+	return &lockedClient{client: client}
+}
+
+func (c *lockedClient) GetGpuCount(ctx context.Context) (int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return 0, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetGpuCount(ctx)
+}
+
+func (c *lockedClient) GetGpuIndex(ctx context.Context, gpuId string) (int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return 0, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetGpuIndex(ctx, gpuId)
+}
+
+func (c *lockedClient) GetMigDeviceGpuIndex(ctx context.Context, migDeviceId string) (int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return 0, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetMigDeviceGpuIndex(ctx, migDeviceId)
+}
+
+func (c *lockedClient) GetMigDeviceInstanceIds(ctx context.Context, migDeviceId string) (int, int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return 0, 0, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetMigDeviceInstanceIds(ctx, migDeviceId)
+}
+
+func (c *lockedClient) DeleteMigDevice(ctx context.Context, id string) gpu.Error {
+	if err := acquireAccessLock(ctx); err != nil {
+		return err
+	}
+	defer releaseAccessLock()
+	return c.client.DeleteMigDevice(ctx, id)
+}
+
+func (c *lockedClient) CreateMigDevices(ctx context.Context, migProfileNames []string, gpuIndex int) gpu.Error {
+	if err := acquireAccessLock(ctx); err != nil {
+		return err
+	}
+	defer releaseAccessLock()
+	return c.client.CreateMigDevices(ctx, migProfileNames, gpuIndex)
+}
+
+func (c *lockedClient) GetMigEnabledGPUs(ctx context.Context) ([]int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return nil, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetMigEnabledGPUs(ctx)
+}
+
+func (c *lockedClient) CountMigDevicesByProfile(ctx context.Context) (map[string]int, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return nil, err
+	}
+	defer releaseAccessLock()
+	return c.client.CountMigDevicesByProfile(ctx)
+}
+
+func (c *lockedClient) DeleteAllMigDevicesExcept(ctx context.Context, migDeviceIds []string) error {
+	if err := acquireAccessLock(ctx); err != nil {
+		return err
+	}
+	defer releaseAccessLock()
+	return c.client.DeleteAllMigDevicesExcept(ctx, migDeviceIds)
+}
+
+func (c *lockedClient) GetDriverVersion(ctx context.Context) (string, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return "", err
+	}
+	defer releaseAccessLock()
+	return c.client.GetDriverVersion(ctx)
+}
+
+func (c *lockedClient) GetCudaDriverVersion(ctx context.Context) (string, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return "", err
+	}
+	defer releaseAccessLock()
+	return c.client.GetCudaDriverVersion(ctx)
+}
+
+func (c *lockedClient) HealthCheck(ctx context.Context) gpu.Error {
+	if err := acquireAccessLock(ctx); err != nil {
+		return err
+	}
+	defer releaseAccessLock()
+	return c.client.HealthCheck(ctx)
+}
+
+func (c *lockedClient) GetGpuInfo(ctx context.Context) ([]gpu.Info, gpu.Error) {
+	if err := acquireAccessLock(ctx); err != nil {
+		return nil, err
+	}
+	defer releaseAccessLock()
+	return c.client.GetGpuInfo(ctx)
+}
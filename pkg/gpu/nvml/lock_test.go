@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	mockednvml "github.com/nebuly-ai/nos/pkg/test/mocks/nvml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithAccessLock__OperationsAreSerialized(t *testing.T) {
+	var running, maxRunning int32
+	track := func(mock.Arguments) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	}
+
+	// Two different clients, e.g. used by the MIG actuator and by a health checker
+	actuatorNvml := mockednvml.Client{}
+	actuatorNvml.On("DeleteMigDevice", mock.Anything, mock.Anything).Run(track).Return(nil)
+	checkerNvml := mockednvml.Client{}
+	checkerNvml.On("HealthCheck", mock.Anything).Run(track).Return(nil)
+	actuatorClient := WithAccessLock(&actuatorNvml)
+	checkerClient := WithAccessLock(&checkerNvml)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, actuatorClient.DeleteMigDevice(context.Background(), "mig-1"))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, checkerClient.HealthCheck(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxRunning)
+	actuatorNvml.AssertNumberOfCalls(t, "DeleteMigDevice", 5)
+	checkerNvml.AssertNumberOfCalls(t, "HealthCheck", 5)
+}
+
+func TestWithAccessLock__CancelledContext(t *testing.T) {
+	nvmlClient := mockednvml.Client{}
+	client := WithAccessLock(&nvmlClient)
+
+	// Lock held by another operation: waiting for it is aborted when the context is cancelled
+	assert.NoError(t, acquireAccessLock(context.Background()))
+	defer releaseAccessLock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetGpuCount(ctx)
+	assert.Error(t, err)
+	nvmlClient.AssertNotCalled(t, "GetGpuCount", mock.Anything)
+}
+
+func TestWithAccessLock__AlreadyLocked(t *testing.T) {
+	client := WithAccessLock(&mockednvml.Client{})
+	assert.Same(t, client, WithAccessLock(client))
+}
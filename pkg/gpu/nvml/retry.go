@@ -0,0 +1,65 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"time"
+)
+
+const (
+	// maxTransientRetries is the max number of times an operation failed with a transient NVML error is retried
+	maxTransientRetries = 3
+)
+
+var (
+	// transientRetryInterval is the time waited before retrying an operation failed with a transient NVML error
+	transientRetryInterval = 2 * time.Second
+)
+
+// retryOnTransientError calls f and, as long as it fails with a transient error, calls it again
+// up to maxRetries times, waiting the provided interval between consecutive attempts.
+// Since each Client operation initializes and shuts down NVML, each retry re-initializes NVML.
+//
+// The error returned by the last attempt is returned. Non-transient errors are returned immediately.
+// If the context is cancelled, f is not called anymore and an error is returned.
+func retryOnTransientError(ctx context.Context, maxRetries int, interval time.Duration, f func() gpu.Error) gpu.Error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := f()
+	for i := 0; i < maxRetries && gpu.IsTransient(err); i++ {
+		select {
+		case <-ctx.Done():
+			return checkContext(ctx)
+		case <-time.After(interval):
+		}
+		err = f()
+	}
+	return err
+}
+
+// checkContext returns an error if the context provided as argument is cancelled or expired.
+// It is called between the visits of the devices and before each destructive NVML call, so that
+// long-running operations are aborted as soon as the context is cancelled.
+func checkContext(ctx context.Context) gpu.Error {
+	if err := ctx.Err(); err != nil {
+		return gpu.GenericErr.Errorf("NVML operation aborted: %s", err)
+	}
+	return nil
+}
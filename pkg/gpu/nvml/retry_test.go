@@ -0,0 +1,121 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRetryOnTransientError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		returnedErrs  []gpu.Error
+		maxRetries    int
+		expectedCalls int
+		expectedErr   gpu.Error
+	}{
+		{
+			name:          "Success at first attempt",
+			returnedErrs:  []gpu.Error{nil},
+			maxRetries:    3,
+			expectedCalls: 1,
+			expectedErr:   nil,
+		},
+		{
+			name:          "Transient error once, then success",
+			returnedErrs:  []gpu.Error{gpu.TransientErr.Errorf("ERROR_UNKNOWN"), nil},
+			maxRetries:    3,
+			expectedCalls: 2,
+			expectedErr:   nil,
+		},
+		{
+			name:          "Permanent errors are not retried",
+			returnedErrs:  []gpu.Error{gpu.GenericErr.Errorf("ERROR_INVALID_ARGUMENT")},
+			maxRetries:    3,
+			expectedCalls: 1,
+			expectedErr:   gpu.GenericErr.Errorf("ERROR_INVALID_ARGUMENT"),
+		},
+		{
+			name: "Retries are bounded",
+			returnedErrs: []gpu.Error{
+				gpu.TransientErr.Errorf("ERROR_UNKNOWN"),
+				gpu.TransientErr.Errorf("ERROR_UNKNOWN"),
+				gpu.TransientErr.Errorf("ERROR_UNKNOWN"),
+				nil,
+			},
+			maxRetries:    2,
+			expectedCalls: 3,
+			expectedErr:   gpu.TransientErr.Errorf("ERROR_UNKNOWN"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			err := retryOnTransientError(context.Background(), tt.maxRetries, 0, func() gpu.Error {
+				err := tt.returnedErrs[calls]
+				calls++
+				return err
+			})
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestRetryOnTransientError__CancelledContext(t *testing.T) {
+	t.Run("Cancelled context: f is never called", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var calls int
+		err := retryOnTransientError(ctx, 3, 0, func() gpu.Error {
+			calls++
+			return nil
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Context cancelled while retrying: retries stop", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int
+		err := retryOnTransientError(ctx, 3, time.Hour, func() gpu.Error {
+			calls++
+			cancel()
+			return gpu.TransientErr.Errorf("ERROR_UNKNOWN")
+		})
+		assert.Error(t, err)
+		assert.False(t, gpu.IsTransient(err))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestCheckContext(t *testing.T) {
+	assert.NoError(t, checkContext(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, checkContext(ctx))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	assert.Error(t, checkContext(ctx))
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysfsPciDevicesPath is the sysfs directory exposing the PCI devices of the host
+const sysfsPciDevicesPath = "/sys/bus/pci/devices"
+
+// pciAddress returns the address of the PCI device with the domain, bus and device numbers provided
+// as argument, in the format used by sysfs (e.g. "0000:3b:00.0")
+func pciAddress(domain, bus, device uint32) string {
+	return fmt.Sprintf("%04x:%02x:%02x.0", domain, bus, device)
+}
+
+// readNumaNode returns the NUMA node of the PCI device with the address provided as argument, reading it
+// from the sysfs directory provided as argument. It returns nil if the NUMA node is unknown.
+func readNumaNode(sysfsPath, address string) *int {
+	content, err := os.ReadFile(filepath.Join(sysfsPath, address, "numa_node"))
+	if err != nil {
+		return nil
+	}
+	numaNode, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || numaNode < 0 {
+		return nil
+	}
+	return &numaNode
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPciAddress(t *testing.T) {
+	assert.Equal(t, "0000:3b:00.0", pciAddress(0, 0x3b, 0))
+	assert.Equal(t, "0001:af:1f.0", pciAddress(1, 0xaf, 0x1f))
+}
+
+func TestReadNumaNode(t *testing.T) {
+	sysfsPath := t.TempDir()
+	writeNumaNode := func(address, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(sysfsPath, address), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(sysfsPath, address, "numa_node"), []byte(content), 0o644))
+	}
+	writeNumaNode("0000:3b:00.0", "1\n")
+	writeNumaNode("0000:5e:00.0", "-1\n")
+	writeNumaNode("0000:86:00.0", "foo")
+
+	numaNode := readNumaNode(sysfsPath, "0000:3b:00.0")
+	if assert.NotNil(t, numaNode) {
+		assert.Equal(t, 1, *numaNode)
+	}
+	// Devices without NUMA affinity report -1
+	assert.Nil(t, readNumaNode(sysfsPath, "0000:5e:00.0"))
+	assert.Nil(t, readNumaNode(sysfsPath, "0000:86:00.0"))
+	assert.Nil(t, readNumaNode(sysfsPath, "0000:af:00.0"))
+}
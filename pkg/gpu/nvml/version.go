@@ -0,0 +1,25 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import "fmt"
+
+// FormatCudaVersion converts the CUDA version returned by NVML, which is encoded as
+// 1000 * major + 10 * minor (e.g. 12020), to the format "<major>.<minor>" (e.g. "12.2")
+func FormatCudaVersion(version int) string {
+	return fmt.Sprintf("%d.%d", version/1000, (version%1000)/10)
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvml
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatCudaVersion(t *testing.T) {
+	testCases := []struct {
+		version  int
+		expected string
+	}{
+		{version: 12020, expected: "12.2"},
+		{version: 11080, expected: "11.8"},
+		{version: 12000, expected: "12.0"},
+		{version: 10010, expected: "10.1"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatCudaVersion(tt.version))
+		})
+	}
+}
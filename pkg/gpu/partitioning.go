@@ -38,6 +38,35 @@ func (g Geometry) Id() string {
 	return g.String()
 }
 
+// Equal returns true if the geometry provided as argument has the same slices with the same quantities,
+// considering slices with zero quantity equivalent to absent ones.
+func (g Geometry) Equal(other Geometry) bool {
+	for slice, quantity := range g {
+		if other[slice] != quantity {
+			return false
+		}
+	}
+	for slice, quantity := range other {
+		if g[slice] != quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// Subtract returns a new geometry containing, for each slice of g, its quantity minus the quantity
+// of the same slice in the geometry provided as argument, clamped at zero (e.g. total minus used
+// returns the free slices). Slices whose resulting quantity is zero are not included, and g is not modified.
+func (g Geometry) Subtract(other Geometry) Geometry {
+	res := make(Geometry, len(g))
+	for slice, quantity := range g {
+		if diff := quantity - other[slice]; diff > 0 {
+			res[slice] = diff
+		}
+	}
+	return res
+}
+
 func (g Geometry) String() string {
 	// Sort profiles
 	var orderedProfiles = make([]Slice, 0, len(g))
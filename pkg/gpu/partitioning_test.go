@@ -19,6 +19,7 @@ package gpu_test
 import (
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/test/factory"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -150,3 +151,107 @@ func TestGetPartitioningKind(t *testing.T) {
 		})
 	}
 }
+
+func TestGeometry__Equal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		first    gpu.Geometry
+		second   gpu.Geometry
+		expected bool
+	}{
+		{
+			name:     "Both empty",
+			first:    gpu.Geometry{},
+			second:   nil,
+			expected: true,
+		},
+		{
+			name:     "Same slices",
+			first:    gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+			second:   gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+			expected: true,
+		},
+		{
+			name:     "Zero-quantity slice and absent slice",
+			first:    gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 0},
+			second:   gpu.Geometry{mig.Profile1g10gb: 2},
+			expected: true,
+		},
+		{
+			name:     "Different quantities",
+			first:    gpu.Geometry{mig.Profile1g10gb: 2},
+			second:   gpu.Geometry{mig.Profile1g10gb: 1},
+			expected: false,
+		},
+		{
+			name:     "Different slices",
+			first:    gpu.Geometry{mig.Profile1g10gb: 1},
+			second:   gpu.Geometry{mig.Profile2g20gb: 1},
+			expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.first.Equal(tt.second))
+			assert.Equal(t, tt.expected, tt.second.Equal(tt.first))
+		})
+	}
+}
+
+func TestGeometry__Subtract(t *testing.T) {
+	testCases := []struct {
+		name     string
+		first    gpu.Geometry
+		second   gpu.Geometry
+		expected gpu.Geometry
+	}{
+		{
+			name:     "Both empty",
+			first:    gpu.Geometry{},
+			second:   nil,
+			expected: gpu.Geometry{},
+		},
+		{
+			name:     "Subtract empty geometry",
+			first:    gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+			second:   gpu.Geometry{},
+			expected: gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+		},
+		{
+			name:     "Subtract part of the slices",
+			first:    gpu.Geometry{mig.Profile1g10gb: 3, mig.Profile2g20gb: 1},
+			second:   gpu.Geometry{mig.Profile1g10gb: 1},
+			expected: gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+		},
+		{
+			name:     "Slices reaching zero are not included",
+			first:    gpu.Geometry{mig.Profile1g10gb: 2, mig.Profile2g20gb: 1},
+			second:   gpu.Geometry{mig.Profile1g10gb: 2},
+			expected: gpu.Geometry{mig.Profile2g20gb: 1},
+		},
+		{
+			name:     "Other exceeds self: quantities are clamped at zero",
+			first:    gpu.Geometry{mig.Profile1g10gb: 1, mig.Profile2g20gb: 2},
+			second:   gpu.Geometry{mig.Profile1g10gb: 3, mig.Profile2g20gb: 1},
+			expected: gpu.Geometry{mig.Profile2g20gb: 1},
+		},
+		{
+			name:     "Slices only in other are ignored",
+			first:    gpu.Geometry{mig.Profile1g10gb: 1},
+			second:   gpu.Geometry{mig.Profile3g40gb: 1},
+			expected: gpu.Geometry{mig.Profile1g10gb: 1},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			original := make(gpu.Geometry, len(tt.first))
+			for k, v := range tt.first {
+				original[k] = v
+			}
+			assert.Equal(t, tt.expected, tt.first.Subtract(tt.second))
+			assert.Equal(t, original, tt.first)
+		})
+	}
+}
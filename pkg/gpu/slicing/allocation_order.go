@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+)
+
+// AllocationOrder defines which profiles are allocated first when a GPU cannot provide
+// all the slices required at the same time
+type AllocationOrder string
+
+const (
+	// AllocationOrderSmallestFirst allocates the smaller profiles first, maximizing the number of
+	// slices that can be created
+	AllocationOrderSmallestFirst AllocationOrder = "smallest-first"
+	// AllocationOrderLargestFirst allocates the larger profiles first, so that large requests
+	// are not starved by the small ones
+	AllocationOrderLargestFirst AllocationOrder = "largest-first"
+)
+
+var allocationOrder = AllocationOrderSmallestFirst
+
+// Validate returns an error if the allocation order is not valid. An empty order is valid and
+// corresponds to AllocationOrderSmallestFirst.
+func (o AllocationOrder) Validate() error {
+	switch o {
+	case "", AllocationOrderSmallestFirst, AllocationOrderLargestFirst:
+		return nil
+	default:
+		return fmt.Errorf("invalid allocation order %q", o)
+	}
+}
+
+// Before returns true if, according to the allocation order, the slice a must be allocated before the slice b
+func (o AllocationOrder) Before(a, b gpu.Slice) bool {
+	if o == AllocationOrderLargestFirst {
+		return b.SmallerThan(a)
+	}
+	return a.SmallerThan(b)
+}
+
+// SetAllocationOrder sets the order in which the profiles are allocated on the GPUs when updating their geometry.
+// An empty order corresponds to AllocationOrderSmallestFirst.
+func SetAllocationOrder(order AllocationOrder) error {
+	if err := order.Validate(); err != nil {
+		return err
+	}
+	if order == "" {
+		order = AllocationOrderSmallestFirst
+	}
+	allocationOrder = order
+	return nil
+}
+
+// GetAllocationOrder returns the order in which the profiles are allocated on the GPUs
+func GetAllocationOrder() AllocationOrder {
+	return allocationOrder
+}
@@ -65,7 +65,7 @@ func (c tsClient) GetUsedDevices(ctx context.Context) (gpu.DeviceList, gpu.Error
 	}
 	usedGpus := util.Filter(usedResources, isNvidiaResource)
 	// Convert to gpu.DeviceList
-	return c.toGpuDeviceList(usedGpus)
+	return c.toGpuDeviceList(ctx, usedGpus)
 }
 
 func (c tsClient) GetAllocatableDevices(ctx context.Context) (gpu.DeviceList, gpu.Error) {
@@ -80,14 +80,14 @@ func (c tsClient) GetAllocatableDevices(ctx context.Context) (gpu.DeviceList, gp
 	}
 	allocatableGPUs := util.Filter(allocatableResources, isNvidiaResource)
 	// Extract MIG devices
-	return c.toGpuDeviceList(allocatableGPUs)
+	return c.toGpuDeviceList(ctx, allocatableGPUs)
 }
 
-func (c tsClient) toGpuDeviceList(resources []resource.Device) (gpu.DeviceList, gpu.Error) {
+func (c tsClient) toGpuDeviceList(ctx context.Context, resources []resource.Device) (gpu.DeviceList, gpu.Error) {
 	var res = make(gpu.DeviceList, len(resources))
 	for i, r := range resources {
 		id := ExtractGpuId(r.DeviceId)
-		index, err := c.nvmlClient.GetGpuIndex(id)
+		index, err := c.nvmlClient.GetGpuIndex(ctx, id)
 		if err != nil {
 			return nil, err
 		}
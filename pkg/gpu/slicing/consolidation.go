@@ -0,0 +1,218 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing
+
+import (
+	"sort"
+)
+
+// ConsolidationMove describes the move of a Pod consuming GPU slices from a GPU of a node
+// to another GPU of the same node.
+type ConsolidationMove struct {
+	Pod          PodRef
+	FromGpuIndex int
+	ToGpuIndex   int
+	// Slices are the slices used by the Pod that would be used on the target GPU
+	Slices map[ProfileName]int
+}
+
+// ConsolidationPlan is a set of moves that would consolidate the Pods consuming GPU slices
+// onto fewer GPUs of a node.
+type ConsolidationPlan struct {
+	Moves []ConsolidationMove
+	// FreedGPUs are the indexes of the GPUs that would not have any used slice after applying the moves
+	FreedGPUs []int
+}
+
+// IsEmpty returns true if the plan does not include any move
+func (p ConsolidationPlan) IsEmpty() bool {
+	return len(p.Moves) == 0
+}
+
+// PlanConsolidation analyzes the slices used on the healthy GPUs of the node and returns a plan that, if applied,
+// would free as many GPUs as possible by moving their Pods to the other GPUs of the node. The plan is computed
+// greedily, and the node is never modified.
+//
+// A GPU is freed only if all the Pods using its slices can be moved to GPUs that are already in use, either
+// by using their free slices or by creating new slices with their spare capacity. GPUs with used slices not
+// assigned to any known Pod are never freed, since their consumers cannot be moved. GPUs with fewer used slices
+// are freed first, and Pods are moved to the GPUs with more used slices first.
+func (n *Node) PlanConsolidation() ConsolidationPlan {
+	n.mtx.RLock()
+	gpus := make([]GPU, 0, len(n.GPUs))
+	for _, g := range n.GPUs {
+		if !g.Unhealthy {
+			gpus = append(gpus, g.Clone())
+		}
+	}
+	n.mtx.RUnlock()
+
+	plan := ConsolidationPlan{
+		Moves:     make([]ConsolidationMove, 0),
+		FreedGPUs: make([]int, 0),
+	}
+
+	// Sort GPUs by number of used slices, lower first
+	sort.SliceStable(gpus, func(i, j int) bool {
+		ui, uj := gpus[i].countUsedSlices(), gpus[j].countUsedSlices()
+		if ui != uj {
+			return ui < uj
+		}
+		return gpus[i].Index < gpus[j].Index
+	})
+
+	freed := make(map[int]bool)
+	receivers := make(map[int]bool)
+	for i := range gpus {
+		source := &gpus[i]
+		// GPUs receiving Pods are not freed, so that each Pod is moved at most once
+		if receivers[source.Index] || source.countUsedSlices() == 0 || !source.allUsedSlicesAssigned() {
+			continue
+		}
+
+		// Targets are the GPUs in use that are not being freed, the most used first
+		targets := make([]GPU, 0, len(gpus))
+		for _, g := range gpus {
+			if g.Index != source.Index && !freed[g.Index] && g.countUsedSlices() > 0 {
+				targets = append(targets, g.Clone())
+			}
+		}
+		sort.SliceStable(targets, func(a, b int) bool {
+			return targets[a].countUsedSlices() > targets[b].countUsedSlices()
+		})
+
+		moves, ok := planMoves(*source, targets)
+		if !ok {
+			continue
+		}
+
+		// Update the simulated GPUs with the result of the moves
+		for _, t := range targets {
+			for j := range gpus {
+				if gpus[j].Index == t.Index {
+					gpus[j] = t
+				}
+			}
+		}
+		for _, m := range moves {
+			source.releaseSlices(m.Pod)
+			receivers[m.ToGpuIndex] = true
+		}
+		freed[source.Index] = true
+		plan.Moves = append(plan.Moves, moves...)
+		plan.FreedGPUs = append(plan.FreedGPUs, source.Index)
+	}
+
+	sort.Ints(plan.FreedGPUs)
+	return plan
+}
+
+// planMoves returns the moves required for moving all the consumers of the source GPU to the
+// target GPUs provided as argument, which are modified accordingly. The boolean value is false
+// if any of the consumers cannot be moved.
+func planMoves(source GPU, targets []GPU) ([]ConsolidationMove, bool) {
+	consumers := make([]PodRef, 0, len(source.consumers))
+	for ref := range source.consumers {
+		consumers = append(consumers, ref)
+	}
+	sortPodRefs(consumers)
+
+	moves := make([]ConsolidationMove, 0, len(consumers))
+	for _, ref := range consumers {
+		slices := source.consumers[ref]
+		var moved bool
+		for i := range targets {
+			if err := targets[i].allocateSlices(ref, slices, source.activeThreads[ref]); err == nil {
+				moves = append(moves, ConsolidationMove{
+					Pod:          ref,
+					FromGpuIndex: source.Index,
+					ToGpuIndex:   targets[i].Index,
+					Slices:       slices,
+				})
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			return nil, false
+		}
+	}
+	return moves, true
+}
+
+// allocateSlices marks the slices provided as argument as used by the Pod provided as argument, which uses
+// the active thread percentage provided as argument, creating the missing slices with the spare capacity of
+// the GPU if needed. The GPU is not modified if an error is returned.
+func (g *GPU) allocateSlices(ref PodRef, slices map[ProfileName]int, activeThreads int) error {
+	if err := g.checkActiveThreads(activeThreads); err != nil {
+		return err
+	}
+	updated := g.Clone()
+	for p, q := range slices {
+		if missing := q - updated.FreeProfiles[p]; missing > 0 {
+			if err := updated.createSlices(p, updated.physicalSlices(missing)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := updated.checkFits(slices); err != nil {
+		return err
+	}
+	for p, q := range slices {
+		updated.FreeProfiles[p] -= q
+		updated.UsedProfiles[p] += q
+	}
+	updated.addConsumer(ref, slices)
+	updated.addActiveThreads(ref, activeThreads)
+	*g = updated
+	return nil
+}
+
+// releaseSlices frees the slices used by the Pod provided as argument
+func (g *GPU) releaseSlices(ref PodRef) {
+	for p, q := range g.consumers[ref] {
+		g.UsedProfiles[p] -= q
+		g.FreeProfiles[p] += q
+	}
+	delete(g.consumers, ref)
+	delete(g.activeThreads, ref)
+}
+
+// countUsedSlices returns the total number of used slices of the GPU
+func (g *GPU) countUsedSlices() int {
+	var res int
+	for _, q := range g.UsedProfiles {
+		res += q
+	}
+	return res
+}
+
+// allUsedSlicesAssigned returns true if all the used slices of the GPU are assigned to a consumer
+func (g *GPU) allUsedSlicesAssigned() bool {
+	assigned := make(map[ProfileName]int)
+	for _, profiles := range g.consumers {
+		for p, q := range profiles {
+			assigned[p] += q
+		}
+	}
+	for p, q := range g.UsedProfiles {
+		if assigned[p] < q {
+			return false
+		}
+	}
+	return true
+}
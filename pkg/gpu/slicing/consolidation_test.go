@@ -0,0 +1,154 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing_test
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"testing"
+)
+
+func TestNode__PlanConsolidation(t *testing.T) {
+	buildPod := func(name string, profile slicing.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithUID(name).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(profile.AsResourceName(), quantity).
+				Get(),
+		).Get()
+	}
+	labels := map[string]string{
+		constant.LabelNvidiaProduct: "foo",
+		constant.LabelNvidiaCount:   "3",
+		constant.LabelNvidiaMemory:  "40000",
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		pods        []v1.Pod
+		expected    slicing.ConsolidationPlan
+	}{
+		{
+			name: "Fragmented node: pods are consolidated onto a single GPU",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 2, "5gb", resource.StatusUsed):  "1",
+			},
+			pods: []v1.Pod{
+				buildPod("pd-1", "10gb", 1),
+				buildPod("pd-2", "20gb", 1),
+				buildPod("pd-3", "5gb", 1),
+			},
+			expected: slicing.ConsolidationPlan{
+				Moves: []slicing.ConsolidationMove{
+					{
+						Pod:          slicing.PodRef{Namespace: "ns-1", Name: "pd-1"},
+						FromGpuIndex: 0,
+						ToGpuIndex:   1,
+						Slices:       map[slicing.ProfileName]int{"10gb": 1},
+					},
+					{
+						Pod:          slicing.PodRef{Namespace: "ns-1", Name: "pd-3"},
+						FromGpuIndex: 2,
+						ToGpuIndex:   1,
+						Slices:       map[slicing.ProfileName]int{"5gb": 1},
+					},
+				},
+				FreedGPUs: []int{0, 2},
+			},
+		},
+		{
+			name: "Least used GPUs are freed first",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusUsed): "1",
+			},
+			pods: []v1.Pod{
+				buildPod("pd-1", "10gb", 2),
+				buildPod("pd-2", "20gb", 1),
+			},
+			expected: slicing.ConsolidationPlan{
+				Moves: []slicing.ConsolidationMove{
+					{
+						Pod:          slicing.PodRef{Namespace: "ns-1", Name: "pd-2"},
+						FromGpuIndex: 1,
+						ToGpuIndex:   0,
+						Slices:       map[slicing.ProfileName]int{"20gb": 1},
+					},
+				},
+				FreedGPUs: []int{1},
+			},
+		},
+		{
+			name: "Pods that do not fit other GPUs are not moved",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "30gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "30gb", resource.StatusUsed): "1",
+			},
+			pods: []v1.Pod{
+				buildPod("pd-1", "30gb", 1),
+				buildPod("pd-2", "30gb", 1),
+			},
+			expected: slicing.ConsolidationPlan{
+				Moves:     []slicing.ConsolidationMove{},
+				FreedGPUs: []int{},
+			},
+		},
+		{
+			name: "Used slices without known consumers are never moved",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusUsed): "1",
+			},
+			pods: []v1.Pod{},
+			expected: slicing.ConsolidationPlan{
+				Moves:     []slicing.ConsolidationMove{},
+				FreedGPUs: []int{},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(labels).WithAnnotations(tt.annotations).Get()
+			pods := make([]*v1.Pod, len(tt.pods))
+			for i := range tt.pods {
+				pods[i] = &tt.pods[i]
+			}
+			nodeInfo := framework.NewNodeInfo(pods...)
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			geometry := n.Geometry()
+
+			plan := n.PlanConsolidation()
+			assert.Equal(t, tt.expected, plan)
+			assert.Equal(t, len(tt.expected.Moves) == 0, plan.IsEmpty())
+
+			// The node should not be modified
+			assert.Equal(t, geometry, n.Geometry())
+		})
+	}
+}
@@ -22,4 +22,7 @@ const (
 	ReplicaGpuIdSeparator = "::"
 	// MinSliceMemoryGB is the smallest slice size that can be created on slicing shared GPUs.
 	MinSliceMemoryGB = 1
+	// maxActiveThreadPercentage is the max total percentage of the threads of a GPU that can be
+	// used by the MPS clients sharing it.
+	maxActiveThreadPercentage = 100
 )
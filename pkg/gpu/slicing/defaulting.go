@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	v1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	"strings"
+)
+
+// GetNamespaceDefaultProfile returns the profile specified by the v1alpha1.AnnotationDefaultGpuSlice
+// annotation of the namespace provided as argument. The returned bool is false if the namespace does
+// not have the annotation, while an error is returned if the annotation is not a valid profile.
+func GetNamespaceDefaultProfile(namespace v1.Namespace) (ProfileName, bool, error) {
+	value, ok := namespace.Annotations[v1alpha1.AnnotationDefaultGpuSlice]
+	if !ok {
+		return "", false, nil
+	}
+	profile := ProfileName(strings.TrimSpace(value))
+	if err := profile.Validate(); err != nil {
+		return "", false, fmt.Errorf("invalid %s annotation of namespace %s: %w", v1alpha1.AnnotationDefaultGpuSlice, namespace.Name, err)
+	}
+	return profile, true, nil
+}
+
+// ApplyNamespaceDefault returns a copy of the Pod provided as argument whose first container requests a slice
+// of the default profile of the namespace provided as argument (see GetNamespaceDefaultProfile), so that it
+// can be used by a mutating webhook. The returned bool is true if the Pod has been changed.
+//
+// The Pod is returned unchanged if the namespace does not specify a default profile, if the Pod does not have
+// any container, or if the Pod already requests any GPU resource, either through the resource requests of its
+// containers or through the v1alpha1.AnnotationGpuSliceRequest annotation.
+func ApplyNamespaceDefault(pod v1.Pod, namespace v1.Namespace) (v1.Pod, bool, error) {
+	profile, ok, err := GetNamespaceDefaultProfile(namespace)
+	if err != nil || !ok {
+		return pod, false, err
+	}
+	if len(pod.Spec.Containers) == 0 || requestsGpu(pod) {
+		return pod, false, nil
+	}
+
+	res := *pod.DeepCopy()
+	container := &res.Spec.Containers[0]
+	quantity := *k8sresource.NewQuantity(1, k8sresource.DecimalSI)
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = make(v1.ResourceList)
+	}
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = make(v1.ResourceList)
+	}
+	// Extended resources cannot be overcommitted, so requests and limits must be equal
+	container.Resources.Requests[profile.AsResourceName()] = quantity
+	container.Resources.Limits[profile.AsResourceName()] = quantity
+	return res, true, nil
+}
+
+// requestsGpu returns true if the Pod provided as argument requests any NVIDIA resource through the
+// resource requests or limits of its containers, or any GPU slice through its annotation
+func requestsGpu(pod v1.Pod) bool {
+	if _, ok := pod.Annotations[v1alpha1.AnnotationGpuSliceRequest]; ok {
+		return true
+	}
+	for r := range resource.ComputePodRequest(pod) {
+		if strings.HasPrefix(r.String(), constant.NvidiaResourcePrefix) {
+			return true
+		}
+	}
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			for r := range c.Resources.Limits {
+				if strings.HasPrefix(r.String(), constant.NvidiaResourcePrefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
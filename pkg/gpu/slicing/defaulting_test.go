@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+func TestApplyNamespaceDefault(t *testing.T) {
+	namespaceWithDefault := factory.BuildNamespace("ns-1").
+		WithAnnotations(map[string]string{v1alpha1.AnnotationDefaultGpuSlice: "10gb"}).
+		Get()
+
+	testCases := []struct {
+		name             string
+		pod              v1.Pod
+		namespace        v1.Namespace
+		expectedChanged  bool
+		expectedProfiles map[slicing.ProfileName]int
+		expectedErr      bool
+	}{
+		{
+			name: "Namespace without default, pod should be left untouched",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithContainer(factory.BuildContainer("c1", "img").Get()).
+				Get(),
+			namespace:        factory.BuildNamespace("ns-1").Get(),
+			expectedChanged:  false,
+			expectedProfiles: map[slicing.ProfileName]int{},
+			expectedErr:      false,
+		},
+		{
+			name: "Invalid default profile, should return error",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithContainer(factory.BuildContainer("c1", "img").Get()).
+				Get(),
+			namespace: factory.BuildNamespace("ns-1").
+				WithAnnotations(map[string]string{v1alpha1.AnnotationDefaultGpuSlice: "foo"}).
+				Get(),
+			expectedChanged:  false,
+			expectedProfiles: map[slicing.ProfileName]int{},
+			expectedErr:      true,
+		},
+		{
+			name: "Pod without GPU requests, default should be injected",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithContainer(factory.BuildContainer("c1", "img").WithCPUMilliRequest(100).Get()).
+				WithContainer(factory.BuildContainer("c2", "img").Get()).
+				Get(),
+			namespace:        namespaceWithDefault,
+			expectedChanged:  true,
+			expectedProfiles: map[slicing.ProfileName]int{"10gb": 1},
+			expectedErr:      false,
+		},
+		{
+			name: "Pod explicitly requesting a slice, should be left untouched",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithContainer(factory.BuildContainer("c1", "img").WithScalarResourceRequest("nvidia.com/gpu-20gb", 2).Get()).
+				Get(),
+			namespace:        namespaceWithDefault,
+			expectedChanged:  false,
+			expectedProfiles: map[slicing.ProfileName]int{"20gb": 2},
+			expectedErr:      false,
+		},
+		{
+			name: "Pod requesting a whole GPU, should be left untouched",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithContainer(factory.BuildContainer("c1", "img").WithNvidiaGPULimit(1).Get()).
+				Get(),
+			namespace:        namespaceWithDefault,
+			expectedChanged:  false,
+			expectedProfiles: map[slicing.ProfileName]int{},
+			expectedErr:      false,
+		},
+		{
+			name: "Pod requesting slices through annotation, should be left untouched",
+			pod: factory.BuildPod("ns-1", "pod-1").
+				WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "20gb x1").
+				WithContainer(factory.BuildContainer("c1", "img").Get()).
+				Get(),
+			namespace:        namespaceWithDefault,
+			expectedChanged:  false,
+			expectedProfiles: map[slicing.ProfileName]int{"20gb": 1},
+			expectedErr:      false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.pod.DeepCopy()
+			res, changed, err := slicing.ApplyNamespaceDefault(tt.pod, tt.namespace)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedChanged, changed)
+			assert.Equal(t, tt.expectedProfiles, slicing.GetRequestedProfiles(res))
+			assert.Equal(t, *original, tt.pod, "input pod should not be modified")
+			if changed {
+				resourceName := slicing.ProfileName("10gb").AsResourceName()
+				assert.Equal(t, int64(1), res.Spec.Containers[0].Resources.Limits.Name(resourceName, "").Value())
+				assert.NotContains(t, res.Spec.Containers[1].Resources.Requests, resourceName)
+			}
+		})
+	}
+}
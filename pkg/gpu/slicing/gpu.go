@@ -21,18 +21,57 @@ import (
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/util"
 	v1 "k8s.io/api/core/v1"
+	"math"
 	"sort"
 )
 
 type GPU struct {
-	Model        gpu.Model
-	Index        int
-	MemoryGB     int
+	Model    gpu.Model
+	Index    int
+	MemoryGB int
+	// Replicas is the number of time-slicing replicas the device plugin advertises for each
+	// slice of the GPU. Values lower than 1 mean that the slices are not time-shared.
+	Replicas int
+	// MemoryDerating is the fraction of MemoryGB that is not usable for creating slices (e.g. because of ECC
+	// overhead), so that the last slice is not advertised if it doesn't actually fit the GPU. Zero means
+	// that the whole memory is usable.
+	MemoryDerating float64
+	// Unhealthy is true if the GPU has been reported as unhealthy, in which case
+	// it should not be considered for hosting Pods.
+	Unhealthy bool
+	// Topology contains the optional topology information of the GPU, it is nil if unknown.
+	Topology     *Topology
 	UsedProfiles map[ProfileName]int
 	FreeProfiles map[ProfileName]int
+
+	// consumers contains, for each Pod using slices of the GPU, the quantity of used slices of each profile
+	consumers map[PodRef]map[ProfileName]int
+	// activeThreads contains the active thread percentage of the Pods using slices of the GPU
+	// that specify one
+	activeThreads map[PodRef]int
 }
 
-func NewFullGPU(model gpu.Model, index int, memoryGB int) GPU {
+// PodRef identifies a Pod consuming GPU slices.
+type PodRef struct {
+	Namespace string
+	Name      string
+}
+
+// NewPodRef returns the PodRef identifying the Pod provided as argument.
+func NewPodRef(pod v1.Pod) PodRef {
+	return PodRef{Namespace: pod.Namespace, Name: pod.Name}
+}
+
+func (p PodRef) String() string {
+	return fmt.Sprintf("%s/%s", p.Namespace, p.Name)
+}
+
+// NewGPUWithMemoryGB returns a GPU without any slice, whose total memory is the amount of GB
+// provided as argument (e.g. 40 for a 40 GB GPU).
+//
+// Note that the node label constant.LabelNvidiaMemory expresses the memory in MiB: use
+// gpu.GetMemoryGB for converting it to GB before calling this function.
+func NewGPUWithMemoryGB(model gpu.Model, index int, memoryGB int) GPU {
 	return GPU{
 		Model:        model,
 		Index:        index,
@@ -42,11 +81,27 @@ func NewFullGPU(model gpu.Model, index int, memoryGB int) GPU {
 	}
 }
 
+// NewFullGPU returns a GPU without any slice. The memory must be expressed in GB,
+// see NewGPUWithMemoryGB.
+func NewFullGPU(model gpu.Model, index int, memoryGB int) GPU {
+	return NewGPUWithMemoryGB(model, index, memoryGB)
+}
+
+// NewGPU returns a GPU with the used and free profiles provided as argument, or an error if
+// the profiles are not valid for the GPU. The memory must be expressed in GB.
 func NewGPU(model gpu.Model, index int, memoryGB int, usedProfiles, freeProfiles map[ProfileName]int) (GPU, error) {
+	return NewGPUWithReplicas(model, index, memoryGB, 0, usedProfiles, freeProfiles)
+}
+
+// NewGPUWithReplicas returns a GPU whose slices are time-shared by the number of replicas provided
+// as argument. The quantities of used and free profiles are expressed in terms of advertised replicas,
+// while the memory is expressed in GB.
+func NewGPUWithReplicas(model gpu.Model, index int, memoryGB int, replicas int, usedProfiles, freeProfiles map[ProfileName]int) (GPU, error) {
 	g := GPU{
 		Model:        model,
 		Index:        index,
 		MemoryGB:     memoryGB,
+		Replicas:     replicas,
 		UsedProfiles: usedProfiles,
 		FreeProfiles: freeProfiles,
 	}
@@ -56,6 +111,7 @@ func NewGPU(model gpu.Model, index int, memoryGB int, usedProfiles, freeProfiles
 	return g, nil
 }
 
+// NewGpuOrPanic is like NewGPU, but it panics if the GPU is not valid. The memory must be expressed in GB.
 func NewGpuOrPanic(model gpu.Model, index int, memoryGB int, usedProfiles, freeProfiles map[ProfileName]int) GPU {
 	g, err := NewGPU(model, index, memoryGB, usedProfiles, freeProfiles)
 	if err != nil {
@@ -65,8 +121,13 @@ func NewGpuOrPanic(model gpu.Model, index int, memoryGB int, usedProfiles, freeP
 }
 
 func (g *GPU) Validate() error {
-	var totalMemoryGB int
-	for p, q := range g.UsedProfiles {
+	for p := range g.UsedProfiles {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if p.IsFractional() {
+			continue
+		}
 		mem := p.GetMemorySizeGB()
 		if mem < MinSliceMemoryGB {
 			return fmt.Errorf(
@@ -76,9 +137,14 @@ func (g *GPU) Validate() error {
 				mem,
 			)
 		}
-		totalMemoryGB += mem * q
 	}
-	for p, q := range g.FreeProfiles {
+	for p := range g.FreeProfiles {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if p.IsFractional() {
+			continue
+		}
 		mem := p.GetMemorySizeGB()
 		if mem < MinSliceMemoryGB {
 			return fmt.Errorf(
@@ -88,14 +154,24 @@ func (g *GPU) Validate() error {
 				mem,
 			)
 		}
-		totalMemoryGB += mem * q
 	}
-	if totalMemoryGB > g.MemoryGB {
+	if g.hasFractionalSlices() && g.hasMemorySlices() {
+		return fmt.Errorf("cannot mix fractional and memory-based profiles on the same GPU")
+	}
+	if totalMemoryGB := g.getTotSlicesMemory(); totalMemoryGB > g.MemoryGB {
 		return fmt.Errorf("total memory of profiles (%d) exceeds GPU memory (%d)", totalMemoryGB, g.MemoryGB)
 	}
+	if totalFraction := g.getTotSlicesFraction(); totalFraction > 1+fractionTolerance {
+		return fmt.Errorf("total fraction of profiles (%g) exceeds the GPU", totalFraction)
+	}
 	return nil
 }
 
+// GetGeometry returns the advertised-replica view of the geometry of the GPU, namely the number of
+// slices of each profile advertised by the device plugin (used plus free). With time-slicing, each
+// physical slice is advertised as multiple replicas, so the geometry counts replicas rather than
+// physical slices: this is the view to use for anything related to the resources requested by Pods
+// (e.g. scheduling and quotas). See GetPhysicalGeometry for the physical-partition view.
 func (g *GPU) GetGeometry() gpu.Geometry {
 	geometry := make(gpu.Geometry)
 	for p, q := range g.UsedProfiles {
@@ -107,11 +183,29 @@ func (g *GPU) GetGeometry() gpu.Geometry {
 	return geometry
 }
 
+// GetPhysicalGeometry returns the physical-partition view of the geometry of the GPU, namely the number of
+// physical slices of each profile into which the GPU is partitioned, regardless of how many time-slicing
+// replicas the device plugin advertises for each of them. Without time-slicing it matches GetGeometry.
+func (g *GPU) GetPhysicalGeometry() gpu.Geometry {
+	geometry := make(gpu.Geometry)
+	for p, q := range g.GetGeometry() {
+		geometry[p] = g.physicalSlices(q)
+	}
+	return geometry
+}
+
 func (g *GPU) Clone() GPU {
 	cloned := GPU{
-		Model:    g.Model,
-		Index:    g.Index,
-		MemoryGB: g.MemoryGB,
+		Model:          g.Model,
+		Index:          g.Index,
+		MemoryGB:       g.MemoryGB,
+		Replicas:       g.Replicas,
+		MemoryDerating: g.MemoryDerating,
+		Unhealthy:      g.Unhealthy,
+	}
+	if g.Topology != nil {
+		topology := g.Topology.Clone()
+		cloned.Topology = &topology
 	}
 	if g.UsedProfiles != nil {
 		cloned.UsedProfiles = make(map[ProfileName]int)
@@ -125,12 +219,32 @@ func (g *GPU) Clone() GPU {
 			cloned.FreeProfiles[k] = v
 		}
 	}
+	if g.consumers != nil {
+		cloned.consumers = make(map[PodRef]map[ProfileName]int, len(g.consumers))
+		for ref, profiles := range g.consumers {
+			cloned.consumers[ref] = make(map[ProfileName]int, len(profiles))
+			for k, v := range profiles {
+				cloned.consumers[ref][k] = v
+			}
+		}
+	}
+	if g.activeThreads != nil {
+		cloned.activeThreads = make(map[PodRef]int, len(g.activeThreads))
+		for ref, p := range g.activeThreads {
+			cloned.activeThreads[ref] = p
+		}
+	}
 	return cloned
 }
 
+// HasFreeCapacity returns true if the GPU has any free advertised replica, or if its spare physical memory
+// is enough for creating more slices. Profiles left in FreeProfiles with a zero quantity, e.g. after AddPod
+// used all their replicas, are not considered free.
 func (g *GPU) HasFreeCapacity() bool {
-	if len(g.FreeProfiles) > 0 {
-		return true
+	for _, quantity := range g.FreeProfiles {
+		if quantity > 0 {
+			return true
+		}
 	}
 	return g.canCreateMoreSlices()
 }
@@ -138,9 +252,80 @@ func (g *GPU) HasFreeCapacity() bool {
 // AddPod adds a Pod to the GPU by updating the free and used slices according to the ones
 // requested by the Pod.
 //
-// AddPod returns an error if the GPU does not have enough free slices for the Pod.
+// AddPod returns an error if the GPU does not have enough free slices for the Pod, if the Pod
+// requests fractional slices and the GPU has memory-based slices (or vice versa), or if the active
+// thread percentage of the Pod would make the total percentage of the GPU exceed 100.
 func (g *GPU) AddPod(pod v1.Pod) error {
-	for r, q := range GetRequestedProfiles(pod) {
+	requested := GetRequestedProfiles(pod)
+	if err := g.checkFits(requested); err != nil {
+		return err
+	}
+	activeThreads, err := GetActiveThreadPercentage(pod)
+	if err != nil {
+		return err
+	}
+	if err = g.checkActiveThreads(activeThreads); err != nil {
+		return err
+	}
+	for r, q := range requested {
+		g.FreeProfiles[r] -= q
+		g.UsedProfiles[r] += q
+	}
+	ref := NewPodRef(pod)
+	g.addConsumer(ref, requested)
+	g.addActiveThreads(ref, activeThreads)
+	return nil
+}
+
+// checkActiveThreads returns an error if adding a Pod with the active thread percentage provided
+// as argument would make the total active thread percentage of the GPU exceed 100.
+// Zero means that the Pod does not specify any percentage, and it always fits.
+func (g *GPU) checkActiveThreads(percentage int) error {
+	if percentage == 0 {
+		return nil
+	}
+	if total := g.getActiveThreads(); total+percentage > maxActiveThreadPercentage {
+		return fmt.Errorf(
+			"not enough active threads (pod requests %d%%, but GPU only has %d%%)",
+			percentage,
+			maxActiveThreadPercentage-total,
+		)
+	}
+	return nil
+}
+
+// getActiveThreads returns the total active thread percentage of the Pods using slices of the GPU
+func (g *GPU) getActiveThreads() int {
+	var res int
+	for _, p := range g.activeThreads {
+		res += p
+	}
+	return res
+}
+
+// addActiveThreads records the active thread percentage of the Pod provided as argument, if any
+func (g *GPU) addActiveThreads(ref PodRef, percentage int) {
+	if percentage == 0 {
+		return
+	}
+	if g.activeThreads == nil {
+		g.activeThreads = make(map[PodRef]int)
+	}
+	g.activeThreads[ref] = percentage
+}
+
+// checkFits returns an error if the GPU does not have enough free slices for the requested ones
+// provided as argument.
+func (g *GPU) checkFits(requested map[ProfileName]int) error {
+	for r := range requested {
+		if r.IsFractional() && g.hasMemorySlices() || !r.IsFractional() && g.hasFractionalSlices() {
+			return fmt.Errorf(
+				"cannot mix fractional and memory-based profiles on the same GPU (pod requests %s)",
+				r,
+			)
+		}
+	}
+	for r, q := range requested {
 		if g.FreeProfiles[r] < q {
 			return fmt.Errorf(
 				"not enough free slices (pod requests %d %s, but GPU only has %d)",
@@ -149,14 +334,95 @@ func (g *GPU) AddPod(pod v1.Pod) error {
 				g.FreeProfiles[r],
 			)
 		}
-		g.FreeProfiles[r] -= q
-		g.UsedProfiles[r] += q
 	}
 	return nil
 }
 
+// RemovePod removes a Pod from the GPU by releasing the used slices requested by the Pod, which
+// become free again.
+//
+// RemovePod returns an error if the GPU does not have enough used slices matching the ones requested by the Pod.
+func (g *GPU) RemovePod(pod v1.Pod) error {
+	requested := GetRequestedProfiles(pod)
+	for r, q := range requested {
+		if g.UsedProfiles[r] < q {
+			return fmt.Errorf(
+				"not enough used slices (pod requests %d %s, but GPU only has %d)",
+				q,
+				r,
+				g.UsedProfiles[r],
+			)
+		}
+	}
+	for r, q := range requested {
+		g.UsedProfiles[r] -= q
+		g.FreeProfiles[r] += q
+	}
+	delete(g.consumers, NewPodRef(pod))
+	delete(g.activeThreads, NewPodRef(pod))
+	return nil
+}
+
+// GetSliceConsumers returns, for each profile, the Pods using slices of that profile on the GPU,
+// sorted by namespace and name.
+func (g *GPU) GetSliceConsumers() map[ProfileName][]PodRef {
+	res := make(map[ProfileName][]PodRef)
+	for ref, profiles := range g.consumers {
+		for p, q := range profiles {
+			if q > 0 {
+				res[p] = append(res[p], ref)
+			}
+		}
+	}
+	for _, refs := range res {
+		sortPodRefs(refs)
+	}
+	return res
+}
+
+// addConsumer records the Pod provided as argument as consumer of the used slices provided as argument
+func (g *GPU) addConsumer(ref PodRef, slices map[ProfileName]int) {
+	if len(slices) == 0 {
+		return
+	}
+	if g.consumers == nil {
+		g.consumers = make(map[PodRef]map[ProfileName]int)
+	}
+	if g.consumers[ref] == nil {
+		g.consumers[ref] = make(map[ProfileName]int, len(slices))
+	}
+	for p, q := range slices {
+		g.consumers[ref][p] += q
+	}
+}
+
+// hasUnassignedUsedSlices returns true if the GPU has enough used slices that are not assigned
+// to any consumer for covering the slices provided as argument.
+func (g *GPU) hasUnassignedUsedSlices(slices map[ProfileName]int) bool {
+	for p, q := range slices {
+		assigned := 0
+		for _, profiles := range g.consumers {
+			assigned += profiles[p]
+		}
+		if g.UsedProfiles[p]-assigned < q {
+			return false
+		}
+	}
+	return true
+}
+
+func sortPodRefs(refs []PodRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		return refs[i].Name < refs[j].Name
+	})
+}
+
 // UpdateGeometryFor tries to update the geometry of the GPU in order to create the highest possible number of required
-// slices provided as argument, without deleting any of the used slices.
+// slices provided as argument, without deleting any of the used slices. If the GPU cannot provide all the required
+// slices, the profiles are created in the order set through SetAllocationOrder (smaller profiles first by default).
 //
 // The method returns true if the GPU geometry gets updated, false otherwise.
 func (g *GPU) UpdateGeometryFor(slices map[gpu.Slice]int) bool {
@@ -170,49 +436,47 @@ func (g *GPU) UpdateGeometryFor(slices map[gpu.Slice]int) bool {
 	var updated bool
 	var originalFreeProfiles = util.CopyMap(g.FreeProfiles)
 
-	// Sort missing slices by size (smaller first)
+	// Sort missing slices by size, according to the allocation order
 	sortedMissingSlices := make([]gpu.Slice, 0, len(missingSlices))
 	for slice := range missingSlices {
 		sortedMissingSlices = append(sortedMissingSlices, slice)
 	}
 	sort.SliceStable(sortedMissingSlices, func(i, j int) bool {
-		first := sortedMissingSlices[i].(ProfileName)
-		second := sortedMissingSlices[j].(ProfileName)
-		return first.GetMemorySizeGB() < second.GetMemorySizeGB()
+		return allocationOrder.Before(sortedMissingSlices[i], sortedMissingSlices[j])
 	})
 
 	for _, s := range sortedMissingSlices {
 		missingProfile := s.(ProfileName)
-		missingProfileMemory := missingProfile.GetMemorySizeGB()
 		// first try to create the missing slices by using spare capacity
 		if g.canCreateMoreSlices() {
-			q := missingSlices[missingProfile]
-			for i := 0; i < q; i++ {
-				if err := g.createSlice(missingProfileMemory); err != nil {
+			for missingSlices[missingProfile] > 0 {
+				if err := g.createSlice(missingProfile); err != nil {
 					break
 				}
-				missingSlices[missingProfile]--
+				missingSlices[missingProfile] -= g.getReplicas()
 				updated = true
 			}
 		}
+		if missingSlices[missingProfile] <= 0 {
+			continue
+		}
 		// then try to free up space by deleting the initial free slices
-		q := missingSlices[missingProfile]
 		for k := range originalFreeProfiles {
 			delete(g.FreeProfiles, k)
 		}
-		for i := 0; i < q; i++ {
+		for missingSlices[missingProfile] > 0 {
 			if !g.canCreateMoreSlices() {
 				break
 			}
-			if err := g.createSlice(missingProfileMemory); err != nil {
+			if err := g.createSlice(missingProfile); err != nil {
 				break
 			}
-			missingSlices[missingProfile]--
+			missingSlices[missingProfile] -= g.getReplicas()
 			updated = true
 		}
 		// try to restore the original free slices
 		for k, v := range originalFreeProfiles {
-			_ = g.createSlices(k.GetMemorySizeGB(), v)
+			_ = g.createSlices(k, g.physicalSlices(v))
 		}
 	}
 
@@ -231,35 +495,133 @@ func (g *GPU) getMissingSlices(required map[gpu.Slice]int) map[gpu.Slice]int {
 	return missingSlices
 }
 
-func (g *GPU) createSlice(sizeGb int) error {
-	return g.createSlices(sizeGb, 1)
+func (g *GPU) createSlice(profile ProfileName) error {
+	return g.createSlices(profile, 1)
 }
 
-func (g *GPU) createSlices(sizeGb, num int) error {
-	totSlicesMemory := g.getTotSlicesMemory()
-	spareMemory := g.MemoryGB - totSlicesMemory
+func (g *GPU) createSlices(profile ProfileName, num int) error {
+	if profile.IsFractional() {
+		return g.createFractionalSlices(profile, num)
+	}
+	if g.hasFractionalSlices() {
+		return fmt.Errorf("cannot create memory-based slices on a GPU with fractional slices")
+	}
+	sizeGb := profile.GetMemorySizeGB()
+	spareMemory := g.getSpareMemory()
 	if spareMemory < sizeGb*num {
 		return fmt.Errorf("not enough spare memory to create %d slices of size %dGB", num, sizeGb)
 	}
 	sliceProfile := NewProfile(sizeGb)
-	g.FreeProfiles[sliceProfile] += num
+	g.FreeProfiles[sliceProfile] += num * g.getReplicas()
+	return nil
+}
+
+func (g *GPU) createFractionalSlices(profile ProfileName, num int) error {
+	if g.hasMemorySlices() {
+		return fmt.Errorf("cannot create fractional slices on a GPU with memory-based slices")
+	}
+	spareFraction := 1 - g.getTotSlicesFraction()
+	if spareFraction+fractionTolerance < profile.GetFraction()*float64(num) {
+		return fmt.Errorf("not enough spare capacity to create %d slices of profile %s", num, profile)
+	}
+	g.FreeProfiles[profile] += num * g.getReplicas()
 	return nil
 }
 
 // canCreateMoreSlices returns true if the GPU has enough free space to create more slices, false otherwise
 func (g *GPU) canCreateMoreSlices() bool {
-	totSlicesMemory := g.getTotSlicesMemory()
-	spareMemory := g.MemoryGB - totSlicesMemory
-	return spareMemory >= MinSliceMemoryGB
+	if g.hasFractionalSlices() {
+		return 1-g.getTotSlicesFraction() > fractionTolerance
+	}
+	return g.getSpareMemory() >= MinSliceMemoryGB
+}
+
+// getSpareMemory returns the amount of usable GPU memory not taken by any slice
+func (g *GPU) getSpareMemory() int {
+	return g.getUsableMemory() - g.getTotSlicesMemory()
 }
 
+// getUsableMemory returns the amount of GPU memory that can be used for creating slices, namely
+// the memory of the GPU reduced by its memory derating, rounded down to the nearest GB
+func (g *GPU) getUsableMemory() int {
+	if g.MemoryDerating <= 0 {
+		return g.MemoryGB
+	}
+	return int(math.Floor(float64(g.MemoryGB)*(1-g.MemoryDerating) + fractionTolerance))
+}
+
+// getTotSlicesMemory returns the amount of GPU memory taken by the slices of the GPU. Slices time-shared
+// by multiple replicas take memory only once.
 func (g *GPU) getTotSlicesMemory() int {
-	var totSlicesMemory int
+	var advertised = make(map[ProfileName]int)
 	for p, q := range g.UsedProfiles {
-		totSlicesMemory += p.GetMemorySizeGB() * q
+		advertised[p] += q
 	}
 	for p, q := range g.FreeProfiles {
-		totSlicesMemory += p.GetMemorySizeGB() * q
+		advertised[p] += q
+	}
+	var totSlicesMemory int
+	for p, q := range advertised {
+		// Malformed profiles are rejected by Validate, here they don't take any memory
+		memoryGB, err := p.GetMemoryGB()
+		if err != nil {
+			continue
+		}
+		totSlicesMemory += memoryGB * g.physicalSlices(q)
 	}
 	return totSlicesMemory
 }
+
+// getTotSlicesFraction returns the fraction of the GPU taken by its fractional slices. Slices time-shared
+// by multiple replicas are counted only once.
+func (g *GPU) getTotSlicesFraction() float64 {
+	var advertised = make(map[ProfileName]int)
+	for p, q := range g.UsedProfiles {
+		advertised[p] += q
+	}
+	for p, q := range g.FreeProfiles {
+		advertised[p] += q
+	}
+	var totFraction float64
+	for p, q := range advertised {
+		totFraction += p.GetFraction() * float64(g.physicalSlices(q))
+	}
+	return totFraction
+}
+
+// hasFractionalSlices returns true if the GPU has at least one fractional slice, either used or free
+func (g *GPU) hasFractionalSlices() bool {
+	return g.hasSlicesMatching(ProfileName.IsFractional)
+}
+
+// hasMemorySlices returns true if the GPU has at least one memory-based slice, either used or free
+func (g *GPU) hasMemorySlices() bool {
+	return g.hasSlicesMatching(func(p ProfileName) bool { return !p.IsFractional() })
+}
+
+func (g *GPU) hasSlicesMatching(match func(ProfileName) bool) bool {
+	for p, q := range g.UsedProfiles {
+		if q > 0 && match(p) {
+			return true
+		}
+	}
+	for p, q := range g.FreeProfiles {
+		if q > 0 && match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// physicalSlices returns the number of slices that back the number of advertised replicas provided as argument.
+func (g *GPU) physicalSlices(replicas int) int {
+	r := g.getReplicas()
+	return (replicas + r - 1) / r
+}
+
+func (g *GPU) getReplicas() int {
+	if g.Replicas < 1 {
+		return 1
+	}
+	return g.Replicas
+}
@@ -17,8 +17,10 @@
 package slicing_test
 
 import (
+	"fmt"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -329,6 +331,42 @@ func TestGPU_UpdateGeometryFor(t *testing.T) {
 				slicing.ProfileName("15gb"): 1,
 			},
 		},
+		{
+			name: "Small profiles, should create as many slices as the GPU memory allows",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				10,
+				map[slicing.ProfileName]int{},
+				map[slicing.ProfileName]int{},
+			),
+			requiredSlices: map[gpu.Slice]int{
+				slicing.ProfileName("2gb"): 10,
+			},
+			expectedUpdate: true,
+			expectedGeometry: map[gpu.Slice]int{
+				slicing.ProfileName("2gb"): 5,
+			},
+		},
+		{
+			name: "GPU with free slices of the required profile, created slices should be kept",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A100_PCIe_80GB,
+				0,
+				40,
+				map[slicing.ProfileName]int{},
+				map[slicing.ProfileName]int{
+					"10gb": 1,
+				},
+			),
+			requiredSlices: map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 2,
+			},
+			expectedUpdate: true,
+			expectedGeometry: map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 2,
+			},
+		},
 	}
 
 	for _, tt := range testCases {
@@ -341,6 +379,75 @@ func TestGPU_UpdateGeometryFor(t *testing.T) {
 	}
 }
 
+func TestGPU_UpdateGeometryFor__AllocationOrder(t *testing.T) {
+	defer func() { _ = slicing.SetAllocationOrder(slicing.AllocationOrderSmallestFirst) }()
+
+	testCases := []struct {
+		name             string
+		order            slicing.AllocationOrder
+		expectedGeometry gpu.Geometry
+	}{
+		{
+			name:  "Smallest first: smaller slices are created first",
+			order: slicing.AllocationOrderSmallestFirst,
+			expectedGeometry: map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 4,
+			},
+		},
+		{
+			name:  "Empty order defaults to smallest first",
+			order: "",
+			expectedGeometry: map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 4,
+			},
+		},
+		{
+			name:  "Largest first: larger slices are created first",
+			order: slicing.AllocationOrderLargestFirst,
+			expectedGeometry: map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 2,
+				slicing.ProfileName("20gb"): 1,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, slicing.SetAllocationOrder(tt.order))
+
+			// Partially-full GPU: 20 GB are used, and the spare 20 GB cannot provide all the required slices
+			g := slicing.NewGpuOrPanic(
+				gpu.GPUModel_A100_PCIe_80GB,
+				0,
+				40,
+				map[slicing.ProfileName]int{
+					"10gb": 2,
+				},
+				map[slicing.ProfileName]int{},
+			)
+			updated := g.UpdateGeometryFor(map[gpu.Slice]int{
+				slicing.ProfileName("10gb"): 2,
+				slicing.ProfileName("20gb"): 1,
+			})
+			assert.True(t, updated)
+			assert.Equal(t, tt.expectedGeometry, g.GetGeometry())
+		})
+	}
+}
+
+func TestSetAllocationOrder(t *testing.T) {
+	defer func() { _ = slicing.SetAllocationOrder(slicing.AllocationOrderSmallestFirst) }()
+
+	assert.NoError(t, slicing.SetAllocationOrder(slicing.AllocationOrderLargestFirst))
+	assert.Equal(t, slicing.AllocationOrderLargestFirst, slicing.GetAllocationOrder())
+
+	assert.Error(t, slicing.SetAllocationOrder("foo"))
+	assert.Equal(t, slicing.AllocationOrderLargestFirst, slicing.GetAllocationOrder())
+
+	assert.NoError(t, slicing.SetAllocationOrder(""))
+	assert.Equal(t, slicing.AllocationOrderSmallestFirst, slicing.GetAllocationOrder())
+}
+
 func TestGPU__Clone(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -385,3 +492,268 @@ func TestGPU__Clone(t *testing.T) {
 		})
 	}
 }
+
+func TestGPU__GetGeometry(t *testing.T) {
+	testCases := []struct {
+		name             string
+		gpu              slicing.GPU
+		expectedGeometry gpu.Geometry
+	}{
+		{
+			name:             "Full GPU",
+			gpu:              slicing.NewFullGPU(gpu.GPUModel_A100_SXM4_40GB, 0, 40),
+			expectedGeometry: gpu.Geometry{},
+		},
+		{
+			name: "GPU with both free and used profiles",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A100_PCIe_80GB,
+				0,
+				80,
+				map[slicing.ProfileName]int{
+					"10gb": 2,
+					"20gb": 1,
+				},
+				map[slicing.ProfileName]int{
+					"10gb": 1,
+					"5gb":  2,
+				},
+			),
+			expectedGeometry: gpu.Geometry{
+				slicing.ProfileName("5gb"):  2,
+				slicing.ProfileName("10gb"): 3,
+				slicing.ProfileName("20gb"): 1,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedGeometry, tt.gpu.GetGeometry())
+		})
+	}
+}
+
+func TestGPU__HasFreeCapacity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		gpu      slicing.GPU
+		expected bool
+	}{
+		{
+			name: "Small profiles, all free",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				10,
+				map[slicing.ProfileName]int{},
+				map[slicing.ProfileName]int{
+					"2gb": 5,
+				},
+			),
+			expected: true,
+		},
+		{
+			name: "Small profiles, all used and no space left",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				10,
+				map[slicing.ProfileName]int{
+					"2gb": 5,
+				},
+				map[slicing.ProfileName]int{},
+			),
+			expected: false,
+		},
+		{
+			name: "Small profiles, all used but space for more slices",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				10,
+				map[slicing.ProfileName]int{
+					"2gb": 4,
+				},
+				map[slicing.ProfileName]int{},
+			),
+			expected: true,
+		},
+		{
+			name: "Single profile taking the whole GPU memory is used",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				20,
+				map[slicing.ProfileName]int{
+					"20gb": 1,
+				},
+				map[slicing.ProfileName]int{},
+			),
+			expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.gpu.HasFreeCapacity())
+		})
+	}
+}
+
+func TestGPU__RemovePod(t *testing.T) {
+	pod := factory.BuildPod("ns-1", "pd-1").WithContainer(
+		factory.BuildContainer("c-1", "foo").
+			WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 2).
+			Get(),
+	).Get()
+
+	testCases := []struct {
+		name                 string
+		gpu                  slicing.GPU
+		expectedUsedProfiles map[slicing.ProfileName]int
+		expectedFreeProfiles map[slicing.ProfileName]int
+		errExpected          bool
+	}{
+		{
+			name: "GPU has enough used slices, should release them",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				40,
+				map[slicing.ProfileName]int{"10gb": 3},
+				map[slicing.ProfileName]int{"10gb": 1},
+			),
+			expectedUsedProfiles: map[slicing.ProfileName]int{"10gb": 1},
+			expectedFreeProfiles: map[slicing.ProfileName]int{"10gb": 3},
+		},
+		{
+			name: "GPU does not have enough used slices, should return error",
+			gpu: slicing.NewGpuOrPanic(
+				gpu.GPUModel_A30,
+				0,
+				40,
+				map[slicing.ProfileName]int{"10gb": 1},
+				map[slicing.ProfileName]int{"10gb": 3},
+			),
+			expectedUsedProfiles: map[slicing.ProfileName]int{"10gb": 1},
+			expectedFreeProfiles: map[slicing.ProfileName]int{"10gb": 3},
+			errExpected:          true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.gpu
+			err := g.RemovePod(pod)
+			if tt.errExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedUsedProfiles, g.UsedProfiles)
+			assert.Equal(t, tt.expectedFreeProfiles, g.FreeProfiles)
+		})
+	}
+}
+
+func TestGPU__FractionalProfiles(t *testing.T) {
+	t.Run("Mixing fractional and memory-based profiles", func(t *testing.T) {
+		_, err := slicing.NewGPU(
+			gpu.GPUModel_A100_PCIe_80GB,
+			0,
+			80,
+			map[slicing.ProfileName]int{"0.25": 1},
+			map[slicing.ProfileName]int{"10gb": 1},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("Sum of fractions exceeds GPU", func(t *testing.T) {
+		_, err := slicing.NewGPU(
+			gpu.GPUModel_A100_PCIe_80GB,
+			0,
+			80,
+			map[slicing.ProfileName]int{"0.5": 1},
+			map[slicing.ProfileName]int{"0.25": 3},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("Four quarter slices fit a GPU, a fifth does not", func(t *testing.T) {
+		g := slicing.NewFullGPU(gpu.GPUModel_A100_PCIe_80GB, 0, 80)
+		assert.True(t, g.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("0.25"): 5}))
+		assert.Equal(t, map[slicing.ProfileName]int{"0.25": 4}, g.FreeProfiles)
+
+		for i := 0; i < 4; i++ {
+			pod := factory.BuildPod("ns-1", fmt.Sprintf("pd-%d", i)).
+				WithContainer(
+					factory.BuildContainer("c-1", "foo").
+						WithScalarResourceRequest(slicing.ProfileName("0.25").AsResourceName(), 1).
+						Get(),
+				).
+				Get()
+			assert.NoError(t, g.AddPod(pod))
+		}
+		pod := factory.BuildPod("ns-1", "pd-4").
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("0.25").AsResourceName(), 1).
+					Get(),
+			).
+			Get()
+		assert.Error(t, g.AddPod(pod))
+	})
+
+	t.Run("Memory-based slices cannot be created on a GPU with fractional slices", func(t *testing.T) {
+		g := slicing.NewGpuOrPanic(
+			gpu.GPUModel_A100_PCIe_80GB,
+			0,
+			80,
+			map[slicing.ProfileName]int{"0.5": 1},
+			map[slicing.ProfileName]int{},
+		)
+		assert.True(t, g.HasFreeCapacity())
+		assert.False(t, g.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("10gb"): 1}))
+
+		pod := factory.BuildPod("ns-1", "pd-1").
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			).
+			Get()
+		assert.Error(t, g.AddPod(pod))
+	})
+}
+
+func TestGPU__MemoryDerating(t *testing.T) {
+	t.Run("Last 20gb slice does not fit the usable memory of a 80GB GPU", func(t *testing.T) {
+		g := slicing.NewFullGPU(gpu.GPUModel_A100_PCIe_80GB, 0, 80)
+		g.MemoryDerating = 0.0125 // ~79GB usable
+		assert.True(t, g.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("20gb"): 4}))
+		assert.Equal(t, map[slicing.ProfileName]int{"20gb": 3}, g.FreeProfiles)
+	})
+
+	t.Run("Without derating the whole memory is usable", func(t *testing.T) {
+		g := slicing.NewFullGPU(gpu.GPUModel_A100_PCIe_80GB, 0, 80)
+		assert.True(t, g.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("20gb"): 4}))
+		assert.Equal(t, map[slicing.ProfileName]int{"20gb": 4}, g.FreeProfiles)
+	})
+
+	t.Run("Derated memory is not advertised as free capacity", func(t *testing.T) {
+		g := slicing.NewGpuOrPanic(
+			gpu.GPUModel_A100_PCIe_80GB,
+			0,
+			80,
+			map[slicing.ProfileName]int{"20gb": 3, "19gb": 1},
+			map[slicing.ProfileName]int{},
+		)
+		assert.True(t, g.HasFreeCapacity())
+
+		g.MemoryDerating = 0.0125
+		assert.False(t, g.HasFreeCapacity())
+		cloned := g.Clone()
+		assert.False(t, cloned.HasFreeCapacity())
+	})
+}
@@ -18,15 +18,29 @@ package slicing
 
 import (
 	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
+// Node represents a node whose GPUs can be shared through slicing.
+//
+// The methods of Node are safe for concurrent use: mutations of the GPU profiles (e.g. AddPod) and reads
+// (e.g. Geometry, HasFreeCapacity) are serialized through an internal RWMutex. Direct accesses to the
+// GPUs field are not guarded and must be coordinated by the caller.
 type Node struct {
 	Name     string
 	GPUs     []GPU
 	nodeInfo framework.NodeInfo
+
+	mtx sync.RWMutex
 }
 
 func NewNode(n framework.NodeInfo) (Node, error) {
@@ -38,6 +52,7 @@ func NewNode(n framework.NodeInfo) (Node, error) {
 	if err != nil {
 		return Node{}, err
 	}
+	assignConsumers(gpus, n.Pods)
 	return Node{
 		Name:     node.Name,
 		GPUs:     gpus,
@@ -76,16 +91,18 @@ func extractGPUs(n v1.Node) ([]GPU, error) {
 				freeProfiles[profileName] = a.Quantity
 			}
 		}
-		g, err := NewGPU(
+		g, err := NewGPUWithReplicas(
 			gpuModel,
 			gpuIndex,
 			gpuMemoryGB,
+			getReplicas(n, gpuIndex),
 			usedProfiles,
 			freeProfiles,
 		)
 		if err != nil {
 			return nil, err
 		}
+		setGpuAttributes(n, &g)
 		result = append(result, g)
 	}
 
@@ -93,18 +110,150 @@ func extractGPUs(n v1.Node) ([]GPU, error) {
 	// (e.g. GPUs enabled but without any slicing replica/profile)
 	nGpus := len(result)
 	for i := nGpus; i < gpuCount; i++ {
-		g := NewFullGPU(
+		g := NewGPUWithMemoryGB(
 			gpuModel,
 			i,
 			gpuMemoryGB,
 		)
+		g.Replicas = getReplicas(n, i)
+		setGpuAttributes(n, &g)
 		result = append(result, g)
 	}
 
 	return result, nil
 }
 
+// assignConsumers assigns the used slices of the GPUs provided as argument to the Pods requesting them,
+// so that each Pod is recorded as consumer of the slices of the first healthy GPU having enough used slices
+// not yet assigned to other Pods. Used slices that cannot be matched with any Pod remain unassigned.
+func assignConsumers(gpus []GPU, pods []*framework.PodInfo) {
+	for _, pi := range pods {
+		if pi == nil || pi.Pod == nil {
+			continue
+		}
+		requested := GetRequestedProfiles(*pi.Pod)
+		if len(requested) == 0 {
+			continue
+		}
+		for i := range gpus {
+			g := &gpus[i]
+			if g.Unhealthy || !g.hasUnassignedUsedSlices(requested) {
+				continue
+			}
+			g.addConsumer(NewPodRef(*pi.Pod), requested)
+			if activeThreads, err := GetActiveThreadPercentage(*pi.Pod); err == nil {
+				g.addActiveThreads(NewPodRef(*pi.Pod), activeThreads)
+			}
+			break
+		}
+	}
+}
+
+// setGpuAttributes sets the health, the memory derating and the topology of the GPU provided as argument
+// from the annotations of the node.
+func setGpuAttributes(n v1.Node, g *GPU) {
+	g.Unhealthy = isUnhealthy(n, g.Index)
+	g.MemoryDerating = getMemoryDerating(n, g.Index)
+	g.Topology = getTopology(n, g.Index)
+}
+
+// getReplicas returns the number of time-slicing replicas advertised for each slice of the GPU
+// with the index provided as argument, or 0 if the node does not expose such information.
+// Invalid values are logged and ignored.
+func getReplicas(n v1.Node, gpuIndex int) int {
+	key := fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, gpuIndex)
+	val, ok := n.Annotations[key]
+	if !ok {
+		return 0
+	}
+	replicas, err := strconv.Atoi(val)
+	if err != nil || replicas < 1 {
+		logInvalidAnnotation(n, key, val)
+		return 0
+	}
+	return replicas
+}
+
+// getMemoryDerating returns the fraction of the memory of the GPU with the index provided as argument
+// that is not usable, or 0 if the node does not expose such information. Invalid values are logged and ignored.
+func getMemoryDerating(n v1.Node, gpuIndex int) float64 {
+	key := fmt.Sprintf(v1alpha1.AnnotationGpuMemoryDeratingFormat, gpuIndex)
+	val, ok := n.Annotations[key]
+	if !ok {
+		return 0
+	}
+	derating, err := strconv.ParseFloat(val, 64)
+	if err != nil || derating < 0 || derating >= 1 {
+		logInvalidAnnotation(n, key, val)
+		return 0
+	}
+	return derating
+}
+
+// logInvalidAnnotation logs that the annotation of the node provided as argument has an invalid value and
+// is ignored, so that a malformed annotation does not prevent the node from being considered.
+func logInvalidAnnotation(n v1.Node, key, val string) {
+	klog.ErrorS(
+		fmt.Errorf("invalid value of annotation %s: %q", key, val),
+		"ignoring invalid GPU annotation",
+		"node",
+		n.Name,
+	)
+}
+
+// isUnhealthy returns true if the GPU with the index provided as argument is annotated as unhealthy.
+func isUnhealthy(n v1.Node, gpuIndex int) bool {
+	key := fmt.Sprintf(v1alpha1.AnnotationGpuHealthFormat, gpuIndex)
+	return n.Annotations[key] == v1alpha1.AnnotationGpuUnhealthy
+}
+
+// GpuAnnotationPrefixes are the prefixes of the node annotations returned by GetGpuAnnotations
+var GpuAnnotationPrefixes = []string{
+	v1alpha1.AnnotationGpuHealthPrefix,
+	v1alpha1.AnnotationGpuNumaNodePrefix,
+	v1alpha1.AnnotationGpuNVLinkPeersPrefix,
+	v1alpha1.AnnotationGpuReplicasPrefix,
+	v1alpha1.AnnotationGpuMemoryDeratingPrefix,
+}
+
+// GetGpuAnnotations returns the node annotations exposing the health, the topology, the time-slicing replicas
+// and the memory derating of the GPUs provided as argument, which are read by NewNode. The replicas of each GPU
+// are returned by the replicas function, if not nil. Healthy GPUs, unknown topology information, replicas lower
+// than 1 and a zero memory derating are not exposed.
+func GetGpuAnnotations(gpus []gpu.Info, replicas func(gpuIndex int) int, memoryDerating float64) map[string]string {
+	res := make(map[string]string)
+	for _, g := range gpus {
+		if !g.Healthy {
+			res[fmt.Sprintf(v1alpha1.AnnotationGpuHealthFormat, g.Index)] = v1alpha1.AnnotationGpuUnhealthy
+		}
+		if g.NumaNode != nil {
+			res[fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, g.Index)] = strconv.Itoa(*g.NumaNode)
+		}
+		if len(g.NVLinkPeers) > 0 {
+			peers := make([]string, len(g.NVLinkPeers))
+			for i, peer := range g.NVLinkPeers {
+				peers[i] = strconv.Itoa(peer)
+			}
+			res[fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, g.Index)] = strings.Join(peers, ",")
+		}
+		if replicas != nil && replicas(g.Index) > 0 {
+			res[fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, g.Index)] = strconv.Itoa(replicas(g.Index))
+		}
+		if memoryDerating > 0 {
+			res[fmt.Sprintf(v1alpha1.AnnotationGpuMemoryDeratingFormat, g.Index)] = strconv.FormatFloat(memoryDerating, 'f', -1, 64)
+		}
+	}
+	return res
+}
+
 func (n *Node) Clone() interface{} {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+	return n.clone()
+}
+
+// clone returns a deep copy of the node, the caller must hold the lock of the node
+func (n *Node) clone() *Node {
 	gpus := make([]GPU, len(n.GPUs))
 	for i, g := range n.GPUs {
 		gpus[i] = g.Clone()
@@ -118,6 +267,9 @@ func (n *Node) Clone() interface{} {
 }
 
 func (n *Node) UpdateGeometryFor(slices map[gpu.Slice]int) (bool, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
 	// If there are no GPUs, then there's nothing to do
 	if len(n.GPUs) == 0 {
 		return false, nil
@@ -134,6 +286,9 @@ func (n *Node) UpdateGeometryFor(slices map[gpu.Slice]int) (bool, error) {
 
 	var anyGpuUpdated bool
 	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
 		updated := g.UpdateGeometryFor(requiredSlices)
 		anyGpuUpdated = anyGpuUpdated || updated
 		for profile, quantity := range g.FreeProfiles {
@@ -151,6 +306,63 @@ func (n *Node) UpdateGeometryFor(slices map[gpu.Slice]int) (bool, error) {
 	return anyGpuUpdated, nil
 }
 
+// ReplenishFreeSlices updates the geometry of the healthy GPUs of the node so that, for each profile of the
+// target provided as argument, the node has at least the target quantity of free slices, creating the
+// missing slices with the spare capacity of the GPUs or by re-partitioning their free slices. Used slices
+// are never changed, and no slice is created for the profiles whose free slices already match the target.
+//
+// The target might not be reached if the GPUs do not have enough capacity. The returned value is true
+// if the geometry of any GPU has been changed.
+func (n *Node) ReplenishFreeSlices(target map[ProfileName]int) bool {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	var anyGpuUpdated bool
+	for i := range n.GPUs {
+		missing := n.getMissingFreeSlices(target)
+		if len(missing) == 0 {
+			break
+		}
+		g := &n.GPUs[i]
+		if g.Unhealthy {
+			continue
+		}
+		// Ask the GPU to provide its current free slices plus the ones missing on the whole node
+		required := make(map[gpu.Slice]int, len(missing))
+		for p, q := range missing {
+			required[p] = g.FreeProfiles[p] + q
+		}
+		updated := g.UpdateGeometryFor(required)
+		anyGpuUpdated = anyGpuUpdated || updated
+	}
+
+	if anyGpuUpdated {
+		n.nodeInfo.Allocatable.ScalarResources = n.computeScalarResources()
+	}
+	return anyGpuUpdated
+}
+
+// getMissingFreeSlices returns, for each profile of the target provided as argument, the number
+// of free slices that the healthy GPUs of the node lack for reaching the target quantity
+func (n *Node) getMissingFreeSlices(target map[ProfileName]int) map[ProfileName]int {
+	free := make(map[ProfileName]int)
+	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
+		for p, q := range g.FreeProfiles {
+			free[p] += q
+		}
+	}
+	res := make(map[ProfileName]int)
+	for p, q := range target {
+		if diff := q - free[p]; diff > 0 {
+			res[p] = diff
+		}
+	}
+	return res
+}
+
 func (n *Node) computeScalarResources() map[v1.ResourceName]int64 {
 	res := make(map[v1.ResourceName]int64)
 
@@ -161,7 +373,7 @@ func (n *Node) computeScalarResources() map[v1.ResourceName]int64 {
 		}
 	}
 	// Set slicing scalar resources
-	for r, v := range n.Geometry() {
+	for r, v := range n.geometry() {
 		resource := r.(ProfileName).AsResourceName()
 		res[resource] = int64(v)
 	}
@@ -174,10 +386,39 @@ func (n *Node) GetName() string {
 }
 
 // Geometry returns the overall geometry of the node, which corresponds to the sum of the geometries of all
-// the GPUs present in the Node.
+// the healthy GPUs present in the Node. The geometry is the advertised-replica view returned by GPU.GetGeometry:
+// with time-slicing, each physical slice is counted once for each of its advertised replicas.
 func (n *Node) Geometry() map[gpu.Slice]int {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	return n.geometry()
+}
+
+// PhysicalGeometry returns the physical-partition view of the overall geometry of the node, which corresponds to
+// the sum of the physical geometries of all the healthy GPUs present in the Node (see GPU.GetPhysicalGeometry).
+func (n *Node) PhysicalGeometry() map[gpu.Slice]int {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
 	res := make(map[gpu.Slice]int)
 	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
+		for p, q := range g.GetPhysicalGeometry() {
+			res[p] += q
+		}
+	}
+	return res
+}
+
+func (n *Node) geometry() map[gpu.Slice]int {
+	res := make(map[gpu.Slice]int)
+	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
 		for p, q := range g.GetGeometry() {
 			res[p] += q
 		}
@@ -186,30 +427,428 @@ func (n *Node) Geometry() map[gpu.Slice]int {
 }
 
 func (n *Node) NodeInfo() framework.NodeInfo {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
 	return n.nodeInfo
 }
 
 // AddPod adds a Pod to the node by updating the free and used slices of the Node GPUs according to the
 // slices requested by the Pod.
 //
-// AddPod returns an error if the node does not have any GPU providing enough free slices resources for the Pod.
+// If the Pod has the label v1alpha1.LabelGpuSlicePlacement set to v1alpha1.GpuSlicePlacementSpread, AddPod
+// prefers the GPUs not hosting slices used by other Pods with the same controller (e.g. the other replicas
+// of the same ReplicaSet), falling back to the remaining GPUs if none of them has enough free slices.
+//
+// If none of the GPUs provides all the slices requested by the Pod, AddPod draws the free slices of each
+// requested profile from multiple GPUs, in the same order, updating the used slices of each of them.
+//
+// If the Pod specifies an active thread percentage through the v1alpha1.AnnotationMpsActiveThreadPercentage
+// annotation, only the GPUs on which the total percentage of the Pods would not exceed 100 are considered.
+//
+// AddPod returns an error if the Pod requests invalid slices, if it requests both fractional and
+// memory-based slices, or if the healthy GPUs of the node do not provide enough free slices for the Pod.
 func (n *Node) AddPod(pod v1.Pod) error {
-	for _, g := range n.GPUs {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.addPod(pod)
+}
+
+// addPod implements AddPod, the caller must hold the write lock of the node
+func (n *Node) addPod(pod v1.Pod) error {
+	if err := validateRequestedProfiles(pod); err != nil {
+		return err
+	}
+
+	order := n.sortGPUsFor(pod)
+	for _, i := range order {
+		g := &n.GPUs[i]
+		if g.Unhealthy {
+			continue
+		}
 		if err := g.AddPod(pod); err == nil {
-			nodeInfo := n.NodeInfo()
-			nodeInfo.AddPod(&pod)
+			n.nodeInfo.AddPod(&pod)
+			return nil
+		}
+	}
+
+	// No single GPU can host the Pod, try to span its slices across multiple GPUs
+	activeThreads, _ := GetActiveThreadPercentage(pod)
+	allocation, err := n.spanSlices(GetRequestedProfiles(pod), activeThreads, order)
+	if err != nil {
+		return err
+	}
+	ref := NewPodRef(pod)
+	for i, slices := range allocation {
+		g := &n.GPUs[i]
+		for p, q := range slices {
+			g.FreeProfiles[p] -= q
+			g.UsedProfiles[p] += q
+		}
+		g.addConsumer(ref, slices)
+		g.addActiveThreads(ref, activeThreads)
+	}
+	n.nodeInfo.AddPod(&pod)
+	return nil
+}
+
+// AddPods adds all the Pods provided as argument to the node, in the same order, as a single transaction:
+// either all the Pods are added or, if any of them cannot be added, the node is left unchanged. The Pods
+// are added to a clone of the node with AddPod, and the node is updated only if all of them succeed.
+// The node is locked for the whole transaction, so that concurrent changes are not lost.
+//
+// AddPods returns the error returned by AddPod for the first Pod that cannot be added.
+func (n *Node) AddPods(pods []v1.Pod) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	simulated := n.clone()
+	for _, pod := range pods {
+		if err := simulated.addPod(pod); err != nil {
+			return fmt.Errorf("cannot add pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	n.GPUs = simulated.GPUs
+	n.nodeInfo = simulated.nodeInfo
+	return nil
+}
+
+// spanSlices returns, for each position of the GPUs of the node, the free slices that should be drawn
+// from that GPU for providing the requested slices provided as argument. GPUs are considered in the
+// order provided as argument, and unhealthy GPUs or GPUs without enough active threads for the
+// active thread percentage provided as argument are skipped. The node is never modified.
+//
+// spanSlices returns an error if the healthy GPUs of the node do not provide enough free slices.
+func (n *Node) spanSlices(requested map[ProfileName]int, activeThreads int, order []int) (map[int]map[ProfileName]int, error) {
+	profiles := make([]ProfileName, 0, len(requested))
+	for p := range requested {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i] < profiles[j]
+	})
+
+	res := make(map[int]map[ProfileName]int)
+	for _, p := range profiles {
+		remaining := requested[p]
+		for _, i := range order {
+			if remaining == 0 {
+				break
+			}
+			g := n.GPUs[i]
+			if g.Unhealthy || g.checkActiveThreads(activeThreads) != nil {
+				continue
+			}
+			quantity := g.FreeProfiles[p]
+			if quantity > remaining {
+				quantity = remaining
+			}
+			if quantity == 0 || g.checkFits(map[ProfileName]int{p: quantity}) != nil {
+				continue
+			}
+			if res[i] == nil {
+				res[i] = make(map[ProfileName]int)
+			}
+			res[i][p] = quantity
+			remaining -= quantity
+		}
+		if remaining > 0 {
+			return nil, fmt.Errorf("not enough free GPU slices")
+		}
+	}
+	return res, nil
+}
+
+// sortGPUsFor returns the positions of the GPUs of the node in the order in which they should be considered
+// for hosting the Pod provided as argument. If the Pod requires its slices to be spread, the GPUs hosting
+// slices used by Pods with the same controller are moved last, otherwise the order of the GPUs is preserved.
+func (n *Node) sortGPUsFor(pod v1.Pod) []int {
+	res := make([]int, len(n.GPUs))
+	for i := range n.GPUs {
+		res[i] = i
+	}
+	if pod.Labels[v1alpha1.LabelGpuSlicePlacement] != v1alpha1.GpuSlicePlacementSpread {
+		return res
+	}
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil {
+		return res
+	}
+
+	siblings := make(map[PodRef]bool)
+	for _, pi := range n.nodeInfo.Pods {
+		if pi == nil || pi.Pod == nil || pi.Pod.Namespace != pod.Namespace {
+			continue
+		}
+		if c := metav1.GetControllerOf(pi.Pod); c != nil && c.UID == owner.UID {
+			siblings[NewPodRef(*pi.Pod)] = true
+		}
+	}
+	hostsSiblings := make(map[int]bool, len(n.GPUs))
+	for i, g := range n.GPUs {
+		for ref := range g.consumers {
+			if siblings[ref] {
+				hostsSiblings[i] = true
+				break
+			}
+		}
+	}
+	sort.SliceStable(res, func(a, b int) bool {
+		return !hostsSiblings[res[a]] && hostsSiblings[res[b]]
+	})
+	return res
+}
+
+// CanFit returns true if the healthy GPUs of the node have enough free slices for all the slices
+// requested by the Pod provided as argument, either on a single GPU or spanning multiple GPUs. It is the
+// non-mutating counterpart of AddPod: the node is never modified.
+//
+// CanFit returns an error if the Pod requests invalid slices or both fractional and memory-based slices.
+func (n *Node) CanFit(pod v1.Pod) (bool, error) {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	if err := validateRequestedProfiles(pod); err != nil {
+		return false, err
+	}
+	requested := GetRequestedProfiles(pod)
+	activeThreads, _ := GetActiveThreadPercentage(pod)
+	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
+		if g.checkFits(requested) == nil && g.checkActiveThreads(activeThreads) == nil {
+			return true, nil
+		}
+	}
+	if _, err := n.spanSlices(requested, activeThreads, n.sortGPUsFor(pod)); err == nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// validateRequestedProfiles returns an error if the Pod provided as argument requests invalid slices,
+// if it requests both fractional and memory-based slices, or if it specifies an invalid active thread percentage.
+func validateRequestedProfiles(pod v1.Pod) error {
+	if _, err := GetAnnotationRequestedProfiles(pod); err != nil {
+		return err
+	}
+	if _, err := GetActiveThreadPercentage(pod); err != nil {
+		return err
+	}
+	var fractional, memoryBased bool
+	for p := range GetRequestedProfiles(pod) {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("pod requests invalid GPU slice: %w", err)
+		}
+		fractional = fractional || p.IsFractional()
+		memoryBased = memoryBased || !p.IsFractional()
+	}
+	if fractional && memoryBased {
+		return fmt.Errorf("pod cannot request both fractional and memory-based GPU slices")
+	}
+	return nil
+}
+
+// RemovePod removes a Pod from the node by releasing the used slices of the first healthy GPU
+// providing all the slices requested by the Pod. If the slices of the Pod span multiple GPUs,
+// the slices used by the Pod on each of them are released.
+//
+// RemovePod returns an error if the Pod is not assigned to the node or if none of the node GPUs
+// has enough used slices matching the ones requested by the Pod.
+func (n *Node) RemovePod(pod v1.Pod) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.isSpanned(pod) {
+		if err := n.nodeInfo.RemovePod(&pod); err != nil {
+			return err
+		}
+		ref := NewPodRef(pod)
+		for i := range n.GPUs {
+			n.GPUs[i].releaseSlices(ref)
+		}
+		return nil
+	}
+
+	// Release the slices of the GPU the Pod is recorded on, if any, otherwise the ones of
+	// the first healthy GPU providing enough used slices
+	candidates := make([]int, 0, len(n.GPUs))
+	if i, ok := n.findConsumerGPU(pod); ok {
+		candidates = append(candidates, i)
+	} else {
+		for i := range n.GPUs {
+			if !n.GPUs[i].Unhealthy {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+	for _, i := range candidates {
+		g := &n.GPUs[i]
+		if err := g.RemovePod(pod); err == nil {
+			if err = n.nodeInfo.RemovePod(&pod); err != nil {
+				_ = g.AddPod(pod)
+				return err
+			}
 			return nil
 		}
 	}
-	return fmt.Errorf("not enough free GPU slices")
+	return fmt.Errorf("not enough used GPU slices")
+}
+
+// findConsumerGPU returns the position of the GPU of the node on which the Pod provided as argument
+// is recorded as consumer of any slice. The boolean value is false if the Pod is not recorded on any GPU.
+func (n *Node) findConsumerGPU(pod v1.Pod) (int, bool) {
+	ref := NewPodRef(pod)
+	for i, g := range n.GPUs {
+		if _, ok := g.consumers[ref]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// isSpanned returns true if the slices used by the Pod provided as argument are spread across
+// multiple healthy GPUs of the node
+func (n *Node) isSpanned(pod v1.Pod) bool {
+	ref := NewPodRef(pod)
+	var count int
+	for _, g := range n.GPUs {
+		if _, ok := g.consumers[ref]; ok && !g.Unhealthy {
+			count++
+		}
+	}
+	return count > 1
+}
+
+// FindPreemptionVictims returns the minimal set of Pods running on the node with a priority lower than
+// the one of the Pod provided as argument that need to be removed in order to free the slices requested
+// by the Pod. The priority of the Pods is computed through the priority function provided as argument.
+//
+// The returned list is empty if the node already provides enough free slices for the Pod.
+// FindPreemptionVictims returns an error if the slices cannot be freed even by removing all the lower-priority
+// Pods. The node is never modified.
+func (n *Node) FindPreemptionVictims(pod v1.Pod, priority func(v1.Pod) int32) ([]v1.Pod, error) {
+	simulated := n.Clone().(*Node)
+	if simulated.canHost(pod) {
+		return []v1.Pod{}, nil
+	}
+
+	// Lower priority Pods requesting slices are candidates for preemption, lowest priority first
+	podPriority := priority(pod)
+	candidates := make([]v1.Pod, 0)
+	for _, pi := range simulated.nodeInfo.Pods {
+		p := *pi.Pod
+		if len(GetRequestedProfiles(p)) == 0 {
+			continue
+		}
+		if priority(p) < podPriority {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return priority(candidates[i]) < priority(candidates[j])
+	})
+
+	// Remove candidates until the Pod fits
+	victims := make([]v1.Pod, 0)
+	for _, c := range candidates {
+		if err := simulated.RemovePod(c); err != nil {
+			continue
+		}
+		victims = append(victims, c)
+		if simulated.canHost(pod) {
+			break
+		}
+	}
+	if !simulated.canHost(pod) {
+		return nil, fmt.Errorf("cannot free enough GPU slices by preempting lower priority pods")
+	}
+
+	// Reprieve victims that are not needed, starting from the ones with the highest priority
+	result := make([]v1.Pod, 0, len(victims))
+	for i := len(victims) - 1; i >= 0; i-- {
+		v := victims[i]
+		if err := simulated.AddPod(v); err == nil {
+			if simulated.canHost(pod) {
+				continue
+			}
+			_ = simulated.RemovePod(v)
+		}
+		result = append(result, v)
+	}
+
+	return result, nil
+}
+
+// canHost returns true if the free slices of the node are enough for hosting the Pod provided as argument
+func (n *Node) canHost(pod v1.Pod) bool {
+	return n.Clone().(*Node).AddPod(pod) == nil
+}
+
+// GetSliceConsumers returns, for each profile, the Pods using slices of that profile on the healthy GPUs
+// of the node, sorted by namespace and name. Pods are tracked when they are added to the node through
+// AddPod, or when they are included in the node info used for creating the node and their requests
+// match the used slices reported by the GPUs. Pods whose slices span multiple GPUs are included once.
+func (n *Node) GetSliceConsumers() map[ProfileName][]PodRef {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	res := make(map[ProfileName][]PodRef)
+	seen := make(map[ProfileName]map[PodRef]bool)
+	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
+		for p, refs := range g.GetSliceConsumers() {
+			if seen[p] == nil {
+				seen[p] = make(map[PodRef]bool)
+			}
+			for _, ref := range refs {
+				if !seen[p][ref] {
+					seen[p][ref] = true
+					res[p] = append(res[p], ref)
+				}
+			}
+		}
+	}
+	for _, refs := range res {
+		sortPodRefs(refs)
+	}
+	return res
 }
 
-// HasFreeCapacity returns true if any of the GPUs of the node has enough free capacity for hosting more pods.
+// HasFreeCapacity returns true if any of the healthy GPUs of the node has enough free capacity for hosting more pods.
+// It considers both views of the geometry: a GPU has free capacity if any of its advertised replicas is free, or if
+// its spare physical memory is enough for creating more physical slices.
 func (n *Node) HasFreeCapacity() bool {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
 	for _, g := range n.GPUs {
-		if g.HasFreeCapacity() {
+		if !g.Unhealthy && g.HasFreeCapacity() {
 			return true
 		}
 	}
 	return false
 }
+
+// GetAllocatableSlices returns the GPU slices that are currently free on the healthy GPUs of the node, expressed
+// as extended resource quantities. Slices reserved by the pods added to the node are not included.
+func (n *Node) GetAllocatableSlices() map[v1.ResourceName]int64 {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	res := make(map[v1.ResourceName]int64)
+	for _, g := range n.GPUs {
+		if g.Unhealthy {
+			continue
+		}
+		for p, q := range g.FreeProfiles {
+			if q > 0 {
+				res[p.AsResourceName()] += int64(q)
+			}
+		}
+	}
+	return res
+}
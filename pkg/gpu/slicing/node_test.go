@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"sync"
 	"testing"
 )
 
@@ -89,6 +90,40 @@ func TestNewNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "vendor-neutral labels, should use the NVIDIA vendor",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				v1alpha1.LabelGpuVendor:  gpu.VendorNvidia,
+				v1alpha1.LabelGpuProduct: "foo",
+				v1alpha1.LabelGpuCount:   "2",
+				v1alpha1.LabelGpuMemory:  "40000",
+			}).Get(),
+			expected: slicing.Node{
+				Name: "node-1",
+				GPUs: []slicing.GPU{
+					slicing.NewFullGPU(
+						"foo",
+						0,
+						40,
+					),
+					slicing.NewFullGPU(
+						"foo",
+						1,
+						40,
+					),
+				},
+			},
+		},
+		{
+			name: "unsupported vendor",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				v1alpha1.LabelGpuVendor:  "foo",
+				v1alpha1.LabelGpuProduct: "foo",
+				v1alpha1.LabelGpuCount:   "2",
+				v1alpha1.LabelGpuMemory:  "40000",
+			}).Get(),
+			errExpected: true,
+		},
 		{
 			name: "free and used labels",
 			node: factory.BuildNode("node-1").WithLabels(map[string]string{
@@ -128,7 +163,8 @@ func TestNewNode(t *testing.T) {
 		},
 	}
 
-	for _, tt := range testCases {
+	for i := range testCases {
+		tt := &testCases[i]
 		t.Run(tt.name, func(t *testing.T) {
 			nodeInfo := framework.NewNodeInfo()
 			nodeInfo.SetNode(&tt.node)
@@ -144,6 +180,62 @@ func TestNewNode(t *testing.T) {
 	}
 }
 
+func TestNewNode__MemoryGB(t *testing.T) {
+	testCases := []struct {
+		name             string
+		memoryLabelValue string
+		expectedMemoryGB int
+	}{
+		{
+			name:             "Memory label in MiB multiple of 1024",
+			memoryLabelValue: "40960",
+			expectedMemoryGB: 40,
+		},
+		{
+			name:             "Memory label in MiB not multiple of 1024 should be rounded up",
+			memoryLabelValue: "41000",
+			expectedMemoryGB: 41,
+		},
+		{
+			name:             "Memory label lower than 1024",
+			memoryLabelValue: "500",
+			expectedMemoryGB: 1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			n := factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "foo",
+				constant.LabelNvidiaCount:   "2",
+				constant.LabelNvidiaMemory:  tt.memoryLabelValue,
+			}).WithAnnotations(map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1gb", resource.StatusFree): "1",
+			}).Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&n)
+			node, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+
+			// GPU initialized from status annotations
+			assert.Equal(
+				t,
+				slicing.NewGpuOrPanic(
+					"foo",
+					0,
+					tt.expectedMemoryGB,
+					map[slicing.ProfileName]int{},
+					map[slicing.ProfileName]int{"1gb": 1},
+				),
+				node.GPUs[0],
+			)
+			// GPU without status annotations
+			assert.Equal(t, slicing.NewGPUWithMemoryGB("foo", 1, tt.expectedMemoryGB), node.GPUs[1])
+			assert.Equal(t, slicing.NewFullGPU("foo", 1, tt.expectedMemoryGB), node.GPUs[1])
+		})
+	}
+}
+
 func TestNode__GetGeometry(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -184,7 +276,8 @@ func TestNode__GetGeometry(t *testing.T) {
 		},
 	}
 
-	for _, tt := range testCases {
+	for i := range testCases {
+		tt := &testCases[i]
 		t.Run(tt.name, func(t *testing.T) {
 			assert.Equal(t, tt.expected, tt.node.Geometry())
 		})
@@ -343,6 +436,245 @@ func TestNode_AddPod(t *testing.T) {
 	}
 }
 
+func TestNode_AddPods(t *testing.T) {
+	buildPod := func(name string, profile slicing.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithUID(name).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(profile.AsResourceName(), quantity).
+				Get(),
+		).Get()
+	}
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "1",
+			constant.LabelNvidiaMemory:  "40960",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusFree): "1",
+		}).Get()
+
+	testCases := []struct {
+		name         string
+		pods         []v1.Pod
+		expectedErr  bool
+		expectedUsed map[slicing.ProfileName]int
+		expectedFree map[slicing.ProfileName]int
+		expectedPods int
+	}{
+		{
+			name: "All pods fit: all of them are added",
+			pods: []v1.Pod{
+				buildPod("pd-1", "10gb", 2),
+				buildPod("pd-2", "20gb", 1),
+			},
+			expectedErr:  false,
+			expectedUsed: map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedFree: map[slicing.ProfileName]int{"10gb": 0, "20gb": 0},
+			expectedPods: 2,
+		},
+		{
+			name: "Second pod does not fit: node is restored to its original state",
+			pods: []v1.Pod{
+				buildPod("pd-1", "10gb", 1),
+				buildPod("pd-2", "20gb", 2),
+				buildPod("pd-3", "10gb", 1),
+			},
+			expectedErr:  true,
+			expectedUsed: map[slicing.ProfileName]int{},
+			expectedFree: map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedPods: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			original := n.Clone().(*slicing.Node)
+
+			err = n.AddPods(tt.pods)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Equal(t, original.GPUs, n.GPUs)
+				assert.Equal(t, *original.NodeInfo().Requested, *n.NodeInfo().Requested)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedUsed, n.GPUs[0].UsedProfiles)
+			assert.Equal(t, tt.expectedFree, n.GPUs[0].FreeProfiles)
+			assert.Len(t, n.NodeInfo().Pods, tt.expectedPods)
+		})
+	}
+}
+
+func TestNode_AddPod__MultipleGPUs(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusFree): "2",
+		}).
+		Get()
+	pod := factory.BuildPod("ns-1", "pd-1").WithUID("pd-1").WithContainer(
+		factory.BuildContainer("c-1", "foo").
+			WithScalarResourceRequest(slicing.ProfileName("20gb").AsResourceName(), 3).
+			Get(),
+	).Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	fits, err := n.CanFit(pod)
+	assert.NoError(t, err)
+	assert.True(t, fits)
+
+	// The request is satisfied by drawing the free slices of both GPUs
+	assert.NoError(t, n.AddPod(pod))
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 2}, n.GPUs[0].UsedProfiles)
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 0}, n.GPUs[0].FreeProfiles)
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 1}, n.GPUs[1].UsedProfiles)
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 1}, n.GPUs[1].FreeProfiles)
+	assert.Equal(t, map[slicing.ProfileName][]slicing.PodRef{
+		"20gb": {slicing.NewPodRef(pod)},
+	}, n.GetSliceConsumers())
+
+	// Not enough free slices left, even across GPUs
+	other := factory.BuildPod("ns-1", "pd-2").WithUID("pd-2").WithContainer(
+		factory.BuildContainer("c-1", "foo").
+			WithScalarResourceRequest(slicing.ProfileName("20gb").AsResourceName(), 2).
+			Get(),
+	).Get()
+	assert.Error(t, n.AddPod(other))
+
+	// Removing the Pod releases its slices on both GPUs
+	assert.NoError(t, n.RemovePod(pod))
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 2}, n.GPUs[0].FreeProfiles)
+	assert.Equal(t, map[slicing.ProfileName]int{"20gb": 2}, n.GPUs[1].FreeProfiles)
+	assert.Empty(t, n.GetSliceConsumers())
+}
+
+func TestNode_AddPod__ActiveThreadPercentage(t *testing.T) {
+	buildPod := func(name string, activeThreads string) v1.Pod {
+		builder := factory.BuildPod("ns-1", name).WithUID(name).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("5gb").AsResourceName(), 1).
+				Get(),
+		)
+		if activeThreads != "" {
+			builder = builder.WithAnnotation(v1alpha1.AnnotationMpsActiveThreadPercentage, activeThreads)
+		}
+		return builder.Get()
+	}
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "1",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "5gb", resource.StatusFree): "8",
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	// Percentages summing exactly to 100 are accepted
+	assert.NoError(t, n.AddPod(buildPod("pd-1", "30")))
+	assert.NoError(t, n.AddPod(buildPod("pd-2", "30")))
+	assert.NoError(t, n.AddPod(buildPod("pd-3", "40")))
+
+	// Pods without a percentage are not limited
+	assert.NoError(t, n.AddPod(buildPod("pd-4", "")))
+
+	// A Pod exceeding the total percentage is rejected, even if free slices are available
+	exceeding := buildPod("pd-5", "10")
+	fits, err := n.CanFit(exceeding)
+	assert.NoError(t, err)
+	assert.False(t, fits)
+	assert.Error(t, n.AddPod(exceeding))
+	assert.Equal(t, 4, n.GPUs[0].FreeProfiles["5gb"])
+
+	// Removing a Pod releases its percentage
+	assert.NoError(t, n.RemovePod(buildPod("pd-3", "40")))
+	assert.NoError(t, n.AddPod(exceeding))
+
+	// Invalid percentages are rejected
+	for _, value := range []string{"0", "101", "foo"} {
+		_, err = n.CanFit(buildPod("pd-6", value))
+		assert.Error(t, err, value)
+	}
+}
+
+func TestNode_RemovePod__ReleasesConsumerGPU(t *testing.T) {
+	buildPod := func(name string) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithUID(name).
+			WithAnnotation(v1alpha1.AnnotationMpsActiveThreadPercentage, "60").
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			).Get()
+	}
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "2",
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	// The active thread percentages force the Pods on different GPUs
+	podA, podB := buildPod("pd-a"), buildPod("pd-b")
+	assert.NoError(t, n.AddPod(podA))
+	assert.NoError(t, n.AddPod(podB))
+	gpuOf := func(pod v1.Pod) int {
+		for i, g := range n.GPUs {
+			for _, refs := range g.GetSliceConsumers() {
+				for _, ref := range refs {
+					if ref == slicing.NewPodRef(pod) {
+						return i
+					}
+				}
+			}
+		}
+		return -1
+	}
+	gpuA, gpuB := gpuOf(podA), gpuOf(podB)
+	assert.NotEqual(t, -1, gpuA)
+	assert.NotEqual(t, -1, gpuB)
+	assert.NotEqual(t, gpuA, gpuB)
+
+	// Removing the Pod releases the slices and the active threads of the GPU it is recorded on
+	assert.NoError(t, n.RemovePod(podB))
+	assert.Equal(t, 1, n.GPUs[gpuA].UsedProfiles["10gb"])
+	assert.Equal(t, 0, n.GPUs[gpuB].UsedProfiles["10gb"])
+	assert.Equal(t, map[slicing.ProfileName][]slicing.PodRef{
+		"10gb": {slicing.NewPodRef(podA)},
+	}, n.GetSliceConsumers())
+	podC := buildPod("pd-c")
+	assert.NoError(t, n.AddPod(podC))
+	assert.Equal(t, gpuB, gpuOf(podC))
+}
+
 func TestNode__UpdateGeometryFor(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -471,6 +803,120 @@ func TestNode__UpdateGeometryFor(t *testing.T) {
 	}
 }
 
+func TestNode__ReplenishFreeSlices(t *testing.T) {
+	labels := map[string]string{
+		constant.LabelNvidiaProduct: "foo",
+		constant.LabelNvidiaCount:   "2",
+		constant.LabelNvidiaMemory:  "40960",
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		target      map[slicing.ProfileName]int
+
+		expectedUpdate bool
+		expectedFree   map[slicing.ProfileName]int
+		expectedUsed   map[slicing.ProfileName]int
+	}{
+		{
+			name:           "Empty node: free slices are created",
+			annotations:    map[string]string{},
+			target:         map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedUpdate: true,
+			expectedFree:   map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedUsed:   map[slicing.ProfileName]int{},
+		},
+		{
+			name: "Buffer is full: node is not updated",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusFree): "1",
+			},
+			target:         map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedUpdate: false,
+			expectedFree:   map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+			expectedUsed:   map[slicing.ProfileName]int{},
+		},
+		{
+			name: "Free slices exceeding the target are not deleted",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "3",
+			},
+			target:         map[slicing.ProfileName]int{"10gb": 2},
+			expectedUpdate: false,
+			expectedFree:   map[slicing.ProfileName]int{"10gb": 3},
+			expectedUsed:   map[slicing.ProfileName]int{},
+		},
+		{
+			name: "Consumed slices are replenished",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "1",
+			},
+			target:         map[slicing.ProfileName]int{"10gb": 2},
+			expectedUpdate: true,
+			expectedFree:   map[slicing.ProfileName]int{"10gb": 2},
+			expectedUsed:   map[slicing.ProfileName]int{"10gb": 2},
+		},
+		{
+			name: "Free slices on other GPUs count towards the target",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "1",
+			},
+			target:         map[slicing.ProfileName]int{"10gb": 2},
+			expectedUpdate: true,
+			expectedFree:   map[slicing.ProfileName]int{"10gb": 2},
+			expectedUsed:   map[slicing.ProfileName]int{"20gb": 2},
+		},
+		{
+			name: "Not enough capacity: target is not reached",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "30gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "30gb", resource.StatusUsed): "1",
+			},
+			target:         map[slicing.ProfileName]int{"20gb": 1},
+			expectedUpdate: false,
+			expectedFree:   map[slicing.ProfileName]int{},
+			expectedUsed:   map[slicing.ProfileName]int{"30gb": 2},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(labels).WithAnnotations(tt.annotations).Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+
+			updated := n.ReplenishFreeSlices(tt.target)
+			assert.Equal(t, tt.expectedUpdate, updated)
+
+			free := make(map[slicing.ProfileName]int)
+			used := make(map[slicing.ProfileName]int)
+			for _, g := range n.GPUs {
+				for p, q := range g.FreeProfiles {
+					if q > 0 {
+						free[p] += q
+					}
+				}
+				for p, q := range g.UsedProfiles {
+					if q > 0 {
+						used[p] += q
+					}
+				}
+			}
+			assert.Equal(t, tt.expectedFree, free)
+			assert.Equal(t, tt.expectedUsed, used)
+
+			// Replenishing again must not exceed the target
+			assert.False(t, n.ReplenishFreeSlices(tt.target))
+		})
+	}
+}
+
 func TestNode__Clone(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -513,3 +959,949 @@ func TestNode__Clone(t *testing.T) {
 		})
 	}
 }
+
+func TestNode__ConcurrentAccess(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "4",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "4",
+		}).Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	nPods := 8
+	var wg sync.WaitGroup
+	for i := 0; i < nPods; i++ {
+		pod := factory.BuildPod("ns-1", fmt.Sprintf("pd-%d", i)).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+				Get(),
+		).Get()
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, n.AddPod(pod))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = n.Geometry()
+			_ = n.HasFreeCapacity()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[gpu.Slice]int{slicing.ProfileName("10gb"): 8}, n.Geometry())
+	assert.False(t, n.HasFreeCapacity())
+}
+
+func TestNode__ConcurrentAddPods(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "1",
+			constant.LabelNvidiaMemory:  "500000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "1gb", resource.StatusFree): "400",
+		}).Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	buildPod := func(name string) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithUID(name).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("1gb").AsResourceName(), 1).
+				Get(),
+		).Get()
+	}
+
+	// Pods added with AddPod while AddPods is running are not lost
+	nBatches, batchSize := 20, 10
+	nSingles := nBatches * batchSize
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < nBatches; i++ {
+			batch := make([]v1.Pod, 0, batchSize)
+			for j := 0; j < batchSize; j++ {
+				batch = append(batch, buildPod(fmt.Sprintf("batch-%d-%d", i, j)))
+			}
+			assert.NoError(t, n.AddPods(batch))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < nSingles; i++ {
+			assert.NoError(t, n.AddPod(buildPod(fmt.Sprintf("single-%d", i))))
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 2*nSingles, n.GPUs[0].UsedProfiles["1gb"])
+	assert.Len(t, n.NodeInfo().Pods, 2*nSingles)
+}
+
+func TestNode__Replicas(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		replicas             string
+		statusAnnotations    map[string]string
+		requiredSlices       map[gpu.Slice]int
+		expectedGeometry     map[gpu.Slice]int
+		expectedPhysical     map[gpu.Slice]int
+		expectedFreeCapacity bool
+	}{
+		{
+			name:                 "4-way time-slicing, geometry reflects advertised replicas",
+			replicas:             "4",
+			requiredSlices:       map[gpu.Slice]int{slicing.ProfileName("10gb"): 100},
+			expectedGeometry:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 16},
+			expectedPhysical:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 4},
+			expectedFreeCapacity: true,
+		},
+		{
+			name:                 "8-way time-slicing, geometry reflects advertised replicas",
+			replicas:             "8",
+			requiredSlices:       map[gpu.Slice]int{slicing.ProfileName("10gb"): 100},
+			expectedGeometry:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 32},
+			expectedPhysical:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 4},
+			expectedFreeCapacity: true,
+		},
+		{
+			name:     "4-way time-slicing, all replicas used",
+			replicas: "4",
+			statusAnnotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "8",
+			},
+			expectedGeometry:     map[gpu.Slice]int{slicing.ProfileName("20gb"): 8},
+			expectedPhysical:     map[gpu.Slice]int{slicing.ProfileName("20gb"): 2},
+			expectedFreeCapacity: false,
+		},
+		{
+			name:     "8-way time-slicing, same replicas take half of the memory",
+			replicas: "8",
+			statusAnnotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "8",
+			},
+			expectedGeometry:     map[gpu.Slice]int{slicing.ProfileName("20gb"): 8},
+			expectedPhysical:     map[gpu.Slice]int{slicing.ProfileName("20gb"): 1},
+			expectedFreeCapacity: true,
+		},
+		{
+			name:                 "invalid replicas annotation is ignored",
+			replicas:             "foo",
+			requiredSlices:       map[gpu.Slice]int{slicing.ProfileName("10gb"): 100},
+			expectedGeometry:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 4},
+			expectedPhysical:     map[gpu.Slice]int{slicing.ProfileName("10gb"): 4},
+			expectedFreeCapacity: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, 0): tt.replicas,
+			}
+			for k, v := range tt.statusAnnotations {
+				annotations[k] = v
+			}
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "1",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(annotations).
+				Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+
+			if len(tt.requiredSlices) > 0 {
+				_, err = n.UpdateGeometryFor(tt.requiredSlices)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedGeometry, n.Geometry())
+			assert.Equal(t, tt.expectedPhysical, n.PhysicalGeometry())
+			assert.Equal(t, tt.expectedFreeCapacity, n.HasFreeCapacity())
+		})
+	}
+}
+
+func TestNode__UnhealthyGPUs(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "3",
+			constant.LabelNvidiaMemory:  "20000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 2, "10gb", resource.StatusUsed): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuHealthFormat, 1):                              v1alpha1.AnnotationGpuUnhealthy,
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+	assert.Len(t, n.GPUs, 3)
+	gpus := make(map[int]slicing.GPU)
+	for _, g := range n.GPUs {
+		gpus[g.Index] = g
+	}
+	assert.False(t, gpus[0].Unhealthy)
+	assert.True(t, gpus[1].Unhealthy)
+	assert.False(t, gpus[2].Unhealthy)
+
+	// Only the healthy GPUs are included in the geometry
+	assert.Equal(t, map[gpu.Slice]int{slicing.ProfileName("10gb"): 4}, n.Geometry())
+	// The free slices of the unhealthy GPU are not considered as free capacity
+	assert.False(t, n.HasFreeCapacity())
+
+	// Pods cannot be added to the unhealthy GPU
+	pod := factory.BuildPod("ns-1", "pd-1").WithContainer(
+		factory.BuildContainer("c-1", "foo").
+			WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+			Get(),
+	).Get()
+	assert.Error(t, n.AddPod(pod))
+	assert.Equal(t, 2, gpus[1].FreeProfiles[slicing.ProfileName("10gb")])
+
+	// Unhealthy GPUs are not updated
+	updated, err := n.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("20gb"): 1})
+	assert.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestNode__MemoryDerating(t *testing.T) {
+	buildNode := func(derating string) v1.Node {
+		return factory.BuildNode("node-1").
+			WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: gpu.GPUModel_A100_PCIe_80GB.String(),
+				constant.LabelNvidiaCount:   "1",
+				constant.LabelNvidiaMemory:  "81920",
+			}).
+			WithAnnotations(map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuMemoryDeratingFormat, 0): derating,
+			}).
+			Get()
+	}
+
+	t.Run("Derating is applied when creating slices", func(t *testing.T) {
+		node := buildNode("0.0125")
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0125, n.GPUs[0].MemoryDerating)
+
+		updated, err := n.UpdateGeometryFor(map[gpu.Slice]int{slicing.ProfileName("20gb"): 4})
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, map[gpu.Slice]int{slicing.ProfileName("20gb"): 3}, n.Geometry())
+	})
+
+	for _, invalid := range []string{"foo", "-0.1", "1"} {
+		t.Run(fmt.Sprintf("Invalid derating %q is ignored", invalid), func(t *testing.T) {
+			node := buildNode(invalid)
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			assert.Zero(t, n.GPUs[0].MemoryDerating)
+		})
+	}
+}
+
+func TestGetGpuAnnotations(t *testing.T) {
+	numaNode := 1
+	gpus := []gpu.Info{
+		{Index: 0, Healthy: true, NumaNode: &numaNode, NVLinkPeers: []int{1}},
+		{Index: 1, Healthy: true, NVLinkPeers: []int{0}},
+		{Index: 2, Healthy: false},
+	}
+
+	t.Run("Replicas and memory derating not set", func(t *testing.T) {
+		annotations := slicing.GetGpuAnnotations(gpus, nil, 0)
+		assert.Equal(t, map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, 0):    "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 1): "0",
+			fmt.Sprintf(v1alpha1.AnnotationGpuHealthFormat, 2):      v1alpha1.AnnotationGpuUnhealthy,
+		}, annotations)
+	})
+
+	// 4-way time-slicing on all the GPUs except GPU 1, which is 8-way time-sliced
+	replicas := func(gpuIndex int) int {
+		if gpuIndex == 1 {
+			return 8
+		}
+		return 4
+	}
+
+	t.Run("Replicas are exposed for each GPU", func(t *testing.T) {
+		annotations := slicing.GetGpuAnnotations(gpus, replicas, 0)
+		assert.Equal(t, "4", annotations[fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, 0)])
+		assert.Equal(t, "8", annotations[fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, 1)])
+		assert.Equal(t, "4", annotations[fmt.Sprintf(v1alpha1.AnnotationGpuReplicasFormat, 2)])
+	})
+
+	t.Run("Annotations are read by NewNode", func(t *testing.T) {
+		node := factory.BuildNode("node-1").
+			WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "foo",
+				constant.LabelNvidiaCount:   "3",
+				constant.LabelNvidiaMemory:  "40000",
+			}).
+			WithAnnotations(slicing.GetGpuAnnotations(gpus, replicas, 0.0125)).
+			Get()
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+		assert.Len(t, n.GPUs, 3)
+		for _, g := range n.GPUs {
+			assert.Equal(t, replicas(g.Index), g.Replicas)
+			assert.Equal(t, 0.0125, g.MemoryDerating)
+			assert.Equal(t, g.Index == 2, g.Unhealthy)
+		}
+		assert.Equal(t, &slicing.Topology{NumaNode: &numaNode, NVLinkPeers: []int{1}}, n.GPUs[0].Topology)
+		assert.Nil(t, n.GPUs[2].Topology)
+		assert.Equal(t, [][]int{{0, 1}, {2}}, n.GroupByNVLinkDomain())
+	})
+}
+
+func TestNode_AddPod__Spread(t *testing.T) {
+	buildReplica := func(name string, spread bool) v1.Pod {
+		builder := factory.BuildPod("ns-1", name).
+			WithUID(name).
+			WithControllerOwner("ReplicaSet", "rs-1", "rs-1").
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			)
+		if spread {
+			builder = builder.WithLabel(v1alpha1.LabelGpuSlicePlacement, v1alpha1.GpuSlicePlacementSpread)
+		}
+		return builder.Get()
+	}
+	countConsumers := func(n *slicing.Node) map[int]int {
+		res := make(map[int]int)
+		for _, g := range n.GPUs {
+			for _, refs := range g.GetSliceConsumers() {
+				res[g.Index] += len(refs)
+			}
+		}
+		return res
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		spread      bool
+		expected    map[int]int
+	}{
+		{
+			name: "Replicas with spread placement land on different GPUs",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "2",
+			},
+			spread:   true,
+			expected: map[int]int{0: 1, 1: 1},
+		},
+		{
+			name: "Spread falls back to packing if other GPUs do not have free slices",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusUsed): "2",
+			},
+			spread:   true,
+			expected: map[int]int{0: 2},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "2",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(tt.annotations).
+				Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+
+			assert.NoError(t, n.AddPod(buildReplica("pd-1", tt.spread)))
+			assert.NoError(t, n.AddPod(buildReplica("pd-2", tt.spread)))
+			assert.Equal(t, tt.expected, countConsumers(&n))
+		})
+	}
+
+	t.Run("Replicas without spread placement are packed", func(t *testing.T) {
+		node := factory.BuildNode("node-1").
+			WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "foo",
+				constant.LabelNvidiaCount:   "2",
+				constant.LabelNvidiaMemory:  "40000",
+			}).
+			WithAnnotations(map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "2",
+			}).
+			Get()
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+
+		assert.NoError(t, n.AddPod(buildReplica("pd-1", false)))
+		assert.NoError(t, n.AddPod(buildReplica("pd-2", false)))
+		consumers := countConsumers(&n)
+		assert.Len(t, consumers, 1)
+		for _, c := range consumers {
+			assert.Equal(t, 2, c)
+		}
+	})
+}
+
+func TestNode__FindPreemptionVictims(t *testing.T) {
+	newPod := func(name string, priority int32, profile slicing.ProfileName) v1.Pod {
+		return factory.BuildPod("ns-1", name).
+			WithUID(name).
+			WithPriority(priority).
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(profile.AsResourceName(), 1).
+					Get(),
+			).Get()
+	}
+	priority := func(pod v1.Pod) int32 {
+		return *pod.Spec.Priority
+	}
+
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		pods            []v1.Pod
+		pod             v1.Pod
+		expectedVictims []v1.Pod
+		errExpected     bool
+	}{
+		{
+			name: "Node has free slices, no victims",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusFree): "1",
+			},
+			pods: []v1.Pod{
+				newPod("pd-1", 1, "20gb"),
+			},
+			pod:             newPod("pd-high", 10, "20gb"),
+			expectedVictims: []v1.Pod{},
+		},
+		{
+			name: "All slices used by lower priority pods, should evict the lowest priority one",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "2",
+			},
+			pods: []v1.Pod{
+				newPod("pd-1", 2, "20gb"),
+				newPod("pd-2", 1, "20gb"),
+			},
+			pod: newPod("pd-high", 10, "20gb"),
+			expectedVictims: []v1.Pod{
+				newPod("pd-2", 1, "20gb"),
+			},
+		},
+		{
+			name: "All slices used by pods with higher or equal priority, should return error",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "2",
+			},
+			pods: []v1.Pod{
+				newPod("pd-1", 10, "20gb"),
+				newPod("pd-2", 20, "20gb"),
+			},
+			pod:         newPod("pd-high", 10, "20gb"),
+			errExpected: true,
+		},
+		{
+			name: "Lower priority pods use slices of a different profile, should return error",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "4",
+			},
+			pods: []v1.Pod{
+				newPod("pd-1", 1, "10gb"),
+				newPod("pd-2", 1, "10gb"),
+				newPod("pd-3", 1, "10gb"),
+				newPod("pd-4", 1, "10gb"),
+			},
+			pod:         newPod("pd-high", 10, "20gb"),
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "1",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(tt.annotations).
+				Get()
+			pods := make([]*v1.Pod, len(tt.pods))
+			for i := range tt.pods {
+				pods[i] = &tt.pods[i]
+			}
+			nodeInfo := framework.NewNodeInfo(pods...)
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			geometry := n.Geometry()
+
+			victims, err := n.FindPreemptionVictims(tt.pod, priority)
+			if tt.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVictims, victims)
+			// The node must not be modified
+			assert.Equal(t, geometry, n.Geometry())
+			assert.Len(t, n.NodeInfo().Pods, len(tt.pods))
+		})
+	}
+}
+
+func TestNode__GetAllocatableSlices(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		pods        []v1.Pod
+		expected    map[v1.ResourceName]int64
+	}{
+		{
+			name:        "Node without status annotations",
+			annotations: map[string]string{},
+			expected:    map[v1.ResourceName]int64{},
+		},
+		{
+			name: "Free slices of multiple GPUs are summed, used slices are not included",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "20gb", resource.StatusUsed): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusFree): "1",
+			},
+			expected: map[v1.ResourceName]int64{
+				slicing.ProfileName("10gb").AsResourceName(): 3,
+				slicing.ProfileName("20gb").AsResourceName(): 1,
+			},
+		},
+		{
+			name: "Free slices of unhealthy GPUs are not included",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusFree): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuHealthFormat, 0):                              v1alpha1.AnnotationGpuUnhealthy,
+			},
+			expected: map[v1.ResourceName]int64{
+				slicing.ProfileName("10gb").AsResourceName(): 1,
+			},
+		},
+		{
+			name: "Slices reserved by pods added to the node are not included",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusFree): "1",
+			},
+			pods: []v1.Pod{
+				factory.BuildPod("ns-1", "pd-1").WithContainer(
+					factory.BuildContainer("c-1", "foo").
+						WithScalarResourceRequest(slicing.ProfileName("20gb").AsResourceName(), 1).
+						Get(),
+				).Get(),
+			},
+			expected: map[v1.ResourceName]int64{
+				slicing.ProfileName("10gb").AsResourceName(): 2,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "2",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(tt.annotations).
+				Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			for _, pod := range tt.pods {
+				assert.NoError(t, n.AddPod(pod))
+			}
+			assert.Equal(t, tt.expected, n.GetAllocatableSlices())
+		})
+	}
+}
+
+func TestNode__InvalidProfiles(t *testing.T) {
+	t.Run("Invalid profile in status annotations, NewNode should return error", func(t *testing.T) {
+		node := factory.BuildNode("node-1").
+			WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "foo",
+				constant.LabelNvidiaCount:   "1",
+				constant.LabelNvidiaMemory:  "40000",
+			}).
+			WithAnnotations(map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "foogb", resource.StatusFree): "1",
+			}).
+			Get()
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+
+		_, err := slicing.NewNode(*nodeInfo)
+		assert.Error(t, err)
+	})
+
+	t.Run("Pod requesting invalid profile, AddPod should return error", func(t *testing.T) {
+		node := factory.BuildNode("node-1").
+			WithLabels(map[string]string{
+				constant.LabelNvidiaProduct: "foo",
+				constant.LabelNvidiaCount:   "1",
+				constant.LabelNvidiaMemory:  "40000",
+			}).
+			WithAnnotations(map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "1",
+			}).
+			Get()
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+
+		pod := factory.BuildPod("ns-1", "pd-1").WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest("nvidia.com/gpu-10gbfoo", 1).
+				Get(),
+		).Get()
+		assert.Error(t, n.AddPod(pod))
+		assert.Len(t, n.NodeInfo().Pods, 0)
+	})
+}
+
+func TestNode_AddPod__AnnotationRequests(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "1",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	// Malformed annotation
+	pod := factory.BuildPod("ns-1", "pd-1").WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "10gb y2").Get()
+	assert.Error(t, n.AddPod(pod))
+
+	// Annotation only
+	pod = factory.BuildPod("ns-1", "pd-2").WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "10gb x2").Get()
+	assert.NoError(t, n.AddPod(pod))
+	assert.Equal(t, 2, n.GPUs[0].UsedProfiles["10gb"])
+	assert.Equal(t, 0, n.GPUs[0].FreeProfiles["10gb"])
+}
+
+func TestNode__FractionalProfiles(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "1",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "0.25", resource.StatusFree): "4",
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	// Pods requesting both fractional and memory-based slices are rejected
+	pod := factory.BuildPod("ns-1", "pd-mixed").
+		WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "0.25, 10gb").
+		Get()
+	assert.Error(t, n.AddPod(pod))
+
+	// Four quarters fit the GPU
+	for i := 0; i < 4; i++ {
+		pod = factory.BuildPod("ns-1", fmt.Sprintf("pd-%d", i)).
+			WithUID(fmt.Sprintf("pd-%d", i)).
+			WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "0.25").
+			Get()
+		assert.NoError(t, n.AddPod(pod))
+	}
+	assert.Equal(t, 4, n.GPUs[0].UsedProfiles["0.25"])
+
+	// A fifth quarter is rejected
+	pod = factory.BuildPod("ns-1", "pd-4").WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "0.25").Get()
+	assert.Error(t, n.AddPod(pod))
+
+	// Fractions exceeding the GPU are rejected when building the node
+	node.Annotations[fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "0.5", resource.StatusUsed)] = "1"
+	nodeInfo.SetNode(&node)
+	_, err = slicing.NewNode(*nodeInfo)
+	assert.Error(t, err)
+}
+
+func TestNode__CanFit(t *testing.T) {
+	podRequesting := func(profile slicing.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", "pd-1").
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(profile.AsResourceName(), quantity).
+					Get(),
+			).
+			Get()
+	}
+
+	testCases := []struct {
+		name        string
+		pod         v1.Pod
+		expected    bool
+		expectedErr bool
+	}{
+		{
+			name:        "Pod fits",
+			pod:         podRequesting("10gb", 2),
+			expected:    true,
+			expectedErr: false,
+		},
+		{
+			name:        "Not enough free slices",
+			pod:         podRequesting("10gb", 3),
+			expected:    false,
+			expectedErr: false,
+		},
+		{
+			name:        "Profile not available on the node",
+			pod:         podRequesting("5gb", 1),
+			expected:    false,
+			expectedErr: false,
+		},
+		{
+			name: "Unknown profile",
+			pod: factory.BuildPod("ns-1", "pd-1").
+				WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "foo").
+				Get(),
+			expected:    false,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "1",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(map[string]string{
+					fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+				}).
+				Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+
+			fits, err := n.CanFit(tt.pod)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, fits)
+
+			// The node should not be modified
+			assert.Equal(t, map[slicing.ProfileName]int{"10gb": 2}, n.GPUs[0].FreeProfiles)
+			assert.Empty(t, n.GPUs[0].UsedProfiles)
+		})
+	}
+}
+
+func TestNode__GetSliceConsumers(t *testing.T) {
+	buildPod := func(name string, profile slicing.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithUID(name).WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(profile.AsResourceName(), quantity).
+				Get(),
+		).Get()
+	}
+
+	t.Run("Pod added via AddPod is the consumer of its slices", func(t *testing.T) {
+		node := factory.BuildNode("node-1").WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "2",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "20gb", resource.StatusFree): "1",
+		}).Get()
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(&node)
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+		assert.Empty(t, n.GetSliceConsumers())
+
+		pod1 := buildPod("pd-1", "10gb", 1)
+		pod2 := buildPod("pd-2", "20gb", 1)
+		pod3 := buildPod("pd-3", "10gb", 1)
+		assert.NoError(t, n.AddPod(pod1))
+		assert.NoError(t, n.AddPod(pod2))
+		assert.NoError(t, n.AddPod(pod3))
+		assert.Equal(
+			t,
+			map[slicing.ProfileName][]slicing.PodRef{
+				"10gb": {{Namespace: "ns-1", Name: "pd-1"}, {Namespace: "ns-1", Name: "pd-3"}},
+				"20gb": {{Namespace: "ns-1", Name: "pd-2"}},
+			},
+			n.GetSliceConsumers(),
+		)
+
+		// Removed pods should not be consumers anymore
+		assert.NoError(t, n.RemovePod(pod1))
+		assert.Equal(
+			t,
+			map[slicing.ProfileName][]slicing.PodRef{
+				"10gb": {{Namespace: "ns-1", Name: "pd-3"}},
+				"20gb": {{Namespace: "ns-1", Name: "pd-2"}},
+			},
+			n.GetSliceConsumers(),
+		)
+
+		// Consumers should be cloned
+		cloned := n.Clone().(*slicing.Node)
+		assert.NoError(t, cloned.RemovePod(pod2))
+		assert.Len(t, n.GetSliceConsumers(), 2)
+		assert.Len(t, cloned.GetSliceConsumers(), 1)
+	})
+
+	t.Run("Pods of node info are the consumers of the used slices", func(t *testing.T) {
+		node := factory.BuildNode("node-1").WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusUsed): "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 1, "10gb", resource.StatusUsed): "1",
+		}).Get()
+		pod1 := buildPod("pd-1", "10gb", 1)
+		pod2 := buildPod("pd-2", "10gb", 1)
+		// Pod without a matching used slice should not be a consumer
+		pod3 := buildPod("pd-3", "10gb", 1)
+		nodeInfo := framework.NewNodeInfo(&pod1, &pod2, &pod3)
+		nodeInfo.SetNode(&node)
+		n, err := slicing.NewNode(*nodeInfo)
+		assert.NoError(t, err)
+
+		assert.Equal(
+			t,
+			map[slicing.ProfileName][]slicing.PodRef{
+				"10gb": {{Namespace: "ns-1", Name: "pd-1"}, {Namespace: "ns-1", Name: "pd-2"}},
+			},
+			n.GetSliceConsumers(),
+		)
+		assert.Equal(
+			t,
+			map[slicing.ProfileName][]slicing.PodRef{"10gb": {{Namespace: "ns-1", Name: "pd-1"}}},
+			n.GPUs[0].GetSliceConsumers(),
+		)
+		assert.Equal(
+			t,
+			map[slicing.ProfileName][]slicing.PodRef{"10gb": {{Namespace: "ns-1", Name: "pd-2"}}},
+			n.GPUs[1].GetSliceConsumers(),
+		)
+	})
+}
+
+func TestNode_AddPod__InitContainers(t *testing.T) {
+	node := factory.BuildNode("node-1").WithLabels(map[string]string{
+		constant.LabelNvidiaProduct: "foo",
+		constant.LabelNvidiaCount:   "1",
+		constant.LabelNvidiaMemory:  "40000",
+	}).WithAnnotations(map[string]string{
+		fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, "10gb", resource.StatusFree): "3",
+	}).Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+
+	// The init container requests more slices than the main container: the pod should take all of them
+	pod := factory.BuildPod("ns-1", "pd-1").
+		WithInitContainer(
+			factory.BuildContainer("init", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 3).
+				Get(),
+		).
+		WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+				Get(),
+		).
+		Get()
+	fits, err := n.CanFit(pod)
+	assert.NoError(t, err)
+	assert.True(t, fits)
+	assert.NoError(t, n.AddPod(pod))
+	assert.Equal(t, map[slicing.ProfileName]int{"10gb": 3}, n.GPUs[0].UsedProfiles)
+	assert.Equal(t, map[slicing.ProfileName]int{"10gb": 0}, n.GPUs[0].FreeProfiles)
+
+	// No slices should be left for other pods
+	other := factory.BuildPod("ns-1", "pd-2").
+		WithContainer(
+			factory.BuildContainer("c-1", "foo").
+				WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+				Get(),
+		).
+		Get()
+	assert.Error(t, n.AddPod(other))
+}
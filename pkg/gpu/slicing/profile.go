@@ -28,9 +28,18 @@ import (
 
 var (
 	profileNamePrefix = fmt.Sprintf("%s-", constant.ResourceNvidiaGPU.String())
-	resourceRegexp    = regexp.MustCompile(`nvidia\.com/gpu-\d+gb`)
+	resourceRegexp    = regexp.MustCompile(`nvidia\.com/gpu-(\d+gb|0\.\d+)`)
+	profileRegexp     = regexp.MustCompile(`^\d+gb$`)
+	// fractionalProfileRegexp matches the profiles representing a fraction of a GPU (e.g. "0.25")
+	fractionalProfileRegexp = regexp.MustCompile(`^0\.\d+$`)
+	// looseProfileRegexp and looseFractionalProfileRegexp match the non-canonical forms accepted by ParseProfile
+	looseProfileRegexp           = regexp.MustCompile(`^(\d+)\s*gb$`)
+	looseFractionalProfileRegexp = regexp.MustCompile(`^0?\.\d+$`)
 )
 
+// fractionTolerance is the tolerance used when comparing sums of GPU fractions
+const fractionTolerance = 1e-9
+
 type ProfileName string
 
 func (p ProfileName) SmallerThan(other gpu.Slice) bool {
@@ -38,6 +47,9 @@ func (p ProfileName) SmallerThan(other gpu.Slice) bool {
 	if !ok {
 		return false
 	}
+	if p.IsFractional() && otherProfile.IsFractional() {
+		return p.GetFraction() < otherProfile.GetFraction()
+	}
 	return p.GetMemorySizeGB() < otherProfile.GetMemorySizeGB()
 }
 
@@ -49,15 +61,153 @@ func NewProfile(sizeGb int) ProfileName {
 	return ProfileName(fmt.Sprintf("%dgb", sizeGb))
 }
 
-func (p ProfileName) GetMemorySizeGB() int {
+// NewFractionalProfile returns the profile corresponding to the fraction of GPU provided as argument.
+//
+// Example:
+//
+//	0.25 => "0.25"
+func NewFractionalProfile(fraction float64) ProfileName {
+	return ProfileName(strconv.FormatFloat(fraction, 'f', -1, 64))
+}
+
+// ParseProfile parses the profile provided as argument, tolerating casing, whitespace and
+// formatting differences, and returns it in its canonical form. It returns an error if the
+// profile cannot be parsed or if the resulting profile is not valid.
+//
+// Example:
+//
+//	10Gb => 10gb
+//	10 GB => 10gb
+//	010gb => 10gb
+//	.25 => 0.25
+//	0.50 => 0.5
+//	foo => error
+func ParseProfile(s string) (ProfileName, error) {
+	normalized := strings.ToLower(strings.TrimSpace(s))
+	var profile ProfileName
+	if matches := looseProfileRegexp.FindStringSubmatch(normalized); matches != nil {
+		memoryGB, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid profile name %q: %v", s, err)
+		}
+		profile = NewProfile(memoryGB)
+	} else if looseFractionalProfileRegexp.MatchString(normalized) {
+		fraction, err := strconv.ParseFloat(normalized, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid profile name %q: %v", s, err)
+		}
+		profile = NewFractionalProfile(fraction)
+	} else {
+		return "", fmt.Errorf("invalid profile name %q: required format is <memory>gb or 0.<digits>", s)
+	}
+	if err := profile.Validate(); err != nil {
+		return "", fmt.Errorf("invalid profile name %q: %v", s, err)
+	}
+	return profile, nil
+}
+
+// NormalizeResourceName returns the canonical form of the GPU slice resource name provided as
+// argument. Resources that are not GPU slices are returned unchanged, while GPU slices whose
+// profile cannot be parsed with ParseProfile result in an error.
+//
+// Example:
+//
+//	nvidia.com/gpu-10GB => nvidia.com/gpu-10gb
+//	nvidia.com/gpu => nvidia.com/gpu
+//	cpu => cpu
+//	nvidia.com/gpu-foo => error
+func NormalizeResourceName(resourceName v1.ResourceName) (v1.ResourceName, error) {
+	name := resourceName.String()
+	if len(name) <= len(profileNamePrefix) || !strings.EqualFold(name[:len(profileNamePrefix)], profileNamePrefix) {
+		return resourceName, nil
+	}
+	profile, err := ParseProfile(name[len(profileNamePrefix):])
+	if err != nil {
+		return "", err
+	}
+	return profile.AsResourceName(), nil
+}
+
+// IsFractional returns true if the profile represents a fraction of a GPU regardless of
+// its memory (e.g. "0.25"), rather than an amount of GPU memory (e.g. "10gb").
+func (p ProfileName) IsFractional() bool {
 	trimmed := strings.TrimPrefix(p.String(), profileNamePrefix)
-	trimmed = strings.TrimSuffix(trimmed, "gb")
-	if i, err := strconv.Atoi(trimmed); err == nil {
+	return fractionalProfileRegexp.MatchString(trimmed)
+}
+
+// GetFraction returns the fraction of GPU represented by the profile, or 0 if the profile is not fractional.
+//
+// Example:
+//
+//	0.25 => 0.25
+//	10gb => 0
+func (p ProfileName) GetFraction() float64 {
+	if !p.IsFractional() {
+		return 0
+	}
+	trimmed := strings.TrimPrefix(p.String(), profileNamePrefix)
+	fraction, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil {
+		return 0
+	}
+	return fraction
+}
+
+// GetMemorySizeGB returns the amount of memory GB of the profile, or 0 if the profile name is malformed.
+func (p ProfileName) GetMemorySizeGB() int {
+	if i, err := p.GetMemoryGB(); err == nil {
 		return i
 	}
 	return 0
 }
 
+// GetMemoryGB parses the memory portion of the profile name and returns it as GB.
+//
+// Example:
+//
+//	10gb => 10
+//	foo => error
+func (p ProfileName) GetMemoryGB() (int, error) {
+	trimmed := strings.TrimPrefix(p.String(), profileNamePrefix)
+	if !strings.HasSuffix(trimmed, "gb") {
+		return 0, fmt.Errorf("invalid profile name %q: missing \"gb\" suffix", p)
+	}
+	memoryGB, err := strconv.Atoi(strings.TrimSuffix(trimmed, "gb"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid profile name %q: %v", p, err)
+	}
+	return memoryGB, nil
+}
+
+// Validate returns an error if the profile name is not in the format "<memory>gb", where
+// memory is a positive integer, or in the format "0.<digits>", representing a fraction of GPU.
+//
+// Example:
+//
+//	10gb => valid
+//	0.25 => valid
+//	0.0 => error
+//	"" => error
+//	10 => error
+//	0gb => error
+func (p ProfileName) Validate() error {
+	if fractionalProfileRegexp.MatchString(p.String()) {
+		if p.GetFraction() <= 0 {
+			return fmt.Errorf("invalid profile name %q: fraction must be greater than 0", p)
+		}
+		return nil
+	}
+	if !profileRegexp.MatchString(p.String()) {
+		return fmt.Errorf("invalid profile name %q: required format is %s", p, profileRegexp.String())
+	}
+	if memoryGB, _ := p.GetMemoryGB(); memoryGB <= 0 {
+		return fmt.Errorf("invalid profile name %q: memory must be greater than 0", p)
+	}
+	return nil
+}
+
+// AsResourceName returns the name of the resource corresponding to the profile. The profile name is
+// not validated, so Validate should be used to check profiles coming from user input.
 func (p ProfileName) AsResourceName() v1.ResourceName {
 	resourceNameStr := fmt.Sprintf("%s%s", profileNamePrefix, p)
 	return v1.ResourceName(resourceNameStr)
@@ -21,6 +21,7 @@ import (
 	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	"testing"
 )
 
@@ -40,6 +41,11 @@ func TestProfileName__GetMemorySizeGB(t *testing.T) {
 			profileName: "nvidia.com/gpu-10gb",
 			expected:    10,
 		},
+		{
+			name:        "Small profile",
+			profileName: "2gb",
+			expected:    2,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -49,6 +55,123 @@ func TestProfileName__GetMemorySizeGB(t *testing.T) {
 	}
 }
 
+func TestProfileName__GetMemoryGB(t *testing.T) {
+	testCases := []struct {
+		name        string
+		profileName slicing.ProfileName
+		expected    int
+		errExpected bool
+	}{
+		{
+			name:        "10gb",
+			profileName: "10gb",
+			expected:    10,
+		},
+		{
+			name:        "20gb",
+			profileName: "20gb",
+			expected:    20,
+		},
+		{
+			name:        "Resource name",
+			profileName: "nvidia.com/gpu-10gb",
+			expected:    10,
+		},
+		{
+			name:        "Missing gb suffix, should return error",
+			profileName: "10",
+			errExpected: true,
+		},
+		{
+			name:        "Malformed memory, should return error",
+			profileName: "foogb",
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryGB, err := tt.profileName.GetMemoryGB()
+			if tt.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, memoryGB)
+		})
+	}
+}
+
+func TestProfileName__Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		profileName slicing.ProfileName
+		errExpected bool
+	}{
+		{
+			name:        "Valid profile",
+			profileName: "10gb",
+			errExpected: false,
+		},
+		{
+			name:        "Empty profile",
+			profileName: "",
+			errExpected: true,
+		},
+		{
+			name:        "Missing gb suffix",
+			profileName: "10",
+			errExpected: true,
+		},
+		{
+			name:        "Non numeric memory",
+			profileName: "foogb",
+			errExpected: true,
+		},
+		{
+			name:        "MIG profile",
+			profileName: "1g.10gb",
+			errExpected: true,
+		},
+		{
+			name:        "Resource name instead of profile",
+			profileName: "nvidia.com/gpu-10gb",
+			errExpected: true,
+		},
+		{
+			name:        "Zero memory",
+			profileName: "0gb",
+			errExpected: true,
+		},
+		{
+			name:        "Fractional profile",
+			profileName: "0.25",
+			errExpected: false,
+		},
+		{
+			name:        "Zero fraction",
+			profileName: "0.0",
+			errExpected: true,
+		},
+		{
+			name:        "Fraction greater than 1",
+			profileName: "1.5",
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profileName.Validate()
+			if tt.errExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestProfileName__SmallerThan(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -94,3 +217,192 @@ func TestProfileName__SmallerThan(t *testing.T) {
 		})
 	}
 }
+
+func TestProfileName__GetFraction(t *testing.T) {
+	testCases := []struct {
+		name               string
+		profileName        slicing.ProfileName
+		expectedFractional bool
+		expectedFraction   float64
+	}{
+		{
+			name:               "Fractional profile",
+			profileName:        "0.25",
+			expectedFractional: true,
+			expectedFraction:   0.25,
+		},
+		{
+			name:               "Fractional resource name",
+			profileName:        "nvidia.com/gpu-0.5",
+			expectedFractional: true,
+			expectedFraction:   0.5,
+		},
+		{
+			name:               "Memory profile",
+			profileName:        "10gb",
+			expectedFractional: false,
+			expectedFraction:   0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFractional, tt.profileName.IsFractional())
+			assert.Equal(t, tt.expectedFraction, tt.profileName.GetFraction())
+		})
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    slicing.ProfileName
+		errExpected bool
+	}{
+		{
+			name:     "Canonical profile",
+			value:    "10gb",
+			expected: "10gb",
+		},
+		{
+			name:     "Mixed case suffix",
+			value:    "10Gb",
+			expected: "10gb",
+		},
+		{
+			name:     "Upper case suffix",
+			value:    "10GB",
+			expected: "10gb",
+		},
+		{
+			name:     "Whitespace",
+			value:    " 10 gb ",
+			expected: "10gb",
+		},
+		{
+			name:     "Leading zeros",
+			value:    "010gb",
+			expected: "10gb",
+		},
+		{
+			name:     "Canonical fractional profile",
+			value:    "0.25",
+			expected: "0.25",
+		},
+		{
+			name:     "Fraction without leading zero",
+			value:    ".25",
+			expected: "0.25",
+		},
+		{
+			name:     "Fraction with trailing zeros",
+			value:    "0.50",
+			expected: "0.5",
+		},
+		{
+			name:        "Empty profile",
+			value:       "",
+			errExpected: true,
+		},
+		{
+			name:        "Missing gb suffix",
+			value:       "10",
+			errExpected: true,
+		},
+		{
+			name:        "Non numeric memory",
+			value:       "foo",
+			errExpected: true,
+		},
+		{
+			name:        "Zero memory",
+			value:       "0gb",
+			errExpected: true,
+		},
+		{
+			name:        "Zero fraction",
+			value:       "0.0",
+			errExpected: true,
+		},
+		{
+			name:        "MIG profile",
+			value:       "1g.10gb",
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := slicing.ParseProfile(tt.value)
+			if tt.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, profile)
+		})
+	}
+}
+
+func TestNormalizeResourceName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		resourceName v1.ResourceName
+		expected     v1.ResourceName
+		errExpected  bool
+	}{
+		{
+			name:         "Canonical GPU slice",
+			resourceName: "nvidia.com/gpu-10gb",
+			expected:     "nvidia.com/gpu-10gb",
+		},
+		{
+			name:         "Mixed case GPU slice",
+			resourceName: "nvidia.com/gpu-10Gb",
+			expected:     "nvidia.com/gpu-10gb",
+		},
+		{
+			name:         "Upper case GPU slice",
+			resourceName: "nvidia.com/gpu-10GB",
+			expected:     "nvidia.com/gpu-10gb",
+		},
+		{
+			name:         "Fractional GPU slice",
+			resourceName: "nvidia.com/gpu-.50",
+			expected:     "nvidia.com/gpu-0.5",
+		},
+		{
+			name:         "Full GPU, should be unchanged",
+			resourceName: "nvidia.com/gpu",
+			expected:     "nvidia.com/gpu",
+		},
+		{
+			name:         "MIG resource, should be unchanged",
+			resourceName: "nvidia.com/mig-1g.10gb",
+			expected:     "nvidia.com/mig-1g.10gb",
+		},
+		{
+			name:         "CPU, should be unchanged",
+			resourceName: v1.ResourceCPU,
+			expected:     v1.ResourceCPU,
+		},
+		{
+			name:         "Unparseable GPU slice",
+			resourceName: "nvidia.com/gpu-foo",
+			errExpected:  true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceName, err := slicing.NormalizeResourceName(tt.resourceName)
+			if tt.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resourceName)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing
+
+import (
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	"sort"
+)
+
+// NamespaceQuotas contains, for each namespace, the max number of slices of each profile
+// that the pods of the namespace can request cluster-wide.
+// Profiles without a quota are not limited.
+type NamespaceQuotas map[string]map[ProfileName]int
+
+// NamespaceAllocations contains, for each namespace, the number of slices of each profile
+// currently requested by the pods of the namespace.
+type NamespaceAllocations map[string]map[ProfileName]int
+
+// QuotaExceededError is returned when admitting a pod would exceed the slices quota of its namespace.
+type QuotaExceededError struct {
+	Namespace string
+	Profile   ProfileName
+	Quota     int
+	Allocated int
+	Requested int
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf(
+		"quota of %d %s slices of namespace %q exceeded: %d already allocated, %d requested",
+		e.Quota,
+		e.Profile,
+		e.Namespace,
+		e.Allocated,
+		e.Requested,
+	)
+}
+
+// CheckNamespaceQuota returns a QuotaExceededError if admitting the pod provided as argument would make
+// its namespace request more slices than the ones allowed by the quotas, given the current allocations.
+// If multiple quotas are violated, the error refers to the first profile in lexicographic order.
+func CheckNamespaceQuota(pod v1.Pod, allocations NamespaceAllocations, quotas NamespaceQuotas) error {
+	namespaceQuotas, ok := quotas[pod.Namespace]
+	if !ok {
+		return nil
+	}
+
+	requested := GetRequestedProfiles(pod)
+	profiles := make([]ProfileName, 0, len(requested))
+	for p := range requested {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i] < profiles[j]
+	})
+
+	for _, p := range profiles {
+		quota, ok := namespaceQuotas[p]
+		if !ok {
+			continue
+		}
+		allocated := allocations[pod.Namespace][p]
+		if allocated+requested[p] > quota {
+			return QuotaExceededError{
+				Namespace: pod.Namespace,
+				Profile:   p,
+				Quota:     quota,
+				Allocated: allocated,
+				Requested: requested[p],
+			}
+		}
+	}
+
+	return nil
+}
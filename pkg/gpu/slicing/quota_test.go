@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckNamespaceQuota(t *testing.T) {
+	quotas := slicing.NamespaceQuotas{
+		"research": {
+			slicing.ProfileName("20gb"): 4,
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		namespace   string
+		requested   map[slicing.ProfileName]int
+		allocations slicing.NamespaceAllocations
+		expectedErr error
+	}{
+		{
+			name:        "Namespace without quotas",
+			namespace:   "other",
+			requested:   map[slicing.ProfileName]int{"20gb": 10},
+			allocations: slicing.NamespaceAllocations{},
+			expectedErr: nil,
+		},
+		{
+			name:      "Profile without quota",
+			namespace: "research",
+			requested: map[slicing.ProfileName]int{"10gb": 10},
+			allocations: slicing.NamespaceAllocations{
+				"research": {"20gb": 4},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:      "Under quota",
+			namespace: "research",
+			requested: map[slicing.ProfileName]int{"20gb": 1},
+			allocations: slicing.NamespaceAllocations{
+				"research": {"20gb": 2},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:      "At quota",
+			namespace: "research",
+			requested: map[slicing.ProfileName]int{"20gb": 2},
+			allocations: slicing.NamespaceAllocations{
+				"research": {"20gb": 2},
+				"other":    {"20gb": 10},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:      "Over quota",
+			namespace: "research",
+			requested: map[slicing.ProfileName]int{"20gb": 2, "10gb": 1},
+			allocations: slicing.NamespaceAllocations{
+				"research": {"20gb": 3},
+			},
+			expectedErr: slicing.QuotaExceededError{
+				Namespace: "research",
+				Profile:   "20gb",
+				Quota:     4,
+				Allocated: 3,
+				Requested: 2,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			container := factory.BuildContainer("c-1", "foo")
+			for p, q := range tt.requested {
+				container = container.WithScalarResourceRequest(p.AsResourceName(), q)
+			}
+			pod := factory.BuildPod(tt.namespace, "pd-1").WithContainer(container.Get()).Get()
+
+			err := slicing.CheckNamespaceQuota(pod, tt.allocations, quotas)
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Topology contains the information about how a GPU is connected to the rest of the node
+type Topology struct {
+	// NumaNode is the NUMA node the GPU is attached to, nil if unknown
+	NumaNode *int
+	// NVLinkPeers are the indexes of the GPUs of the node directly connected to the GPU through NVLink
+	NVLinkPeers []int
+}
+
+func (t Topology) Clone() Topology {
+	cloned := Topology{}
+	if t.NumaNode != nil {
+		numaNode := *t.NumaNode
+		cloned.NumaNode = &numaNode
+	}
+	if t.NVLinkPeers != nil {
+		cloned.NVLinkPeers = make([]int, len(t.NVLinkPeers))
+		copy(cloned.NVLinkPeers, t.NVLinkPeers)
+	}
+	return cloned
+}
+
+// getTopology returns the topology of the GPU with the index provided as argument extracted from the
+// node annotations, or nil if the node does not expose any valid topology information about the GPU.
+// Invalid values are logged and ignored.
+func getTopology(n v1.Node, gpuIndex int) *Topology {
+	var topology *Topology
+
+	numaNodeKey := fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, gpuIndex)
+	if numaNodeVal, ok := n.Annotations[numaNodeKey]; ok {
+		if numaNode, err := strconv.Atoi(numaNodeVal); err != nil {
+			logInvalidAnnotation(n, numaNodeKey, numaNodeVal)
+		} else {
+			topology = &Topology{NumaNode: &numaNode}
+		}
+	}
+
+	peersKey := fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, gpuIndex)
+	if peersVal, ok := n.Annotations[peersKey]; ok {
+		if peers, err := parseNVLinkPeers(peersVal); err != nil {
+			logInvalidAnnotation(n, peersKey, peersVal)
+		} else {
+			if topology == nil {
+				topology = &Topology{}
+			}
+			topology.NVLinkPeers = peers
+		}
+	}
+
+	return topology
+}
+
+// parseNVLinkPeers parses the comma-separated GPU indexes provided as argument
+func parseNVLinkPeers(s string) ([]int, error) {
+	peers := make([]int, 0)
+	for _, p := range strings.Split(s, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		peer, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+// GroupByNVLinkDomain groups the GPUs of the node by NVLink domain, namely the sets of GPUs connected to each
+// other either directly or transitively through NVLink. GPUs without topology information are returned
+// as single-GPU domains.
+//
+// The returned domains contain the GPU indexes sorted in ascending order, and are sorted by their first index.
+func (n *Node) GroupByNVLinkDomain() [][]int {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	// Init union-find structure
+	parent := make(map[int]int, len(n.GPUs))
+	for _, g := range n.GPUs {
+		parent[g.Index] = g.Index
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Join GPUs connected through NVLink, ignoring peers that are not GPUs of the node
+	for _, g := range n.GPUs {
+		if g.Topology == nil {
+			continue
+		}
+		for _, peer := range g.Topology.NVLinkPeers {
+			if _, ok := parent[peer]; !ok {
+				continue
+			}
+			parent[find(peer)] = find(g.Index)
+		}
+	}
+
+	domainsLookup := make(map[int][]int)
+	for _, g := range n.GPUs {
+		root := find(g.Index)
+		domainsLookup[root] = append(domainsLookup[root], g.Index)
+	}
+	domains := make([][]int, 0, len(domainsLookup))
+	for _, d := range domainsLookup {
+		sort.Ints(d)
+		domains = append(domains, d)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i][0] < domains[j][0]
+	})
+	return domains
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slicing_test
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"testing"
+)
+
+func TestNode__GroupByNVLinkDomain(t *testing.T) {
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedDomains [][]int
+	}{
+		{
+			name:            "No topology annotations, each GPU is its own domain",
+			annotations:     map[string]string{},
+			expectedDomains: [][]int{{0}, {1}, {2}, {3}},
+		},
+		{
+			name: "Two NVLink pairs",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "1",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 1): "0",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 2): "3",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 3): "2",
+			},
+			expectedDomains: [][]int{{0, 1}, {2, 3}},
+		},
+		{
+			name: "Transitive links and peers not belonging to the node",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "2, 7",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 3): "2",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, 1):    "1",
+			},
+			expectedDomains: [][]int{{0, 2, 3}, {1}},
+		},
+		{
+			name: "Invalid NVLink peers annotation is ignored",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "foo",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 2): "3",
+			},
+			expectedDomains: [][]int{{0}, {1}, {2, 3}},
+		},
+		{
+			name: "Invalid NUMA node annotation is ignored",
+			annotations: map[string]string{
+				fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, 0):    "foo",
+				fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "1",
+			},
+			expectedDomains: [][]int{{0, 1}, {2}, {3}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").
+				WithLabels(map[string]string{
+					constant.LabelNvidiaProduct: "foo",
+					constant.LabelNvidiaCount:   "4",
+					constant.LabelNvidiaMemory:  "40000",
+				}).
+				WithAnnotations(tt.annotations).
+				Get()
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&node)
+
+			n, err := slicing.NewNode(*nodeInfo)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDomains, n.GroupByNVLinkDomain())
+		})
+	}
+}
+
+func TestNewNode__Topology(t *testing.T) {
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{
+			constant.LabelNvidiaProduct: "foo",
+			constant.LabelNvidiaCount:   "2",
+			constant.LabelNvidiaMemory:  "40000",
+		}).
+		WithAnnotations(map[string]string{
+			fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, 0):    "1",
+			fmt.Sprintf(v1alpha1.AnnotationGpuNVLinkPeersFormat, 0): "1",
+		}).
+		Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+
+	n, err := slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+	numaNode := 1
+	assert.Equal(t, &slicing.Topology{NumaNode: &numaNode, NVLinkPeers: []int{1}}, n.GPUs[0].Topology)
+	assert.Nil(t, n.GPUs[1].Topology)
+
+	// Invalid values are ignored, keeping the valid ones
+	node.Annotations[fmt.Sprintf(v1alpha1.AnnotationGpuNumaNodeFormat, 0)] = "foo"
+	nodeInfo.SetNode(&node)
+	n, err = slicing.NewNode(*nodeInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, &slicing.Topology{NVLinkPeers: []int{1}}, n.GPUs[0].Topology)
+
+	// Topology is deep-copied when cloning the node
+	cloned := n.Clone().(*slicing.Node)
+	cloned.GPUs[0].Topology.NVLinkPeers[0] = 0
+	assert.Equal(t, []int{1}, n.GPUs[0].Topology.NVLinkPeers)
+}
@@ -18,9 +18,13 @@ package slicing
 
 import (
 	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/util"
 	v1 "k8s.io/api/core/v1"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -56,6 +60,16 @@ func ExtractGpuId(resourceId string) string {
 	return before
 }
 
+// GetRequestedProfiles returns the slices requested by the Pod provided as argument, either through
+// the resource requests of its containers or through the v1alpha1.AnnotationGpuSliceRequest annotation.
+//
+// Since init containers do not run at the same time as the regular containers, the slices of each profile
+// requested through the containers are computed as the max between the slices requested by any init
+// container and the sum of the slices requested by the regular containers, matching the way Kubernetes
+// computes the effective requests of a Pod.
+//
+// Container resource requests take precedence: the annotation is used only for the profiles that are not
+// requested by any container. If the annotation is malformed, it is ignored.
 func GetRequestedProfiles(pod v1.Pod) map[ProfileName]int {
 	res := make(map[ProfileName]int)
 	for r, quantity := range resource.ComputePodRequest(pod) {
@@ -63,9 +77,141 @@ func GetRequestedProfiles(pod v1.Pod) map[ProfileName]int {
 			res[profile] += int(quantity.Value())
 		}
 	}
+	annotationProfiles, _ := GetAnnotationRequestedProfiles(pod)
+	for profile, quantity := range annotationProfiles {
+		if _, ok := res[profile]; !ok {
+			res[profile] = quantity
+		}
+	}
+	return res
+}
+
+// GetAnnotationRequestedProfiles returns the slices requested by the Pod provided as argument through the
+// v1alpha1.AnnotationGpuSliceRequest annotation, or an error if the annotation is malformed.
+// If the quantity of a profile is omitted, it defaults to 1.
+//
+// Example:
+//
+//	"10gb x2, 20gb" => {10gb: 2, 20gb: 1}
+func GetAnnotationRequestedProfiles(pod v1.Pod) (map[ProfileName]int, error) {
+	res := make(map[ProfileName]int)
+	value, ok := pod.Annotations[v1alpha1.AnnotationGpuSliceRequest]
+	if !ok {
+		return res, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid %s annotation %q: malformed entry %q", v1alpha1.AnnotationGpuSliceRequest, value, entry)
+		}
+		profile := ProfileName(fields[0])
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation %q: %w", v1alpha1.AnnotationGpuSliceRequest, value, err)
+		}
+		quantity := 1
+		if len(fields) == 2 {
+			q, err := strconv.Atoi(strings.TrimPrefix(fields[1], "x"))
+			if err != nil || !strings.HasPrefix(fields[1], "x") || q <= 0 {
+				return nil, fmt.Errorf("invalid %s annotation %q: malformed quantity %q", v1alpha1.AnnotationGpuSliceRequest, value, fields[1])
+			}
+			quantity = q
+		}
+		res[profile] += quantity
+	}
+	return res, nil
+}
+
+// GetActiveThreadPercentage returns the percentage of the threads of each GPU that the Pod provided as argument
+// can use, as specified by its v1alpha1.AnnotationMpsActiveThreadPercentage annotation. It returns 0 if the Pod
+// does not specify any percentage, and an error if the annotation is not an integer between 1 and 100.
+func GetActiveThreadPercentage(pod v1.Pod) (int, error) {
+	value, ok := pod.Annotations[v1alpha1.AnnotationMpsActiveThreadPercentage]
+	if !ok {
+		return 0, nil
+	}
+	percentage, err := strconv.Atoi(value)
+	if err != nil || percentage < 1 || percentage > maxActiveThreadPercentage {
+		return 0, fmt.Errorf(
+			"invalid %s annotation %q: must be an integer between 1 and %d",
+			v1alpha1.AnnotationMpsActiveThreadPercentage,
+			value,
+			maxActiveThreadPercentage,
+		)
+	}
+	return percentage, nil
+}
+
+// ReconcileStatusAnnotations corrects the status annotations provided as argument against the slices
+// requested by the running Pods provided as argument, so that stale annotations over-reporting free
+// slices can be self-healed. For each profile whose used slices are fewer than the ones requested by the
+// Pods, free slices are marked as used starting from the GPUs with the lowest index.
+//
+// The returned annotations are sorted by GPU index and do not include annotations with zero quantity.
+func ReconcileStatusAnnotations(annotations gpu.StatusAnnotationList, pods []v1.Pod) gpu.StatusAnnotationList {
+	// Compute slices requested by running pods
+	requested := make(map[string]int)
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		for p, q := range GetRequestedProfiles(pod) {
+			requested[p.String()] += q
+		}
+	}
+
+	res := make(gpu.StatusAnnotationList, len(annotations))
+	copy(res, annotations)
+	sortByGpuIndex(res)
+
+	// Compute the used slices that are missing for each profile
+	missing := requested
+	for _, a := range res {
+		if a.IsUsed() {
+			missing[a.ProfileName] -= a.Quantity
+		}
+	}
+
+	// Mark free slices as used until no slices are missing
+	for i, a := range res {
+		if !a.IsFree() || missing[a.ProfileName] <= 0 {
+			continue
+		}
+		moved := util.Min(a.Quantity, missing[a.ProfileName])
+		missing[a.ProfileName] -= moved
+		res[i].Quantity -= moved
+		res = addUsedSlices(res, a.Index, a.ProfileName, moved)
+	}
+
+	res = res.Filter(func(a gpu.StatusAnnotation) bool {
+		return a.Quantity > 0
+	})
+	sortByGpuIndex(res)
 	return res
 }
 
+func sortByGpuIndex(annotations gpu.StatusAnnotationList) {
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Index < annotations[j].Index
+	})
+}
+
+// addUsedSlices increases the quantity of the used status annotation of the profile and GPU provided as
+// argument, adding the annotation to the list if it does not exist yet
+func addUsedSlices(annotations gpu.StatusAnnotationList, gpuIndex int, profile string, quantity int) gpu.StatusAnnotationList {
+	for i, a := range annotations {
+		if a.IsUsed() && a.Index == gpuIndex && a.ProfileName == profile {
+			annotations[i].Quantity += quantity
+			return annotations
+		}
+	}
+	return append(annotations, gpu.StatusAnnotation{
+		ProfileName: profile,
+		Index:       gpuIndex,
+		Status:      resource.StatusUsed,
+		Quantity:    quantity,
+	})
+}
+
 func IsGpuSlice(r v1.ResourceName) bool {
 	return resourceRegexp.MatchString(r.String())
 }
@@ -17,9 +17,11 @@
 package slicing_test
 
 import (
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	"github.com/nebuly-ai/nos/pkg/gpu"
 	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
 	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	"testing"
@@ -115,3 +117,214 @@ func TestExtractGpuId(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequestedProfiles(t *testing.T) {
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		initContainers []v1.Container
+		containers     []v1.Container
+		expected       map[slicing.ProfileName]int
+		errExpected    bool
+	}{
+		{
+			name:        "No requests",
+			annotations: map[string]string{},
+			expected:    map[slicing.ProfileName]int{},
+		},
+		{
+			name: "Annotation only",
+			annotations: map[string]string{
+				v1alpha1.AnnotationGpuSliceRequest: "10gb x2, 20gb",
+			},
+			expected: map[slicing.ProfileName]int{"10gb": 2, "20gb": 1},
+		},
+		{
+			name: "Mixed, container requests take precedence",
+			annotations: map[string]string{
+				v1alpha1.AnnotationGpuSliceRequest: "10gb x2,20gb x3",
+			},
+			containers: []v1.Container{
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+				factory.BuildContainer("c-2", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("40gb").AsResourceName(), 1).
+					Get(),
+			},
+			expected: map[slicing.ProfileName]int{"10gb": 1, "20gb": 3, "40gb": 1},
+		},
+		{
+			name: "Init container requesting more slices than the sum of the containers",
+			initContainers: []v1.Container{
+				factory.BuildContainer("init-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 3).
+					Get(),
+			},
+			containers: []v1.Container{
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+				factory.BuildContainer("c-2", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			},
+			expected: map[slicing.ProfileName]int{"10gb": 3},
+		},
+		{
+			name: "Init containers requesting fewer slices than the sum of the containers",
+			initContainers: []v1.Container{
+				factory.BuildContainer("init-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+				factory.BuildContainer("init-2", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			},
+			containers: []v1.Container{
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+				factory.BuildContainer("c-2", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			},
+			expected: map[slicing.ProfileName]int{"10gb": 2},
+		},
+		{
+			name: "Init container requesting a different profile than the containers",
+			initContainers: []v1.Container{
+				factory.BuildContainer("init-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("20gb").AsResourceName(), 1).
+					Get(),
+			},
+			containers: []v1.Container{
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(slicing.ProfileName("10gb").AsResourceName(), 1).
+					Get(),
+			},
+			expected: map[slicing.ProfileName]int{"10gb": 1, "20gb": 1},
+		},
+		{
+			name: "Malformed profile in annotation",
+			annotations: map[string]string{
+				v1alpha1.AnnotationGpuSliceRequest: "foo x2",
+			},
+			expected:    map[slicing.ProfileName]int{},
+			errExpected: true,
+		},
+		{
+			name: "Malformed quantity in annotation",
+			annotations: map[string]string{
+				v1alpha1.AnnotationGpuSliceRequest: "10gb 2",
+			},
+			expected:    map[slicing.ProfileName]int{},
+			errExpected: true,
+		},
+		{
+			name: "Empty entry in annotation",
+			annotations: map[string]string{
+				v1alpha1.AnnotationGpuSliceRequest: "10gb x2,",
+			},
+			expected:    map[slicing.ProfileName]int{},
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := factory.BuildPod("ns-1", "pd-1")
+			for k, v := range tt.annotations {
+				builder = builder.WithAnnotation(k, v)
+			}
+			for _, c := range tt.initContainers {
+				builder = builder.WithInitContainer(c)
+			}
+			for _, c := range tt.containers {
+				builder = builder.WithContainer(c)
+			}
+			pod := builder.Get()
+
+			_, err := slicing.GetAnnotationRequestedProfiles(pod)
+			if tt.errExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, slicing.GetRequestedProfiles(pod))
+		})
+	}
+}
+
+func TestReconcileStatusAnnotations(t *testing.T) {
+	runningPod := func(name string, profile slicing.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", name).
+			WithPhase(v1.PodRunning).
+			WithContainer(
+				factory.BuildContainer("c-1", "foo").
+					WithScalarResourceRequest(profile.AsResourceName(), quantity).
+					Get(),
+			).
+			Get()
+	}
+
+	testCases := []struct {
+		name        string
+		annotations gpu.StatusAnnotationList
+		pods        []v1.Pod
+		expected    gpu.StatusAnnotationList
+	}{
+		{
+			name: "Annotations matching allocations, should not change",
+			annotations: gpu.StatusAnnotationList{
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusUsed, Quantity: 1},
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+			},
+			pods: []v1.Pod{runningPod("pd-1", "10gb", 1)},
+			expected: gpu.StatusAnnotationList{
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusUsed, Quantity: 1},
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+			},
+		},
+		{
+			name: "Annotations over-report free slices, should be reduced to match allocations",
+			annotations: gpu.StatusAnnotationList{
+				{ProfileName: "10gb", Index: 1, Status: resource.StatusFree, Quantity: 2},
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusFree, Quantity: 2},
+				{ProfileName: "20gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+			},
+			pods: []v1.Pod{
+				runningPod("pd-1", "10gb", 1),
+				runningPod("pd-2", "10gb", 2),
+			},
+			expected: gpu.StatusAnnotationList{
+				{ProfileName: "20gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusUsed, Quantity: 2},
+				{ProfileName: "10gb", Index: 1, Status: resource.StatusFree, Quantity: 1},
+				{ProfileName: "10gb", Index: 1, Status: resource.StatusUsed, Quantity: 1},
+			},
+		},
+		{
+			name: "Pods not running, should not change",
+			annotations: gpu.StatusAnnotationList{
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+			},
+			pods: []v1.Pod{
+				factory.BuildPod("ns-1", "pd-1").
+					WithPhase(v1.PodSucceeded).
+					WithAnnotation(v1alpha1.AnnotationGpuSliceRequest, "10gb").
+					Get(),
+			},
+			expected: gpu.StatusAnnotationList{
+				{ProfileName: "10gb", Index: 0, Status: resource.StatusFree, Quantity: 1},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res := slicing.ReconcileStatusAnnotations(tt.annotations, tt.pods)
+			assert.ElementsMatch(t, tt.expected, res)
+		})
+	}
+}
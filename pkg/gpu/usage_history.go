@@ -0,0 +1,100 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// UsageSample contains the free and used GPU slices of a node at a given time,
+// aggregated by profile over all the GPUs of the node.
+type UsageSample struct {
+	Timestamp metav1.Time    `json:"timestamp"`
+	Free      map[string]int `json:"free,omitempty"`
+	Used      map[string]int `json:"used,omitempty"`
+}
+
+// NewUsageSample returns the UsageSample taken at the time provided as argument
+// from the status annotations of a node.
+func NewUsageSample(statusAnnotations StatusAnnotationList, timestamp time.Time) UsageSample {
+	sample := UsageSample{
+		Timestamp: metav1.NewTime(timestamp),
+		Free:      make(map[string]int),
+		Used:      make(map[string]int),
+	}
+	for _, a := range statusAnnotations {
+		if a.IsFree() {
+			sample.Free[a.ProfileName] += a.Quantity
+		}
+		if a.IsUsed() {
+			sample.Used[a.ProfileName] += a.Quantity
+		}
+	}
+	return sample
+}
+
+// UsageHistory is a list of UsageSample ordered from the oldest to the newest.
+type UsageHistory []UsageSample
+
+// ParseUsageHistory returns the UsageHistory exposed by the annotation v1alpha1.AnnotationGpuUsageHistory
+// of the node provided as argument, or an empty history if the node does not have the annotation.
+func ParseUsageHistory(node v1.Node) (UsageHistory, error) {
+	value, ok := node.Annotations[v1alpha1.AnnotationGpuUsageHistory]
+	if !ok {
+		return UsageHistory{}, nil
+	}
+	var res UsageHistory
+	if err := json.Unmarshal([]byte(value), &res); err != nil {
+		return UsageHistory{}, fmt.Errorf("invalid GPU usage history annotation: %w", err)
+	}
+	return res, nil
+}
+
+// Add returns a new history with the sample provided as argument appended as newest sample.
+// If the resulting history has more than maxSamples samples, the oldest ones are evicted.
+func (h UsageHistory) Add(sample UsageSample, maxSamples int) UsageHistory {
+	res := make(UsageHistory, 0, len(h)+1)
+	res = append(res, h...)
+	res = append(res, sample)
+	if maxSamples > 0 && len(res) > maxSamples {
+		res = res[len(res)-maxSamples:]
+	}
+	return res
+}
+
+// Last returns the newest sample of the history. The boolean value is false if the history is empty.
+func (h UsageHistory) Last() (UsageSample, bool) {
+	if len(h) == 0 {
+		return UsageSample{}, false
+	}
+	return h[len(h)-1], true
+}
+
+// String returns the JSON representation of the history, which is used as value
+// of the annotation v1alpha1.AnnotationGpuUsageHistory
+func (h UsageHistory) String() string {
+	asBytes, err := json.Marshal(h)
+	if err != nil {
+		return ""
+	}
+	return string(asBytes)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestNewUsageSample(t *testing.T) {
+	timestamp := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	sample := gpu.NewUsageSample(gpu.StatusAnnotationList{
+		{ProfileName: "1g.10gb", Index: 0, Status: resource.StatusFree, Quantity: 2},
+		{ProfileName: "1g.10gb", Index: 1, Status: resource.StatusFree, Quantity: 1},
+		{ProfileName: "1g.10gb", Index: 1, Status: resource.StatusUsed, Quantity: 1},
+		{ProfileName: "2g.20gb", Index: 0, Status: resource.StatusUsed, Quantity: 1},
+	}, timestamp)
+
+	assert.Equal(t, gpu.UsageSample{
+		Timestamp: metav1.NewTime(timestamp),
+		Free:      map[string]int{"1g.10gb": 3},
+		Used:      map[string]int{"1g.10gb": 1, "2g.20gb": 1},
+	}, sample)
+}
+
+func TestUsageHistory__Add(t *testing.T) {
+	newSample := func(minute int) gpu.UsageSample {
+		return gpu.UsageSample{
+			Timestamp: metav1.NewTime(time.Date(2023, 1, 1, 10, minute, 0, 0, time.UTC)),
+			Used:      map[string]int{"10gb": minute},
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		history    gpu.UsageHistory
+		sample     gpu.UsageSample
+		maxSamples int
+		expected   gpu.UsageHistory
+	}{
+		{
+			name:       "Empty history",
+			history:    gpu.UsageHistory{},
+			sample:     newSample(0),
+			maxSamples: 3,
+			expected:   gpu.UsageHistory{newSample(0)},
+		},
+		{
+			name:       "Samples accumulate up to max samples",
+			history:    gpu.UsageHistory{newSample(0), newSample(1)},
+			sample:     newSample(2),
+			maxSamples: 3,
+			expected:   gpu.UsageHistory{newSample(0), newSample(1), newSample(2)},
+		},
+		{
+			name:       "Oldest sample is evicted past max samples",
+			history:    gpu.UsageHistory{newSample(0), newSample(1), newSample(2)},
+			sample:     newSample(3),
+			maxSamples: 3,
+			expected:   gpu.UsageHistory{newSample(1), newSample(2), newSample(3)},
+		},
+		{
+			name:       "History longer than max samples is truncated",
+			history:    gpu.UsageHistory{newSample(0), newSample(1), newSample(2)},
+			sample:     newSample(3),
+			maxSamples: 1,
+			expected:   gpu.UsageHistory{newSample(3)},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			original := make(gpu.UsageHistory, len(tt.history))
+			copy(original, tt.history)
+
+			res := tt.history.Add(tt.sample, tt.maxSamples)
+			assert.Equal(t, tt.expected, res)
+			assert.Equal(t, original, tt.history)
+
+			last, ok := res.Last()
+			assert.True(t, ok)
+			assert.Equal(t, tt.sample, last)
+		})
+	}
+}
+
+func TestParseUsageHistory(t *testing.T) {
+	t.Run("Node without annotation", func(t *testing.T) {
+		history, err := gpu.ParseUsageHistory(factory.BuildNode("node-1").Get())
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("Invalid annotation", func(t *testing.T) {
+		node := factory.BuildNode("node-1").WithAnnotations(map[string]string{
+			v1alpha1.AnnotationGpuUsageHistory: "foo",
+		}).Get()
+		_, err := gpu.ParseUsageHistory(node)
+		assert.Error(t, err)
+	})
+
+	t.Run("Annotation written by the history", func(t *testing.T) {
+		history := gpu.UsageHistory{
+			{
+				Timestamp: metav1.NewTime(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)),
+				Free:      map[string]int{"1g.10gb": 1},
+				Used:      map[string]int{"2g.20gb": 2},
+			},
+		}
+		node := factory.BuildNode("node-1").WithAnnotations(map[string]string{
+			v1alpha1.AnnotationGpuUsageHistory: history.String(),
+		}).Get()
+		parsed, err := gpu.ParseUsageHistory(node)
+		assert.NoError(t, err)
+		assert.Len(t, parsed, 1)
+		assert.True(t, history[0].Timestamp.Equal(&parsed[0].Timestamp))
+		assert.Equal(t, history[0].Free, parsed[0].Free)
+		assert.Equal(t, history[0].Used, parsed[0].Used)
+	})
+}
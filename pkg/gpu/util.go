@@ -21,55 +21,56 @@ import (
 	"github.com/nebuly-ai/nos/pkg/constant"
 	"github.com/nebuly-ai/nos/pkg/resource"
 	"k8s.io/api/core/v1"
-	"math"
-	"strconv"
 )
 
-// GetModel returns the model of the GPUs on the node.
+// GetModel returns the model of the GPUs on the node, according to the Vendor of the node GPUs.
 // It is assumed that all the GPUs of the node are of the same model.
 func GetModel(node v1.Node) (Model, error) {
-	val, ok := node.Labels[constant.LabelNvidiaProduct]
-	if !ok {
-		return "", fmt.Errorf(
-			"cannot get GPU model from node %s labels: missing label %s",
-			node.Name,
-			constant.LabelNvidiaProduct,
-		)
+	vendor, err := GetVendor(node)
+	if err != nil {
+		return "", err
 	}
-	return Model(val), nil
+	return vendor.GetModel(node)
 }
 
-// GetCount returns the number of GPUs on the node.
+// GetCount returns the number of GPUs on the node, according to the Vendor of the node GPUs.
 func GetCount(node v1.Node) (int, error) {
-	val, ok := node.Labels[constant.LabelNvidiaCount]
-	if !ok {
-		return 0, fmt.Errorf(
-			"cannot get GPU count from node labels, missing label %s",
-			constant.LabelNvidiaCount,
-		)
-	}
-	valAsInt, err := strconv.Atoi(val)
+	vendor, err := GetVendor(node)
 	if err != nil {
 		return 0, err
 	}
-	return valAsInt, nil
+	return vendor.GetCount(node)
 }
 
-// GetMemoryGB returns the amount of memory GB of the GPUs on the node.
-func GetMemoryGB(node v1.Node) (int, error) {
-	memoryStr, ok := node.Labels[constant.LabelNvidiaMemory]
-	if !ok {
-		return 0, fmt.Errorf(
-			"cannot get GPU Memory GB from node labels, missing label %s",
-			constant.LabelNvidiaMemory,
+// CheckCount returns an error if the number of GPUs reported by the node label constant.LabelNvidiaCount
+// differs from the actual number of GPUs provided as argument (e.g. as enumerated by NVML), which
+// can happen when the label is stale, for instance after a driver update.
+func CheckCount(node v1.Node, actualCount int) error {
+	labelCount, err := GetCount(node)
+	if err != nil {
+		return err
+	}
+	if labelCount != actualCount {
+		return fmt.Errorf(
+			"label %s of node %s reports %d GPUs, but %d GPUs were found",
+			constant.LabelNvidiaCount,
+			node.Name,
+			labelCount,
+			actualCount,
 		)
 	}
-	memoryBytes, err := strconv.Atoi(memoryStr)
+	return nil
+}
+
+// GetMemoryGB returns the amount of memory GB of the GPUs on the node, according to the Vendor of the node GPUs.
+// For NVIDIA GPUs, the value of the label constant.LabelNvidiaMemory is expressed in MiB, as reported by
+// the GPU Feature Discovery, and it is converted to GB rounding up to the nearest integer
+func GetMemoryGB(node v1.Node) (int, error) {
+	vendor, err := GetVendor(node)
 	if err != nil {
 		return 0, err
 	}
-	memoryGb := math.Ceil(float64(memoryBytes) / 1000)
-	return int(memoryGb), nil
+	return vendor.GetMemoryGB(node)
 }
 
 func ComputeFreeDevicesAndUpdateStatus(used []Device, allocatable []Device) []Device {
@@ -54,6 +54,22 @@ func TestGetMemoryGB(t *testing.T) {
 			expectedVal: 2,
 			expectedErr: false,
 		},
+		{
+			name: "memory value is expressed in MiB",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaMemory: "40960",
+			}).Get(),
+			expectedVal: 40,
+			expectedErr: false,
+		},
+		{
+			name: "memory value of an 80 GB GPU",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaMemory: "81920",
+			}).Get(),
+			expectedVal: 80,
+			expectedErr: false,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -67,3 +83,46 @@ func TestGetMemoryGB(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckCount(t *testing.T) {
+	testCases := []struct {
+		name        string
+		node        v1.Node
+		actualCount int
+		expectedErr bool
+	}{
+		{
+			name:        "no label",
+			node:        factory.BuildNode("node-1").Get(),
+			actualCount: 2,
+			expectedErr: true,
+		},
+		{
+			name: "label matches actual count",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaCount: "2",
+			}).Get(),
+			actualCount: 2,
+			expectedErr: false,
+		},
+		{
+			name: "label reports fewer GPUs than actual count",
+			node: factory.BuildNode("node-1").WithLabels(map[string]string{
+				constant.LabelNvidiaCount: "2",
+			}).Get(),
+			actualCount: 4,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gpu.CheckCount(tt.node, tt.actualCount)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
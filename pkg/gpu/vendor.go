@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	v1 "k8s.io/api/core/v1"
+	"math"
+	"strconv"
+)
+
+// VendorNvidia is the name of the NVIDIA GPU vendor
+const VendorNvidia = "nvidia"
+
+// Vendor extracts the information about the GPUs of a node from the node labels, which are set by the
+// tools of the GPU vendor (e.g. the NVIDIA GPU Feature Discovery). Each vendor can also read the
+// vendor-neutral labels v1alpha1.LabelGpuProduct, v1alpha1.LabelGpuCount and v1alpha1.LabelGpuMemory.
+type Vendor interface {
+	// Name returns the name of the vendor, which matches the value of the v1alpha1.LabelGpuVendor label
+	Name() string
+	// GetModel returns the model of the GPUs of the node
+	GetModel(node v1.Node) (Model, error)
+	// GetCount returns the number of GPUs of the node
+	GetCount(node v1.Node) (int, error)
+	// GetMemoryGB returns the amount of memory GB of each GPU of the node
+	GetMemoryGB(node v1.Node) (int, error)
+}
+
+var vendors = map[string]Vendor{
+	VendorNvidia: nvidiaVendor{},
+}
+
+// GetVendor returns the Vendor of the GPUs of the node provided as argument, according to the value of its
+// v1alpha1.LabelGpuVendor label. Nodes without the label are assumed to have NVIDIA GPUs.
+//
+// GetVendor returns an error if the label specifies a vendor that is not supported.
+func GetVendor(node v1.Node) (Vendor, error) {
+	name, ok := node.Labels[v1alpha1.LabelGpuVendor]
+	if !ok {
+		return vendors[VendorNvidia], nil
+	}
+	vendor, ok := vendors[name]
+	if !ok {
+		return nil, fmt.Errorf("node %s has GPUs of unsupported vendor %q", node.Name, name)
+	}
+	return vendor, nil
+}
+
+// nvidiaVendor reads the labels exposed by the NVIDIA GPU Feature Discovery. If a node does not
+// have a label, the corresponding vendor-neutral label is used instead.
+type nvidiaVendor struct{}
+
+func (nvidiaVendor) Name() string {
+	return VendorNvidia
+}
+
+func (nvidiaVendor) GetModel(node v1.Node) (Model, error) {
+	val, ok := lookupLabel(node, constant.LabelNvidiaProduct, v1alpha1.LabelGpuProduct)
+	if !ok {
+		return "", fmt.Errorf(
+			"cannot get GPU model from node %s labels: missing label %s",
+			node.Name,
+			constant.LabelNvidiaProduct,
+		)
+	}
+	return Model(val), nil
+}
+
+func (nvidiaVendor) GetCount(node v1.Node) (int, error) {
+	val, ok := lookupLabel(node, constant.LabelNvidiaCount, v1alpha1.LabelGpuCount)
+	if !ok {
+		return 0, fmt.Errorf(
+			"cannot get GPU count from node labels, missing label %s",
+			constant.LabelNvidiaCount,
+		)
+	}
+	valAsInt, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+	return valAsInt, nil
+}
+
+// GetMemoryGB returns the amount of memory GB of the GPUs of the node. The value of the label
+// constant.LabelNvidiaMemory is expressed in MiB, as reported by the GPU Feature Discovery, and
+// it is converted to GB rounding up to the nearest integer.
+func (nvidiaVendor) GetMemoryGB(node v1.Node) (int, error) {
+	memoryStr, ok := lookupLabel(node, constant.LabelNvidiaMemory, v1alpha1.LabelGpuMemory)
+	if !ok {
+		return 0, fmt.Errorf(
+			"cannot get GPU Memory GB from node labels, missing label %s",
+			constant.LabelNvidiaMemory,
+		)
+	}
+	memoryMiB, err := strconv.Atoi(memoryStr)
+	if err != nil {
+		return 0, err
+	}
+	memoryGb := math.Ceil(float64(memoryMiB) / 1024)
+	return int(memoryGb), nil
+}
+
+// lookupLabel returns the value of the first label of the node provided as argument, among
+// the ones provided as argument, that the node has
+func lookupLabel(node v1.Node, labels ...string) (string, bool) {
+	for _, l := range labels {
+		if val, ok := node.Labels[l]; ok {
+			return val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu_test
+
+import (
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetVendor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		labels         map[string]string
+		expectedErr    bool
+		expectedVendor string
+		expectedModel  gpu.Model
+		expectedCount  int
+		expectedMemory int
+	}{
+		{
+			name: "No vendor label, NVIDIA labels",
+			labels: map[string]string{
+				constant.LabelNvidiaProduct: "A30",
+				constant.LabelNvidiaCount:   "2",
+				constant.LabelNvidiaMemory:  "24576",
+			},
+			expectedVendor: gpu.VendorNvidia,
+			expectedModel:  gpu.GPUModel_A30,
+			expectedCount:  2,
+			expectedMemory: 24,
+		},
+		{
+			name: "NVIDIA vendor, vendor-neutral labels",
+			labels: map[string]string{
+				v1alpha1.LabelGpuVendor:  gpu.VendorNvidia,
+				v1alpha1.LabelGpuProduct: "A30",
+				v1alpha1.LabelGpuCount:   "4",
+				v1alpha1.LabelGpuMemory:  "24576",
+			},
+			expectedVendor: gpu.VendorNvidia,
+			expectedModel:  gpu.GPUModel_A30,
+			expectedCount:  4,
+			expectedMemory: 24,
+		},
+		{
+			name: "NVIDIA labels take precedence over vendor-neutral labels",
+			labels: map[string]string{
+				v1alpha1.LabelGpuVendor:     gpu.VendorNvidia,
+				v1alpha1.LabelGpuProduct:    "foo",
+				v1alpha1.LabelGpuCount:      "4",
+				v1alpha1.LabelGpuMemory:     "1024",
+				constant.LabelNvidiaProduct: "A30",
+				constant.LabelNvidiaCount:   "2",
+				constant.LabelNvidiaMemory:  "24576",
+			},
+			expectedVendor: gpu.VendorNvidia,
+			expectedModel:  gpu.GPUModel_A30,
+			expectedCount:  2,
+			expectedMemory: 24,
+		},
+		{
+			name: "Unsupported vendor",
+			labels: map[string]string{
+				v1alpha1.LabelGpuVendor:  "amd",
+				v1alpha1.LabelGpuProduct: "MI250",
+				v1alpha1.LabelGpuCount:   "4",
+				v1alpha1.LabelGpuMemory:  "65536",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node := factory.BuildNode("node-1").WithLabels(tt.labels).Get()
+
+			vendor, err := gpu.GetVendor(node)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				_, err = gpu.GetModel(node)
+				assert.Error(t, err)
+				_, err = gpu.GetCount(node)
+				assert.Error(t, err)
+				_, err = gpu.GetMemoryGB(node)
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVendor, vendor.Name())
+
+			model, err := gpu.GetModel(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, model)
+			count, err := gpu.GetCount(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+			memory, err := gpu.GetMemoryGB(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMemory, memory)
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
 	schedulerconfig "github.com/nebuly-ai/nos/pkg/api/scheduler"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
 	gpu_util "github.com/nebuly-ai/nos/pkg/gpu/util"
 	"github.com/nebuly-ai/nos/pkg/resource"
 	podutil "github.com/nebuly-ai/nos/pkg/util/pod"
@@ -117,6 +118,12 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 	}
 
 	klog.Info("using nvidiaGpuResourceMemoryGB=", args.NvidiaGpuResourceMemoryGB)
+	if args.MigResourceNamePrefix != "" {
+		if err := mig.SetResourceNamePrefix(args.MigResourceNamePrefix); err != nil {
+			return nil, fmt.Errorf("[CapacityScheduling] %w", err)
+		}
+		klog.Info("using migResourceNamePrefix=", args.MigResourceNamePrefix)
+	}
 
 	c := &CapacityScheduling{
 		fh:                handle,
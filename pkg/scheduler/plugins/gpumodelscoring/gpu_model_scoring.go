@@ -0,0 +1,138 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpumodelscoring
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	schedulerconfig "github.com/nebuly-ai/nos/pkg/api/scheduler"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.ScorePlugin = &GpuModelScoring{}
+
+const (
+	// Name is the name of the plugin used in Registry and configurations.
+	Name = "GpuModelScoring"
+)
+
+// GpuModelScoring is a plugin that scores nodes according to the performance tier of their GPU models:
+// latency-sensitive Pods prefer nodes with faster GPUs, while batch Pods prefer nodes with slower GPUs.
+// The class of a Pod is specified by the label v1alpha1.LabelGpuWorkloadClass.
+type GpuModelScoring struct {
+	nodeInfoLister framework.NodeInfoLister
+	modelTiers     map[gpu.Model]int64
+	minTier        int64
+	maxTier        int64
+}
+
+// New initializes a new plugin and returns it.
+func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	args, ok := obj.(*schedulerconfig.GpuModelScoringArgs)
+	if !ok {
+		return nil, fmt.Errorf("[GpuModelScoring] want args to be of type GpuModelScoringArgs, got %T", obj)
+	}
+	return newGpuModelScoring(handle.SnapshotSharedLister().NodeInfos(), args.ModelTiers)
+}
+
+func newGpuModelScoring(nodeInfoLister framework.NodeInfoLister, modelTiers map[string]int64) (*GpuModelScoring, error) {
+	if len(modelTiers) == 0 {
+		return nil, fmt.Errorf("[GpuModelScoring] model tiers cannot be empty")
+	}
+	res := &GpuModelScoring{
+		nodeInfoLister: nodeInfoLister,
+		modelTiers:     make(map[gpu.Model]int64, len(modelTiers)),
+	}
+	first := true
+	for model, tier := range modelTiers {
+		if tier < 0 {
+			return nil, fmt.Errorf("[GpuModelScoring] tier of model %s cannot be negative: %d", model, tier)
+		}
+		res.modelTiers[gpu.Model(model)] = tier
+		if first || tier < res.minTier {
+			res.minTier = tier
+		}
+		if first || tier > res.maxTier {
+			res.maxTier = tier
+		}
+		first = false
+	}
+	return res, nil
+}
+
+// Name returns name of the plugin. It is used in logs, etc.
+func (g *GpuModelScoring) Name() string {
+	return Name
+}
+
+// Score returns the preference score of the node for the Pod, computed according to the
+// tier of the GPU model of the node and the class of the Pod.
+//
+// Pods without a class, nodes without GPUs and nodes with GPU models not included in the
+// configured tiers get the minimum score.
+func (g *GpuModelScoring) Score(_ context.Context, _ *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	class, ok := pod.Labels[v1alpha1.LabelGpuWorkloadClass]
+	if !ok {
+		return framework.MinNodeScore, nil
+	}
+
+	nodeInfo, err := g.nodeInfoLister.Get(nodeName)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting node %q from snapshot: %w", nodeName, err))
+	}
+	if nodeInfo.Node() == nil {
+		return 0, framework.NewStatus(framework.Error, fmt.Sprintf("node %q not found", nodeName))
+	}
+
+	model, err := gpu.GetModel(*nodeInfo.Node())
+	if err != nil {
+		return framework.MinNodeScore, nil
+	}
+	tier, ok := g.modelTiers[model]
+	if !ok {
+		return framework.MinNodeScore, nil
+	}
+
+	switch class {
+	case v1alpha1.GpuWorkloadClassLatency:
+		return g.normalize(tier - g.minTier), nil
+	case v1alpha1.GpuWorkloadClassBatch:
+		return g.normalize(g.maxTier - tier), nil
+	default:
+		return framework.MinNodeScore, nil
+	}
+}
+
+// normalize scales the distance between tiers provided as argument to the
+// range [framework.MinNodeScore, framework.MaxNodeScore]
+func (g *GpuModelScoring) normalize(tierDistance int64) int64 {
+	tierRange := g.maxTier - g.minTier
+	if tierRange == 0 {
+		return framework.MaxNodeScore
+	}
+	return framework.MinNodeScore + tierDistance*(framework.MaxNodeScore-framework.MinNodeScore)/tierRange
+}
+
+// ScoreExtensions returns nil, since scores are already in the range
+// [framework.MinNodeScore, framework.MaxNodeScore]
+func (g *GpuModelScoring) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpumodelscoring
+
+import (
+	"context"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	testutil "github.com/nebuly-ai/nos/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"testing"
+)
+
+func TestGpuModelScoring__Score(t *testing.T) {
+	modelTiers := map[string]int64{
+		gpu.GPUModel_T4.String():             1,
+		gpu.GPUModel_A30.String():            2,
+		gpu.GPUModel_A100_SXM4_40GB.String(): 3,
+	}
+	a100Node := factory.BuildNode("a100").
+		WithLabels(map[string]string{constant.LabelNvidiaProduct: gpu.GPUModel_A100_SXM4_40GB.String()}).
+		Get()
+	a30Node := factory.BuildNode("a30").
+		WithLabels(map[string]string{constant.LabelNvidiaProduct: gpu.GPUModel_A30.String()}).
+		Get()
+	t4Node := factory.BuildNode("t4").
+		WithLabels(map[string]string{constant.LabelNvidiaProduct: gpu.GPUModel_T4.String()}).
+		Get()
+	unknownModelNode := factory.BuildNode("unknown").
+		WithLabels(map[string]string{constant.LabelNvidiaProduct: "unknown"}).
+		Get()
+	cpuNode := factory.BuildNode("cpu").Get()
+	nodes := []*v1.Node{&a100Node, &a30Node, &t4Node, &unknownModelNode, &cpuNode}
+
+	testCases := []struct {
+		name     string
+		pod      v1.Pod
+		expected map[string]int64
+	}{
+		{
+			name: "Latency pod should prefer faster GPU models",
+			pod: factory.BuildPod("ns-1", "pd-1").
+				WithLabel(v1alpha1.LabelGpuWorkloadClass, v1alpha1.GpuWorkloadClassLatency).
+				Get(),
+			expected: map[string]int64{
+				a100Node.Name:         framework.MaxNodeScore,
+				a30Node.Name:          50,
+				t4Node.Name:           framework.MinNodeScore,
+				unknownModelNode.Name: framework.MinNodeScore,
+				cpuNode.Name:          framework.MinNodeScore,
+			},
+		},
+		{
+			name: "Batch pod should prefer slower GPU models",
+			pod: factory.BuildPod("ns-1", "pd-1").
+				WithLabel(v1alpha1.LabelGpuWorkloadClass, v1alpha1.GpuWorkloadClassBatch).
+				Get(),
+			expected: map[string]int64{
+				a100Node.Name:         framework.MinNodeScore,
+				a30Node.Name:          50,
+				t4Node.Name:           framework.MaxNodeScore,
+				unknownModelNode.Name: framework.MinNodeScore,
+				cpuNode.Name:          framework.MinNodeScore,
+			},
+		},
+		{
+			name: "Pod without class should not have any preference",
+			pod:  factory.BuildPod("ns-1", "pd-1").Get(),
+			expected: map[string]int64{
+				a100Node.Name:         framework.MinNodeScore,
+				a30Node.Name:          framework.MinNodeScore,
+				t4Node.Name:           framework.MinNodeScore,
+				unknownModelNode.Name: framework.MinNodeScore,
+				cpuNode.Name:          framework.MinNodeScore,
+			},
+		},
+		{
+			name: "Pod with unknown class should not have any preference",
+			pod: factory.BuildPod("ns-1", "pd-1").
+				WithLabel(v1alpha1.LabelGpuWorkloadClass, "unknown").
+				Get(),
+			expected: map[string]int64{
+				a100Node.Name:         framework.MinNodeScore,
+				a30Node.Name:          framework.MinNodeScore,
+				t4Node.Name:           framework.MinNodeScore,
+				unknownModelNode.Name: framework.MinNodeScore,
+				cpuNode.Name:          framework.MinNodeScore,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := testutil.NewFakeSharedLister(nil, nodes)
+			plugin, err := newGpuModelScoring(lister.NodeInfos(), modelTiers)
+			assert.NoError(t, err)
+
+			for nodeName, expectedScore := range tt.expected {
+				score, status := plugin.Score(context.Background(), framework.NewCycleState(), &tt.pod, nodeName)
+				assert.True(t, status.IsSuccess())
+				assert.Equal(t, expectedScore, score, "node %s", nodeName)
+			}
+		})
+	}
+}
+
+func TestGpuModelScoring__New(t *testing.T) {
+	testCases := []struct {
+		name        string
+		modelTiers  map[string]int64
+		expectedErr bool
+	}{
+		{
+			name:        "Empty tiers",
+			modelTiers:  map[string]int64{},
+			expectedErr: true,
+		},
+		{
+			name:        "Negative tier",
+			modelTiers:  map[string]int64{gpu.GPUModel_T4.String(): -1},
+			expectedErr: true,
+		},
+		{
+			name:        "Single tier",
+			modelTiers:  map[string]int64{gpu.GPUModel_T4.String(): 1},
+			expectedErr: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGpuModelScoring(testutil.NewFakeSharedLister(nil, nil).NodeInfos(), tt.modelTiers)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
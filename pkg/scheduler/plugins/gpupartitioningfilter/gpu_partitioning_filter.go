@@ -0,0 +1,158 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpupartitioningfilter
+
+import (
+	"context"
+	"fmt"
+	schedulerconfig "github.com/nebuly-ai/nos/pkg/api/scheduler"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.FilterPlugin = &GpuPartitioningFilter{}
+
+const (
+	// Name is the name of the plugin used in Registry and configurations.
+	Name = "GpuPartitioningFilter"
+)
+
+// GpuPartitioningFilter is a plugin that filters out the nodes whose GPU partitioning kind, specified by the
+// label v1alpha1.LabelGpuPartitioning, does not match the family of the GPU resources requested by the Pod:
+// Pods requesting MIG resources cannot run on nodes partitioned with MPS, and Pods requesting GPU slices
+// cannot run on nodes partitioned with MIG. Nodes without partitioning kind or with hybrid partitioning
+// are not filtered.
+//
+// If ConsiderPendingMigReconfiguration is enabled in the plugin args, the plugin also filters out the nodes that
+// do not have enough MIG resources for the Pod, considering as capacity of the nodes whose MIG reconfiguration is
+// pending the MIG geometry requested by their spec annotations. See CheckMigCapacity.
+type GpuPartitioningFilter struct {
+	considerPendingMigReconfiguration bool
+}
+
+// New initializes a new plugin and returns it.
+func New(obj runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+	res := &GpuPartitioningFilter{}
+	if obj == nil {
+		return res, nil
+	}
+	args, ok := obj.(*schedulerconfig.GpuPartitioningFilterArgs)
+	if !ok {
+		return nil, fmt.Errorf("[GpuPartitioningFilter] want args to be of type GpuPartitioningFilterArgs, got %T", obj)
+	}
+	res.considerPendingMigReconfiguration = args.ConsiderPendingMigReconfiguration
+	if args.MigResourceNamePrefix != "" {
+		if err := mig.SetResourceNamePrefix(args.MigResourceNamePrefix); err != nil {
+			return nil, fmt.Errorf("[GpuPartitioningFilter] %w", err)
+		}
+	}
+	return res, nil
+}
+
+// Name returns name of the plugin. It is used in logs, etc.
+func (g *GpuPartitioningFilter) Name() string {
+	return Name
+}
+
+// Filter returns Unschedulable if the GPU resources requested by the Pod do not match
+// the partitioning kind of the node, or if the node does not have enough MIG resources
+// for the Pod when pending MIG reconfigurations are considered.
+func (g *GpuPartitioningFilter) Filter(_ context.Context, _ *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if nodeInfo.Node() == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+	if err := CheckPartitioningKind(*pod, *nodeInfo.Node()); err != nil {
+		return framework.NewStatus(framework.Unschedulable, err.Error())
+	}
+	if g.considerPendingMigReconfiguration {
+		if err := CheckMigCapacity(*pod, *nodeInfo, true); err != nil {
+			return framework.NewStatus(framework.Unschedulable, err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckPartitioningKind returns an error if the family of the GPU resources requested by the Pod provided
+// as argument does not match the partitioning kind of the node provided as argument.
+func CheckPartitioningKind(pod v1.Pod, node v1.Node) error {
+	kind, ok := gpu.GetPartitioningKind(node)
+	if !ok || kind == gpu.PartitioningKindHybrid {
+		return nil
+	}
+	var requestsMig, requestsSlices bool
+	for r, q := range resource.ComputePodRequest(pod) {
+		if q.IsZero() {
+			continue
+		}
+		if mig.IsNvidiaMigDevice(r) {
+			requestsMig = true
+		}
+		if slicing.IsGpuSlice(r) {
+			requestsSlices = true
+		}
+	}
+	if requestsMig && kind != gpu.PartitioningKindMig {
+		return fmt.Errorf("pod requests MIG resources, but node GPUs are partitioned with %s", kind)
+	}
+	if requestsSlices && kind != gpu.PartitioningKindMps {
+		return fmt.Errorf("pod requests GPU slices, but node GPUs are partitioned with %s", kind)
+	}
+	return nil
+}
+
+// CheckMigCapacity returns an error if the MIG resources requested by the Pod provided as argument exceed the
+// MIG resources of the node that are not requested by the Pods already assigned to it.
+//
+// If considerPendingReconfiguration is true and the MIG reconfiguration of the node is pending (see
+// mig.IsReconfigurationPending), the capacity of the node is the MIG geometry requested by its spec annotations,
+// so that Pods can be assigned to the node before the MIG agent creates their MIG devices. Otherwise, the capacity
+// of the node is given by the MIG resources it currently advertises. Since the requests of the Pods already assigned
+// to the node are always subtracted, the node is never over-committed with respect to its capacity, and nodes whose
+// reconfiguration failed fall back to the resources they actually advertise.
+func CheckMigCapacity(pod v1.Pod, nodeInfo framework.NodeInfo, considerPendingReconfiguration bool) error {
+	node := nodeInfo.Node()
+	if node == nil {
+		return fmt.Errorf("node not found")
+	}
+
+	capacity := make(map[v1.ResourceName]int64)
+	if considerPendingReconfiguration && mig.IsReconfigurationPending(*node) {
+		capacity = mig.GetSpecResources(*node)
+	} else {
+		for r, q := range node.Status.Allocatable {
+			if mig.IsNvidiaMigDevice(r) {
+				capacity[r] = q.Value()
+			}
+		}
+	}
+
+	for r, q := range resource.ComputePodRequest(pod) {
+		if q.IsZero() || !mig.IsNvidiaMigDevice(r) {
+			continue
+		}
+		available := capacity[r] - nodeInfo.Requested.ScalarResources[r]
+		if q.Value() > available {
+			return fmt.Errorf("insufficient %s: pod requests %d, but node only has %d available", r, q.Value(), available)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2023 nebuly.com.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpupartitioningfilter
+
+import (
+	"context"
+	"fmt"
+	"github.com/nebuly-ai/nos/pkg/api/nos.nebuly.com/v1alpha1"
+	schedulerconfig "github.com/nebuly-ai/nos/pkg/api/scheduler"
+	"github.com/nebuly-ai/nos/pkg/constant"
+	"github.com/nebuly-ai/nos/pkg/gpu"
+	"github.com/nebuly-ai/nos/pkg/gpu/mig"
+	"github.com/nebuly-ai/nos/pkg/gpu/slicing"
+	"github.com/nebuly-ai/nos/pkg/resource"
+	"github.com/nebuly-ai/nos/pkg/test/factory"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"testing"
+)
+
+func TestGpuPartitioningFilter__Filter(t *testing.T) {
+	buildNode := func(kind gpu.PartitioningKind) v1.Node {
+		if kind == "" {
+			return factory.BuildNode("node-1").Get()
+		}
+		return factory.BuildNode("node-1").
+			WithLabels(map[string]string{v1alpha1.LabelGpuPartitioning: kind.String()}).
+			Get()
+	}
+	buildPod := func(resourceName v1.ResourceName) v1.Pod {
+		return factory.BuildPod("ns-1", "pd-1").WithContainer(
+			factory.BuildContainer("c-1", "foo").WithScalarResourceRequest(resourceName, 1).Get(),
+		).Get()
+	}
+	migPod := buildPod(mig.Profile1g5gb.AsResourceName())
+	slicesPod := buildPod(slicing.ProfileName("10gb").AsResourceName())
+	cpuPod := factory.BuildPod("ns-1", "pd-1").Get()
+
+	testCases := []struct {
+		name     string
+		pod      v1.Pod
+		node     v1.Node
+		expected framework.Code
+	}{
+		{
+			name:     "MIG pod on MIG node",
+			pod:      migPod,
+			node:     buildNode(gpu.PartitioningKindMig),
+			expected: framework.Success,
+		},
+		{
+			name:     "MIG pod on MPS node",
+			pod:      migPod,
+			node:     buildNode(gpu.PartitioningKindMps),
+			expected: framework.Unschedulable,
+		},
+		{
+			name:     "GPU slices pod on MPS node",
+			pod:      slicesPod,
+			node:     buildNode(gpu.PartitioningKindMps),
+			expected: framework.Success,
+		},
+		{
+			name:     "GPU slices pod on MIG node",
+			pod:      slicesPod,
+			node:     buildNode(gpu.PartitioningKindMig),
+			expected: framework.Unschedulable,
+		},
+		{
+			name:     "MIG pod on hybrid node",
+			pod:      migPod,
+			node:     buildNode(gpu.PartitioningKindHybrid),
+			expected: framework.Success,
+		},
+		{
+			name:     "MIG pod on node without partitioning",
+			pod:      migPod,
+			node:     buildNode(""),
+			expected: framework.Success,
+		},
+		{
+			name:     "Pod without GPU requests on MIG node",
+			pod:      cpuPod,
+			node:     buildNode(gpu.PartitioningKindMig),
+			expected: framework.Success,
+		},
+	}
+
+	plugin := &GpuPartitioningFilter{}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&tt.node)
+			status := plugin.Filter(context.Background(), framework.NewCycleState(), &tt.pod, nodeInfo)
+			assert.Equal(t, tt.expected, status.Code())
+			if tt.expected != framework.Success {
+				assert.NotEmpty(t, status.Message())
+			}
+		})
+	}
+}
+
+func TestNew__MigResourceNamePrefix(t *testing.T) {
+	defer func() {
+		assert.NoError(t, mig.SetResourceNamePrefix(constant.NvidiaMigResourcePrefix))
+	}()
+	plugin, err := New(&schedulerconfig.GpuPartitioningFilterArgs{MigResourceNamePrefix: "example.com/mig-"}, nil)
+	assert.NoError(t, err)
+
+	// Pods requesting MIG resources with the custom prefix are not scheduled on MPS nodes
+	node := factory.BuildNode("node-1").
+		WithLabels(map[string]string{v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMps.String()}).
+		Get()
+	pod := factory.BuildPod("ns-1", "pd-1").WithContainer(
+		factory.BuildContainer("c-1", "foo").WithScalarResourceRequest("example.com/mig-1g.5gb", 1).Get(),
+	).Get()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&node)
+	status := plugin.(*GpuPartitioningFilter).Filter(context.Background(), framework.NewCycleState(), &pod, nodeInfo)
+	assert.Equal(t, framework.Unschedulable, status.Code())
+}
+
+func TestCheckMigCapacity(t *testing.T) {
+	buildNode := func(annotations map[string]string, conditions ...v1.NodeCondition) v1.Node {
+		node := factory.BuildNode("node-1").
+			WithLabels(map[string]string{v1alpha1.LabelGpuPartitioning: gpu.PartitioningKindMig.String()}).
+			WithAnnotations(annotations).
+			WithAllocatableResources(v1.ResourceList{
+				mig.Profile1g10gb.AsResourceName(): *k8sresource.NewQuantity(2, k8sresource.DecimalSI),
+			}).
+			Get()
+		node.Status.Conditions = conditions
+		return node
+	}
+	buildPod := func(name string, profile mig.ProfileName, quantity int) v1.Pod {
+		return factory.BuildPod("ns-1", name).WithContainer(
+			factory.BuildContainer("c-1", "foo").WithScalarResourceRequest(profile.AsResourceName(), quantity).Get(),
+		).Get()
+	}
+	// The node currently exposes 2x 1g.10gb, while the spec requests 1x 1g.10gb and 1x 3g.40gb
+	reconfiguringAnnotations := map[string]string{
+		fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, mig.Profile1g10gb, resource.StatusFree): "2",
+		fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb):                        "1",
+		fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile3g40gb):                        "1",
+	}
+	appliedAnnotations := map[string]string{
+		fmt.Sprintf(v1alpha1.AnnotationGpuStatusFormat, 0, mig.Profile1g10gb, resource.StatusFree): "2",
+		fmt.Sprintf(v1alpha1.AnnotationGpuSpecFormat, 0, mig.Profile1g10gb):                        "2",
+	}
+	applyFailed := v1.NodeCondition{
+		Type:   v1alpha1.NodeConditionMigConfigApplied,
+		Status: v1.ConditionFalse,
+		Reason: v1alpha1.NodeConditionReasonMigConfigApplyFailed,
+	}
+
+	testCases := []struct {
+		name               string
+		node               v1.Node
+		existingPods       []v1.Pod
+		pod                v1.Pod
+		expectedFitsActual bool
+		expectedFitsTarget bool
+	}{
+		{
+			name:               "Pod requesting a profile only in the target geometry",
+			node:               buildNode(reconfiguringAnnotations),
+			pod:                buildPod("pd-1", mig.Profile3g40gb, 1),
+			expectedFitsActual: false,
+			expectedFitsTarget: true,
+		},
+		{
+			name:               "Pod requesting more devices than the target geometry provides",
+			node:               buildNode(reconfiguringAnnotations),
+			pod:                buildPod("pd-1", mig.Profile1g10gb, 2),
+			expectedFitsActual: true,
+			expectedFitsTarget: false,
+		},
+		{
+			name:               "Target geometry already requested by the existing pods, should not over-commit",
+			node:               buildNode(reconfiguringAnnotations),
+			existingPods:       []v1.Pod{buildPod("pd-2", mig.Profile3g40gb, 1)},
+			pod:                buildPod("pd-1", mig.Profile3g40gb, 1),
+			expectedFitsActual: false,
+			expectedFitsTarget: false,
+		},
+		{
+			name:               "Reconfiguration failed, target geometry should be ignored",
+			node:               buildNode(reconfiguringAnnotations, applyFailed),
+			pod:                buildPod("pd-1", mig.Profile3g40gb, 1),
+			expectedFitsActual: false,
+			expectedFitsTarget: false,
+		},
+		{
+			name:               "No pending reconfiguration, actual geometry is used",
+			node:               buildNode(appliedAnnotations),
+			pod:                buildPod("pd-1", mig.Profile1g10gb, 2),
+			expectedFitsActual: true,
+			expectedFitsTarget: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			existingPods := make([]*v1.Pod, len(tt.existingPods))
+			for i := range tt.existingPods {
+				existingPods[i] = &tt.existingPods[i]
+			}
+			nodeInfo := framework.NewNodeInfo(existingPods...)
+			nodeInfo.SetNode(&tt.node)
+
+			errActual := CheckMigCapacity(tt.pod, *nodeInfo, false)
+			assert.Equal(t, tt.expectedFitsActual, errActual == nil, errActual)
+			errTarget := CheckMigCapacity(tt.pod, *nodeInfo, true)
+			assert.Equal(t, tt.expectedFitsTarget, errTarget == nil, errTarget)
+
+			plugin := &GpuPartitioningFilter{considerPendingMigReconfiguration: true}
+			status := plugin.Filter(context.Background(), framework.NewCycleState(), &tt.pod, nodeInfo)
+			assert.Equal(t, tt.expectedFitsTarget, status.IsSuccess())
+		})
+	}
+}
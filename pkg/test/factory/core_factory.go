@@ -64,6 +64,11 @@ type namespaceBuilder struct {
 	v1.Namespace
 }
 
+func (b *namespaceBuilder) WithAnnotations(annotations map[string]string) *namespaceBuilder {
+	b.Namespace.Annotations = annotations
+	return b
+}
+
 func (b *namespaceBuilder) Get() v1.Namespace {
 	return b.Namespace
 }
@@ -113,6 +118,14 @@ func (b *podBuilder) WithLabel(label, value string) *podBuilder {
 	return b
 }
 
+func (b *podBuilder) WithAnnotation(annotation, value string) *podBuilder {
+	if b.Annotations == nil {
+		b.Annotations = make(map[string]string)
+	}
+	b.Annotations[annotation] = value
+	return b
+}
+
 func (b *podBuilder) WithNodeName(nodeName string) *podBuilder {
 	b.Spec.NodeName = nodeName
 	return b
@@ -128,6 +141,19 @@ func (b *podBuilder) WithPriority(priority int32) *podBuilder {
 	return b
 }
 
+// WithControllerOwner sets the object with the kind, name and UID provided as argument as the controller of the Pod
+func (b *podBuilder) WithControllerOwner(kind, name, uid string) *podBuilder {
+	isController := true
+	b.OwnerReferences = append(b.OwnerReferences, metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Name:       name,
+		UID:        types.UID(uid),
+		Controller: &isController,
+	})
+	return b
+}
+
 func (b *podBuilder) Get() v1.Pod {
 	return b.Pod
 }
@@ -31,6 +31,7 @@ type Client struct {
 
 	ReturnedMigDeviceResources gpu.DeviceList
 	ReturnedError              gpu.Error
+	CreatedMigProfiles         mig.ProfileList
 
 	lockReset                 sync.Mutex
 	lockGetMigDeviceResources sync.Mutex
@@ -44,6 +45,7 @@ func (m *Client) Reset() {
 	m.NumCallsDeleteMigResource = 0
 	m.NumCallsCreateMigResources = 0
 	m.NumCallsGetMigDeviceResources = 0
+	m.CreatedMigProfiles = nil
 }
 
 func (m *Client) GetMigDevices(_ context.Context) (gpu.DeviceList, gpu.Error) {
@@ -53,11 +55,45 @@ func (m *Client) GetMigDevices(_ context.Context) (gpu.DeviceList, gpu.Error) {
 	return m.ReturnedMigDeviceResources, m.ReturnedError
 }
 
-func (m *Client) CreateMigDevices(_ context.Context, _ mig.ProfileList) (mig.ProfileList, error) {
+// GetMigDeviceById returns the device among ReturnedMigDeviceResources with the ID provided as argument,
+// with zero GPU/Compute Instance IDs
+func (m *Client) GetMigDeviceById(_ context.Context, deviceId string) (mig.DeviceInfo, gpu.Error) {
+	m.lockGetMigDeviceResources.Lock()
+	defer m.lockGetMigDeviceResources.Unlock()
+	if m.ReturnedError != nil {
+		return mig.DeviceInfo{}, m.ReturnedError
+	}
+	for _, d := range m.ReturnedMigDeviceResources {
+		if d.DeviceId == deviceId {
+			return mig.DeviceInfo{Device: d}, nil
+		}
+	}
+	return mig.DeviceInfo{}, gpu.NotFoundErr.Errorf("MIG device %s not found", deviceId)
+}
+
+// CountCreatedMigDevices returns the number of devices of each profile among ReturnedMigDeviceResources
+func (m *Client) CountCreatedMigDevices(_ context.Context) (map[mig.ProfileName]int, gpu.Error) {
+	m.lockGetMigDeviceResources.Lock()
+	defer m.lockGetMigDeviceResources.Unlock()
+	if m.ReturnedError != nil {
+		return nil, m.ReturnedError
+	}
+	res := make(map[mig.ProfileName]int)
+	for _, d := range m.ReturnedMigDeviceResources {
+		res[mig.GetMigProfileName(d)]++
+	}
+	return res, nil
+}
+
+func (m *Client) CreateMigDevices(_ context.Context, profileList mig.ProfileList) (mig.ProfileList, error) {
 	m.lockCreateMigResource.Lock()
 	defer m.lockCreateMigResource.Unlock()
 	m.NumCallsCreateMigResources++
-	return nil, m.ReturnedError
+	if m.ReturnedError != nil {
+		return nil, m.ReturnedError
+	}
+	m.CreatedMigProfiles = append(m.CreatedMigProfiles, profileList...)
+	return profileList, nil
 }
 
 func (m *Client) DeleteMigDevice(_ context.Context, _ gpu.Device) gpu.Error {
@@ -78,3 +114,10 @@ func (m *Client) GetAllocatableMigDevices(ctx context.Context) (gpu.DeviceList,
 func (m *Client) DeleteAllExcept(_ context.Context, resources gpu.DeviceList) error {
 	return m.ReturnedError
 }
+
+func (m *Client) ClearGpu(_ context.Context, _ int) (int, error) {
+	if m.ReturnedError != nil {
+		return 0, m.ReturnedError
+	}
+	return 0, nil
+}
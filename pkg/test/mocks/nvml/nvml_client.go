@@ -19,6 +19,8 @@
 package mocks
 
 import (
+	context "context"
+
 	gpu "github.com/nebuly-ai/nos/pkg/gpu"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -28,13 +30,38 @@ type Client struct {
 	mock.Mock
 }
 
-// CreateMigDevices provides a mock function with given fields: migProfileNames, gpuIndex
-func (_m *Client) CreateMigDevices(migProfileNames []string, gpuIndex int) gpu.Error {
-	ret := _m.Called(migProfileNames, gpuIndex)
+// CountMigDevicesByProfile provides a mock function with given fields: ctx
+func (_m *Client) CountMigDevicesByProfile(ctx context.Context) (map[string]int, gpu.Error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]int
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	var r1 gpu.Error
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(gpu.Error)
+		}
+	}
+
+	return r0, r1
+}
+
+// CreateMigDevices provides a mock function with given fields: ctx, migProfileNames, gpuIndex
+func (_m *Client) CreateMigDevices(ctx context.Context, migProfileNames []string, gpuIndex int) gpu.Error {
+	ret := _m.Called(ctx, migProfileNames, gpuIndex)
 
 	var r0 gpu.Error
-	if rf, ok := ret.Get(0).(func([]string, int) gpu.Error); ok {
-		r0 = rf(migProfileNames, gpuIndex)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int) gpu.Error); ok {
+		r0 = rf(ctx, migProfileNames, gpuIndex)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(gpu.Error)
@@ -44,13 +71,13 @@ func (_m *Client) CreateMigDevices(migProfileNames []string, gpuIndex int) gpu.E
 	return r0
 }
 
-// DeleteAllMigDevicesExcept provides a mock function with given fields: migDeviceIds
-func (_m *Client) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
-	ret := _m.Called(migDeviceIds)
+// DeleteAllMigDevicesExcept provides a mock function with given fields: ctx, migDeviceIds
+func (_m *Client) DeleteAllMigDevicesExcept(ctx context.Context, migDeviceIds []string) error {
+	ret := _m.Called(ctx, migDeviceIds)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]string) error); ok {
-		r0 = rf(migDeviceIds)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, migDeviceIds)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -58,13 +85,13 @@ func (_m *Client) DeleteAllMigDevicesExcept(migDeviceIds []string) error {
 	return r0
 }
 
-// DeleteMigDevice provides a mock function with given fields: id
-func (_m *Client) DeleteMigDevice(id string) gpu.Error {
-	ret := _m.Called(id)
+// DeleteMigDevice provides a mock function with given fields: ctx, id
+func (_m *Client) DeleteMigDevice(ctx context.Context, id string) gpu.Error {
+	ret := _m.Called(ctx, id)
 
 	var r0 gpu.Error
-	if rf, ok := ret.Get(0).(func(string) gpu.Error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) gpu.Error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(gpu.Error)
@@ -74,20 +101,66 @@ func (_m *Client) DeleteMigDevice(id string) gpu.Error {
 	return r0
 }
 
-// GetGpuIndex provides a mock function with given fields: gpuId
-func (_m *Client) GetGpuIndex(gpuId string) (int, gpu.Error) {
-	ret := _m.Called(gpuId)
+// GetCudaDriverVersion provides a mock function with given fields: ctx
+func (_m *Client) GetCudaDriverVersion(ctx context.Context) (string, gpu.Error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 gpu.Error
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(gpu.Error)
+		}
+	}
+
+	return r0, r1
+}
+
+// GetDriverVersion provides a mock function with given fields: ctx
+func (_m *Client) GetDriverVersion(ctx context.Context) (string, gpu.Error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 gpu.Error
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(gpu.Error)
+		}
+	}
+
+	return r0, r1
+}
+
+// GetGpuCount provides a mock function with given fields: ctx
+func (_m *Client) GetGpuCount(ctx context.Context) (int, gpu.Error) {
+	ret := _m.Called(ctx)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(gpuId)
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 gpu.Error
-	if rf, ok := ret.Get(1).(func(string) gpu.Error); ok {
-		r1 = rf(gpuId)
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(gpu.Error)
@@ -97,20 +170,68 @@ func (_m *Client) GetGpuIndex(gpuId string) (int, gpu.Error) {
 	return r0, r1
 }
 
-// GetMigDeviceGpuIndex provides a mock function with given fields: migDeviceId
-func (_m *Client) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
-	ret := _m.Called(migDeviceId)
+// GetGpuInfo provides a mock function with given fields: ctx
+func (_m *Client) GetGpuInfo(ctx context.Context) ([]gpu.Info, gpu.Error) {
+	ret := _m.Called(ctx)
+
+	var r0 []gpu.Info
+	if rf, ok := ret.Get(0).(func(context.Context) []gpu.Info); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gpu.Info)
+		}
+	}
+
+	var r1 gpu.Error
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(gpu.Error)
+		}
+	}
+
+	return r0, r1
+}
+
+// GetGpuIndex provides a mock function with given fields: ctx, gpuId
+func (_m *Client) GetGpuIndex(ctx context.Context, gpuId string) (int, gpu.Error) {
+	ret := _m.Called(ctx, gpuId)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, gpuId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 gpu.Error
+	if rf, ok := ret.Get(1).(func(context.Context, string) gpu.Error); ok {
+		r1 = rf(ctx, gpuId)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(gpu.Error)
+		}
+	}
+
+	return r0, r1
+}
+
+// GetMigDeviceGpuIndex provides a mock function with given fields: ctx, migDeviceId
+func (_m *Client) GetMigDeviceGpuIndex(ctx context.Context, migDeviceId string) (int, gpu.Error) {
+	ret := _m.Called(ctx, migDeviceId)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(migDeviceId)
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, migDeviceId)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 gpu.Error
-	if rf, ok := ret.Get(1).(func(string) gpu.Error); ok {
-		r1 = rf(migDeviceId)
+	if rf, ok := ret.Get(1).(func(context.Context, string) gpu.Error); ok {
+		r1 = rf(ctx, migDeviceId)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(gpu.Error)
@@ -120,13 +241,43 @@ func (_m *Client) GetMigDeviceGpuIndex(migDeviceId string) (int, gpu.Error) {
 	return r0, r1
 }
 
-// GetMigEnabledGPUs provides a mock function with given fields:
-func (_m *Client) GetMigEnabledGPUs() ([]int, gpu.Error) {
-	ret := _m.Called()
+// GetMigDeviceInstanceIds provides a mock function with given fields: ctx, migDeviceId
+func (_m *Client) GetMigDeviceInstanceIds(ctx context.Context, migDeviceId string) (int, int, gpu.Error) {
+	ret := _m.Called(ctx, migDeviceId)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, migDeviceId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, string) int); ok {
+		r1 = rf(ctx, migDeviceId)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 gpu.Error
+	if rf, ok := ret.Get(2).(func(context.Context, string) gpu.Error); ok {
+		r2 = rf(ctx, migDeviceId)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(gpu.Error)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// GetMigEnabledGPUs provides a mock function with given fields: ctx
+func (_m *Client) GetMigEnabledGPUs(ctx context.Context) ([]int, gpu.Error) {
+	ret := _m.Called(ctx)
 
 	var r0 []int
-	if rf, ok := ret.Get(0).(func() []int); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []int); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]int)
@@ -134,8 +285,8 @@ func (_m *Client) GetMigEnabledGPUs() ([]int, gpu.Error) {
 	}
 
 	var r1 gpu.Error
-	if rf, ok := ret.Get(1).(func() gpu.Error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) gpu.Error); ok {
+		r1 = rf(ctx)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(gpu.Error)
@@ -145,6 +296,22 @@ func (_m *Client) GetMigEnabledGPUs() ([]int, gpu.Error) {
 	return r0, r1
 }
 
+// HealthCheck provides a mock function with given fields: ctx
+func (_m *Client) HealthCheck(ctx context.Context) gpu.Error {
+	ret := _m.Called(ctx)
+
+	var r0 gpu.Error
+	if rf, ok := ret.Get(0).(func(context.Context) gpu.Error); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gpu.Error)
+		}
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewClient interface {
 	mock.TestingT
 	Cleanup(func())
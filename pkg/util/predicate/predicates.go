@@ -21,6 +21,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"strings"
 )
 
 // MatchingName
@@ -66,6 +67,30 @@ func (p AnnotationsChangedPredicate) Update(updateEvent event.UpdateEvent) bool
 	return !cmp.Equal(updateEvent.ObjectOld.GetAnnotations(), updateEvent.ObjectNew.GetAnnotations())
 }
 
+// AnnotationsWithPrefixChanged filters out the update events that do not change any of the
+// annotations whose key starts with one of the Prefixes
+type AnnotationsWithPrefixChanged struct {
+	predicate.Funcs
+	Prefixes []string
+}
+
+func (p AnnotationsWithPrefixChanged) Update(updateEvent event.UpdateEvent) bool {
+	return !cmp.Equal(p.filter(updateEvent.ObjectOld.GetAnnotations()), p.filter(updateEvent.ObjectNew.GetAnnotations()))
+}
+
+func (p AnnotationsWithPrefixChanged) filter(annotations map[string]string) map[string]string {
+	res := make(map[string]string)
+	for k, v := range annotations {
+		for _, prefix := range p.Prefixes {
+			if strings.HasPrefix(k, prefix) {
+				res[k] = v
+				break
+			}
+		}
+	}
+	return res
+}
+
 // ExcludeDelete
 type ExcludeDelete struct {
 	predicate.Funcs